@@ -4,6 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"expvar"
+	"flag"
 	"fmt"
 	_ "github.com/lib/pq"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
@@ -11,6 +14,7 @@ import (
 	"go-ton-pass-telegram-bot/internal/config"
 	"go-ton-pass-telegram-bot/internal/container"
 	"go-ton-pass-telegram-bot/internal/model/app"
+	"go-ton-pass-telegram-bot/internal/model/telegram"
 	"go-ton-pass-telegram-bot/internal/repository"
 	"go-ton-pass-telegram-bot/internal/router"
 	"go-ton-pass-telegram-bot/internal/service"
@@ -20,14 +24,41 @@ import (
 	"golang.org/x/text/language"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
+// setWebhookCmd registers the webhook on telegram servers and exits, e.g. `main set-webhook -drop-pending-updates`.
+const setWebhookCmd = "set-webhook"
+
+// syncBotProfileCmd pushes commands, descriptions and names of every localized language and exits.
+const syncBotProfileCmd = "sync-bot-profile"
+
+// shutdownTimeout bounds how long requests being served may delay the shutdown.
+const shutdownTimeout = 15 * time.Second
+
 func main() {
 	conf, err := config.ParseConfig()
 	if err != nil {
 		log.Fatalln(err)
 	}
+	bundle := loadBundle()
+	logger := logger.NewLogger(logger.DEV, logger.LevelDebug)
+	box := container.NewContainer(logger, conf, bundle)
+	if len(os.Args) > 1 && os.Args[1] == setWebhookCmd {
+		if err := setWebhook(box, os.Args[2:]); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == syncBotProfileCmd {
+		if err := syncTelegramBotProfile(context.Background(), box); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
 	db, err := openConnectionToDB(conf.DB())
 	if err != nil {
 		log.Fatalln(err)
@@ -37,9 +68,6 @@ func main() {
 			log.Fatalln(err)
 		}
 	}()
-	bundle := loadBundle()
-	logger := logger.NewLogger(logger.DEV, logger.LevelDebug)
-	box := container.NewContainer(logger, conf, bundle)
 	if err := box.PreloadData(); err != nil {
 		log.Fatalln(err)
 	}
@@ -59,8 +87,35 @@ func main() {
 		log.Fatalln(err)
 	}
 	defer temporalClient.Close()
-	//updateTelegramBotProfile(box)
-	RunServer(box, db, sessionService, temporalClient, cacheService)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := resolveTelegramBotUsername(ctx, box); err != nil {
+		log.Fatalln("fail to get username of the bot", err)
+	}
+	go func() {
+		if err := syncTelegramBotProfile(ctx, box); err != nil {
+			log.Println("syncTelegramBotProfile: ", err)
+		}
+	}()
+	RunServer(ctx, box, db, sessionService, temporalClient, cacheService)
+}
+
+// resolveTelegramBotUsername asks telegram servers for the username of the bot when TELEGRAM_BOT_USERNAME
+// is unset, deep links of inline results need it.
+func resolveTelegramBotUsername(ctx context.Context, box container.Container) error {
+	conf := box.GetConfig()
+	if conf.Telegram().BotUsername != "" {
+		return nil
+	}
+	bot, err := service.NewTelegramBotClient(box).GetMe(ctx)
+	if err != nil {
+		return err
+	}
+	if bot.Username == nil {
+		return app.EmptyValueError
+	}
+	conf.SetTelegramBotUsername(*bot.Username)
+	return nil
 }
 
 func configureAndConnectToRedisClient(conf config.Config) *redis.Client {
@@ -83,18 +138,35 @@ func configureAndConnectToRedisClient(conf config.Config) *redis.Client {
 	return c
 }
 
-func RunServer(box container.Container, conn *sql.DB, sessionService service.SessionService, temporalClient client.Client, cacheService service.Cache) {
+func RunServer(ctx context.Context, box container.Container, conn *sql.DB, sessionService service.SessionService, temporalClient client.Client, cacheService service.Cache) {
 	profileRepository := repository.NewProfileRepository(conn)
 	smsHistoryRepository := repository.NewSMSHistoryRepository(conn)
 	temporalWorkflowRepository := repository.NewTemporalWorkflowRepository(conn)
 	telegramPaymentRepository := repository.NewTelegramPaymentRepository(conn)
+	smsActivateUpdateRepository := repository.NewSMSActivateUpdateRepository(conn)
+	activationGroupRepository := repository.NewActivationGroupRepository(conn)
+	favoriteRepository := repository.NewFavoriteRepository(conn)
+	catalogRepository := repository.NewCatalogRepository(conn)
+	adminAuditLogRepository := repository.NewAdminAuditLogRepository(conn)
+	broadcastRepository := repository.NewBroadcastRepository(conn)
+	supportTicketRepository := repository.NewSupportTicketRepository(conn)
 	smsService := service.NewSMSService(box)
-	postponeService := postpone.NewPostpone(box, temporalClient, profileRepository, smsHistoryRepository)
+	telegramBotService := service.NewTelegramBot(box, cacheService, profileRepository)
+	// the dispatcher outlives the server, so replies of requests being served on shutdown are still sent
+	dispatcherCtx, stopDispatcher := context.WithCancel(context.Background())
+	defer stopDispatcher()
+	go func() {
+		if err := telegramBotService.Run(dispatcherCtx); err != nil {
+			log.Fatalln("fail to dispatch outbound messages", err)
+		}
+	}()
+	postponeService := postpone.NewPostpone(box, telegramBotService, temporalClient, cacheService, profileRepository, smsHistoryRepository, activationGroupRepository, catalogRepository, broadcastRepository)
 	if err := postponeService.Prepare(); err != nil {
 		log.Fatalln("fail to prepare postpone service", logger.FError(err))
 	}
 	r := router.PrepareAndConfigureRouter(
 		box,
+		telegramBotService,
 		sessionService,
 		cacheService,
 		smsService,
@@ -103,6 +175,13 @@ func RunServer(box container.Container, conn *sql.DB, sessionService service.Ses
 		smsHistoryRepository,
 		temporalWorkflowRepository,
 		telegramPaymentRepository,
+		smsActivateUpdateRepository,
+		activationGroupRepository,
+		favoriteRepository,
+		catalogRepository,
+		adminAuditLogRepository,
+		broadcastRepository,
+		supportTicketRepository,
 	)
 	openServer := &http.Server{
 		Handler:      r,
@@ -117,17 +196,54 @@ func RunServer(box container.Container, conn *sql.DB, sessionService service.Ses
 	//	ReadTimeout:  15 * time.Second,
 	//}
 
-	//go func() {
-	//	if err := openServer.ListenAndServe(); err != nil {
-	//		log.Fatalln(err)
-	//	}
-	//}()
-	if err := openServer.ListenAndServe(); err != nil {
-		log.Fatalln(err)
-	}
+	go func() {
+		if err := openServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalln(err)
+		}
+	}()
+	debugServer := runDebugServer(box.GetConfig().DebugConnectionAddress())
 	//if err := secureServer.ListenAndServeTLS("tls/public.pem", "tls/private.key"); err != nil {
 	//	log.Fatalln(err)
 	//}
+	if box.GetConfig().Telegram().IsPolling() {
+		telegramPolling := service.NewTelegramPolling(box, telegramBotService, cacheService, r)
+		if err := telegramPolling.Run(ctx); err != nil {
+			log.Fatalln("fail to poll telegram updates", err)
+		}
+	} else {
+		<-ctx.Done()
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := openServer.Shutdown(shutdownCtx); err != nil {
+		log.Println("fail to shutdown server: ", err)
+	}
+	if debugServer != nil {
+		if err := debugServer.Shutdown(shutdownCtx); err != nil {
+			log.Println("fail to shutdown debug server: ", err)
+		}
+	}
+}
+
+// runDebugServer serves the expvar metrics on an internal address, apart from the public webhook server.
+func runDebugServer(addr string) *http.Server {
+	if addr == "" {
+		return nil
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	debugServer := &http.Server{
+		Handler:      mux,
+		Addr:         addr,
+		WriteTimeout: 15 * time.Second,
+		ReadTimeout:  15 * time.Second,
+	}
+	go func() {
+		if err := debugServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalln(err)
+		}
+	}()
+	return debugServer
 }
 
 func loadBundle() *i18n.Bundle {
@@ -158,31 +274,34 @@ func openConnectionToDB(db config.DB) (*sql.DB, error) {
 	return conn, err
 }
 
-func updateTelegramBotProfile(box container.Container) {
-	telegramService := service.NewTelegramBot(box)
-
-	go setBotCommands(telegramService)
-	go setBotDescription(telegramService)
-	go setBotName(telegramService)
-}
-
-func setBotCommands(telegramService service.TelegramBotService) {
-	model := telegramService.GetSetMyCommands()
-	if err := telegramService.SendResponse(model, app.SetMyCommandsTelegramMethod); err != nil {
-		log.Println("setBotCommands: ", err)
+func setWebhook(box container.Container, args []string) error {
+	telegramConfig := box.GetConfig().Telegram()
+	flags := flag.NewFlagSet(setWebhookCmd, flag.ExitOnError)
+	webhookURL := flags.String("url", telegramConfig.WebhookURL, "public url of the telegram webhook")
+	certificatePath := flags.String("certificate", telegramConfig.WebhookCertificatePath, "path to the self-signed certificate")
+	dropPendingUpdates := flags.Bool("drop-pending-updates", false, "drop updates received while the webhook was unset")
+	if err := flags.Parse(args); err != nil {
+		return err
 	}
-}
-
-func setBotDescription(telegramService service.TelegramBotService) {
-	model := telegramService.GetSetMyDescription()
-	if err := telegramService.SendResponse(model, app.SetMyDescriptionTelegramMethod); err != nil {
-		log.Println("setBotDescription: ", err)
+	if *webhookURL == "" || telegramConfig.WebhookSecretToken == "" {
+		return app.RequiredFieldError
 	}
+	model := telegram.SetWebhook{
+		URL:                *webhookURL,
+		SecretToken:        telegramConfig.WebhookSecretToken,
+		MaxConnections:     telegramConfig.WebhookMaxConnections,
+		AllowedUpdates:     telegramConfig.WebhookAllowedUpdates,
+		DropPendingUpdates: *dropPendingUpdates,
+	}
+	if err := service.NewTelegramBotClient(box).SetWebhook(context.Background(), &model, *certificatePath); err != nil {
+		return err
+	}
+	log.Println("webhook is set: ", *webhookURL)
+	return nil
 }
 
-func setBotName(telegramService service.TelegramBotService) {
-	model := telegramService.GetSetMyName()
-	if err := telegramService.SendResponse(model, app.SetMyNameTelegramMethod); err != nil {
-		log.Println("setMyName: ", err)
-	}
+// syncTelegramBotProfile talks to telegram servers directly, the profile is synced rarely and isn't worth
+// the outbound queue.
+func syncTelegramBotProfile(ctx context.Context, box container.Container) error {
+	return service.NewTelegramBotProfile(box, service.NewTelegramBotClient(box)).Sync(ctx)
 }
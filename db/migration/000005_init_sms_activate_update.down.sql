@@ -0,0 +1 @@
+DROP TABLE IF EXISTS sms_activate_update;
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS sms_activate_update
+(
+    id SERIAL PRIMARY KEY,
+    activation_id BIGINT NOT NULL,
+    received_at VARCHAR(64) NOT NULL,
+    payload JSONB,
+    processed_at TIMESTAMP,
+    created_at TIMESTAMP,
+    UNIQUE (activation_id, received_at)
+);
\ No newline at end of file
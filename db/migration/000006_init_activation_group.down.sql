@@ -0,0 +1,2 @@
+ALTER TABLE sms_history DROP COLUMN IF EXISTS activation_group_id;
+DROP TABLE IF EXISTS activation_group;
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS activation_group
+(
+    id SERIAL PRIMARY KEY,
+    profile_id INT REFERENCES profile(id) ON DELETE CASCADE,
+    chat_id BIGINT,
+    message_id BIGINT,
+    quantity INT NOT NULL,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+);
+
+ALTER TABLE sms_history ADD COLUMN IF NOT EXISTS activation_group_id INT REFERENCES activation_group(id) ON DELETE SET NULL;
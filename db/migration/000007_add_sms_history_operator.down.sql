@@ -0,0 +1 @@
+ALTER TABLE sms_history DROP COLUMN IF EXISTS operator;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE sms_history ADD COLUMN IF NOT EXISTS operator VARCHAR(64);
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS favorite
+(
+    id SERIAL PRIMARY KEY,
+    profile_id INT REFERENCES profile(id) ON DELETE CASCADE,
+    favorite_type VARCHAR(16) NOT NULL,
+    code VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP,
+    UNIQUE (profile_id, favorite_type, code)
+);
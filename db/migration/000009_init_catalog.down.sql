@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS catalog_service_price;
+DROP TABLE IF EXISTS catalog_country;
+DROP TABLE IF EXISTS catalog_service;
\ No newline at end of file
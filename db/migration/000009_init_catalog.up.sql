@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS catalog_service
+(
+    code VARCHAR(32) PRIMARY KEY,
+    name VARCHAR(128) NOT NULL,
+    popularity_rank INT,
+    last_seen_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS catalog_country
+(
+    id INT PRIMARY KEY,
+    title VARCHAR(128) NOT NULL,
+    visible INT,
+    retry INT,
+    rent INT,
+    multi_service INT,
+    last_seen_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS catalog_service_price
+(
+    service_code VARCHAR(32) NOT NULL,
+    country_id INT NOT NULL,
+    retail_price DOUBLE PRECISION,
+    min_price DOUBLE PRECISION,
+    count INT,
+    last_seen_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    PRIMARY KEY (service_code, country_id)
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS admin_audit_log;
+ALTER TABLE profile DROP COLUMN IF EXISTS role;
\ No newline at end of file
@@ -0,0 +1,13 @@
+ALTER TABLE profile ADD COLUMN IF NOT EXISTS role VARCHAR(16) NOT NULL DEFAULT 'user';
+
+CREATE TABLE IF NOT EXISTS admin_audit_log
+(
+    id SERIAL PRIMARY KEY,
+    profile_id INT REFERENCES profile(id) ON DELETE SET NULL,
+    telegram_id BIGINT NOT NULL,
+    command VARCHAR(32) NOT NULL,
+    arguments TEXT,
+    allowed BOOLEAN NOT NULL,
+    result TEXT,
+    created_at TIMESTAMP
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS broadcast_delivery;
+DROP TABLE IF EXISTS broadcast;
+ALTER TABLE profile DROP COLUMN IF EXISTS bot_blocked_at;
\ No newline at end of file
@@ -0,0 +1,38 @@
+ALTER TABLE profile ADD COLUMN IF NOT EXISTS bot_blocked_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS broadcast
+(
+    id SERIAL PRIMARY KEY,
+    author_profile_id INT REFERENCES profile(id) ON DELETE SET NULL,
+    status VARCHAR(16) NOT NULL,
+    text TEXT,
+    photo VARCHAR(256),
+    entities JSONB,
+    buttons JSONB,
+    language_codes TEXT[],
+    currencies TEXT[],
+    activity VARCHAR(16),
+    activity_days INT,
+    control_chat_id BIGINT,
+    control_message_id BIGINT,
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS broadcast_delivery
+(
+    id SERIAL PRIMARY KEY,
+    broadcast_id INT REFERENCES broadcast(id) ON DELETE CASCADE,
+    profile_id INT REFERENCES profile(id) ON DELETE CASCADE,
+    chat_id BIGINT NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    message_id BIGINT,
+    error TEXT,
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP,
+    UNIQUE (broadcast_id, profile_id)
+);
+
+CREATE INDEX IF NOT EXISTS broadcast_delivery_broadcast_id_status_idx ON broadcast_delivery (broadcast_id, status);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS support_message;
+DROP TABLE IF EXISTS support_ticket;
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS support_ticket
+(
+    id SERIAL PRIMARY KEY,
+    profile_id INT REFERENCES profile(id) ON DELETE CASCADE,
+    status VARCHAR(16) NOT NULL,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    closed_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS support_ticket_active_profile_id_idx ON support_ticket (profile_id) WHERE status <> 'closed';
+
+CREATE TABLE IF NOT EXISTS support_message
+(
+    id SERIAL PRIMARY KEY,
+    ticket_id INT REFERENCES support_ticket(id) ON DELETE CASCADE,
+    sender VARCHAR(16) NOT NULL,
+    sender_telegram_id BIGINT NOT NULL,
+    text TEXT NOT NULL,
+    user_message_id BIGINT,
+    support_message_id BIGINT,
+    created_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS support_message_support_message_id_idx ON support_message (support_message_id);
@@ -0,0 +1 @@
+ALTER TABLE profile DROP COLUMN IF EXISTS bot_unblocked_at;
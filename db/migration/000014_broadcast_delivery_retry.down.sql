@@ -0,0 +1,2 @@
+ALTER TABLE broadcast_delivery DROP COLUMN IF EXISTS not_before;
+ALTER TABLE broadcast_delivery DROP COLUMN IF EXISTS attempts;
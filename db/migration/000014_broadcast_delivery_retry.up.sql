@@ -0,0 +1,2 @@
+ALTER TABLE broadcast_delivery ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE broadcast_delivery ADD COLUMN IF NOT EXISTS not_before TIMESTAMP;
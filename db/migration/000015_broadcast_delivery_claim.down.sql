@@ -0,0 +1 @@
+ALTER TABLE broadcast_delivery DROP COLUMN IF EXISTS claimed_at;
@@ -0,0 +1 @@
+ALTER TABLE broadcast_delivery ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMP;
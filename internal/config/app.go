@@ -12,6 +12,7 @@ import (
 type Config interface {
 	SecureConnectionAddress() string
 	OpenConnectionAddress() string
+	DebugConnectionAddress() string
 	TelegramBotToken() string
 	CryptoBotToken() string
 	GetStripeSecretKey() string
@@ -21,6 +22,18 @@ type Config interface {
 	Redis() Redis
 	DB() DB
 	Temporal() Temporal
+	ProviderBalance() ProviderBalance
+	ServicePopularity() ServicePopularity
+	Catalog() Catalog
+	SMSActivateWebhook() SMSActivateWebhook
+	Telegram() Telegram
+	// SetTelegramBotUsername keeps the username telegram servers have returned when the environment has none.
+	SetTelegramBotUsername(username string)
+	Subscription() Subscription
+	AdminChatID() int64
+	SupportChatID() int64
+	AdminTelegramIDs() []int64
+	IsAdminTelegramID(telegramID int64) bool
 	AvailablePreferredCurrencies() []app.Currency
 	AvailableCryptoBotPayCurrencies() []app.Currency
 	CurrencyByAbbr(abbr string) *app.Currency
@@ -51,6 +64,119 @@ type Temporal struct {
 	Port string
 }
 
+type ProviderBalance struct {
+	SMSActivateMinBalance    float64
+	CryptoBotMinBalance      float64
+	CryptoBotBalanceCurrency string
+	CheckCronSchedule        string
+	SuspendPurchases         bool
+}
+
+type ServicePopularity struct {
+	WindowDays          int
+	RefreshCronSchedule string
+}
+
+type Catalog struct {
+	SyncCronSchedule string
+}
+
+type TelegramUpdatesMode string
+
+const (
+	WebhookTelegramUpdatesMode TelegramUpdatesMode = "webhook"
+	PollingTelegramUpdatesMode TelegramUpdatesMode = "polling"
+)
+
+type Telegram struct {
+	BotAPIURL string
+	// BotUsername is used in deep links to the bot, e.g. t.me/<username>?start=<parameter>.
+	BotUsername string
+	// Environment is either production or test, the test environment of telegram servers has its own bots.
+	Environment        string
+	UpdatesMode        TelegramUpdatesMode
+	PollingTimeoutSecs int
+	// WebhookSecretToken is sent by telegram servers in the X-Telegram-Bot-Api-Secret-Token header of every update.
+	WebhookSecretToken     string
+	WebhookURL             string
+	WebhookMaxConnections  int
+	WebhookAllowedUpdates  []string
+	WebhookCertificatePath string
+	// OutboundGlobalRate and OutboundChatRate are messages per second the bot sends in total and to a chat.
+	OutboundGlobalRate  float64
+	OutboundChatRate    float64
+	OutboundMaxAttempts int
+	// CallbackDataSecret signs tokens of buttons, CallbackDataTTLHours is how long buttons stay usable.
+	CallbackDataSecret   string
+	CallbackDataTTLHours int
+}
+
+func (t Telegram) IsPolling() bool {
+	return t.UpdatesMode == PollingTelegramUpdatesMode
+}
+
+// DeepLink opens a private chat with the bot started with the parameter (A-Z, a-z, 0-9, _ and -),
+// it's nil without the username of the bot.
+func (t Telegram) DeepLink(startParameter string) *string {
+	if t.BotUsername == "" {
+		return nil
+	}
+	return utils.NewString("https://t.me/" + t.BotUsername + "?start=" + startParameter)
+}
+
+// RequiredChannel is a channel users must be subscribed to, Chat is either the @username or the numeric id of the channel.
+// The bot must be an administrator of the channel to see its members.
+type RequiredChannel struct {
+	Chat    string
+	Enabled bool
+}
+
+// Link opens the channel, a channel without a public username has none.
+func (r RequiredChannel) Link() *string {
+	if !strings.HasPrefix(r.Chat, "@") {
+		return nil
+	}
+	return utils.NewString("https://t.me/" + strings.TrimPrefix(r.Chat, "@"))
+}
+
+type Subscription struct {
+	RequiredChannels []RequiredChannel
+	// MembershipCacheTTLSeconds is how long a confirmed membership is trusted without asking telegram servers.
+	MembershipCacheTTLSeconds int
+}
+
+// EnabledChannels returns the channels the gate checks, no channels turn the gate off.
+func (s Subscription) EnabledChannels() []RequiredChannel {
+	return utils.Filter(s.RequiredChannels, func(requiredChannel RequiredChannel) bool {
+		return requiredChannel.Enabled
+	})
+}
+
+type SMSActivateWebhook struct {
+	Token           string
+	AllowedNetworks []*net.IPNet
+	TrustedProxies  []*net.IPNet
+}
+
+// IsAllowedIP fails closed, updates aren't accepted from anywhere while the allowlist is empty.
+func (s SMSActivateWebhook) IsAllowedIP(ip net.IP) bool {
+	return containsIP(s.AllowedNetworks, ip)
+}
+
+// IsTrustedProxy reports whether the forwarded headers of the peer can be relied on.
+func (s SMSActivateWebhook) IsTrustedProxy(ip net.IP) bool {
+	return containsIP(s.TrustedProxies, ip)
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *Redis) Address() string {
 	return net.JoinHostPort(r.Host, r.Port)
 }
@@ -63,18 +189,28 @@ type config struct {
 	serverAddr            string
 	secureServerPort      string
 	openServerPort        string
+	debugServerAddr       string
 	telegramBotToken      string
 	cryptoBotToken        string
 	smsServiceToken       string
 	stripeSecretKey       string
 	stripeSuccessURL      string
 	stripeCancelURL       string
+	adminChatID           int64
+	supportChatID         int64
+	adminTelegramIDs      []int64
 	allLanguages          []app.Language
 	localizedLanguageTags []string
 	allCurrencies         []app.Currency
 	redis                 Redis
 	db                    DB
 	temporal              Temporal
+	providerBalance       ProviderBalance
+	servicePopularity     ServicePopularity
+	catalog               Catalog
+	smsActivateWebhook    SMSActivateWebhook
+	telegram              Telegram
+	subscription          Subscription
 }
 
 func (c *config) SecureConnectionAddress() string {
@@ -85,6 +221,11 @@ func (c *config) OpenConnectionAddress() string {
 	return net.JoinHostPort(c.serverAddr, c.openServerPort)
 }
 
+// DebugConnectionAddress is the internal listener of the expvar metrics, it's empty when the listener is off.
+func (c *config) DebugConnectionAddress() string {
+	return c.debugServerAddr
+}
+
 func (c *config) TelegramBotToken() string {
 	return c.telegramBotToken
 }
@@ -192,11 +333,56 @@ func (c *config) Temporal() Temporal {
 	return c.temporal
 }
 
+func (c *config) ProviderBalance() ProviderBalance {
+	return c.providerBalance
+}
+
+func (c *config) ServicePopularity() ServicePopularity {
+	return c.servicePopularity
+}
+
+func (c *config) Catalog() Catalog {
+	return c.catalog
+}
+
+func (c *config) SMSActivateWebhook() SMSActivateWebhook {
+	return c.smsActivateWebhook
+}
+
+func (c *config) Telegram() Telegram {
+	return c.telegram
+}
+
+func (c *config) SetTelegramBotUsername(username string) {
+	c.telegram.BotUsername = strings.TrimPrefix(username, "@")
+}
+
+func (c *config) Subscription() Subscription {
+	return c.subscription
+}
+
+func (c *config) AdminChatID() int64 {
+	return c.adminChatID
+}
+
+func (c *config) SupportChatID() int64 {
+	return c.supportChatID
+}
+
+func (c *config) AdminTelegramIDs() []int64 {
+	return c.adminTelegramIDs
+}
+
+func (c *config) IsAdminTelegramID(telegramID int64) bool {
+	return utils.ContainsValue(c.adminTelegramIDs, telegramID)
+}
+
 func ParseConfig() (Config, error) {
 	config := config{
 		serverAddr:       os.Getenv("SERVER_HOST"),
 		secureServerPort: os.Getenv("SERVER_SECURE_PORT"),
 		openServerPort:   os.Getenv("SERVER_OPEN_PORT"),
+		debugServerAddr:  os.Getenv("DEBUG_SERVER_ADDRESS"),
 		telegramBotToken: os.Getenv("TELEGRAM_BOT_TOKEN"),
 		cryptoBotToken:   os.Getenv("CRYPTO_BOT_TOKEN"),
 		smsServiceToken:  os.Getenv("SMS_SERVICE_API_KEY"),
@@ -204,7 +390,7 @@ func ParseConfig() (Config, error) {
 		stripeSuccessURL: os.Getenv("STRIPE_SUCCESS_LINK"),
 		stripeCancelURL:  os.Getenv("STRIPE_CANCEL_LINK"),
 	}
-	
+
 	allLanguages, err := fetchAllLanguages()
 	if err != nil {
 		return nil, err
@@ -224,6 +410,34 @@ func ParseConfig() (Config, error) {
 	config.redis = ParseRedisConfig()
 	config.db = ParseDBConfig()
 	config.temporal = ParseTemporalConfig()
+	config.providerBalance = ParseProviderBalanceConfig()
+	config.servicePopularity = ParseServicePopularityConfig()
+	config.catalog = ParseCatalogConfig()
+	smsActivateWebhook, err := ParseSMSActivateWebhookConfig()
+	if err != nil {
+		return nil, err
+	}
+	config.smsActivateWebhook = smsActivateWebhook
+	telegram, err := ParseTelegramConfig()
+	if err != nil {
+		return nil, err
+	}
+	if telegram.CallbackDataSecret == "" {
+		telegram.CallbackDataSecret = config.telegramBotToken
+	}
+	config.telegram = telegram
+	subscription, err := ParseSubscriptionConfig()
+	if err != nil {
+		return nil, err
+	}
+	config.subscription = subscription
+	config.adminChatID, _ = strconv.ParseInt(os.Getenv("ADMIN_CHAT_ID"), 10, 64)
+	config.supportChatID, _ = strconv.ParseInt(os.Getenv("SUPPORT_CHAT_ID"), 10, 64)
+	adminTelegramIDs, err := parseAdminTelegramIDs()
+	if err != nil {
+		return nil, err
+	}
+	config.adminTelegramIDs = adminTelegramIDs
 
 	return &config, nil
 }
@@ -247,6 +461,228 @@ func ParseTemporalConfig() Temporal {
 	return temporal
 }
 
+func ParseProviderBalanceConfig() ProviderBalance {
+	providerBalance := ProviderBalance{
+		CryptoBotBalanceCurrency: os.Getenv("CRYPTO_BOT_BALANCE_CURRENCY"),
+		CheckCronSchedule:        os.Getenv("PROVIDER_BALANCE_CHECK_SCHEDULE"),
+	}
+	providerBalance.SMSActivateMinBalance, _ = strconv.ParseFloat(os.Getenv("SMS_ACTIVATE_MIN_BALANCE"), 64)
+	providerBalance.CryptoBotMinBalance, _ = strconv.ParseFloat(os.Getenv("CRYPTO_BOT_MIN_BALANCE"), 64)
+	providerBalance.SuspendPurchases, _ = strconv.ParseBool(os.Getenv("SUSPEND_PURCHASES_ON_LOW_BALANCE"))
+	if providerBalance.CryptoBotBalanceCurrency == "" {
+		providerBalance.CryptoBotBalanceCurrency = "USDT"
+	}
+	if providerBalance.CheckCronSchedule == "" {
+		providerBalance.CheckCronSchedule = "*/10 * * * *"
+	}
+	return providerBalance
+}
+
+func ParseServicePopularityConfig() ServicePopularity {
+	servicePopularity := ServicePopularity{
+		RefreshCronSchedule: os.Getenv("SERVICE_POPULARITY_REFRESH_SCHEDULE"),
+	}
+	servicePopularity.WindowDays, _ = strconv.Atoi(os.Getenv("SERVICE_POPULARITY_WINDOW_DAYS"))
+	if servicePopularity.WindowDays <= 0 {
+		servicePopularity.WindowDays = 30
+	}
+	if servicePopularity.RefreshCronSchedule == "" {
+		servicePopularity.RefreshCronSchedule = "0 * * * *"
+	}
+	return servicePopularity
+}
+
+func ParseCatalogConfig() Catalog {
+	catalog := Catalog{
+		SyncCronSchedule: os.Getenv("CATALOG_SYNC_SCHEDULE"),
+	}
+	if catalog.SyncCronSchedule == "" {
+		catalog.SyncCronSchedule = "*/30 * * * *"
+	}
+	return catalog
+}
+
+// ParseTelegramConfig reads how updates are received, polling lets the bot run locally without a public url.
+// The webhook mode requires a secret token, otherwise anyone could post updates on behalf of telegram servers.
+func ParseTelegramConfig() (Telegram, error) {
+	telegram := Telegram{
+		BotAPIURL:              os.Getenv("TELEGRAM_BOT_API_URL"),
+		BotUsername:            strings.TrimPrefix(os.Getenv("TELEGRAM_BOT_USERNAME"), "@"),
+		Environment:            os.Getenv("TELEGRAM_ENVIRONMENT"),
+		UpdatesMode:            TelegramUpdatesMode(os.Getenv("TELEGRAM_UPDATES_MODE")),
+		WebhookSecretToken:     os.Getenv("TELEGRAM_WEBHOOK_SECRET_TOKEN"),
+		WebhookURL:             os.Getenv("TELEGRAM_WEBHOOK_URL"),
+		WebhookAllowedUpdates:  make([]string, 0),
+		WebhookCertificatePath: os.Getenv("TELEGRAM_WEBHOOK_CERTIFICATE_PATH"),
+		CallbackDataSecret:     os.Getenv("TELEGRAM_CALLBACK_DATA_SECRET"),
+	}
+	telegram.PollingTimeoutSecs, _ = strconv.Atoi(os.Getenv("TELEGRAM_POLLING_TIMEOUT_SECS"))
+	telegram.WebhookMaxConnections, _ = strconv.Atoi(os.Getenv("TELEGRAM_WEBHOOK_MAX_CONNECTIONS"))
+	telegram.OutboundGlobalRate, _ = strconv.ParseFloat(os.Getenv("TELEGRAM_OUTBOUND_GLOBAL_RATE"), 64)
+	telegram.OutboundChatRate, _ = strconv.ParseFloat(os.Getenv("TELEGRAM_OUTBOUND_CHAT_RATE"), 64)
+	telegram.OutboundMaxAttempts, _ = strconv.Atoi(os.Getenv("TELEGRAM_OUTBOUND_MAX_ATTEMPTS"))
+	telegram.CallbackDataTTLHours, _ = strconv.Atoi(os.Getenv("TELEGRAM_CALLBACK_DATA_TTL_HOURS"))
+	for _, allowedUpdate := range strings.Split(os.Getenv("TELEGRAM_WEBHOOK_ALLOWED_UPDATES"), ",") {
+		allowedUpdate = strings.TrimSpace(allowedUpdate)
+		if allowedUpdate != "" {
+			telegram.WebhookAllowedUpdates = append(telegram.WebhookAllowedUpdates, allowedUpdate)
+		}
+	}
+	if telegram.BotAPIURL == "" {
+		telegram.BotAPIURL = "https://api.telegram.org/bot"
+	}
+	if telegram.Environment != "test" {
+		telegram.Environment = "production"
+	}
+	if telegram.UpdatesMode != PollingTelegramUpdatesMode {
+		telegram.UpdatesMode = WebhookTelegramUpdatesMode
+	}
+	if telegram.PollingTimeoutSecs <= 0 {
+		telegram.PollingTimeoutSecs = 30
+	}
+	if telegram.OutboundGlobalRate <= 0 {
+		telegram.OutboundGlobalRate = 30
+	}
+	if telegram.OutboundChatRate <= 0 {
+		telegram.OutboundChatRate = 1
+	}
+	if telegram.OutboundMaxAttempts <= 0 {
+		telegram.OutboundMaxAttempts = 5
+	}
+	if telegram.WebhookMaxConnections <= 0 {
+		telegram.WebhookMaxConnections = 40
+	}
+	if telegram.CallbackDataTTLHours <= 0 {
+		telegram.CallbackDataTTLHours = 30 * 24
+	}
+	if len(telegram.WebhookAllowedUpdates) == 0 {
+		telegram.WebhookAllowedUpdates = []string{"message", "callback_query", "inline_query", "pre_checkout_query", "my_chat_member", "chat_member"}
+	}
+	if telegram.WebhookSecretToken == "" {
+		if telegram.IsPolling() {
+			return telegram, nil
+		}
+		return telegram, app.RequiredFieldError
+	}
+	if !isValidWebhookSecretToken(telegram.WebhookSecretToken) {
+		return telegram, app.InvalidWebhookSecretTokenError
+	}
+	return telegram, nil
+}
+
+// isValidWebhookSecretToken checks the token against the format telegram servers accept: 1-256 characters
+// of A-Z, a-z, 0-9, _ and -.
+func isValidWebhookSecretToken(token string) bool {
+	if len(token) == 0 || len(token) > 256 {
+		return false
+	}
+	for _, r := range token {
+		isLetter := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		isDigit := r >= '0' && r <= '9'
+		if !isLetter && !isDigit && r != '_' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+func ParseSMSActivateWebhookConfig() (SMSActivateWebhook, error) {
+	smsActivateWebhook := SMSActivateWebhook{
+		Token: os.Getenv("SMS_ACTIVATE_WEBHOOK_TOKEN"),
+	}
+	allowedNetworks, err := parseNetworks(os.Getenv("SMS_ACTIVATE_WEBHOOK_ALLOWED_IPS"))
+	if err != nil {
+		return smsActivateWebhook, err
+	}
+	trustedProxies, err := parseNetworks(os.Getenv("SMS_ACTIVATE_WEBHOOK_TRUSTED_PROXIES"))
+	if err != nil {
+		return smsActivateWebhook, err
+	}
+	smsActivateWebhook.AllowedNetworks = allowedNetworks
+	smsActivateWebhook.TrustedProxies = trustedProxies
+	return smsActivateWebhook, nil
+}
+
+// parseNetworks reads a comma separated list of ips and networks in the CIDR notation.
+func parseNetworks(value string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0)
+	for _, ip := range strings.Split(value, ",") {
+		ip = strings.TrimSpace(ip)
+		if ip == "" {
+			continue
+		}
+		if !strings.Contains(ip, "/") {
+			if strings.Contains(ip, ":") {
+				ip += "/128"
+			} else {
+				ip += "/32"
+			}
+		}
+		_, network, err := net.ParseCIDR(ip)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// ParseSubscriptionConfig reads REQUIRED_CHANNELS, a comma separated list of channels, a channel followed by `:off`
+// stays in the list but isn't checked.
+func ParseSubscriptionConfig() (Subscription, error) {
+	subscription := Subscription{
+		RequiredChannels: make([]RequiredChannel, 0),
+	}
+	for _, requiredChannel := range strings.Split(os.Getenv("REQUIRED_CHANNELS"), ",") {
+		requiredChannel = strings.TrimSpace(requiredChannel)
+		if requiredChannel == "" {
+			continue
+		}
+		chat, switchValue, hasSwitch := strings.Cut(requiredChannel, ":")
+		channel := RequiredChannel{
+			Chat:    strings.TrimSpace(chat),
+			Enabled: true,
+		}
+		if hasSwitch {
+			switch strings.ToLower(strings.TrimSpace(switchValue)) {
+			case "on":
+				channel.Enabled = true
+			case "off":
+				channel.Enabled = false
+			default:
+				return subscription, app.UnknownValueError
+			}
+		}
+		if channel.Chat == "" {
+			return subscription, app.RequiredFieldError
+		}
+		subscription.RequiredChannels = append(subscription.RequiredChannels, channel)
+	}
+	subscription.MembershipCacheTTLSeconds, _ = strconv.Atoi(os.Getenv("REQUIRED_CHANNELS_CACHE_TTL_SECONDS"))
+	if subscription.MembershipCacheTTLSeconds <= 0 {
+		subscription.MembershipCacheTTLSeconds = 300
+	}
+	return subscription, nil
+}
+
+// parseAdminTelegramIDs reads telegram ids of users that may run admin commands
+// regardless of the role of their profile.
+func parseAdminTelegramIDs() ([]int64, error) {
+	adminTelegramIDs := make([]int64, 0)
+	for _, adminID := range strings.Split(os.Getenv("ADMIN_IDS"), ",") {
+		adminID = strings.TrimSpace(adminID)
+		if adminID == "" {
+			continue
+		}
+		adminTelegramID, err := strconv.ParseInt(adminID, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		adminTelegramIDs = append(adminTelegramIDs, adminTelegramID)
+	}
+	return adminTelegramIDs, nil
+}
+
 func ParseDBConfig() DB {
 	return DB{
 		Host:     os.Getenv("POSTGRES_HOST"),
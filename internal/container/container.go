@@ -8,6 +8,9 @@ import (
 	"go-ton-pass-telegram-bot/internal/utils"
 	"go-ton-pass-telegram-bot/pkg/localizer"
 	"go-ton-pass-telegram-bot/pkg/logger"
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+	"regexp"
 )
 
 type Container interface {
@@ -18,7 +21,12 @@ type Container interface {
 	GetPreferredCountryCodesOrder() []string
 	GetFlagEmoji(name string) *string
 	GetRepresentableCountryName(countryID int64) *string
+	GetCountryDialingCode(countryID int64) *app.CountryDialingCode
+	GetDialingCodes() []string
+	GetLocalizedCountryName(langCode string, countryName string) *string
+	GetLocalizedServiceName(langCode string, serviceCode string) *string
 	GetExtraService(serviceCode string) *app.ExtraService
+	GetSMSCodeRules(serviceCode string) []*regexp.Regexp
 	PreloadData() error
 }
 
@@ -31,6 +39,11 @@ type container struct {
 	smsCountryActivateName     map[string]string
 	emojiFlag                  map[string]string
 	extraServices              map[string]app.ExtraService
+	smsCodeRules               map[string][]*regexp.Regexp
+	localizedCountryNames      map[string]map[string]string
+	localizedServiceNames      map[string]map[string]string
+	countryDialingCodes        map[string]app.CountryDialingCode
+	dialingCodes               []string
 }
 
 func NewContainer(logger logger.Logger, config config.Config, bundle *i18n.Bundle) Container {
@@ -43,6 +56,11 @@ func NewContainer(logger logger.Logger, config config.Config, bundle *i18n.Bundl
 		smsCountryActivateName:     make(map[string]string, 0),
 		emojiFlag:                  make(map[string]string),
 		extraServices:              make(map[string]app.ExtraService),
+		smsCodeRules:               make(map[string][]*regexp.Regexp),
+		localizedCountryNames:      make(map[string]map[string]string),
+		localizedServiceNames:      make(map[string]map[string]string),
+		countryDialingCodes:        make(map[string]app.CountryDialingCode),
+		dialingCodes:               make([]string, 0),
 	}
 }
 
@@ -60,6 +78,7 @@ func (c *container) PreloadData() error {
 	for _, value := range flagEmoji {
 		c.emojiFlag[value.Name] = value.Flag
 	}
+	c.localizedCountryNames = localizeCountryNames(flagEmoji, c.config.AvailableLanguages())
 	var extraServices = make([]app.ExtraService, 0)
 	if err := utils.UnmarshalFromFile("/jsons/extra_info_services.json", &extraServices); err != nil {
 		return err
@@ -70,6 +89,29 @@ func (c *container) PreloadData() error {
 	if err := utils.UnmarshalFromFile("/jsons/country_sms_activate_name.json", &c.smsCountryActivateName); err != nil {
 		return err
 	}
+	if err := utils.UnmarshalFromFile("/jsons/localized_service_names.json", &c.localizedServiceNames); err != nil {
+		return err
+	}
+	if err := utils.UnmarshalFromFile("/jsons/country_dialing_codes.json", &c.countryDialingCodes); err != nil {
+		return err
+	}
+	isKnownDialingCode := make(map[string]bool)
+	for _, countryDialingCode := range c.countryDialingCodes {
+		if isKnownDialingCode[countryDialingCode.DialingCode] {
+			continue
+		}
+		isKnownDialingCode[countryDialingCode.DialingCode] = true
+		c.dialingCodes = append(c.dialingCodes, countryDialingCode.DialingCode)
+	}
+	var smsCodeRules = make([]app.SMSCodeRule, 0)
+	if err := utils.UnmarshalFromFile("/jsons/sms_code_rules.json", &smsCodeRules); err != nil {
+		return err
+	}
+	compiledSMSCodeRules, err := utils.CompileSMSCodeRules(smsCodeRules)
+	if err != nil {
+		return err
+	}
+	c.smsCodeRules = compiledSMSCodeRules
 	return nil
 }
 
@@ -110,6 +152,38 @@ func (c *container) GetRepresentableCountryName(countryID int64) *string {
 	return nil
 }
 
+func (c *container) GetCountryDialingCode(countryID int64) *app.CountryDialingCode {
+	key := fmt.Sprintf("%d", countryID)
+	countryDialingCode, ok := c.countryDialingCodes[key]
+	if ok {
+		return &countryDialingCode
+	}
+	return nil
+}
+
+// GetDialingCodes returns every known dialing code once, in no particular order.
+func (c *container) GetDialingCodes() []string {
+	return c.dialingCodes
+}
+
+// GetLocalizedCountryName translates the english country name, the names come from CLDR by the ISO code of the country.
+func (c *container) GetLocalizedCountryName(langCode string, countryName string) *string {
+	name, ok := c.localizedCountryNames[langCode][countryName]
+	if ok {
+		return &name
+	}
+	return nil
+}
+
+// GetLocalizedServiceName returns the override of the service name for the language, brands usually have none.
+func (c *container) GetLocalizedServiceName(langCode string, serviceCode string) *string {
+	name, ok := c.localizedServiceNames[langCode][serviceCode]
+	if ok {
+		return &name
+	}
+	return nil
+}
+
 func (c *container) GetExtraService(serviceCode string) *app.ExtraService {
 	extraService, ok := c.extraServices[serviceCode]
 	if ok {
@@ -117,3 +191,36 @@ func (c *container) GetExtraService(serviceCode string) *app.ExtraService {
 	}
 	return nil
 }
+
+func (c *container) GetSMSCodeRules(serviceCode string) []*regexp.Regexp {
+	return c.smsCodeRules[serviceCode]
+}
+
+// localizeCountryNames builds names of countries for every language but English, english names are the source.
+// The ISO code of a country is read from its flag emoji.
+func localizeCountryNames(flagEmoji []app.FlagEmoji, languages []app.Language) map[string]map[string]string {
+	localizedCountryNames := make(map[string]map[string]string, len(languages))
+	for _, lang := range languages {
+		tag, err := language.Parse(lang.Code)
+		if err != nil || tag == language.English {
+			continue
+		}
+		namer := display.Regions(tag)
+		countryNames := make(map[string]string, len(flagEmoji))
+		for _, value := range flagEmoji {
+			isoCode := utils.ISOCodeFromFlagEmoji(value.Flag)
+			if isoCode == nil {
+				continue
+			}
+			region, err := language.ParseRegion(*isoCode)
+			if err != nil {
+				continue
+			}
+			if name := namer.Name(region); name != "" {
+				countryNames[value.Name] = name
+			}
+		}
+		localizedCountryNames[lang.Code] = countryNames
+	}
+	return localizedCountryNames
+}
@@ -29,12 +29,13 @@ type cryptoController struct {
 
 func NewCryptoController(
 	container container.Container,
+	telegramBotService service.TelegramBotService,
 	sessionService service.SessionService,
 	profileRepository repository.ProfileRepository,
 ) CryptoController {
 	return &cryptoController{
 		container:          container,
-		telegramBotService: service.NewTelegramBot(container),
+		telegramBotService: telegramBotService,
 		cryptoPayBot:       service.NewCryptoPayBot(container),
 		sessionService:     sessionService,
 		profileRepository:  profileRepository,
@@ -72,6 +73,7 @@ func (c *cryptoController) Serve(update *bot.WebhookUpdates) error {
 	if err != nil {
 		log.Debug("paidUsdRate has unknown float format", logger.FError(err))
 		return c.SendTextWithPhotoMedia(
+			ctx,
 			profile.TelegramChatID,
 			localizer.LocalizedString("internal_error_markdown"),
 			avatarImageURL,
@@ -82,6 +84,7 @@ func (c *cryptoController) Serve(update *bot.WebhookUpdates) error {
 	if err != nil {
 		log.Debug("amount has unknown float format", logger.FError(err))
 		return c.SendTextWithPhotoMedia(
+			ctx,
 			profile.TelegramChatID,
 			localizer.LocalizedString("internal_error_markdown"),
 			avatarImageURL,
@@ -97,6 +100,7 @@ func (c *cryptoController) Serve(update *bot.WebhookUpdates) error {
 	); err != nil {
 		log.Debug("fail to top up balance", logger.FError(err))
 		return c.SendTextWithPhotoMedia(
+			ctx,
 			profile.TelegramChatID,
 			localizer.LocalizedString("internal_error_markdown"),
 			avatarImageURL,
@@ -104,6 +108,7 @@ func (c *cryptoController) Serve(update *bot.WebhookUpdates) error {
 		)
 	}
 	return c.SendTextWithPhotoMedia(
+		ctx,
 		profile.TelegramChatID,
 		localizer.LocalizedString("balance_updated_markdown"),
 		avatarImageURL,
@@ -111,7 +116,7 @@ func (c *cryptoController) Serve(update *bot.WebhookUpdates) error {
 	)
 }
 
-func (c *cryptoController) SendTextWithPhotoMedia(chatID int64, text string, photoURL string, replyMarkup any) error {
+func (c *cryptoController) SendTextWithPhotoMedia(ctx context.Context, chatID int64, text string, photoURL string, replyMarkup any) error {
 	log := c.container.GetLogger()
 	resp := telegram.SendPhoto{
 		ChatID:      chatID,
@@ -120,7 +125,7 @@ func (c *cryptoController) SendTextWithPhotoMedia(chatID int64, text string, pho
 		ParseMode:   utils.NewString("MarkdownV2"),
 		ReplyMarkup: replyMarkup,
 	}
-	if err := c.telegramBotService.SendResponse(resp, app.SendPhotoTelegramMethod); err != nil {
+	if err := c.telegramBotService.Enqueue(ctx, app.TransactionalOutboundPriority, app.SendPhotoOutboundMethod, chatID, &resp); err != nil {
 		log.Debug("fail to send message with photo media", logger.FError(err))
 		return err
 	}
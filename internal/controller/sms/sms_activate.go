@@ -3,7 +3,9 @@ package sms
 import (
 	"context"
 	"go-ton-pass-telegram-bot/internal/container"
+	"go-ton-pass-telegram-bot/internal/manager"
 	"go-ton-pass-telegram-bot/internal/model/app"
+	"go-ton-pass-telegram-bot/internal/model/domain"
 	"go-ton-pass-telegram-bot/internal/model/sms"
 	"go-ton-pass-telegram-bot/internal/model/telegram"
 	"go-ton-pass-telegram-bot/internal/repository"
@@ -18,41 +20,95 @@ const (
 )
 
 type SMSActivateController interface {
-	Serve(update *sms.WebhookUpdates) error
+	Serve(update *sms.WebhookUpdates, payload []byte) error
 }
 
 type smsActivateController struct {
-	container            container.Container
-	telegramBotService   service.TelegramBotService
-	profileRepository    repository.ProfileRepository
-	smsHistoryRepository repository.SMSHistoryRepository
-	formatterWorker      worker.Formatter
+	container                   container.Container
+	telegramBotService          service.TelegramBotService
+	profileRepository           repository.ProfileRepository
+	smsHistoryRepository        repository.SMSHistoryRepository
+	smsActivateUpdateRepository repository.SMSActivateUpdateRepository
+	activationGroupMessenger    manager.ActivationGroupMessenger
+	formatterWorker             worker.Formatter
 }
 
 func NewSMSActivateController(
 	container container.Container,
+	telegramBotService service.TelegramBotService,
 	profileRepository repository.ProfileRepository,
 	smsHistoryRepository repository.SMSHistoryRepository,
+	smsActivateUpdateRepository repository.SMSActivateUpdateRepository,
+	activationGroupRepository repository.ActivationGroupRepository,
 ) *smsActivateController {
+	activationGroupMessenger := manager.NewActivationGroupMessenger(container, telegramBotService, activationGroupRepository, smsHistoryRepository)
 	return &smsActivateController{
-		container:            container,
-		profileRepository:    profileRepository,
-		smsHistoryRepository: smsHistoryRepository,
-		telegramBotService:   service.NewTelegramBot(container),
-		formatterWorker:      worker.NewFormatter(container),
+		container:                   container,
+		profileRepository:           profileRepository,
+		smsHistoryRepository:        smsHistoryRepository,
+		smsActivateUpdateRepository: smsActivateUpdateRepository,
+		telegramBotService:          telegramBotService,
+		activationGroupMessenger:    activationGroupMessenger,
+		formatterWorker:             worker.NewFormatter(container),
 	}
 }
 
-func (s *smsActivateController) Serve(update *sms.WebhookUpdates) error {
+func (s *smsActivateController) Serve(update *sms.WebhookUpdates, payload []byte) error {
 	ctx := context.Background()
+	log := s.container.GetLogger()
+	recordedUpdate, err := s.smsActivateUpdateRepository.Record(ctx, &domain.SMSActivateUpdate{
+		ActivationID: update.ActivationID,
+		ReceivedAt:   update.ReceivedAt,
+		Payload:      payload,
+	})
+	if err != nil {
+		log.Error("fail to record sms activate update", logger.FError(err))
+		return err
+	}
+	isClaimed, err := s.smsActivateUpdateRepository.Claim(ctx, recordedUpdate.ID)
+	if err != nil {
+		log.Error("fail to claim sms activate update", logger.FError(err))
+		return err
+	}
+	if !isClaimed {
+		log.Debug(
+			"skip already processed sms activate update",
+			logger.F("activation_id", update.ActivationID),
+			logger.F("received_at", update.ReceivedAt),
+		)
+		return nil
+	}
+	if err := s.serveUpdate(ctx, update); err != nil {
+		if err := s.smsActivateUpdateRepository.Release(ctx, recordedUpdate.ID); err != nil {
+			log.Error("fail to release sms activate update", logger.FError(err))
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *smsActivateController) serveUpdate(ctx context.Context, update *sms.WebhookUpdates) error {
 	log := s.container.GetLogger()
 	domainSMSHistory, err := s.smsHistoryRepository.GetByActivationID(ctx, update.ActivationID)
 	if err != nil {
 		log.Error("fail to get sms history from db", logger.FError(err))
 		return err
 	}
+	code := update.Code
+	if utils.IsSuspiciousSMSCode(code, update.Text) {
+		extractedCode := utils.ExtractSMSCode(update.Text, s.container.GetSMSCodeRules(domainSMSHistory.ServiceCode))
+		log.Debug(
+			"extract code from sms text",
+			logger.F("provided_code", code),
+			logger.F("extracted_code", extractedCode),
+			logger.F("service_code", domainSMSHistory.ServiceCode),
+		)
+		if extractedCode != "" {
+			code = extractedCode
+		}
+	}
 	domainSMSHistory.SMSText = utils.NewString(update.Text)
-	domainSMSHistory.SMSCode = utils.NewString(update.Code)
+	domainSMSHistory.SMSCode = utils.NewString(code)
 	if err := s.smsHistoryRepository.ReceiveSMSCode(ctx, domainSMSHistory); err != nil {
 		log.Error("fail to get sms history from db", logger.FError(err))
 		return err
@@ -62,7 +118,15 @@ func (s *smsActivateController) Serve(update *sms.WebhookUpdates) error {
 		log.Error("fail to get fetch profile from db by id", logger.FError(err))
 		return err
 	}
+	if domainProfile.IsBotBlocked() {
+		// the code is saved in the history, the user sees it after starting the bot again
+		log.Debug("skip code to profile that has blocked the bot", logger.F("profile_id", domainProfile.ID))
+		return nil
+	}
 	langCode := *domainProfile.PreferredLanguage
+	if domainSMSHistory.ActivationGroupID != nil {
+		return s.activationGroupMessenger.Refresh(ctx, *domainSMSHistory.ActivationGroupID, langCode)
+	}
 	replyKeyboardRemove := telegram.ReplyKeyboardRemove{
 		RemoveKeyboard: true,
 	}
@@ -73,7 +137,14 @@ func (s *smsActivateController) Serve(update *sms.WebhookUpdates) error {
 		Caption:     respText,
 		ReplyMarkup: replyKeyboardRemove,
 	}
-	if err := s.telegramBotService.SendResponse(sendPhoto, app.SendPhotoTelegramMethod); err != nil {
+	err = s.telegramBotService.Enqueue(
+		ctx,
+		app.CodeOutboundPriority,
+		app.SendPhotoOutboundMethod,
+		domainProfile.TelegramChatID,
+		&sendPhoto,
+	)
+	if err != nil {
 		log.Error("send code to telegram chat has failed", logger.FError(err))
 		return err
 	}
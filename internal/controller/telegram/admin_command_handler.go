@@ -0,0 +1,314 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-ton-pass-telegram-bot/internal/model/app"
+	"go-ton-pass-telegram-bot/internal/model/domain"
+	model "go-ton-pass-telegram-bot/internal/model/postpone"
+	"go-ton-pass-telegram-bot/pkg/logger"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	adminRecentActivationsLimit = 5
+	adminStatsPeriod            = 24 * time.Hour
+)
+
+// hasAdminPermission lets in profiles with the admin role and telegram ids listed in the config.
+func (b *botController) hasAdminPermission(profile *domain.Profile) bool {
+	if profile == nil {
+		return false
+	}
+	return profile.Role == domain.AdminProfileRole || b.container.GetConfig().IsAdminTelegramID(profile.TelegramID)
+}
+
+// deniedAdminTelegramCommandHandler answers as to any unknown command, so the admin commands stay hidden.
+func (b *botController) deniedAdminTelegramCommandHandler(ctx context.Context, ctxOptions *ContextOptions) error {
+	b.saveAdminAuditLog(ctx, ctxOptions, false, nil)
+	return b.unknownTelegramCommandHandler(ctx, ctxOptions)
+}
+
+// adminTelegramCommandHandler runs the command and records the reply to the admin in the audit log.
+func (b *botController) adminTelegramCommandHandler(ctx context.Context, ctxOptions *ContextOptions) error {
+	log := b.container.GetLogger()
+	cmdText, arguments := splitAdminCommand(*ctxOptions.Update.Message.Text)
+	var (
+		text string
+		err  error
+	)
+	switch cmdText {
+	case app.UserAdminCmdText:
+		text, err = b.userAdminCommandHandler(ctx, ctxOptions, arguments)
+	case app.CreditAdminCmdText:
+		text, err = b.creditAdminCommandHandler(ctx, ctxOptions, arguments)
+	case app.DebitAdminCmdText:
+		text, err = b.debitAdminCommandHandler(ctx, ctxOptions, arguments)
+	case app.ActivationAdminCmdText:
+		text, err = b.activationAdminCommandHandler(ctx, ctxOptions, arguments)
+	case app.StatsAdminCmdText:
+		text, err = b.statsAdminCommandHandler(ctx, ctxOptions)
+	case app.BroadcastAdminCmdText:
+		text, err = b.broadcastAdminCommandHandler(ctx, ctxOptions, arguments)
+	default:
+		err = app.NotSupportedTelegramCommandError
+	}
+	if err != nil {
+		log.Error("fail to run admin command", logger.F("command", cmdText), logger.FError(err))
+		result := err.Error()
+		b.saveAdminAuditLog(ctx, ctxOptions, true, &result)
+		return b.sendMessageInternalServerError(ctx, ctxOptions)
+	}
+	b.saveAdminAuditLog(ctx, ctxOptions, true, &text)
+	return b.sendMessagePlainText(ctx, text, ctxOptions)
+}
+
+func (b *botController) userAdminCommandHandler(
+	ctx context.Context,
+	ctxOptions *ContextOptions,
+	arguments []string,
+) (string, error) {
+	localizer := b.container.GetLocalizer(b.getPreferredLanguage(ctxOptions))
+	if len(arguments) != 1 {
+		return localizer.LocalizedString("admin_user_usage"), nil
+	}
+	profile, err := b.fetchAdminTargetProfile(ctx, arguments[0])
+	if errors.Is(err, app.UserNotFoundError) {
+		return localizer.LocalizedStringWithTemplateData("admin_user_not_found", map[string]any{
+			"User": arguments[0],
+		}), nil
+	} else if err != nil {
+		return "", err
+	}
+	smsHistories, err := b.smsHistoryRepository.FetchList(ctx, profile.ID, 0, adminRecentActivationsLimit)
+	if err != nil {
+		return "", err
+	}
+	return b.formatterWorker.AdminProfile(b.getPreferredLanguage(ctxOptions), profile, smsHistories), nil
+}
+
+func (b *botController) creditAdminCommandHandler(
+	ctx context.Context,
+	ctxOptions *ContextOptions,
+	arguments []string,
+) (string, error) {
+	localizer := b.container.GetLocalizer(b.getPreferredLanguage(ctxOptions))
+	user, amount, ok := parseAdminBalanceArguments(arguments)
+	if !ok {
+		return localizer.LocalizedString("admin_credit_usage"), nil
+	}
+	profile, err := b.fetchAdminTargetProfile(ctx, user)
+	if errors.Is(err, app.UserNotFoundError) {
+		return localizer.LocalizedStringWithTemplateData("admin_user_not_found", map[string]any{
+			"User": user,
+		}), nil
+	} else if err != nil {
+		return "", err
+	}
+	if err := b.profileRepository.TopUpBalanceByProfileID(ctx, profile.ID, amount); err != nil {
+		return "", err
+	}
+	profile, err = b.profileRepository.FetchByID(ctx, profile.ID)
+	if err != nil {
+		return "", err
+	}
+	return localizer.LocalizedStringWithTemplateData("admin_balance_credited", map[string]any{
+		"Amount":  strconv.FormatFloat(amount, 'f', 2, 64),
+		"User":    user,
+		"Balance": strconv.FormatFloat(profile.Balance, 'f', 2, 64),
+	}), nil
+}
+
+func (b *botController) debitAdminCommandHandler(
+	ctx context.Context,
+	ctxOptions *ContextOptions,
+	arguments []string,
+) (string, error) {
+	localizer := b.container.GetLocalizer(b.getPreferredLanguage(ctxOptions))
+	user, amount, ok := parseAdminBalanceArguments(arguments)
+	if !ok {
+		return localizer.LocalizedString("admin_debit_usage"), nil
+	}
+	profile, err := b.fetchAdminTargetProfile(ctx, user)
+	if errors.Is(err, app.UserNotFoundError) {
+		return localizer.LocalizedStringWithTemplateData("admin_user_not_found", map[string]any{
+			"User": user,
+		}), nil
+	} else if err != nil {
+		return "", err
+	}
+	debited, err := b.profileRepository.DebitIfSufficient(ctx, profile.TelegramID, amount)
+	if err != nil {
+		return "", err
+	}
+	profile, err = b.profileRepository.FetchByID(ctx, profile.ID)
+	if err != nil {
+		return "", err
+	}
+	if !debited {
+		return localizer.LocalizedStringWithTemplateData("admin_insufficient_funds", map[string]any{
+			"User":    user,
+			"Balance": strconv.FormatFloat(profile.Balance, 'f', 2, 64),
+		}), nil
+	}
+	return localizer.LocalizedStringWithTemplateData("admin_balance_debited", map[string]any{
+		"Amount":  strconv.FormatFloat(amount, 'f', 2, 64),
+		"User":    user,
+		"Balance": strconv.FormatFloat(profile.Balance, 'f', 2, 64),
+	}), nil
+}
+
+// activationAdminCommandHandler shows the activation, with the cancel argument it cancels a pending activation
+// the same way the user does, so the user gets the refund and the message about it.
+func (b *botController) activationAdminCommandHandler(
+	ctx context.Context,
+	ctxOptions *ContextOptions,
+	arguments []string,
+) (string, error) {
+	preferredLanguage := b.getPreferredLanguage(ctxOptions)
+	localizer := b.container.GetLocalizer(preferredLanguage)
+	if len(arguments) == 0 || len(arguments) > 2 {
+		return localizer.LocalizedString("admin_activation_usage"), nil
+	}
+	shouldCancel := len(arguments) == 2
+	if shouldCancel && arguments[1] != app.CancelActivationAdminArgument {
+		return localizer.LocalizedString("admin_activation_usage"), nil
+	}
+	activationID, err := strconv.ParseInt(arguments[0], 10, 64)
+	if err != nil {
+		return localizer.LocalizedString("admin_activation_usage"), nil
+	}
+	smsHistory, err := b.smsHistoryRepository.GetByActivationID(ctx, activationID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return localizer.LocalizedStringWithTemplateData("admin_activation_not_found", map[string]any{
+			"ActivationID": activationID,
+		}), nil
+	} else if err != nil {
+		return "", err
+	}
+	isCancelable := app.SMSActivationState(smsHistory.Status) == app.PendingSMSActivateState && smsHistory.SMSCode == nil
+	if !shouldCancel {
+		text := b.formatterWorker.AdminActivation(preferredLanguage, smsHistory)
+		if isCancelable {
+			text += "\n\n" + localizer.LocalizedStringWithTemplateData("admin_activation_cancel_hint", map[string]any{
+				"ActivationID": activationID,
+			})
+		}
+		return text, nil
+	}
+	if !isCancelable {
+		return localizer.LocalizedStringWithTemplateData("admin_activation_not_cancelable", map[string]any{
+			"ActivationID": activationID,
+		}), nil
+	}
+	temporalWorkflowDomain, err := b.temporalWorkflowRepository.GetBySMSHistoryID(ctx, smsHistory.ID)
+	if err != nil {
+		return "", err
+	}
+	workflow := model.Workflow{
+		ID:    temporalWorkflowDomain.TemporalID,
+		RunID: temporalWorkflowDomain.TemporalRunID,
+	}
+	if err := b.postponeService.CancelSMSActivation(ctx, workflow); err != nil {
+		return "", err
+	}
+	return localizer.LocalizedStringWithTemplateData("admin_activation_canceled", map[string]any{
+		"ActivationID": activationID,
+	}), nil
+}
+
+func (b *botController) statsAdminCommandHandler(ctx context.Context, ctxOptions *ContextOptions) (string, error) {
+	since := time.Now().Add(-adminStatsPeriod)
+	stats, err := b.profileRepository.FetchStats(ctx, since)
+	if err != nil {
+		return "", err
+	}
+	stats.ActivationCounts, err = b.smsHistoryRepository.FetchStatusCounts(ctx, since)
+	if err != nil {
+		return "", err
+	}
+	return b.formatterWorker.AdminStats(b.getPreferredLanguage(ctxOptions), stats), nil
+}
+
+// fetchAdminTargetProfile finds the profile by a telegram id or by a username prefixed with @.
+func (b *botController) fetchAdminTargetProfile(ctx context.Context, user string) (*domain.Profile, error) {
+	var (
+		profile *domain.Profile
+		err     error
+	)
+	if username, ok := strings.CutPrefix(user, "@"); ok {
+		profile, err = b.profileRepository.FetchByUsername(ctx, username)
+	} else if telegramID, parseErr := strconv.ParseInt(user, 10, 64); parseErr == nil {
+		profile, err = b.profileRepository.FetchByTelegramID(ctx, telegramID)
+	} else {
+		return nil, app.UserNotFoundError
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, app.UserNotFoundError
+	} else if err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// saveAdminAuditLog records the admin command of the message.
+func (b *botController) saveAdminAuditLog(ctx context.Context, ctxOptions *ContextOptions, allowed bool, result *string) {
+	cmdText, arguments := splitAdminCommand(*ctxOptions.Update.Message.Text)
+	b.recordAdminAuditLog(ctx, ctxOptions, string(cmdText), strings.Join(arguments, " "), allowed, result)
+}
+
+// recordAdminAuditLog doesn't fail the command, the command has already been run when its result is recorded.
+func (b *botController) recordAdminAuditLog(
+	ctx context.Context,
+	ctxOptions *ContextOptions,
+	command string,
+	arguments string,
+	allowed bool,
+	result *string,
+) {
+	log := b.container.GetLogger()
+	adminAuditLog := domain.AdminAuditLog{
+		TelegramID: ctxOptions.Update.GetTelegramID(),
+		Command:    command,
+		Arguments:  arguments,
+		Allowed:    allowed,
+		Result:     result,
+	}
+	if ctxOptions.Profile != nil {
+		adminAuditLog.ProfileID = &ctxOptions.Profile.ID
+	}
+	if _, err := b.adminAuditLogRepository.Create(ctx, &adminAuditLog); err != nil {
+		log.Error(
+			"fail to save admin audit log",
+			logger.F("command", adminAuditLog.Command),
+			logger.F("arguments", adminAuditLog.Arguments),
+			logger.F("allowed", allowed),
+			logger.FError(err),
+		)
+	}
+}
+
+func splitAdminCommand(text string) (app.AdminCmdText, []string) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return "", nil
+	}
+	return app.AdminCmdText(fields[0]), fields[1:]
+}
+
+// parseAdminBalanceArguments parses `<id|@username> <amount in USD> <reason>`, the reason is required,
+// so every balance change made by hand is explained in the audit log.
+func parseAdminBalanceArguments(arguments []string) (string, float64, bool) {
+	if len(arguments) < 3 {
+		return "", 0, false
+	}
+	amount, err := strconv.ParseFloat(arguments[1], 64)
+	if err != nil || !(amount > 0) || math.IsInf(amount, 1) {
+		return "", 0, false
+	}
+	return arguments[0], amount, true
+}
@@ -6,21 +6,29 @@ import (
 	"encoding/json"
 	"go-ton-pass-telegram-bot/internal/model/app"
 	"go-ton-pass-telegram-bot/internal/model/telegram"
-	"go-ton-pass-telegram-bot/internal/service"
 	"go-ton-pass-telegram-bot/internal/utils"
+	"go-ton-pass-telegram-bot/pkg/fsm"
 	"go-ton-pass-telegram-bot/pkg/logger"
 	"math"
 )
 
-func (b *botController) enteringAmountCurrencyBotStageHandler(ctx context.Context, ctxOptions *ContextOptions) error {
+// enteringAmountCurrencyBotStageHandler sends the invoice for the amount, the conversation is over once
+// the amount is entered.
+func (b *botController) enteringAmountCurrencyBotStageHandler(
+	ctx context.Context,
+	ctxOptions *ContextOptions,
+	data *enteringAmountConversationData,
+) (fsm.Transition, error) {
+	return fsm.Finish(), b.payEnteredAmount(ctx, ctxOptions, data)
+}
+
+func (b *botController) payEnteredAmount(
+	ctx context.Context,
+	ctxOptions *ContextOptions,
+	data *enteringAmountConversationData,
+) error {
 	log := b.container.GetLogger()
 	text := ctxOptions.Update.Message.Text
-	telegramID := ctxOptions.Update.GetTelegramID()
-	if text == nil {
-		log.Error("text has nil value")
-		return b.sendMessageInternalServerError(ctx, ctxOptions)
-	}
-
 	amount, err := utils.ParseFloat64FromText(*text)
 	if err != nil {
 		log.Error(
@@ -30,31 +38,15 @@ func (b *botController) enteringAmountCurrencyBotStageHandler(ctx context.Contex
 		)
 		return b.editMessageEnterAmountPayError(ctx, ctxOptions)
 	}
-	paymentMethod, err := b.sessionService.GetString(ctx, service.SelectedPaymentMethodSessionKey, telegramID)
-	if err != nil {
-		log.Error("fail to get payment method from session service", logger.FError(err))
-		return b.sendMessageInternalServerError(ctx, ctxOptions)
-	}
-	if paymentMethod == nil {
-		log.Error("paymentMethod is nil")
-		return b.sendMessageInternalServerError(ctx, ctxOptions)
-	}
+	paymentMethod := data.PaymentMethod
 	var (
 		selectedCurrency *string
 	)
-	switch *paymentMethod {
+	switch paymentMethod {
 	case app.TelegramStarsPaymentMethod:
 		selectedCurrency = utils.NewString("XTR")
 	case app.CryptoBotPaymentMethod:
-		currency, err := b.sessionService.GetString(ctx, service.SelectedCryptoBotPayCurrencyAbbrSessionKey, telegramID)
-		if err != nil {
-			log.Error(
-				"fail to get the pay currency from session service",
-				logger.FError(err),
-			)
-			return b.sendMessageInternalServerError(ctx, ctxOptions)
-		}
-		selectedCurrency = currency
+		selectedCurrency = data.PayCurrency
 	case app.StripePaymentMethod:
 		selectedCurrency = ctxOptions.Profile.PreferredCurrency
 	default:
@@ -83,7 +75,7 @@ func (b *botController) enteringAmountCurrencyBotStageHandler(ctx context.Contex
 		return b.sendMessageInternalServerError(ctx, ctxOptions)
 	}
 
-	switch *paymentMethod {
+	switch paymentMethod {
 	case app.CryptoBotPaymentMethod:
 		return b.sendCryptoBotInvoice(ctx, ctxOptions, selectedCurrency, convertedAmount)
 	case app.TelegramStarsPaymentMethod:
@@ -111,7 +103,7 @@ func (b *botController) enteringAmountCurrencyBotStageHandler(ctx context.Contex
 	}
 }
 
-func (b *botController) sendTelegramStarsInvoice(_ context.Context, ctxOptions *ContextOptions, creditBalance float64, stars int64) error {
+func (b *botController) sendTelegramStarsInvoice(ctx context.Context, ctxOptions *ContextOptions, creditBalance float64, stars int64) error {
 	log := b.container.GetLogger()
 	telegramID := ctxOptions.Update.GetTelegramID()
 	preferredLanguage := b.getPreferredLanguage(ctxOptions)
@@ -127,7 +119,7 @@ func (b *botController) sendTelegramStarsInvoice(_ context.Context, ctxOptions *
 		return err
 	}
 	encodedPayloadData := base64.StdEncoding.EncodeToString(payloadData)
-	replyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.TelegramStarsPayInlineKeyboardMarkup(stars)
+	replyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.TelegramStarsPayInlineKeyboardMarkup(ctx, stars)
 	if err != nil {
 		log.Error("fail to get telegram stars inline keyboard markup", logger.FError(err))
 		return err
@@ -150,7 +142,7 @@ func (b *botController) sendTelegramStarsInvoice(_ context.Context, ctxOptions *
 		ReplyMarkup:    replyMarkup,
 	}
 
-	if err := b.telegramBotService.SendResponse(sendInvoice, app.SendInvoiceTelegramMethod); err != nil {
+	if _, err := b.telegramBotService.SendInvoice(ctx, &sendInvoice); err != nil {
 		log.Error(
 			"fail to send invoice",
 			logger.FError(err),
@@ -0,0 +1,337 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-ton-pass-telegram-bot/internal/manager"
+	"go-ton-pass-telegram-bot/internal/model/app"
+	"go-ton-pass-telegram-bot/internal/model/domain"
+	"go-ton-pass-telegram-bot/internal/model/telegram"
+	"go-ton-pass-telegram-bot/internal/utils"
+	"go-ton-pass-telegram-bot/pkg/fsm"
+	"go-ton-pass-telegram-bot/pkg/logger"
+	"go-ton-pass-telegram-bot/pkg/telegram_bot"
+	"strconv"
+	"strings"
+)
+
+const (
+	languageBroadcastArgument = "language"
+	currencyBroadcastArgument = "currency"
+)
+
+// broadcastAdminCommandHandler creates a draft for the segment given in arguments like `language=en,ru currency=USD
+// active=30`, the next message of the admin becomes the content of the broadcast.
+func (b *botController) broadcastAdminCommandHandler(
+	ctx context.Context,
+	ctxOptions *ContextOptions,
+	arguments []string,
+) (string, error) {
+	localizer := b.container.GetLocalizer(b.getPreferredLanguage(ctxOptions))
+	segment, ok := b.parseBroadcastSegment(arguments)
+	if !ok {
+		return localizer.LocalizedString("admin_broadcast_usage"), nil
+	}
+	broadcast := domain.Broadcast{
+		AuthorProfileID: &ctxOptions.Profile.ID,
+		Status:          domain.DraftBroadcastStatus,
+		Segment:         *segment,
+	}
+	broadcastID, err := b.broadcastRepository.Create(ctx, &broadcast)
+	if err != nil {
+		return "", err
+	}
+	data := composingBroadcastConversationData{BroadcastID: *broadcastID}
+	err = b.conversation.Start(ctx, ctxOptions.Update.GetTelegramID(), ctxOptions, composingBroadcastConversationState, data)
+	if err != nil {
+		return "", err
+	}
+	return localizer.LocalizedStringWithTemplateData("broadcast_compose", map[string]any{
+		"ID": *broadcastID,
+	}), nil
+}
+
+// composingBroadcastBotStageHandler saves the message of the admin as the content of the draft, sends the preview
+// and the control message with the number of recipients. The admin is asked again for a content telegram servers
+// refuse or fail to send.
+func (b *botController) composingBroadcastBotStageHandler(
+	ctx context.Context,
+	ctxOptions *ContextOptions,
+	data *composingBroadcastConversationData,
+) (fsm.Transition, error) {
+	log := b.container.GetLogger()
+	if !b.hasAdminPermission(ctxOptions.Profile) {
+		return fsm.Finish(), b.helpTelegramCommandHandler(ctx, ctxOptions)
+	}
+	chatID := ctxOptions.Update.GetChatID()
+	preferredLanguage := b.getPreferredLanguage(ctxOptions)
+	localizer := b.container.GetLocalizer(preferredLanguage)
+	broadcastID := data.BroadcastID
+	broadcast, err := b.broadcastRepository.FetchByID(ctx, broadcastID)
+	if err != nil {
+		log.Error("fail to fetch broadcast", logger.F("broadcast_id", broadcastID), logger.FError(err))
+		return fsm.Finish(), b.sendMessageInternalServerError(ctx, ctxOptions)
+	}
+	message := ctxOptions.Update.Message
+	var (
+		text     string
+		entities []telegram.MessageEntity
+	)
+	if photo := message.LargestPhoto(); photo != nil {
+		broadcast.Photo = &photo.FileID
+		if message.Caption != nil {
+			text = *message.Caption
+		}
+		entities = message.CaptionEntities
+	} else if message.Text != nil {
+		broadcast.Photo = nil
+		text = *message.Text
+		entities = message.Entities
+	}
+	text, broadcast.Buttons = utils.SplitBroadcastButtons(text)
+	broadcast.Text = &text
+	broadcast.Entities = nil
+	if keptEntities := keepBroadcastEntities(entities, text); len(keptEntities) > 0 {
+		broadcast.Entities, err = json.Marshal(keptEntities)
+		if err != nil {
+			log.Error("fail to marshal broadcast entities", logger.FError(err))
+			return fsm.Stay(), b.sendMessageInternalServerError(ctx, ctxOptions)
+		}
+	}
+	method, payload, err := manager.NewBroadcastMessage(broadcast, chatID)
+	if err != nil {
+		log.Error("fail to create broadcast message", logger.F("broadcast_id", broadcastID), logger.FError(err))
+		return fsm.Stay(), b.sendMessageInternalServerError(ctx, ctxOptions)
+	}
+	// the preview is the broadcast itself
+	if _, err := b.telegramBotService.Send(ctx, app.TransactionalOutboundPriority, method, chatID, payload); telegram_bot.IsBadRequest(err) {
+		return fsm.Stay(), b.sendMessagePlainText(ctx, localizer.LocalizedStringWithTemplateData("broadcast_invalid", map[string]any{
+			"Error": err.Error(),
+		}), ctxOptions)
+	} else if err != nil {
+		log.Error("fail to send broadcast preview", logger.F("broadcast_id", broadcastID), logger.FError(err))
+		return fsm.Stay(), b.sendMessageInternalServerError(ctx, ctxOptions)
+	}
+	if err := b.broadcastRepository.SetContent(ctx, broadcast); err != nil {
+		log.Error("fail to save broadcast content", logger.F("broadcast_id", broadcastID), logger.FError(err))
+		return fsm.Stay(), b.sendMessageInternalServerError(ctx, ctxOptions)
+	}
+	recipientsCount, err := b.broadcastRepository.CountRecipients(ctx, broadcast.Segment)
+	if err != nil {
+		log.Error("fail to count broadcast recipients", logger.F("broadcast_id", broadcastID), logger.FError(err))
+		return fsm.Stay(), b.sendMessageInternalServerError(ctx, ctxOptions)
+	}
+	replyMarkup, err := manager.NewBroadcastControlInlineKeyboardMarkup(
+		ctx,
+		b.telegramBotService,
+		localizer,
+		broadcast.ID,
+		broadcast.Status,
+	)
+	if err != nil {
+		log.Error("fail to create broadcast control keyboard", logger.FError(err))
+		return fsm.Stay(), b.sendMessageInternalServerError(ctx, ctxOptions)
+	}
+	resp := telegram.SendResponse{
+		ChatID: chatID,
+		Text: localizer.LocalizedStringWithTemplateData("broadcast_draft", map[string]any{
+			"ID":              broadcast.ID,
+			"RecipientsCount": recipientsCount,
+		}),
+		ReplyMarkup: replyMarkup,
+	}
+	controlMessage, err := b.telegramBotService.SendMessage(ctx, &resp)
+	if err != nil {
+		log.Error("fail to send broadcast control message", logger.F("broadcast_id", broadcastID), logger.FError(err))
+		return fsm.Transition{}, err
+	}
+	if err := b.broadcastRepository.SetControlMessage(ctx, broadcast.ID, chatID, controlMessage.ID); err != nil {
+		log.Error("fail to save broadcast control message", logger.F("broadcast_id", broadcastID), logger.FError(err))
+		return fsm.Stay(), b.sendMessageInternalServerError(ctx, ctxOptions)
+	}
+	return fsm.Finish(), nil
+}
+
+// controlBroadcastCallbackQueryCommandHandler starts the draft, actions for a started broadcast are passed
+// to its workflow, which reports the result in the control message.
+func (b *botController) controlBroadcastCallbackQueryCommandHandler(
+	ctx context.Context,
+	ctxOptions *ContextOptions,
+	callbackData *app.TelegramCallbackData,
+) error {
+	log := b.container.GetLogger()
+	callbackQuery := ctxOptions.Update.CallbackQuery
+	localizer := b.container.GetLocalizer(b.getPreferredLanguage(ctxOptions))
+	if callbackData.Parameters == nil {
+		log.Error("parameters has nil value", logger.FError(app.NilError))
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	parameters := *callbackData.Parameters
+	if len(parameters) < 2 {
+		log.Error("not enough length parameters")
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	broadcastID := utils.GetInt64(parameters[0])
+	rawAction, ok := parameters[1].(string)
+	if !ok {
+		log.Error("parameters[1] should be a string")
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	action := app.BroadcastAction(rawAction)
+	accepted, err := b.controlBroadcast(ctx, broadcastID, action)
+	result := fmt.Sprintf("accepted: %t", accepted)
+	if err != nil {
+		result = err.Error()
+	}
+	b.recordAdminAuditLog(ctx, ctxOptions, string(app.BroadcastAdminCmdText), fmt.Sprintf("%d %s", broadcastID, action), true, &result)
+	if err != nil {
+		log.Error(
+			"fail to control broadcast",
+			logger.F("broadcast_id", broadcastID),
+			logger.F("action", action),
+			logger.FError(err),
+		)
+		return b.AnswerCallbackQuery(ctx, callbackQuery, utils.NewString(localizer.LocalizedString("broadcast_action_failed")), true)
+	}
+	if !accepted {
+		return b.AnswerCallbackQuery(ctx, callbackQuery, utils.NewString(localizer.LocalizedString("broadcast_action_rejected")), true)
+	}
+	return b.AnswerCallbackQuery(ctx, callbackQuery, utils.NewString(localizer.LocalizedString("broadcast_action_accepted")), false)
+}
+
+// controlBroadcast reports whether the status of the broadcast allows the action.
+func (b *botController) controlBroadcast(ctx context.Context, broadcastID int64, action app.BroadcastAction) (bool, error) {
+	broadcast, err := b.broadcastRepository.FetchByID(ctx, broadcastID)
+	if err != nil {
+		return false, err
+	}
+	switch {
+	case broadcast.Status.IsFinished():
+		return false, nil
+	case action == app.StartBroadcastAction:
+		draftStatuses := []domain.BroadcastStatus{domain.DraftBroadcastStatus}
+		changed, err := b.broadcastRepository.ChangeStatus(ctx, broadcastID, draftStatuses, domain.RunningBroadcastStatus)
+		if err != nil || !changed {
+			return false, err
+		}
+		if err := b.postponeService.StartBroadcast(ctx, broadcastID); err != nil {
+			// the broadcast goes back to the draft, so the admin can start it again
+			runningStatuses := []domain.BroadcastStatus{domain.RunningBroadcastStatus}
+			_, rollbackErr := b.broadcastRepository.ChangeStatus(
+				context.WithoutCancel(ctx),
+				broadcastID,
+				runningStatuses,
+				domain.DraftBroadcastStatus,
+			)
+			return false, errors.Join(err, rollbackErr)
+		}
+		return true, nil
+	case action == app.CancelBroadcastAction && broadcast.Status == domain.DraftBroadcastStatus:
+		draftStatuses := []domain.BroadcastStatus{domain.DraftBroadcastStatus}
+		changed, err := b.broadcastRepository.ChangeStatus(ctx, broadcastID, draftStatuses, domain.CancelledBroadcastStatus)
+		if err != nil || !changed {
+			return false, err
+		}
+		broadcast.Status = domain.CancelledBroadcastStatus
+		return true, b.editBroadcastControlMessage(ctx, broadcast)
+	case broadcast.Status == domain.DraftBroadcastStatus:
+		return false, nil
+	default:
+		return true, b.postponeService.ControlBroadcast(ctx, broadcastID, action)
+	}
+}
+
+func (b *botController) editBroadcastControlMessage(ctx context.Context, broadcast *domain.Broadcast) error {
+	if broadcast.ControlChatID == nil || broadcast.ControlMessageID == nil {
+		return nil
+	}
+	langCode := b.getPreferredLanguage(nil)
+	if broadcast.AuthorProfileID != nil {
+		profile, err := b.profileRepository.FetchByID(ctx, *broadcast.AuthorProfileID)
+		if err != nil {
+			return err
+		}
+		if profile.PreferredLanguage != nil {
+			langCode = *profile.PreferredLanguage
+		}
+	}
+	deliveryCounts, err := b.broadcastRepository.FetchDeliveryCounts(ctx, broadcast.ID)
+	if err != nil {
+		return err
+	}
+	replyMarkup, err := manager.NewBroadcastControlInlineKeyboardMarkup(
+		ctx,
+		b.telegramBotService,
+		b.container.GetLocalizer(langCode),
+		broadcast.ID,
+		broadcast.Status,
+	)
+	if err != nil {
+		return err
+	}
+	editMessage := telegram.EditMessage{
+		ChatID:      broadcast.ControlChatID,
+		MessageID:   broadcast.ControlMessageID,
+		Text:        b.formatterWorker.BroadcastProgress(langCode, broadcast, deliveryCounts),
+		ReplyMarkup: replyMarkup,
+	}
+	_, err = b.telegramBotService.EditMessageText(ctx, &editMessage)
+	return err
+}
+
+// parseBroadcastSegment accepts known language codes and currencies only, so a typo doesn't send the broadcast
+// to nobody.
+func (b *botController) parseBroadcastSegment(arguments []string) (*domain.BroadcastSegment, bool) {
+	config := b.container.GetConfig()
+	segment := domain.BroadcastSegment{
+		LanguageCodes: make([]string, 0),
+		Currencies:    make([]string, 0),
+	}
+	for _, argument := range arguments {
+		key, value, ok := strings.Cut(argument, "=")
+		if !ok || value == "" {
+			return nil, false
+		}
+		switch key {
+		case languageBroadcastArgument:
+			for _, code := range strings.Split(value, ",") {
+				if config.LanguageByCode(code) == nil {
+					return nil, false
+				}
+				segment.LanguageCodes = append(segment.LanguageCodes, code)
+			}
+		case currencyBroadcastArgument:
+			for _, abbr := range strings.Split(strings.ToUpper(value), ",") {
+				if config.CurrencyByAbbr(abbr) == nil {
+					return nil, false
+				}
+				segment.Currencies = append(segment.Currencies, abbr)
+			}
+		case string(domain.ActiveBroadcastActivity), string(domain.InactiveBroadcastActivity):
+			days, err := strconv.Atoi(value)
+			if err != nil || days <= 0 || segment.Activity != domain.AnyBroadcastActivity {
+				return nil, false
+			}
+			segment.Activity = domain.BroadcastActivity(key)
+			segment.ActivityDays = days
+		default:
+			return nil, false
+		}
+	}
+	return &segment, true
+}
+
+// keepBroadcastEntities drops entities of the button lines cut off the text.
+func keepBroadcastEntities(entities []telegram.MessageEntity, text string) []telegram.MessageEntity {
+	textLength := utils.UTF16Length(text)
+	keptEntities := make([]telegram.MessageEntity, 0, len(entities))
+	for _, entity := range entities {
+		if entity.Offset+entity.Length > textLength {
+			continue
+		}
+		keptEntities = append(keptEntities, entity)
+	}
+	return keptEntities
+}
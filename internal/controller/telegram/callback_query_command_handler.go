@@ -8,7 +8,6 @@ import (
 	model "go-ton-pass-telegram-bot/internal/model/postpone"
 	"go-ton-pass-telegram-bot/internal/model/sms"
 	"go-ton-pass-telegram-bot/internal/model/telegram"
-	"go-ton-pass-telegram-bot/internal/service"
 	"go-ton-pass-telegram-bot/internal/utils"
 	"go-ton-pass-telegram-bot/pkg/logger"
 	"strconv"
@@ -51,7 +50,7 @@ func (b *botController) selectedInitialLanguageCallbackQueryCommandHandler(
 		ChatID:    ctxOptions.Update.CallbackQuery.Message.Chat.ID,
 		MessageID: ctxOptions.Update.CallbackQuery.Message.ID,
 	}
-	if err := b.telegramBotService.SendResponse(deleteMessage, app.DeleteMessageTelegramMethod); err != nil {
+	if err := b.telegramBotService.DeleteMessage(ctx, &deleteMessage); err != nil {
 		log.Error("fail perform to delete a message", logger.FError(err))
 		return b.editMessageInternalServerError(ctx, ctxOptions)
 	}
@@ -105,21 +104,12 @@ func (b *botController) cancelEnteringAmountCallbackQueryCommandHandler(
 	ctx context.Context,
 	ctxOptions *ContextOptions,
 ) error {
-	telegramID := ctxOptions.Profile.TelegramID
 	log := b.container.GetLogger()
 	deleteMessage := telegram.DeleteMessage{
 		ChatID:    ctxOptions.Update.GetChatID(),
 		MessageID: ctxOptions.Update.CallbackQuery.Message.ID,
 	}
-	if err := b.sessionService.ClearBotStateForUser(ctx, telegramID); err != nil {
-		log.Error(
-			"fail to clear bot state",
-			logger.FError(err),
-			logger.F("telegram_id", telegramID),
-		)
-		return b.editMessageInternalServerError(ctx, ctxOptions)
-	}
-	if err := b.deleteMessage(&deleteMessage); err != nil {
+	if err := b.deleteMessage(ctx, &deleteMessage); err != nil {
 		log.Error("fail to delete message", logger.FError(err))
 		return b.editMessageInternalServerError(ctx, ctxOptions)
 	}
@@ -143,21 +133,12 @@ func (b *botController) selectedCryptoBotPayCurrenciesCallbackQueryCommandHandle
 		log.Error("parameters[0] should be a string")
 		return b.editMessageInternalServerError(ctx, ctxOptions)
 	}
-	paymentMethod := app.CryptoBotPaymentMethod
-	if err := b.sessionService.SaveString(ctx, service.SelectedPaymentMethodSessionKey, paymentMethod, telegramID); err != nil {
-		log.Error("fail to save the selected payment method", logger.FError(err), logger.F("payment_method", paymentMethod))
-		return err
+	data := enteringAmountConversationData{
+		PaymentMethod: app.CryptoBotPaymentMethod,
+		PayCurrency:   &selectedPayCurrencyAbbr,
 	}
-	if err := b.sessionService.SaveString(ctx, service.SelectedCryptoBotPayCurrencyAbbrSessionKey, selectedPayCurrencyAbbr, telegramID); err != nil {
-		log.Error("fail to save selected pay currency", logger.FError(err))
-		return b.editMessageInternalServerError(ctx, ctxOptions)
-	}
-	if err := b.sendMessageEnterAmountCurrency(ctx, ctxOptions); err != nil {
-		log.Error("fail to send message with enter amount of currency", logger.FError(err))
-		return b.editMessageInternalServerError(ctx, ctxOptions)
-	}
-	if err := b.sessionService.SaveBotStateForUser(ctx, app.EnteringAmountCurrencyBotState, telegramID); err != nil {
-		log.Error("fail to save the bot state entering amount currency", logger.FError(err))
+	if err := b.conversation.Start(ctx, telegramID, ctxOptions, enteringAmountConversationState, data); err != nil {
+		log.Error("fail to start entering amount of currency", logger.FError(err))
 		return b.editMessageInternalServerError(ctx, ctxOptions)
 	}
 	return nil
@@ -265,17 +246,22 @@ func (b *botController) servicesCallbackQueryCommandHandler(
 	parameters := *callbackData.Parameters
 	currentPage := utils.GetInt64(parameters[0])
 	itemsPerPage := 16
-	smsServices, err := b.smsActivateWorker.GetOrderedServices()
+	favorites, err := b.fetchFavorites(ctx, ctxOptions)
 	if err != nil {
-		log.Error("fail to get ordered services", logger.FError(err))
+		log.Error("fail to fetch favorites", logger.FError(err))
 		return b.editMessageInternalServerError(ctx, ctxOptions)
 	}
-	pagination := app.Pagination{
-		CurrentPage:  int(currentPage),
-		LenItems:     len(smsServices),
-		ItemsPerPage: itemsPerPage,
+	smsServicesPage, err := b.smsActivateWorker.GetOrderedServices(
+		b.getPreferredLanguage(ctxOptions),
+		*favorites,
+		int(currentPage),
+		itemsPerPage,
+	)
+	if err != nil {
+		log.Error("fail to get ordered services", logger.FError(err))
+		return b.editMessageInternalServerError(ctx, ctxOptions)
 	}
-	return b.editMessageServices(ctx, ctxOptions, pagination, smsServices)
+	return b.editMessageServices(ctx, ctxOptions, smsServicesPage, *favorites)
 }
 
 func (b *botController) selectServiceCallbackQueryCommandHandler(
@@ -292,22 +278,27 @@ func (b *botController) selectServiceCallbackQueryCommandHandler(
 	}
 	currentPage := utils.GetInt64(parameters[1])
 	itemsPerPage := 10
-	servicePrices, err := b.smsActivateWorker.GetPriceForService(selectedServiceCode)
+	favorites, err := b.fetchFavorites(ctx, ctxOptions)
+	if err != nil {
+		log.Error("fail to fetch favorites", logger.FError(err))
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	servicePricesPage, err := b.smsActivateWorker.GetPriceForService(
+		selectedServiceCode,
+		*favorites,
+		int(currentPage),
+		itemsPerPage,
+	)
 	if err != nil {
 		log.Error("fail to fetch price for services", logger.FError(err))
 		return b.editMessageInternalServerError(ctx, ctxOptions)
 	}
-	countries, err := b.smsService.GetCountries()
+	countries, err := b.smsActivateWorker.GetCountriesByID()
 	if err != nil {
 		log.Error("fail to fetch countries", logger.FError(err))
 		return b.editMessageInternalServerError(ctx, ctxOptions)
 	}
-	pagination := app.Pagination{
-		CurrentPage:  int(currentPage),
-		ItemsPerPage: itemsPerPage,
-		LenItems:     len(servicePrices),
-	}
-	return b.editMessageServiceCountries(ctx, ctxOptions, pagination, selectedServiceCode, servicePrices, countries)
+	return b.editMessageServiceCountries(ctx, ctxOptions, selectedServiceCode, servicePricesPage, countries, *favorites)
 }
 
 func (b *botController) preferredCurrenciesQueryCommandHandler(
@@ -323,7 +314,6 @@ func (b *botController) payServiceQueryCommandHandler(
 	callbackData *app.TelegramCallbackData,
 ) error {
 	log := b.container.GetLogger()
-	telegramID := ctxOptions.Profile.TelegramID
 	if callbackData.Parameters == nil {
 		log.Error("parameters has nil value", logger.FError(app.NilError))
 		return b.editMessageInternalServerError(ctx, ctxOptions)
@@ -340,22 +330,34 @@ func (b *botController) payServiceQueryCommandHandler(
 	}
 	countryID := utils.GetInt64(parameters[1])
 	maxPrice := utils.GetFloat64(parameters[2])
-	priceWithFee := b.exchangeRateWorker.PriceWithFee(maxPrice)
-	priceWithFeeUSD, err := b.exchangeRateWorker.ConvertToUSD(priceWithFee, "RUB")
-	if err != nil {
-		log.Error("fail to convert rubles to usd", logger.FError(err))
+	quantity := 1
+	if len(parameters) > 3 {
+		quantity = int(utils.GetInt64(parameters[3]))
+	}
+	var operator string
+	if len(parameters) > 4 {
+		operator, ok = parameters[4].(string)
+		if !ok {
+			log.Error("parameters[4] should be a string")
+			return b.editMessageInternalServerError(ctx, ctxOptions)
+		}
+	}
+	if quantity < 1 || quantity > app.MaxBulkPurchaseQuantity {
+		log.Error("unsupported quantity of numbers", logger.F("quantity", quantity))
 		return b.editMessageInternalServerError(ctx, ctxOptions)
 	}
-	hasSufficientFunds, err := b.profileRepository.HasSufficientFunds(ctx, telegramID, *priceWithFeeUSD)
+	isPurchasesSuspended, err := b.cacheService.IsPurchasesSuspended(ctx)
 	if err != nil {
-		log.Error("fail to check that a profile has sufficient funds", logger.FError(err))
+		log.Error("fail to check purchases suspension", logger.FError(err))
 		return b.editMessageInternalServerError(ctx, ctxOptions)
 	}
-	if !hasSufficientFunds {
-		log.Error("hasn't sufficient funds for buy service")
-		return b.editMessageInternalServerError(ctx, ctxOptions)
+	if isPurchasesSuspended {
+		return b.editMessagePurchasesUnavailable(ctx, ctxOptions)
+	}
+	if quantity > 1 {
+		return b.bulkPurchaseNumbers(ctx, ctxOptions, serviceCode, countryID, operator, maxPrice, quantity)
 	}
-	requestedNumber, err := b.smsService.RequestNumber(serviceCode, countryID, maxPrice)
+	domainSMSHistory, smsHistoryID, err := b.purchaseNumber(ctx, ctxOptions, serviceCode, countryID, operator, maxPrice)
 	smsError, ok := err.(sms.Error)
 	if ok && strings.EqualFold(smsError.Name, sms.NoNumbersErrorName) {
 		log.Error("no numbers available", logger.FError(smsError))
@@ -364,54 +366,241 @@ func (b *botController) payServiceQueryCommandHandler(
 		log.Error("other sms activation error", logger.FError(smsError))
 		return b.editMessageInternalServerError(ctx, ctxOptions)
 	} else if err != nil {
-		log.Error("unhandled error occurred", logger.FError(err))
+		log.Error("fail to purchase number", logger.FError(err))
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	if err := b.sendMessageStartSMSActivation(ctx, ctxOptions, domainSMSHistory, *smsHistoryID); err != nil {
+		log.Error("fail to send message with sms activation", logger.FError(err))
+		return nil
+	}
+	return b.sendMessageMainMenu(ctx, ctxOptions)
+}
+
+func (b *botController) payCheapestServiceQueryCommandHandler(
+	ctx context.Context,
+	ctxOptions *ContextOptions,
+	callbackData *app.TelegramCallbackData,
+) error {
+	log := b.container.GetLogger()
+	if callbackData.Parameters == nil {
+		log.Error("parameters has nil value", logger.FError(app.NilError))
 		return b.editMessageInternalServerError(ctx, ctxOptions)
 	}
+	parameters := *callbackData.Parameters
+	if len(parameters) < 1 {
+		log.Error("not enough length parameters")
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	serviceCode, ok := parameters[0].(string)
+	if !ok {
+		log.Error("parameters[0] should be a string")
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	isPurchasesSuspended, err := b.cacheService.IsPurchasesSuspended(ctx)
+	if err != nil {
+		log.Error("fail to check purchases suspension", logger.FError(err))
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	if isPurchasesSuspended {
+		return b.editMessagePurchasesUnavailable(ctx, ctxOptions)
+	}
+	servicePrices, err := b.smsActivateWorker.GetCheapestPricesForService(serviceCode)
+	if err != nil {
+		log.Error("fail to fetch price for service", logger.FError(err))
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	if len(servicePrices) == 0 {
+		return b.editMessageCheapestNumberUnavailable(ctx, ctxOptions)
+	}
+	if len(parameters) < 2 {
+		return b.editMessageCheapestPriceCeilings(ctx, ctxOptions, serviceCode, servicePrices)
+	}
+	maxPrice := utils.GetFloat64(parameters[1])
+	for _, servicePrice := range servicePrices {
+		if servicePrice.RetailPrice > maxPrice {
+			// prices are sorted in ascending order, so the remaining countries exceed the chosen price too
+			break
+		}
+		// the number is bought at the price of the country, but never above the ceiling the user has chosen
+		priceCeiling := min(servicePrice.RetailPrice, maxPrice)
+		domainSMSHistory, smsHistoryID, err := b.purchaseNumber(ctx, ctxOptions, serviceCode, servicePrice.CountryCode, "", priceCeiling)
+		smsError, ok := err.(sms.Error)
+		if ok && strings.EqualFold(smsError.Name, sms.NoNumbersErrorName) {
+			log.Debug("no numbers available, try next country", logger.F("country_id", servicePrice.CountryCode))
+			continue
+		} else if errors.Is(err, app.InsufficientFundsError) {
+			// prices are sorted in ascending order, so the remaining countries exceed the balance too
+			break
+		} else if err != nil {
+			log.Error("fail to purchase the cheapest number", logger.FError(err))
+			return b.editMessageInternalServerError(ctx, ctxOptions)
+		}
+		if err := b.answerCheapestNumberObtained(ctx, ctxOptions, domainSMSHistory); err != nil {
+			log.Error("fail to answer callback query", logger.FError(err))
+		}
+		if err := b.sendMessageStartSMSActivation(ctx, ctxOptions, domainSMSHistory, *smsHistoryID); err != nil {
+			log.Error("fail to send message with sms activation", logger.FError(err))
+			return nil
+		}
+		return b.sendMessageMainMenu(ctx, ctxOptions)
+	}
+	return b.editMessageCheapestNumberUnavailable(ctx, ctxOptions)
+}
+
+// purchaseNumber charges the profile for the price of that country only, then requests a number and records it
+// to the history. The charge is returned when the number isn't obtained.
+func (b *botController) purchaseNumber(
+	ctx context.Context,
+	ctxOptions *ContextOptions,
+	serviceCode string,
+	countryID int64,
+	operator string,
+	maxPrice float64,
+) (*domain.SMSHistory, *int64, error) {
+	log := b.container.GetLogger()
+	priceWithFee := b.exchangeRateWorker.PriceWithFee(maxPrice)
+	priceWithFeeUSD, err := b.exchangeRateWorker.ConvertToUSD(priceWithFee, "RUB")
+	if err != nil {
+		log.Error("fail to convert rubles to usd", logger.FError(err))
+		return nil, nil, err
+	}
+	var domainSMSHistory *domain.SMSHistory
+	var smsHistoryID *int64
+	activate := func(ctx context.Context) error {
+		var err error
+		domainSMSHistory, smsHistoryID, err = b.activateNumber(ctx, ctxOptions, serviceCode, countryID, operator, maxPrice, *priceWithFeeUSD, nil)
+		return err
+	}
+	if _, err := b.numberPurchase.Purchase(ctx, ctxOptions.Profile.TelegramID, *priceWithFeeUSD, 1, activate); err != nil {
+		return nil, nil, err
+	}
+	return domainSMSHistory, smsHistoryID, nil
+}
+
+// bulkPurchaseNumbers reserves the price of all numbers at once, requests them one by one and returns
+// the price of the numbers that weren't obtained back to the balance. A number that fails after being requested
+// is cancelled by activateNumber, so the user never keeps a number without its check workflow.
+func (b *botController) bulkPurchaseNumbers(
+	ctx context.Context,
+	ctxOptions *ContextOptions,
+	serviceCode string,
+	countryID int64,
+	operator string,
+	maxPrice float64,
+	quantity int,
+) error {
+	log := b.container.GetLogger()
+	priceWithFee := b.exchangeRateWorker.PriceWithFee(maxPrice)
+	priceWithFeeUSD, err := b.exchangeRateWorker.ConvertToUSD(priceWithFee, "RUB")
+	if err != nil {
+		log.Error("fail to convert rubles to usd", logger.FError(err))
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	activationGroup := domain.ActivationGroup{
+		ProfileID: ctxOptions.Profile.ID,
+		ChatID:    ctxOptions.Update.GetChatID(),
+		Quantity:  quantity,
+	}
+	// the group is created once the price is reserved, so a purchase short of funds leaves no empty group
+	activate := func(ctx context.Context) error {
+		if activationGroup.ID == 0 {
+			activationGroupID, err := b.activationGroupRepository.Create(ctx, &activationGroup)
+			if err != nil {
+				log.Error("fail to create activation group", logger.FError(err))
+				return err
+			}
+			activationGroup.ID = *activationGroupID
+		}
+		_, _, err := b.activateNumber(ctx, ctxOptions, serviceCode, countryID, operator, maxPrice, *priceWithFeeUSD, &activationGroup.ID)
+		return err
+	}
+	obtained, err := b.numberPurchase.Purchase(ctx, ctxOptions.Profile.TelegramID, *priceWithFeeUSD, quantity, activate)
+	smsError, ok := err.(sms.Error)
+	if errors.Is(err, app.InsufficientFundsError) {
+		return b.editMessageInsufficientFunds(ctx, ctxOptions)
+	} else if err != nil && activationGroup.ID == 0 {
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	} else if ok && strings.EqualFold(smsError.Name, sms.NoNumbersErrorName) {
+		log.Debug("numbers ran out during bulk purchase", logger.F("obtained", obtained), logger.F("quantity", quantity))
+	} else if err != nil {
+		log.Error("fail to activate number during bulk purchase", logger.F("obtained", obtained), logger.FError(err))
+	}
+	if obtained == 0 {
+		return b.editMessageNumbersUnavailable(ctx, ctxOptions)
+	}
+	if err := b.sendMessageActivationGroup(ctx, ctxOptions, &activationGroup); err != nil {
+		log.Error("fail to send message with activation group", logger.FError(err))
+		return nil
+	}
+	return b.sendMessageMainMenu(ctx, ctxOptions)
+}
+
+// activateNumber requests a number, records it to the history and schedules its own workflow that refunds amount
+// when the code doesn't arrive. Charging the profile is up to the caller, a number that fails to be recorded
+// or scheduled is cancelled on SMS-Activate.
+func (b *botController) activateNumber(
+	ctx context.Context,
+	ctxOptions *ContextOptions,
+	serviceCode string,
+	countryID int64,
+	operator string,
+	maxPrice float64,
+	amount float64,
+	activationGroupID *int64,
+) (*domain.SMSHistory, *int64, error) {
+	log := b.container.GetLogger()
+	telegramID := ctxOptions.Profile.TelegramID
+	requestedNumber, err := b.smsService.RequestNumber(serviceCode, countryID, operator, maxPrice)
+	if err != nil {
+		return nil, nil, err
+	}
 	activationID, err := strconv.ParseInt(requestedNumber.ActivationID, 10, 64)
 	if err != nil {
 		log.Error("convert activation_id to string has failed", logger.FError(err))
-		return b.editMessageInternalServerError(ctx, ctxOptions)
+		return nil, nil, err
 	}
 	country, err := b.smsActivateWorker.GetCountry(countryID)
 	if err != nil {
 		log.Error("fail to get country by id", logger.FError(err))
-		return b.editMessageInternalServerError(ctx, ctxOptions)
+		b.cancelRequestedNumber(ctx, activationID, false)
+		return nil, nil, err
 	}
 	smsService, err := b.smsActivateWorker.GetService(serviceCode)
 	if err != nil {
 		log.Error("fail to get sms service", logger.FError(err))
-		return b.editMessageInternalServerError(ctx, ctxOptions)
+		b.cancelRequestedNumber(ctx, activationID, false)
+		return nil, nil, err
 	}
-	fullPhoneNumber := utils.PhoneNumberTitle(requestedNumber.PhoneNumber)
-	phoneNumber, err := utils.ParsePhoneNumber(fullPhoneNumber)
-	if err != nil {
-		log.Error("fail to parse phone number", logger.FError(err))
-		return b.editMessageInternalServerError(ctx, ctxOptions)
+	phoneNumber := b.parsePhoneNumber(requestedNumber.PhoneNumber, countryID)
+	var selectedOperator *string
+	if operator != "" {
+		selectedOperator = &operator
 	}
 	domainSMSHistory := domain.SMSHistory{
-		ProfileID:        ctxOptions.Profile.ID,
-		ActivationID:     activationID,
-		Status:           string(app.PendingSMSActivateState),
-		ServiceCode:      smsService.Code,
-		ServiceName:      smsService.Name,
-		CountryID:        country.ID,
-		CountryName:      country.Title,
-		PhoneCodeNumber:  phoneNumber.CountryCode,
-		PhoneShortNumber: phoneNumber.ShortPhoneNumber,
+		ProfileID:         ctxOptions.Profile.ID,
+		ActivationGroupID: activationGroupID,
+		ActivationID:      activationID,
+		Status:            string(app.PendingSMSActivateState),
+		ServiceCode:       smsService.Code,
+		ServiceName:       smsService.Name,
+		CountryID:         country.ID,
+		CountryName:       country.Title,
+		Operator:          selectedOperator,
+		PhoneCodeNumber:   phoneNumber.CountryCode,
+		PhoneShortNumber:  phoneNumber.ShortPhoneNumber,
 	}
 	smsHistoryID, err := b.smsHistoryRepository.Create(ctx, &domainSMSHistory)
 	if err != nil {
 		log.Error("fail to create sms history", logger.FError(err))
-		return b.editMessageInternalServerError(ctx, ctxOptions)
-	}
-	if err := b.profileRepository.Debit(ctx, telegramID, *priceWithFeeUSD); err != nil {
-		log.Error("fail to withdraw money from account")
-		return b.editMessageInternalServerError(ctx, ctxOptions)
+		b.cancelRequestedNumber(ctx, activationID, false)
+		return nil, nil, err
 	}
-	workflow, err := b.postponeService.ScheduleCheckSMSActivation(ctx, telegramID, activationID, *priceWithFeeUSD)
+	domainSMSHistory.ID = *smsHistoryID
+	workflow, err := b.postponeService.ScheduleCheckSMSActivation(ctx, telegramID, activationID, amount)
 	if err != nil {
 		log.Error("fail to prepare schedule to check the sms activation", logger.FError(err))
-		return b.editMessageInternalServerError(ctx, ctxOptions)
+		b.cancelRequestedNumber(ctx, activationID, true)
+		return nil, nil, err
 	}
 	temporalWorkflowDomain := domain.TemporalWorkflow{
 		SMSHistoryID:  *smsHistoryID,
@@ -420,19 +609,32 @@ func (b *botController) payServiceQueryCommandHandler(
 	}
 	_, err = b.temporalWorkflowRepository.Create(ctx, &temporalWorkflowDomain)
 	if err != nil {
+		// the scheduled workflow already owns the refund, the number is kept and only the refund button can't find it
 		log.Error("fail to record temporal workflow to db", logger.FError(err))
-		return b.editMessageInternalServerError(ctx, ctxOptions)
 	}
-	if err := b.sendMessageStartSMSActivation(ctx, ctxOptions, &domainSMSHistory, *smsHistoryID); err != nil {
-		log.Error("fail to send message with sms activation", logger.FError(err))
-		return nil
+	return &domainSMSHistory, smsHistoryID, nil
+}
+
+// cancelRequestedNumber gives back a number that can't be served, so SMS-Activate returns its price to our balance.
+// The cancellation is recorded even when the request has been given up.
+func (b *botController) cancelRequestedNumber(ctx context.Context, activationID int64, isRecorded bool) {
+	log := b.container.GetLogger()
+	ctx = context.WithoutCancel(ctx)
+	if err := b.smsService.CancelActivation(activationID); err != nil {
+		log.Error("fail to cancel requested number", logger.F("activation_id", activationID), logger.FError(err))
+		return
+	}
+	if !isRecorded {
+		return
+	}
+	if err := b.smsHistoryRepository.ChangeActivationStatus(ctx, activationID, string(app.CancelSMSActivateState)); err != nil {
+		log.Error("fail to change status of cancelled number", logger.F("activation_id", activationID), logger.FError(err))
 	}
-	return b.sendMessageMainMenu(ctx, ctxOptions)
 }
 
-func (b *botController) emptyQueryCommandHandler(_ context.Context, callbackQuery *telegram.CallbackQuery) error {
+func (b *botController) emptyQueryCommandHandler(ctx context.Context, callbackQuery *telegram.CallbackQuery) error {
 	log := b.container.GetLogger()
-	if err := b.AnswerCallbackQuery(callbackQuery, nil, false); err != nil {
+	if err := b.AnswerCallbackQuery(ctx, callbackQuery, nil, false); err != nil {
 		log.Error("fail to answer callback query", logger.FError(err))
 		return err
 	}
@@ -487,7 +689,7 @@ func (b *botController) deleteCryptoBotQueryCommandHandler(
 		ChatID:    ctxOptions.Update.GetChatID(),
 		MessageID: ctxOptions.Update.CallbackQuery.Message.ID,
 	}
-	if err := b.deleteMessage(&deleteMessage); err != nil {
+	if err := b.deleteMessage(ctx, &deleteMessage); err != nil {
 		log.Error("fail to delete message in bot chat", logger.FError(err))
 		return b.editMessageInternalServerError(ctx, ctxOptions)
 	}
@@ -508,6 +710,7 @@ func (b *botController) deleteCryptoBotQueryCommandHandler(
 	return b.sendMessageMainMenu(ctx, ctxOptions)
 }
 
+// confirmServiceQueryCommandHandler confirms a number of any operator at the price of the country list.
 func (b *botController) confirmServiceQueryCommandHandler(
 	ctx context.Context,
 	ctxOptions *ContextOptions,
@@ -519,6 +722,10 @@ func (b *botController) confirmServiceQueryCommandHandler(
 		return b.editMessageInternalServerError(ctx, ctxOptions)
 	}
 	parameters := *callbackData.Parameters
+	if len(parameters) < 4 {
+		log.Error("not enough length parameters")
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
 	serviceCode, ok := parameters[0].(string)
 	if !ok {
 		log.Error("parameters[0] should be a string")
@@ -527,6 +734,65 @@ func (b *botController) confirmServiceQueryCommandHandler(
 	countryID := utils.GetInt64(parameters[1])
 	priceInRub := utils.GetFloat64(parameters[2])
 	priceWithFeeInPreferredCurrency := utils.GetFloat64(parameters[3])
+	return b.confirmService(ctx, ctxOptions, serviceCode, countryID, "", priceInRub, priceWithFeeInPreferredCurrency)
+}
+
+// confirmOperatorQueryCommandHandler confirms a number of the selected operator, its price is resolved again
+// to keep the callback data within the limit.
+func (b *botController) confirmOperatorQueryCommandHandler(
+	ctx context.Context,
+	ctxOptions *ContextOptions,
+	callbackData *app.TelegramCallbackData,
+) error {
+	log := b.container.GetLogger()
+	if callbackData.Parameters == nil {
+		log.Error("parameters has nil value", logger.FError(app.NilError))
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	parameters := *callbackData.Parameters
+	if len(parameters) < 3 {
+		log.Error("not enough length parameters")
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	serviceCode, ok := parameters[0].(string)
+	if !ok {
+		log.Error("parameters[0] should be a string")
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	countryID := utils.GetInt64(parameters[1])
+	operator, ok := parameters[2].(string)
+	if !ok {
+		log.Error("parameters[2] should be a string")
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	operatorPrice, err := b.smsActivateWorker.GetOperatorPrice(serviceCode, countryID, operator)
+	if err != nil {
+		log.Error("fail to get operator price", logger.F("operator", operator), logger.FError(err))
+		return b.editMessageNumbersUnavailable(ctx, ctxOptions)
+	}
+	if ctxOptions.Profile.PreferredCurrency == nil {
+		log.Error("profile must have preferred currency")
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	priceInPreferredCurrency, err := b.exchangeRateWorker.ConvertFromRUB(operatorPrice.Cost, *ctxOptions.Profile.PreferredCurrency)
+	if err != nil {
+		log.Error("fail to convert amount from rub", logger.FError(err))
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	priceWithFeeInPreferredCurrency := b.exchangeRateWorker.PriceWithFee(*priceInPreferredCurrency)
+	return b.confirmService(ctx, ctxOptions, serviceCode, countryID, operator, operatorPrice.Cost, priceWithFeeInPreferredCurrency)
+}
+
+func (b *botController) confirmService(
+	ctx context.Context,
+	ctxOptions *ContextOptions,
+	serviceCode string,
+	countryID int64,
+	operator string,
+	priceInRub float64,
+	priceWithFeeInPreferredCurrency float64,
+) error {
+	log := b.container.GetLogger()
 	country, err := b.smsActivateWorker.GetCountry(countryID)
 	if err != nil {
 		log.Error("fail to get country", logger.FError(err))
@@ -537,13 +803,62 @@ func (b *botController) confirmServiceQueryCommandHandler(
 		log.Error("fail to get service", logger.FError(err))
 		return b.editMessageInternalServerError(ctx, ctxOptions)
 	}
+	favorites, err := b.fetchFavorites(ctx, ctxOptions)
+	if err != nil {
+		log.Error("fail to fetch favorites", logger.FError(err))
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
 	return b.editMessageConfirmService(
 		ctx,
 		ctxOptions,
 		selectedService,
 		country,
+		operator,
+		priceInRub,
+		priceWithFeeInPreferredCurrency,
+		favorites.IsFavoriteCountry(country.ID),
+	)
+}
+
+func (b *botController) selectOperatorQueryCommandHandler(
+	ctx context.Context,
+	ctxOptions *ContextOptions,
+	callbackData *app.TelegramCallbackData,
+) error {
+	log := b.container.GetLogger()
+	if callbackData.Parameters == nil {
+		log.Error("parameters has nil value", logger.FError(app.NilError))
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	parameters := *callbackData.Parameters
+	if len(parameters) < 4 {
+		log.Error("not enough length parameters")
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	serviceCode, ok := parameters[0].(string)
+	if !ok {
+		log.Error("parameters[0] should be a string")
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	countryID := utils.GetInt64(parameters[1])
+	priceInRub := utils.GetFloat64(parameters[2])
+	priceWithFeeInPreferredCurrency := utils.GetFloat64(parameters[3])
+	operatorPrices, err := b.smsActivateWorker.GetOperatorPrices(serviceCode, countryID)
+	if err != nil {
+		log.Debug("fail to get operator prices, skip the operator step", logger.FError(err))
+		return b.confirmServiceQueryCommandHandler(ctx, ctxOptions, callbackData)
+	}
+	if len(operatorPrices) == 0 {
+		return b.confirmServiceQueryCommandHandler(ctx, ctxOptions, callbackData)
+	}
+	return b.editMessageServiceOperators(
+		ctx,
+		ctxOptions,
+		serviceCode,
+		countryID,
 		priceInRub,
 		priceWithFeeInPreferredCurrency,
+		operatorPrices,
 	)
 }
 
@@ -575,23 +890,15 @@ func (b *botController) refundAmountFromSMSActivationQueryCommandHandler(
 		log.Error("fail to cancel sms activation", logger.FError(err))
 		return b.editMessageInternalServerError(ctx, ctxOptions)
 	}
-	return b.AnswerCallbackQuery(ctxOptions.Update.CallbackQuery, nil, false)
+	return b.AnswerCallbackQuery(ctx, ctxOptions.Update.CallbackQuery, nil, false)
 }
 
 func (b *botController) selectTelegramStarsQueryCommandHandler(ctx context.Context, ctxOptions *ContextOptions) error {
 	log := b.container.GetLogger()
 	telegramID := ctxOptions.Profile.TelegramID
-	if err := b.sessionService.SaveString(ctx, service.SelectedPaymentMethodSessionKey, app.TelegramStarsPaymentMethod, telegramID); err != nil {
-		log.Error("fail to save payment method in session", logger.FError(err))
-		return b.editMessageInternalServerError(ctx, ctxOptions)
-	}
-	if err := b.sendMessageEnterAmountCurrency(ctx, ctxOptions); err != nil {
-		log.Error("fail to send message enter amount currency", logger.FError(err))
-		return b.editMessageInternalServerError(ctx, ctxOptions)
-	}
-	enteringAmountCurrencyBotState := app.EnteringAmountCurrencyBotState
-	if err := b.sessionService.SaveBotStateForUser(ctx, enteringAmountCurrencyBotState, telegramID); err != nil {
-		log.Error("fail to save bot state", logger.FError(err), logger.F("user_state", enteringAmountCurrencyBotState))
+	data := enteringAmountConversationData{PaymentMethod: app.TelegramStarsPaymentMethod}
+	if err := b.conversation.Start(ctx, telegramID, ctxOptions, enteringAmountConversationState, data); err != nil {
+		log.Error("fail to start entering amount of currency", logger.FError(err))
 		return b.editMessageInternalServerError(ctx, ctxOptions)
 	}
 	return nil
@@ -604,17 +911,9 @@ func (b *botController) selectedStripeCallbackQueryCommandHandler(
 	log := b.container.GetLogger()
 	telegramID := ctxOptions.Profile.TelegramID
 
-	if err := b.sessionService.SaveString(ctx, service.SelectedPaymentMethodSessionKey, app.StripePaymentMethod, telegramID); err != nil {
-		log.Error("fail to save payment method in session", logger.FError(err))
-		return b.editMessageInternalServerError(ctx, ctxOptions)
-	}
-	if err := b.sendMessageEnterAmountCurrency(ctx, ctxOptions); err != nil {
-		log.Error("fail to send message enter amount currency", logger.FError(err))
-		return b.editMessageInternalServerError(ctx, ctxOptions)
-	}
-	enteringAmountCurrencyBotState := app.EnteringAmountCurrencyBotState
-	if err := b.sessionService.SaveBotStateForUser(ctx, enteringAmountCurrencyBotState, telegramID); err != nil {
-		log.Error("fail to save bot state", logger.FError(err), logger.F("user_state", enteringAmountCurrencyBotState))
+	data := enteringAmountConversationData{PaymentMethod: app.StripePaymentMethod}
+	if err := b.conversation.Start(ctx, telegramID, ctxOptions, enteringAmountConversationState, data); err != nil {
+		log.Error("fail to start entering amount of currency", logger.FError(err))
 		return b.editMessageInternalServerError(ctx, ctxOptions)
 	}
 	return nil
@@ -626,7 +925,7 @@ func (b *botController) cancelPayTelegramStarsQueryCommandHandler(ctx context.Co
 		ChatID:    ctxOptions.Update.GetChatID(),
 		MessageID: ctxOptions.Update.CallbackQuery.Message.ID,
 	}
-	if err := b.deleteMessage(&deleteMessage); err != nil {
+	if err := b.deleteMessage(ctx, &deleteMessage); err != nil {
 		log.Error(
 			"fail to delete message",
 			logger.F("chat_id", ctxOptions.Update.GetChatID()),
@@ -641,3 +940,120 @@ func (b *botController) cancelPayTelegramStarsQueryCommandHandler(ctx context.Co
 	}
 	return nil
 }
+
+func (b *botController) favoritesCallbackQueryCommandHandler(
+	ctx context.Context,
+	ctxOptions *ContextOptions,
+) error {
+	log := b.container.GetLogger()
+	favorites, err := b.fetchFavorites(ctx, ctxOptions)
+	if err != nil {
+		log.Error("fail to fetch favorites", logger.FError(err))
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	services := make([]sms.Service, 0, len(favorites.ServiceCodes))
+	for _, serviceCode := range favorites.ServiceCodes {
+		service, err := b.smsActivateWorker.GetService(serviceCode)
+		if err != nil {
+			log.Debug("skip unknown favorite service", logger.F("service_code", serviceCode), logger.FError(err))
+			continue
+		}
+		services = append(services, *service)
+	}
+	countries := make([]sms.Country, 0, len(favorites.CountryIDs))
+	for _, countryID := range favorites.CountryIDs {
+		country, err := b.smsActivateWorker.GetCountry(countryID)
+		if err != nil {
+			log.Debug("skip unknown favorite country", logger.F("country_id", countryID), logger.FError(err))
+			continue
+		}
+		countries = append(countries, *country)
+	}
+	return b.editMessageFavorites(ctx, ctxOptions, services, countries)
+}
+
+func (b *botController) toggleFavoriteServiceQueryCommandHandler(
+	ctx context.Context,
+	ctxOptions *ContextOptions,
+	callbackData *app.TelegramCallbackData,
+) error {
+	log := b.container.GetLogger()
+	if callbackData.Parameters == nil {
+		log.Error("parameters has nil value", logger.FError(app.NilError))
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	parameters := *callbackData.Parameters
+	if len(parameters) < 2 {
+		log.Error("not enough length parameters")
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	serviceCode, ok := parameters[0].(string)
+	if !ok {
+		log.Error("parameters[0] should be a string")
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	favorite := domain.Favorite{
+		ProfileID: ctxOptions.Profile.ID,
+		Type:      domain.ServiceFavoriteType,
+		Code:      serviceCode,
+	}
+	if _, err := b.favoriteRepository.Toggle(ctx, &favorite); err != nil {
+		log.Error("fail to toggle favorite service", logger.F("service_code", serviceCode), logger.FError(err))
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	return b.selectServiceCallbackQueryCommandHandler(ctx, ctxOptions, &app.TelegramCallbackData{
+		Name:       app.SelectSMSServiceCallbackQueryCmdText,
+		Parameters: &[]any{serviceCode, parameters[1]},
+	})
+}
+
+func (b *botController) toggleFavoriteCountryQueryCommandHandler(
+	ctx context.Context,
+	ctxOptions *ContextOptions,
+	callbackData *app.TelegramCallbackData,
+) error {
+	log := b.container.GetLogger()
+	if callbackData.Parameters == nil {
+		log.Error("parameters has nil value", logger.FError(app.NilError))
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	parameters := *callbackData.Parameters
+	if len(parameters) < 2 {
+		log.Error("not enough length parameters")
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	serviceCode, ok := parameters[0].(string)
+	if !ok {
+		log.Error("parameters[0] should be a string")
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	countryID := utils.GetInt64(parameters[1])
+	favorite := domain.Favorite{
+		ProfileID: ctxOptions.Profile.ID,
+		Type:      domain.CountryFavoriteType,
+		Code:      strconv.FormatInt(countryID, 10),
+	}
+	if _, err := b.favoriteRepository.Toggle(ctx, &favorite); err != nil {
+		log.Error("fail to toggle favorite country", logger.F("country_id", countryID), logger.FError(err))
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	// the confirmation screen is on top of the stack, render it again to refresh the favorite button
+	confirmationCallbackData, err := b.callbackDataStack.Top(ctx, ctxOptions.Update.CallbackQuery)
+	if err != nil || confirmationCallbackData == nil {
+		return b.selectServiceCallbackQueryCommandHandler(ctx, ctxOptions, &app.TelegramCallbackData{
+			Name:       app.SelectSMSServiceCallbackQueryCmdText,
+			Parameters: &[]any{serviceCode, 0},
+		})
+	}
+	switch confirmationCallbackData.CallbackQueryCommand() {
+	case app.ConfirmationPayServiceCallbackQueryCommand:
+		return b.confirmServiceQueryCommandHandler(ctx, ctxOptions, confirmationCallbackData)
+	case app.ConfirmationPayOperatorCallbackQueryCommand:
+		return b.confirmOperatorQueryCommandHandler(ctx, ctxOptions, confirmationCallbackData)
+	default:
+		return b.selectServiceCallbackQueryCommandHandler(ctx, ctxOptions, &app.TelegramCallbackData{
+			Name:       app.SelectSMSServiceCallbackQueryCmdText,
+			Parameters: &[]any{serviceCode, 0},
+		})
+	}
+}
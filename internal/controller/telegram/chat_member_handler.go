@@ -0,0 +1,38 @@
+package telegram
+
+import (
+	"context"
+	"go-ton-pass-telegram-bot/internal/model/telegram"
+	"go-ton-pass-telegram-bot/pkg/logger"
+)
+
+// myChatMemberHandler tracks whether the user has the bot blocked, updates of groups and channels
+// the bot is added to are skipped.
+func (b *botController) myChatMemberHandler(ctx context.Context, ctxOptions *ContextOptions) error {
+	log := b.container.GetLogger()
+	myChatMember := ctxOptions.Update.MyChatMember
+	if myChatMember.Chat.Type != telegram.PrivateChatType {
+		return nil
+	}
+	profile := ctxOptions.Profile
+	switch myChatMember.NewChatMember.Status {
+	case telegram.BannedMemberStatus:
+		log.Debug("user has blocked the bot", logger.F("profile_id", profile.ID))
+		if err := b.profileRepository.MarkBotBlocked(ctx, profile.ID); err != nil {
+			log.Error("fail to mark bot as blocked", logger.F("profile_id", profile.ID), logger.FError(err))
+			return err
+		}
+		// the user can't answer anymore, the conversation is dropped without any message
+		return b.conversation.Reset(ctx, profile.TelegramID)
+	case telegram.MemberMemberStatus:
+		if !profile.IsBotBlocked() {
+			return nil
+		}
+		log.Debug("user has unblocked the bot", logger.F("profile_id", profile.ID))
+		if err := b.profileRepository.MarkBotUnblocked(ctx, profile.ID); err != nil {
+			log.Error("fail to mark bot as unblocked", logger.F("profile_id", profile.ID), logger.FError(err))
+			return err
+		}
+	}
+	return nil
+}
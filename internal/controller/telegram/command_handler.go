@@ -2,7 +2,9 @@ package telegram
 
 import (
 	"context"
+	"go-ton-pass-telegram-bot/internal/model/app"
 	"go-ton-pass-telegram-bot/pkg/logger"
+	"strings"
 )
 
 func (b *botController) startTelegramCommandHandler(ctx context.Context, ctxOptions *ContextOptions) error {
@@ -20,8 +22,8 @@ func (b *botController) startTelegramCommandHandler(ctx context.Context, ctxOpti
 func (b *botController) helpTelegramCommandHandler(ctx context.Context, ctxOptions *ContextOptions) error {
 	log := b.container.GetLogger()
 	telegramID := ctxOptions.Update.GetTelegramID()
-	if err := b.sessionService.ClearBotStateForUser(ctx, telegramID); err != nil {
-		log.Error("fail to clear bot state for telegram profile", logger.FError(err))
+	if err := b.conversation.Cancel(ctx, telegramID, ctxOptions); err != nil {
+		log.Error("fail to cancel conversation of telegram profile", logger.FError(err))
 		return err
 	}
 	return b.sendHelpText(ctx, ctxOptions)
@@ -30,9 +32,41 @@ func (b *botController) helpTelegramCommandHandler(ctx context.Context, ctxOptio
 func (b *botController) unknownTelegramCommandHandler(ctx context.Context, ctxOptions *ContextOptions) error {
 	telegramID := ctxOptions.Profile.TelegramID
 	preferredLanguage := b.getPreferredLanguage(ctxOptions)
-	if err := b.sessionService.ClearBotStateForUser(ctx, telegramID); err != nil {
+	if err := b.conversation.Cancel(ctx, telegramID, ctxOptions); err != nil {
 		return err
 	}
 	text := b.container.GetLocalizer(preferredLanguage).LocalizedString("unknown_cmd_text")
 	return b.sendMessagePlainText(ctx, text, ctxOptions)
 }
+
+func (b *botController) serviceTelegramCommandHandler(ctx context.Context, ctxOptions *ContextOptions) error {
+	log := b.container.GetLogger()
+	if ctxOptions.Profile.PreferredLanguage == nil || ctxOptions.Profile.PreferredCurrency == nil {
+		return b.startTelegramCommandHandler(ctx, ctxOptions)
+	}
+	var serviceCode string
+	// the code follows either `/service ` or `/start service_` of a deep link
+	if fields := strings.Fields(*ctxOptions.Update.Message.Text); len(fields) > 1 {
+		serviceCode = strings.TrimPrefix(fields[1], app.ServiceStartParameterPrefix)
+	}
+	if _, err := b.smsActivateWorker.GetService(serviceCode); err != nil {
+		log.Debug("unknown service in service command", logger.F("service_code", serviceCode), logger.FError(err))
+		return b.unknownTelegramCommandHandler(ctx, ctxOptions)
+	}
+	favorites, err := b.fetchFavorites(ctx, ctxOptions)
+	if err != nil {
+		log.Error("fail to fetch favorites", logger.FError(err))
+		return b.sendMessageInternalServerError(ctx, ctxOptions)
+	}
+	servicePricesPage, err := b.smsActivateWorker.GetPriceForService(serviceCode, *favorites, 0, 10)
+	if err != nil {
+		log.Error("fail to fetch price for services", logger.FError(err))
+		return b.sendMessageInternalServerError(ctx, ctxOptions)
+	}
+	countries, err := b.smsActivateWorker.GetCountriesByID()
+	if err != nil {
+		log.Error("fail to fetch countries", logger.FError(err))
+		return b.sendMessageInternalServerError(ctx, ctxOptions)
+	}
+	return b.sendMessageServiceCountries(ctx, ctxOptions, serviceCode, servicePricesPage, countries, *favorites)
+}
@@ -2,6 +2,8 @@ package telegram
 
 import (
 	"context"
+	"errors"
+	"go-ton-pass-telegram-bot/internal/config"
 	"go-ton-pass-telegram-bot/internal/container"
 	"go-ton-pass-telegram-bot/internal/manager"
 	"go-ton-pass-telegram-bot/internal/model/app"
@@ -11,19 +13,21 @@ import (
 	"go-ton-pass-telegram-bot/internal/service"
 	"go-ton-pass-telegram-bot/internal/service/postpone"
 	"go-ton-pass-telegram-bot/internal/worker"
+	"go-ton-pass-telegram-bot/pkg/fsm"
 	"go-ton-pass-telegram-bot/pkg/logger"
 	"go-ton-pass-telegram-bot/pkg/stripe_payment"
 )
 
 type BotController interface {
-	Serve(*ContextOptions) error
+	Serve(context.Context, *ContextOptions) error
 }
 
 type ContextOptions struct {
 	TelegramInlineKeyboardManager manager.TelegramInlineKeyboardManager
 	Update                        *telegram.Update
 	Profile                       *domain.Profile
-	IsMemberSubscription          bool
+	// MissingChannels are the required channels the user isn't subscribed to.
+	MissingChannels []config.RequiredChannel
 }
 
 const (
@@ -53,14 +57,22 @@ type botController struct {
 	smsHistoryRepository       repository.SMSHistoryRepository
 	temporalWorkflowRepository repository.TemporalWorkflowRepository
 	telegramPaymentRepository  repository.TelegramPaymentRepository
+	activationGroupRepository  repository.ActivationGroupRepository
+	favoriteRepository         repository.FavoriteRepository
+	adminAuditLogRepository    repository.AdminAuditLogRepository
+	broadcastRepository        repository.BroadcastRepository
+	supportTicketRepository    repository.SupportTicketRepository
 	exchangeRateWorker         worker.ExchangeRate
 	smsActivateWorker          worker.SMSActivate
 	formatterWorker            worker.Formatter
 	callbackDataStack          service.CallbackDataStack
+	numberPurchase             manager.NumberPurchase
+	conversation               fsm.Machine[*ContextOptions]
 }
 
 func NewBotController(
 	container container.Container,
+	telegramBotService service.TelegramBotService,
 	sessionService service.SessionService,
 	cacheService service.Cache,
 	smsService service.SMSService,
@@ -71,8 +83,14 @@ func NewBotController(
 	exchangeRateWorker worker.ExchangeRate,
 	temporalWorkflowRepository repository.TemporalWorkflowRepository,
 	telegramPaymentRepository repository.TelegramPaymentRepository,
+	activationGroupRepository repository.ActivationGroupRepository,
+	favoriteRepository repository.FavoriteRepository,
+	catalogRepository repository.CatalogRepository,
+	adminAuditLogRepository repository.AdminAuditLogRepository,
+	broadcastRepository repository.BroadcastRepository,
+	supportTicketRepository repository.SupportTicketRepository,
 ) BotController {
-	smsActivateWorker := worker.NewSMSActivate(container, smsService, cacheService)
+	smsActivateWorker := worker.NewSMSActivate(container, smsService, cacheService, catalogRepository)
 	formatterWorker := worker.NewFormatter(container)
 	callbackDataStack := service.NewCallbackDataStack(container, cacheService)
 	paymentClient := stripe_payment.NewStripePaymentClient(
@@ -80,9 +98,9 @@ func NewBotController(
 		container.GetConfig().GetStripeSuccessURL(),
 		container.GetConfig().GetStripeCancelURL(),
 	)
-	return &botController{
+	controller := &botController{
 		container:                  container,
-		telegramBotService:         service.NewTelegramBot(container),
+		telegramBotService:         telegramBotService,
 		cryptoPayBot:               cryptoPayBot,
 		sessionService:             sessionService,
 		cacheService:               cacheService,
@@ -93,21 +111,31 @@ func NewBotController(
 		smsHistoryRepository:       smsHistoryRepository,
 		temporalWorkflowRepository: temporalWorkflowRepository,
 		telegramPaymentRepository:  telegramPaymentRepository,
+		activationGroupRepository:  activationGroupRepository,
+		favoriteRepository:         favoriteRepository,
+		adminAuditLogRepository:    adminAuditLogRepository,
+		broadcastRepository:        broadcastRepository,
+		supportTicketRepository:    supportTicketRepository,
 		exchangeRateWorker:         exchangeRateWorker,
 		smsActivateWorker:          smsActivateWorker,
 		formatterWorker:            formatterWorker,
 		callbackDataStack:          callbackDataStack,
+		numberPurchase:             manager.NewNumberPurchase(container, profileRepository),
 	}
+	controller.conversation = controller.newConversation()
+	return controller
 }
 
-func (b *botController) Serve(ctxOptions *ContextOptions) error {
-	ctx := context.Background()
+func (b *botController) Serve(ctx context.Context, ctxOptions *ContextOptions) error {
 	log := b.container.GetLogger()
 	log.Debug(
 		"serve data from bot",
 		logger.F("update", ctxOptions.Update),
 	)
 
+	if ctxOptions.Update.MyChatMember != nil {
+		return b.myChatMemberHandler(ctx, ctxOptions)
+	}
 	hasSubscription, err := b.ServeSubscription(ctx, ctxOptions)
 	if err != nil {
 		log.Error("fail to serve subscription", logger.FError(err))
@@ -116,6 +144,12 @@ func (b *botController) Serve(ctxOptions *ContextOptions) error {
 	if !hasSubscription {
 		return nil
 	}
+	if ctxOptions.Update.InlineQuery != nil {
+		return b.InlineQueryHandler(ctx, ctxOptions)
+	}
+	if b.isSupportChatMessage(ctxOptions.Update) {
+		return b.supportChatMessageHandler(ctx, ctxOptions)
+	}
 
 	telegramCmd, err := b.telegramBotService.ParseTelegramCommand(ctxOptions.Update)
 	switch telegramCmd {
@@ -123,22 +157,23 @@ func (b *botController) Serve(ctxOptions *ContextOptions) error {
 		return b.startTelegramCommandHandler(ctx, ctxOptions)
 	case app.HelpTelegramCommand:
 		return b.helpTelegramCommandHandler(ctx, ctxOptions)
+	case app.ServiceTelegramCommand:
+		return b.serviceTelegramCommandHandler(ctx, ctxOptions)
+	case app.AdminTelegramCommand:
+		if !b.hasAdminPermission(ctxOptions.Profile) {
+			return b.deniedAdminTelegramCommandHandler(ctx, ctxOptions)
+		}
+		return b.adminTelegramCommandHandler(ctx, ctxOptions)
 	default:
 		break
 	}
 	if err != nil && telegramCmd == app.UnknownTelegramCommand {
-		err := b.sessionService.ClearBotStateForUser(ctx, ctxOptions.Update.GetChatID())
-		if err != nil {
+		if err := b.conversation.Cancel(ctx, ctxOptions.Update.GetTelegramID(), ctxOptions); err != nil {
 			return err
 		}
 		return b.unknownTelegramCommandHandler(ctx, ctxOptions)
 	}
 
-	userBotState := b.sessionService.GetBotStateForUser(ctx, ctxOptions.Update.GetTelegramID())
-	log.Debug(
-		"got bot state from session service",
-		logger.F("userBotState", userBotState),
-	)
 	message := ctxOptions.Update.Message
 	preCheckoutQuery := ctxOptions.Update.PreCheckoutQuery
 	callbackQuery := ctxOptions.Update.CallbackQuery
@@ -150,9 +185,9 @@ func (b *botController) Serve(ctxOptions *ContextOptions) error {
 		return b.RefundPaymentHandler(ctx, ctxOptions)
 	} else if callbackQuery == nil {
 		// probably user type a message ...
-		switch userBotState {
-		case app.EnteringAmountCurrencyBotState:
-			return b.enteringAmountCurrencyBotStageHandler(ctx, ctxOptions)
+		handled, err := b.conversation.Handle(ctx, ctxOptions.Update.GetTelegramID(), ctxOptions)
+		if handled {
+			return err
 		}
 
 		log.Error(
@@ -162,8 +197,12 @@ func (b *botController) Serve(ctxOptions *ContextOptions) error {
 		// fallback
 		return b.helpTelegramCommandHandler(ctx, ctxOptions)
 	}
-	telegramCallbackData, err := b.telegramBotService.ParseTelegramCallbackData(callbackQuery)
-	if err != nil {
+	telegramCallbackData, err := b.telegramBotService.ParseTelegramCallbackData(ctx, callbackQuery)
+	if errors.Is(err, app.ExpiredCallbackDataError) {
+		// buttons of old messages lead to the main menu once their payloads have expired
+		log.Debug("callback data has expired", logger.F("data", callbackQuery.Data))
+		return b.editMessageMainMenu(ctx, ctxOptions)
+	} else if err != nil {
 		log.Error(
 			"fail to parse telegram callback data",
 			logger.F("telegramCallbackData", telegramCallbackData),
@@ -175,6 +214,10 @@ func (b *botController) Serve(ctxOptions *ContextOptions) error {
 		log.Error("telegramCallbackData has nil value")
 		return b.helpTelegramCommandHandler(ctx, ctxOptions)
 	}
+	// pressing a button leaves the conversation unless its state keeps on the command
+	if err := b.conversation.Interrupt(ctx, ctxOptions.Update.GetTelegramID(), ctxOptions, telegramCallbackData.Name); err != nil {
+		log.Error("fail to interrupt conversation", logger.FError(err))
+	}
 	transformedTelegramCallbackData, err := b.ServeCallbackQueryStack(ctx, callbackQuery, telegramCallbackData)
 	if err != nil {
 		log.Error("fail to serve callback query stack", logger.FError(err))
@@ -204,6 +247,8 @@ func (b *botController) Serve(ctxOptions *ContextOptions) error {
 		return b.historyCallbackQueryCommandHandler(ctx, ctxOptions, transformedTelegramCallbackData)
 	case app.PayServiceCallbackQueryCommand:
 		return b.payServiceQueryCommandHandler(ctx, ctxOptions, transformedTelegramCallbackData)
+	case app.PayCheapestServiceCallbackQueryCommand:
+		return b.payCheapestServiceQueryCommandHandler(ctx, ctxOptions, transformedTelegramCallbackData)
 	case app.CryptoBotListPayCurrenciesCallbackQueryCommand:
 		return b.cryptoBotListPayCurrenciesCallbackQueryCommandHandler(ctx, ctxOptions, transformedTelegramCallbackData)
 	case app.SelectCryptoBotPayCurrencyCallbackQueryCommand:
@@ -222,12 +267,31 @@ func (b *botController) Serve(ctxOptions *ContextOptions) error {
 		return b.emptyQueryCommandHandler(ctx, callbackQuery)
 	case app.DeleteCryptoBotInvoiceCallbackQueryCommand:
 		return b.deleteCryptoBotQueryCommandHandler(ctx, ctxOptions, transformedTelegramCallbackData)
+	case app.SelectOperatorCallbackQueryCommand:
+		return b.selectOperatorQueryCommandHandler(ctx, ctxOptions, transformedTelegramCallbackData)
 	case app.ConfirmationPayServiceCallbackQueryCommand:
 		return b.confirmServiceQueryCommandHandler(ctx, ctxOptions, transformedTelegramCallbackData)
+	case app.ConfirmationPayOperatorCallbackQueryCommand:
+		return b.confirmOperatorQueryCommandHandler(ctx, ctxOptions, transformedTelegramCallbackData)
 	case app.RefundAmountFromSMSActivationCallbackQueryCommand:
 		return b.refundAmountFromSMSActivationQueryCommandHandler(ctx, ctxOptions, transformedTelegramCallbackData)
 	case app.CancelPayTelegramStarsCallbackQueryCommand:
 		return b.cancelPayTelegramStarsQueryCommandHandler(ctx, ctxOptions)
+	case app.FavoritesCallbackQueryCommand:
+		return b.favoritesCallbackQueryCommandHandler(ctx, ctxOptions)
+	case app.ToggleFavoriteServiceCallbackQueryCommand:
+		return b.toggleFavoriteServiceQueryCommandHandler(ctx, ctxOptions, transformedTelegramCallbackData)
+	case app.ToggleFavoriteCountryCallbackQueryCommand:
+		return b.toggleFavoriteCountryQueryCommandHandler(ctx, ctxOptions, transformedTelegramCallbackData)
+	case app.SupportCallbackQueryCommand:
+		return b.supportCallbackQueryCommandHandler(ctx, ctxOptions)
+	case app.CloseSupportTicketCallbackQueryCommand:
+		return b.closeSupportTicketCallbackQueryCommandHandler(ctx, ctxOptions)
+	case app.ControlBroadcastCallbackQueryCommand:
+		if !b.hasAdminPermission(ctxOptions.Profile) {
+			return b.developingCallbackQueryCommandHandler(ctx, ctxOptions)
+		}
+		return b.controlBroadcastCallbackQueryCommandHandler(ctx, ctxOptions, transformedTelegramCallbackData)
 	default:
 		return b.developingCallbackQueryCommandHandler(ctx, ctxOptions)
 	}
@@ -311,7 +375,11 @@ func (b *botController) ServeCallbackQueryStack(
 		app.BackCallbackQueryCommand,
 		app.CancelEnterAmountCallbackQueryCommand,
 		app.SelectTelegramStarsCallbackQueryCommand,
-		app.SelectCryptoBotPayCurrencyCallbackQueryCommand:
+		app.SelectCryptoBotPayCurrencyCallbackQueryCommand,
+		app.ToggleFavoriteServiceCallbackQueryCommand,
+		app.ToggleFavoriteCountryCallbackQueryCommand,
+		app.ControlBroadcastCallbackQueryCommand,
+		app.CloseSupportTicketCallbackQueryCommand:
 		// skip serving these commands
 		break
 	default:
@@ -319,7 +387,7 @@ func (b *botController) ServeCallbackQueryStack(
 			// we should omit pushing commands with pagination
 			break
 		}
-		err := b.callbackDataStack.Push(ctx, callbackQuery)
+		err := b.callbackDataStack.Push(ctx, callbackQuery, telegramCallbackData)
 		if err != nil {
 			log.Error("fail to record callback query to cache", logger.FError(err))
 			return nil, err
@@ -338,7 +406,7 @@ func (b *botController) ServeCallbackQueryStack(
 }
 
 func (b *botController) ServeSubscription(ctx context.Context, ctxOption *ContextOptions) (bool, error) {
-	shouldSendMessageAboutSubscription := !ctxOption.IsMemberSubscription
+	shouldSendMessageAboutSubscription := len(ctxOption.MissingChannels) > 0
 	if shouldSendMessageAboutSubscription {
 		return false, b.sendMessageSubscription(ctx, ctxOption)
 	}
@@ -0,0 +1,136 @@
+package telegram
+
+import (
+	"context"
+	"go-ton-pass-telegram-bot/internal/model/app"
+	"go-ton-pass-telegram-bot/internal/model/domain"
+	"go-ton-pass-telegram-bot/internal/utils"
+	"go-ton-pass-telegram-bot/pkg/fsm"
+	"go-ton-pass-telegram-bot/pkg/logger"
+	"strings"
+	"time"
+)
+
+// States of conversations, a message that isn't a command is the input of the state the user is in.
+const (
+	enteringAmountConversationState     fsm.StateID = "entering_amount"
+	contactingSupportConversationState  fsm.StateID = "contacting_support"
+	composingBroadcastConversationState fsm.StateID = "composing_broadcast"
+)
+
+const (
+	enteringAmountConversationTimeout     = 5 * time.Minute
+	contactingSupportConversationTimeout  = 30 * time.Minute
+	composingBroadcastConversationTimeout = 30 * time.Minute
+)
+
+type enteringAmountConversationData struct {
+	PaymentMethod string `json:"payment_method"`
+	// PayCurrency is the currency of crypto bot invoices.
+	PayCurrency *string `json:"pay_currency,omitempty"`
+}
+
+type contactingSupportConversationData struct{}
+
+type composingBroadcastConversationData struct {
+	BroadcastID int64 `json:"broadcast_id"`
+}
+
+// newConversation declares the states users talk to the bot in, pressing a button leaves any of them
+// but the ones the state keeps on.
+func (b *botController) newConversation() fsm.Machine[*ContextOptions] {
+	return fsm.NewMachine[*ContextOptions](
+		b.sessionService,
+		fsm.NewState(fsm.StateConfig[*ContextOptions, enteringAmountConversationData]{
+			ID:      enteringAmountConversationState,
+			Timeout: enteringAmountConversationTimeout,
+			Enter: func(ctx context.Context, ctxOptions *ContextOptions, _ *enteringAmountConversationData) error {
+				return b.sendMessageEnterAmountCurrency(ctx, ctxOptions)
+			},
+			Validate: validateEnteringAmount,
+			Invalid: func(ctx context.Context, ctxOptions *ContextOptions, _ *enteringAmountConversationData, _ *fsm.ValidationError) error {
+				return b.editMessageEnterAmountPayError(ctx, ctxOptions)
+			},
+			Handle: b.enteringAmountCurrencyBotStageHandler,
+			Expire: func(ctx context.Context, ctxOptions *ContextOptions, _ *enteringAmountConversationData) error {
+				localizer := b.container.GetLocalizer(b.getPreferredLanguage(ctxOptions))
+				return b.sendMessagePlainText(ctx, localizer.LocalizedString("enter_amount_expired"), ctxOptions)
+			},
+		}),
+		fsm.NewState(fsm.StateConfig[*ContextOptions, contactingSupportConversationData]{
+			ID:       contactingSupportConversationState,
+			Timeout:  contactingSupportConversationTimeout,
+			KeepOn:   []string{app.SupportCallbackQueryCmdText},
+			Validate: validateSupportMessage,
+			Invalid: func(ctx context.Context, ctxOptions *ContextOptions, _ *contactingSupportConversationData, _ *fsm.ValidationError) error {
+				localizer := b.container.GetLocalizer(b.getPreferredLanguage(ctxOptions))
+				return b.sendMessagePlainText(ctx, localizer.LocalizedString("support_text_or_photo_only"), ctxOptions)
+			},
+			Handle: b.contactingSupportBotStageHandler,
+		}),
+		fsm.NewState(fsm.StateConfig[*ContextOptions, composingBroadcastConversationData]{
+			ID:       composingBroadcastConversationState,
+			Timeout:  composingBroadcastConversationTimeout,
+			KeepOn:   []string{app.ControlBroadcastQueryCmdText},
+			Validate: validateBroadcastContent,
+			Invalid: func(ctx context.Context, ctxOptions *ContextOptions, _ *composingBroadcastConversationData, _ *fsm.ValidationError) error {
+				localizer := b.container.GetLocalizer(b.getPreferredLanguage(ctxOptions))
+				return b.sendMessagePlainText(ctx, localizer.LocalizedString("broadcast_empty"), ctxOptions)
+			},
+			Handle: b.composingBroadcastBotStageHandler,
+			Cancel: b.cancelComposingBroadcast,
+		}),
+	)
+}
+
+// validateEnteringAmount leaves parsing the amount to the handler, the amount is checked here only.
+func validateEnteringAmount(_ context.Context, ctxOptions *ContextOptions, _ *enteringAmountConversationData) error {
+	text := ctxOptions.Update.Message.Text
+	if text == nil {
+		return &fsm.ValidationError{Reason: "amount isn't a text"}
+	}
+	if _, err := utils.ParseFloat64FromText(*text); err != nil {
+		return &fsm.ValidationError{Reason: err.Error()}
+	}
+	return nil
+}
+
+func validateSupportMessage(_ context.Context, ctxOptions *ContextOptions, _ *contactingSupportConversationData) error {
+	message := ctxOptions.Update.Message
+	if message.LargestPhoto() != nil {
+		return nil
+	}
+	if text := supportMessageText(message); text == nil || strings.TrimSpace(*text) == "" {
+		return &fsm.ValidationError{Reason: "support message has neither text nor photo"}
+	}
+	return nil
+}
+
+func validateBroadcastContent(_ context.Context, ctxOptions *ContextOptions, _ *composingBroadcastConversationData) error {
+	message := ctxOptions.Update.Message
+	if message.LargestPhoto() != nil {
+		return nil
+	}
+	if message.Text == nil {
+		return &fsm.ValidationError{Reason: "broadcast has neither text nor photo"}
+	}
+	if text, _ := utils.SplitBroadcastButtons(*message.Text); text == "" {
+		return &fsm.ValidationError{Reason: "broadcast has neither text nor photo"}
+	}
+	return nil
+}
+
+// cancelComposingBroadcast cancels the draft the admin has left without a content.
+func (b *botController) cancelComposingBroadcast(
+	ctx context.Context,
+	_ *ContextOptions,
+	data *composingBroadcastConversationData,
+) error {
+	log := b.container.GetLogger()
+	fromStatuses := []domain.BroadcastStatus{domain.DraftBroadcastStatus}
+	_, err := b.broadcastRepository.ChangeStatus(ctx, data.BroadcastID, fromStatuses, domain.CancelledBroadcastStatus)
+	if err != nil {
+		log.Error("fail to cancel broadcast draft", logger.F("broadcast_id", data.BroadcastID), logger.FError(err))
+	}
+	return err
+}
@@ -3,7 +3,9 @@ package telegram
 import (
 	"context"
 	"fmt"
+	"go-ton-pass-telegram-bot/internal/manager"
 	"go-ton-pass-telegram-bot/internal/model/app"
+	"go-ton-pass-telegram-bot/internal/model/domain"
 	"go-ton-pass-telegram-bot/internal/model/sms"
 	"go-ton-pass-telegram-bot/internal/model/telegram"
 	"go-ton-pass-telegram-bot/internal/utils"
@@ -15,12 +17,13 @@ func (b *botController) editMessageMainMenu(ctx context.Context, ctxOptions *Con
 	preferredLanguage := b.getPreferredLanguage(ctxOptions)
 	callbackQuery := ctxOptions.Update.CallbackQuery
 	localizer := b.container.GetLocalizer(preferredLanguage)
-	mainMenuInlineKeyboardMarkup, err := ctxOptions.TelegramInlineKeyboardManager.MainMenuKeyboardMarkup()
+	mainMenuInlineKeyboardMarkup, err := ctxOptions.TelegramInlineKeyboardManager.MainMenuKeyboardMarkup(ctx)
 	if err != nil {
 		log.Error("fail to get a main menu keyboard markup", logger.FError(err))
 		return b.editMessageInternalServerError(ctx, ctxOptions)
 	}
 	return b.AnswerCallbackQueryWithEditMessageMedia(
+		ctx,
 		callbackQuery,
 		localizer.LocalizedString("short_description_markdown"),
 		avatarImageURL,
@@ -28,7 +31,7 @@ func (b *botController) editMessageMainMenu(ctx context.Context, ctxOptions *Con
 	)
 }
 
-func (b *botController) editMessageDevelopingMode(_ context.Context, ctxOptions *ContextOptions) error {
+func (b *botController) editMessageDevelopingMode(ctx context.Context, ctxOptions *ContextOptions) error {
 	preferredLanguage := b.getPreferredLanguage(ctxOptions)
 	text := b.container.GetLocalizer(preferredLanguage).LocalizedString("development_process_markdown")
 	answerCallbackQuery := telegram.AnswerCallbackQuery{
@@ -36,19 +39,72 @@ func (b *botController) editMessageDevelopingMode(_ context.Context, ctxOptions
 		Text:      &text,
 		ShowAlert: true,
 	}
-	return b.telegramBotService.SendResponse(answerCallbackQuery, app.AnswerCallbackQueryTelegramMethod)
+	return b.telegramBotService.AnswerCallbackQuery(ctx, &answerCallbackQuery)
+}
+
+func (b *botController) editMessagePurchasesUnavailable(ctx context.Context, ctxOptions *ContextOptions) error {
+	preferredLanguage := b.getPreferredLanguage(ctxOptions)
+	text := b.container.GetLocalizer(preferredLanguage).LocalizedString("purchases_temporarily_unavailable")
+	answerCallbackQuery := telegram.AnswerCallbackQuery{
+		ID:        ctxOptions.Update.CallbackQuery.ID,
+		Text:      &text,
+		ShowAlert: true,
+	}
+	return b.telegramBotService.AnswerCallbackQuery(ctx, &answerCallbackQuery)
+}
+
+func (b *botController) editMessageCheapestNumberUnavailable(ctx context.Context, ctxOptions *ContextOptions) error {
+	preferredLanguage := b.getPreferredLanguage(ctxOptions)
+	text := b.container.GetLocalizer(preferredLanguage).LocalizedString("cheapest_available_country_not_found")
+	answerCallbackQuery := telegram.AnswerCallbackQuery{
+		ID:        ctxOptions.Update.CallbackQuery.ID,
+		Text:      &text,
+		ShowAlert: true,
+	}
+	return b.telegramBotService.AnswerCallbackQuery(ctx, &answerCallbackQuery)
+}
+
+func (b *botController) editMessageInsufficientFunds(ctx context.Context, ctxOptions *ContextOptions) error {
+	preferredLanguage := b.getPreferredLanguage(ctxOptions)
+	text := b.container.GetLocalizer(preferredLanguage).LocalizedString("insufficient_funds_alert")
+	answerCallbackQuery := telegram.AnswerCallbackQuery{
+		ID:        ctxOptions.Update.CallbackQuery.ID,
+		Text:      &text,
+		ShowAlert: true,
+	}
+	return b.telegramBotService.AnswerCallbackQuery(ctx, &answerCallbackQuery)
+}
+
+func (b *botController) editMessageNumbersUnavailable(ctx context.Context, ctxOptions *ContextOptions) error {
+	preferredLanguage := b.getPreferredLanguage(ctxOptions)
+	text := b.container.GetLocalizer(preferredLanguage).LocalizedString("numbers_unavailable_alert")
+	answerCallbackQuery := telegram.AnswerCallbackQuery{
+		ID:        ctxOptions.Update.CallbackQuery.ID,
+		Text:      &text,
+		ShowAlert: true,
+	}
+	return b.telegramBotService.AnswerCallbackQuery(ctx, &answerCallbackQuery)
+}
+
+func (b *botController) answerCheapestNumberObtained(ctx context.Context, ctxOptions *ContextOptions, smsHistory *domain.SMSHistory) error {
+	preferredLanguage := b.getPreferredLanguage(ctxOptions)
+	text := b.container.GetLocalizer(preferredLanguage).LocalizedStringWithTemplateData("cheapest_available_country_obtained", map[string]any{
+		"Country": smsHistory.CountryName,
+	})
+	return b.AnswerCallbackQuery(ctx, ctxOptions.Update.CallbackQuery, &text, true)
 }
 
 func (b *botController) editMessageCryptoBotListPayCurrencies(ctx context.Context, ctxOptions *ContextOptions) error {
 	log := b.container.GetLogger()
 	preferredLanguage := b.getPreferredLanguage(ctxOptions)
 	localizer := b.container.GetLocalizer(preferredLanguage)
-	payCurrenciesInlineKeyboardMarkup, err := ctxOptions.TelegramInlineKeyboardManager.CryptoBotPayCurrenciesKeyboardMarkup()
+	payCurrenciesInlineKeyboardMarkup, err := ctxOptions.TelegramInlineKeyboardManager.CryptoBotPayCurrenciesKeyboardMarkup(ctx)
 	if err != nil {
 		log.Error("fail to get a pay currencies inline keyboard", logger.FError(err))
 		return b.sendMessageInternalServerError(ctx, ctxOptions)
 	}
 	return b.AnswerCallbackQueryWithEditMessageMedia(
+		ctx,
 		ctxOptions.Update.CallbackQuery,
 		localizer.LocalizedString("select_currency_to_pay_markdown"),
 		topUpImageURL,
@@ -57,30 +113,38 @@ func (b *botController) editMessageCryptoBotListPayCurrencies(ctx context.Contex
 }
 
 func (b *botController) editMessageHelp(
-	_ context.Context,
+	ctx context.Context,
 	ctxOptions *ContextOptions,
 ) error {
+	log := b.container.GetLogger()
 	preferredLanguage := b.getPreferredLanguage(ctxOptions)
 	localizer := b.container.GetLocalizer(preferredLanguage)
+	helpKeyboardMarkup, err := ctxOptions.TelegramInlineKeyboardManager.HelpKeyboardMarkup(ctx)
+	if err != nil {
+		log.Error("fail to get a help keyboard markup", logger.FError(err))
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
 	return b.AnswerCallbackQueryWithEditMessageMedia(
+		ctx,
 		ctxOptions.Update.CallbackQuery,
 		localizer.LocalizedString("help_cmd_text_markdown"),
 		helpImageURL,
-		ctxOptions.TelegramInlineKeyboardManager.BackKeyboardMarkup(),
+		helpKeyboardMarkup,
 	)
 }
 
-func (b *botController) editMessageInternalServerError(_ context.Context, ctxOptions *ContextOptions) error {
+func (b *botController) editMessageInternalServerError(ctx context.Context, ctxOptions *ContextOptions) error {
 	log := b.container.GetLogger()
 	preferredLanguage := b.getPreferredLanguage(ctxOptions)
 	callbackQuery := ctxOptions.Update.CallbackQuery
 	localizer := b.container.GetLocalizer(preferredLanguage)
-	mainMenuInlineKeyboardMarkup, err := ctxOptions.TelegramInlineKeyboardManager.MainMenuKeyboardMarkup()
+	mainMenuInlineKeyboardMarkup, err := ctxOptions.TelegramInlineKeyboardManager.MainMenuKeyboardMarkup(ctx)
 	if err != nil {
 		log.Error("fail to get a main menu keyboard markup", logger.FError(err))
 		return err
 	}
 	return b.AnswerCallbackQueryWithEditMessageMedia(
+		ctx,
 		callbackQuery,
 		localizer.LocalizedString("internal_error_markdown"),
 		avatarImageURL,
@@ -94,7 +158,7 @@ func (b *botController) editMessageProfileBalance(ctx context.Context, ctxOption
 	callbackQuery := ctxOptions.Update.CallbackQuery
 	localizer := b.container.GetLocalizer(preferredLanguage)
 	preferredCurrency := ctxOptions.Profile.PreferredCurrency
-	topUpBalanceKeyboardMarkup, err := ctxOptions.TelegramInlineKeyboardManager.TopUpBalanceKeyboardMarkup()
+	topUpBalanceKeyboardMarkup, err := ctxOptions.TelegramInlineKeyboardManager.TopUpBalanceKeyboardMarkup(ctx)
 	if err != nil {
 		log.Error("fail to get a main menu keyboard markup", logger.FError(err))
 		return b.editMessageInternalServerError(ctx, ctxOptions)
@@ -119,6 +183,7 @@ func (b *botController) editMessageProfileBalance(ctx context.Context, ctxOption
 	text := fmt.Sprintf("%s\n\n%s", balanceText, choosePaymentMethodText)
 
 	return b.AnswerCallbackQueryWithEditMessageMedia(
+		ctx,
 		callbackQuery,
 		text,
 		topUpImageURL,
@@ -130,7 +195,7 @@ func (b *botController) editMessageProfileLanguages(ctx context.Context, ctxOpti
 	log := b.container.GetLogger()
 	preferredLanguage := b.getPreferredLanguage(ctxOptions)
 	localizer := b.container.GetLocalizer(preferredLanguage)
-	replyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.LanguagesKeyboardMarkup()
+	replyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.LanguagesKeyboardMarkup(ctx)
 	if err != nil {
 		log.Error("fail to get languages inline keyboard", logger.FError(err))
 		return b.editMessageInternalServerError(ctx, ctxOptions)
@@ -140,6 +205,7 @@ func (b *botController) editMessageProfileLanguages(ctx context.Context, ctxOpti
 		"Language": utils.EscapeMarkdownText(utils.LanguageTextFormat(*language)),
 	})
 	return b.AnswerCallbackQueryWithEditMessageMedia(
+		ctx,
 		ctxOptions.Update.CallbackQuery,
 		text,
 		selectPreferredLanguageImageURL,
@@ -158,12 +224,18 @@ func (b *botController) editMessageHistories(
 	profileID := ctxOptions.Profile.ID
 	offset := pagination.CurrentPage * pagination.ItemsPerPage
 	if pagination.LenItems == 0 {
+		backReplyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.BackKeyboardMarkup(ctx)
+		if err != nil {
+			log.Error("fail to get back keyboard markup", logger.FError(err))
+			return b.editMessageInternalServerError(ctx, ctxOptions)
+		}
 		text := localizer.LocalizedString("empty_history_markdown")
 		return b.EditMessageMedia(
+			ctx,
 			ctxOptions.Update.CallbackQuery,
 			text,
 			historyImageURL,
-			ctxOptions.TelegramInlineKeyboardManager.BackKeyboardMarkup(),
+			backReplyMarkup,
 		)
 	}
 	smsHistories, err := b.smsHistoryRepository.FetchList(ctx, profileID, offset, pagination.ItemsPerPage)
@@ -178,7 +250,9 @@ func (b *botController) editMessageHistories(
 	}
 	prevPageParameters := []any{pagination.PrevPage(), pagination.ItemsPerPage}
 	nextPageParameters := []any{pagination.NextPage(), pagination.ItemsPerPage}
+	batch := manager.NewCallbackDataBatch(b.telegramBotService)
 	pageControlButtons, err := ctxOptions.TelegramInlineKeyboardManager.PageControlKeyboardButtons(
+		batch,
 		app.HistoryCallbackQueryCmdText,
 		pagination,
 		prevPageParameters,
@@ -188,16 +262,19 @@ func (b *botController) editMessageHistories(
 		log.Error("fail to get page control keyboard buttons", logger.FError(err))
 		return b.editMessageInternalServerError(ctx, ctxOptions)
 	}
-	backButton := ctxOptions.TelegramInlineKeyboardManager.BackKeyboardButton()
+	backButton := ctxOptions.TelegramInlineKeyboardManager.BackKeyboardButton(batch)
 	buttons := [][]telegram.InlineKeyboardButton{
 		pageControlButtons,
 		{*backButton},
 	}
-	replyMarkup := &telegram.InlineKeyboardMarkup{
-		InlineKeyboard: buttons,
+	replyMarkup, err := batch.Markup(ctx, buttons)
+	if err != nil {
+		log.Error("fail to save history keyboard", logger.FError(err))
+		return b.editMessageInternalServerError(ctx, ctxOptions)
 	}
 	text := b.formatterWorker.SHSHistories(preferredLanguage, smsHistories)
 	return b.AnswerCallbackQueryWithEditMessageMedia(
+		ctx,
 		ctxOptions.Update.CallbackQuery,
 		text,
 		historyImageURL,
@@ -208,11 +285,16 @@ func (b *botController) editMessageHistories(
 func (b *botController) editMessageServices(
 	ctx context.Context,
 	ctxOptions *ContextOptions,
-	pagination app.Pagination,
-	smsServices []sms.Service,
+	smsServicesPage *app.Page[sms.Service],
+	favorites app.Favorites,
 ) error {
 	log := b.container.GetLogger()
-	replyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.ServicesInlineKeyboardMarkup(smsServices, pagination)
+	replyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.ServicesInlineKeyboardMarkup(
+		ctx,
+		smsServicesPage.Items,
+		favorites,
+		smsServicesPage.Pagination,
+	)
 	if err != nil {
 		log.Error("fail to get services inline keyboard markup", logger.FError(err))
 		return b.editMessageInternalServerError(ctx, ctxOptions)
@@ -221,6 +303,7 @@ func (b *botController) editMessageServices(
 	localizer := b.container.GetLocalizer(preferredLanguage)
 	text := localizer.LocalizedString("select_sms_service_markdown")
 	return b.AnswerCallbackQueryWithEditMessageMedia(
+		ctx,
 		ctxOptions.Update.CallbackQuery,
 		text,
 		chooseServiceImageURL,
@@ -231,10 +314,10 @@ func (b *botController) editMessageServices(
 func (b *botController) editMessageServiceCountries(
 	ctx context.Context,
 	ctxOptions *ContextOptions,
-	pagination app.Pagination,
 	selectedServiceCode string,
-	servicePrices []sms.PriceForService,
-	countries []sms.Country,
+	servicePricesPage *app.Page[sms.PriceForService],
+	countries map[int64]sms.Country,
+	favorites app.Favorites,
 ) error {
 	log := b.container.GetLogger()
 	if ctxOptions.Profile.PreferredCurrency == nil {
@@ -243,11 +326,13 @@ func (b *botController) editMessageServiceCountries(
 	}
 	preferredCurrency := *ctxOptions.Profile.PreferredCurrency
 	replyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.ServiceCountriesInlineKeyboardMarkup(
+		ctx,
 		selectedServiceCode,
 		preferredCurrency,
-		pagination,
-		servicePrices,
+		servicePricesPage.Pagination,
+		servicePricesPage.Items,
 		countries,
+		favorites,
 	)
 	if err != nil {
 		log.Error("fail to get service countries inline keyboard markup", logger.FError(err))
@@ -257,6 +342,79 @@ func (b *botController) editMessageServiceCountries(
 	localizer := b.container.GetLocalizer(preferredLanguage)
 	text := localizer.LocalizedString("select_sms_service_with_country_markdown")
 	return b.AnswerCallbackQueryWithEditMessageMedia(
+		ctx,
+		ctxOptions.Update.CallbackQuery,
+		text,
+		chooseCountryImageURL,
+		replyMarkup,
+	)
+}
+
+func (b *botController) editMessageServiceOperators(
+	ctx context.Context,
+	ctxOptions *ContextOptions,
+	serviceCode string,
+	countryID int64,
+	priceInRub float64,
+	priceWithFeeInPreferredCurrency float64,
+	operatorPrices []sms.OperatorPrice,
+) error {
+	log := b.container.GetLogger()
+	if ctxOptions.Profile.PreferredCurrency == nil {
+		log.Error("profile must have preferred currency")
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	preferredCurrency := *ctxOptions.Profile.PreferredCurrency
+	replyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.ServiceOperatorsInlineKeyboardMarkup(
+		ctx,
+		serviceCode,
+		countryID,
+		priceInRub,
+		priceWithFeeInPreferredCurrency,
+		preferredCurrency,
+		operatorPrices,
+	)
+	if err != nil {
+		log.Error("fail to get service operators inline keyboard markup", logger.FError(err))
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	preferredLanguage := b.getPreferredLanguage(ctxOptions)
+	localizer := b.container.GetLocalizer(preferredLanguage)
+	text := localizer.LocalizedString("select_sms_service_operator_markdown")
+	return b.AnswerCallbackQueryWithEditMessageMedia(
+		ctx,
+		ctxOptions.Update.CallbackQuery,
+		text,
+		chooseCountryImageURL,
+		replyMarkup,
+	)
+}
+
+func (b *botController) editMessageCheapestPriceCeilings(
+	ctx context.Context,
+	ctxOptions *ContextOptions,
+	serviceCode string,
+	servicePrices []sms.PriceForService,
+) error {
+	log := b.container.GetLogger()
+	if ctxOptions.Profile.PreferredCurrency == nil {
+		log.Error("profile must have preferred currency")
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	replyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.CheapestPriceCeilingsInlineKeyboardMarkup(
+		ctx,
+		serviceCode,
+		*ctxOptions.Profile.PreferredCurrency,
+		servicePrices,
+	)
+	if err != nil {
+		log.Error("fail to get cheapest price ceilings inline keyboard markup", logger.FError(err))
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	preferredLanguage := b.getPreferredLanguage(ctxOptions)
+	text := b.container.GetLocalizer(preferredLanguage).LocalizedString("select_cheapest_price_ceiling_markdown")
+	return b.AnswerCallbackQueryWithEditMessageMedia(
+		ctx,
 		ctxOptions.Update.CallbackQuery,
 		text,
 		chooseCountryImageURL,
@@ -275,7 +433,7 @@ func (b *botController) editMessagePreferredCurrencies(
 	}
 	preferredCurrency := *ctxOptions.Profile.PreferredCurrency
 	currency := b.container.GetConfig().CurrencyByAbbr(preferredCurrency)
-	replyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.PreferredCurrenciesKeyboardMarkup()
+	replyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.PreferredCurrenciesKeyboardMarkup(ctx)
 	if err != nil {
 		log.Error("fail to get currencies keyboard markup", logger.FError(err))
 		return b.editMessageInternalServerError(ctx, ctxOptions)
@@ -287,6 +445,7 @@ func (b *botController) editMessagePreferredCurrencies(
 		"Currency": utils.EscapeMarkdownText(myCurrencyText),
 	})
 	return b.AnswerCallbackQueryWithEditMessageMedia(
+		ctx,
 		ctxOptions.Update.CallbackQuery,
 		text,
 		selectPreferredCurrencyImageURL,
@@ -299,8 +458,10 @@ func (b *botController) editMessageConfirmService(
 	ctxOptions *ContextOptions,
 	service *sms.Service,
 	country *sms.Country,
+	operator string,
 	priceInRub float64,
 	priceWithFeeInPreferredCurrency float64,
+	isFavoriteCountry bool,
 ) error {
 	log := b.container.GetLogger()
 	profile := ctxOptions.Profile
@@ -326,19 +487,24 @@ func (b *botController) editMessageConfirmService(
 		preferredLanguage,
 		service,
 		country,
+		operator,
 		priceWithFeeInPreferredCurrency,
 		*preferredCurrency,
 	)
 	replyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.ConfirmationPayInlineKeyboardMarkup(
+		ctx,
 		service.Code,
 		country.ID,
 		priceInRub,
+		operator,
+		isFavoriteCountry,
 	)
 	if err != nil {
 		log.Error("fail to get confirmation inline keyboard", logger.FError(err))
 		return b.editMessageInternalServerError(ctx, ctxOptions)
 	}
 	return b.AnswerCallbackQueryWithEditMessageMedia(
+		ctx,
 		ctxOptions.Update.CallbackQuery,
 		text,
 		avatarImageURL,
@@ -363,15 +529,39 @@ func (b *botController) editMessageEnterAmountPayError(
 			"Currency": utils.EscapeMarkdownText(utils.ShortCurrencyTextFormat(*profileCurrency)),
 		},
 	)
-	enteringAmountInlineKeyboardMarkup, err := ctxOptions.TelegramInlineKeyboardManager.EnteringAmountInlineKeyboardMarkup()
+	enteringAmountInlineKeyboardMarkup, err := ctxOptions.TelegramInlineKeyboardManager.EnteringAmountInlineKeyboardMarkup(ctx)
 	if err != nil {
 		log.Error("fail to get entering amount inline keyboard markup", logger.FError(err))
 		return err
 	}
 	return b.SendTextWithPhotoMedia(
+		ctx,
 		ctxOptions.Update.GetChatID(),
 		text,
 		avatarImageURL,
 		enteringAmountInlineKeyboardMarkup,
 	)
 }
+
+func (b *botController) editMessageFavorites(
+	ctx context.Context,
+	ctxOptions *ContextOptions,
+	services []sms.Service,
+	countries []sms.Country,
+) error {
+	log := b.container.GetLogger()
+	replyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.FavoritesInlineKeyboardMarkup(ctx, services)
+	if err != nil {
+		log.Error("fail to get favorites inline keyboard markup", logger.FError(err))
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	preferredLanguage := b.getPreferredLanguage(ctxOptions)
+	text := b.formatterWorker.Favorites(preferredLanguage, services, countries)
+	return b.AnswerCallbackQueryWithEditMessageMedia(
+		ctx,
+		ctxOptions.Update.CallbackQuery,
+		text,
+		chooseServiceImageURL,
+		replyMarkup,
+	)
+}
@@ -0,0 +1,68 @@
+package telegram
+
+import (
+	"context"
+	"go-ton-pass-telegram-bot/internal/model/app"
+	"go-ton-pass-telegram-bot/internal/model/telegram"
+	"go-ton-pass-telegram-bot/internal/worker"
+	"go-ton-pass-telegram-bot/pkg/logger"
+)
+
+const (
+	// telegram accepts up to 50 results per answer
+	inlineQueryResultsLimit    = 50
+	inlineQueryCacheTimeInSecs = 300
+)
+
+func (b *botController) InlineQueryHandler(ctx context.Context, ctxOptions *ContextOptions) error {
+	log := b.container.GetLogger()
+	inlineQuery := ctxOptions.Update.InlineQuery
+	preferredLanguage := b.getPreferredLanguage(ctxOptions)
+	services, err := b.smsActivateWorker.SearchServices(inlineQuery.Query, preferredLanguage, inlineQueryResultsLimit)
+	if err != nil {
+		log.Error("fail to search services", logger.F("query", inlineQuery.Query), logger.FError(err))
+		return err
+	}
+	localizer := b.container.GetLocalizer(preferredLanguage)
+	telegramConfig := b.container.GetConfig().Telegram()
+	results := make([]telegram.InlineQueryResultArticle, 0, len(services))
+	for _, service := range services {
+		description := localizer.LocalizedStringWithTemplateData("inline_query_service_description", map[string]any{
+			"Code": service.Code,
+		})
+		serviceName := b.formatterWorker.Service(preferredLanguage, &service, worker.DefaultFormatterType)
+		// the message is posted to the chat of the inline query, the button opens the service in the private chat
+		var replyMarkup *telegram.InlineKeyboardMarkup
+		if link := telegramConfig.DeepLink(app.ServiceStartParameterPrefix + service.Code); link != nil {
+			replyMarkup = &telegram.InlineKeyboardMarkup{
+				InlineKeyboard: [][]telegram.InlineKeyboardButton{{
+					{Text: localizer.LocalizedString("inline_query_open_service"), URL: link},
+				}},
+			}
+		}
+		results = append(results, telegram.InlineQueryResultArticle{
+			Type:        "article",
+			ID:          service.Code,
+			Title:       serviceName,
+			Description: &description,
+			InputMessageContent: telegram.InputTextMessageContent{
+				MessageText: localizer.LocalizedStringWithTemplateData("inline_query_service_message", map[string]any{
+					"Service": serviceName,
+				}),
+			},
+			ReplyMarkup: replyMarkup,
+		})
+	}
+	answerInlineQuery := telegram.AnswerInlineQuery{
+		InlineQueryID: inlineQuery.ID,
+		Results:       results,
+		CacheTime:     inlineQueryCacheTimeInSecs,
+		// titles and descriptions are localized with the language of the profile
+		IsPersonal: true,
+	}
+	if err := b.telegramBotService.AnswerInlineQuery(ctx, &answerInlineQuery); err != nil {
+		log.Error("fail to answer inline query", logger.FError(err))
+		return err
+	}
+	return nil
+}
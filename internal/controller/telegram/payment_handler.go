@@ -19,7 +19,7 @@ func (b *botController) PreCheckoutHandler(ctx context.Context, ctxOptions *Cont
 		OK:                 true,
 	}
 
-	err := b.telegramBotService.SendResponse(answerPreCheckoutQuery, app.AnswerPreCheckoutQueryTelegramMethod)
+	err := b.telegramBotService.AnswerPreCheckoutQuery(ctx, &answerPreCheckoutQuery)
 	if err != nil {
 		log.Error("fail to send answerPreCheckoutQuery message", logger.FError(err))
 		return b.sendMessageInternalServerError(ctx, ctxOptions)
@@ -5,19 +5,19 @@ import (
 	"go-ton-pass-telegram-bot/internal/model/app"
 	"go-ton-pass-telegram-bot/internal/model/crypto/bot"
 	"go-ton-pass-telegram-bot/internal/model/domain"
+	"go-ton-pass-telegram-bot/internal/model/sms"
 	"go-ton-pass-telegram-bot/internal/model/telegram"
-	"go-ton-pass-telegram-bot/internal/service"
 	"go-ton-pass-telegram-bot/internal/utils"
 	"go-ton-pass-telegram-bot/pkg/logger"
 	"go-ton-pass-telegram-bot/pkg/stripe_payment/model"
 	"strings"
 )
 
-func (b *botController) sendMessageToSelectInitialLanguage(_ context.Context, ctxOptions *ContextOptions) error {
+func (b *botController) sendMessageToSelectInitialLanguage(ctx context.Context, ctxOptions *ContextOptions) error {
 	log := b.container.GetLogger()
 	preferredLanguage := b.getPreferredLanguage(ctxOptions)
 	localizer := b.container.GetLocalizer(preferredLanguage)
-	replyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.InitialLanguagesKeyboardMarkup()
+	replyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.InitialLanguagesKeyboardMarkup(ctx)
 	if err != nil {
 		log.Error("fail to get initial languages keyboard markup", logger.FError(err))
 		return err
@@ -28,14 +28,15 @@ func (b *botController) sendMessageToSelectInitialLanguage(_ context.Context, ct
 		Caption:     localizer.LocalizedString("select_preferred_language_markdown"),
 		ReplyMarkup: replyMarkup,
 	}
-	return b.telegramBotService.SendResponse(sendPhoto, app.SendPhotoTelegramMethod)
+	_, err = b.telegramBotService.SendPhoto(ctx, &sendPhoto)
+	return err
 }
 
-func (b *botController) sendMessageToSelectInitialPreferredCurrency(_ context.Context, ctxOptions *ContextOptions) error {
+func (b *botController) sendMessageToSelectInitialPreferredCurrency(ctx context.Context, ctxOptions *ContextOptions) error {
 	log := b.container.GetLogger()
 	preferredLanguage := b.getPreferredLanguage(ctxOptions)
 	localizer := b.container.GetLocalizer(preferredLanguage)
-	replyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.InitialPreferredCurrenciesKeyboardMarkup()
+	replyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.InitialPreferredCurrenciesKeyboardMarkup(ctx)
 	if err != nil {
 		log.Error("fail to get initial preferred currencies keyboard markup", logger.FError(err))
 		return err
@@ -46,10 +47,11 @@ func (b *botController) sendMessageToSelectInitialPreferredCurrency(_ context.Co
 		Caption:     localizer.LocalizedString("select_preferred_currency_markdown"),
 		ReplyMarkup: replyMarkup,
 	}
-	return b.telegramBotService.SendResponse(resp, app.SendPhotoTelegramMethod)
+	_, err = b.telegramBotService.SendPhoto(ctx, &resp)
+	return err
 }
 
-func (b *botController) sendMessageWelcome(_ context.Context, ctxOptions *ContextOptions) error {
+func (b *botController) sendMessageWelcome(ctx context.Context, ctxOptions *ContextOptions) error {
 	preferredLanguage := b.getPreferredLanguage(ctxOptions)
 	sendPhotoResp := telegram.SendPhoto{
 		ChatID:    ctxOptions.Update.GetChatID(),
@@ -57,13 +59,14 @@ func (b *botController) sendMessageWelcome(_ context.Context, ctxOptions *Contex
 		Caption:   b.container.GetLocalizer(preferredLanguage).LocalizedString("bot_markdown_description"),
 		ParseMode: utils.NewString("MarkdownV2"),
 	}
-	return b.telegramBotService.SendResponse(sendPhotoResp, app.SendPhotoTelegramMethod)
+	_, err := b.telegramBotService.SendPhoto(ctx, &sendPhotoResp)
+	return err
 }
 
-func (b *botController) sendMessageMainMenu(_ context.Context, ctxOptions *ContextOptions) error {
+func (b *botController) sendMessageMainMenu(ctx context.Context, ctxOptions *ContextOptions) error {
 	log := b.container.GetLogger()
 	preferredLanguage := b.getPreferredLanguage(ctxOptions)
-	mainMenuInlineKeyboardMarkup, err := ctxOptions.TelegramInlineKeyboardManager.MainMenuKeyboardMarkup()
+	mainMenuInlineKeyboardMarkup, err := ctxOptions.TelegramInlineKeyboardManager.MainMenuKeyboardMarkup(ctx)
 	if err != nil {
 		log.Error(
 			"fail to get a main menu keyboard markup",
@@ -78,7 +81,8 @@ func (b *botController) sendMessageMainMenu(_ context.Context, ctxOptions *Conte
 		ParseMode:   utils.NewString("MarkdownV2"),
 		ReplyMarkup: mainMenuInlineKeyboardMarkup,
 	}
-	return b.telegramBotService.SendResponse(resp, app.SendPhotoTelegramMethod)
+	_, err = b.telegramBotService.SendPhoto(ctx, &resp)
+	return err
 }
 
 func (b *botController) sendMessageEnterAmountCurrency(
@@ -93,7 +97,7 @@ func (b *botController) sendMessageEnterAmountCurrency(
 		log.Error("fail to get profile's currency")
 		return b.sendMessageInternalServerError(ctx, ctxOptions)
 	}
-	enteringAmountInlineKeyboardMarkup, err := ctxOptions.TelegramInlineKeyboardManager.EnteringAmountInlineKeyboardMarkup()
+	enteringAmountInlineKeyboardMarkup, err := ctxOptions.TelegramInlineKeyboardManager.EnteringAmountInlineKeyboardMarkup(ctx)
 	if err != nil {
 		log.Error("fail to entering amount inline keyboard markup", logger.FError(err))
 		return b.sendMessageInternalServerError(ctx, ctxOptions)
@@ -107,18 +111,20 @@ func (b *botController) sendMessageEnterAmountCurrency(
 		ParseMode:   utils.NewString("MarkdownV2"),
 		ReplyMarkup: enteringAmountInlineKeyboardMarkup,
 	}
-	return b.telegramBotService.SendResponse(resp, app.SendPhotoTelegramMethod)
+	_, err = b.telegramBotService.SendPhoto(ctx, &resp)
+	return err
 }
 
-func (b *botController) sendMessagePlainText(_ context.Context, text string, options *ContextOptions) error {
+func (b *botController) sendMessagePlainText(ctx context.Context, text string, options *ContextOptions) error {
 	resp := telegram.SendResponse{
 		ChatID: options.Update.GetChatID(),
 		Text:   text,
 	}
-	return b.telegramBotService.SendResponse(resp, app.SendMessageTelegramMethod)
+	_, err := b.telegramBotService.SendMessage(ctx, &resp)
+	return err
 }
 
-func (b *botController) sendHelpText(_ context.Context, ctxOptions *ContextOptions) error {
+func (b *botController) sendHelpText(ctx context.Context, ctxOptions *ContextOptions) error {
 	preferredLanguage := b.getPreferredLanguage(ctxOptions)
 	text := b.container.GetLocalizer(preferredLanguage).LocalizedString("help_cmd_text_markdown")
 	resp := telegram.SendResponse{
@@ -127,14 +133,15 @@ func (b *botController) sendHelpText(_ context.Context, ctxOptions *ContextOptio
 		ParseMode:   utils.NewString("MarkdownV2"),
 		ReplyMarkup: nil,
 	}
-	return b.telegramBotService.SendResponse(resp, app.SendMessageTelegramMethod)
+	_, err := b.telegramBotService.SendMessage(ctx, &resp)
+	return err
 }
 
-func (b *botController) sendMessageInternalServerError(_ context.Context, ctxOptions *ContextOptions) error {
+func (b *botController) sendMessageInternalServerError(ctx context.Context, ctxOptions *ContextOptions) error {
 	log := b.container.GetLogger()
 	preferredLanguage := b.getPreferredLanguage(ctxOptions)
 	localizer := b.container.GetLocalizer(preferredLanguage)
-	replyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.MainMenuKeyboardMarkup()
+	replyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.MainMenuKeyboardMarkup(ctx)
 	if err != nil {
 		log.Error(
 			"fail to get main menu keyboard markup",
@@ -142,6 +149,7 @@ func (b *botController) sendMessageInternalServerError(_ context.Context, ctxOpt
 		)
 	}
 	return b.SendTextWithPhotoMedia(
+		ctx,
 		ctxOptions.Update.GetChatID(),
 		localizer.LocalizedString("internal_error_markdown"),
 		avatarImageURL,
@@ -158,12 +166,13 @@ func (b *botController) sendMessageStartSMSActivation(
 	log := b.container.GetLogger()
 	preferredLanguage := b.getPreferredLanguage(ctxOptions)
 	text := b.formatterWorker.StartSMSActivation(preferredLanguage, smsHistory)
-	refundReplyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.RefundInlineKeyboardMarkup(smsHistoryID)
+	refundReplyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.RefundInlineKeyboardMarkup(ctx, smsHistoryID)
 	if err != nil {
 		log.Error("fail to get refund inline keyboard", logger.FError(err))
 		return b.sendMessageInternalServerError(ctx, ctxOptions)
 	}
 	return b.SendTextWithPhotoMedia(
+		ctx,
 		ctxOptions.Update.GetChatID(),
 		text,
 		avatarImageURL,
@@ -171,14 +180,82 @@ func (b *botController) sendMessageStartSMSActivation(
 	)
 }
 
+func (b *botController) sendMessageServiceCountries(
+	ctx context.Context,
+	ctxOptions *ContextOptions,
+	selectedServiceCode string,
+	servicePricesPage *app.Page[sms.PriceForService],
+	countries map[int64]sms.Country,
+	favorites app.Favorites,
+) error {
+	log := b.container.GetLogger()
+	preferredCurrency := *ctxOptions.Profile.PreferredCurrency
+	replyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.ServiceCountriesInlineKeyboardMarkup(
+		ctx,
+		selectedServiceCode,
+		preferredCurrency,
+		servicePricesPage.Pagination,
+		servicePricesPage.Items,
+		countries,
+		favorites,
+	)
+	if err != nil {
+		log.Error("fail to get service countries inline keyboard markup", logger.FError(err))
+		return b.sendMessageInternalServerError(ctx, ctxOptions)
+	}
+	preferredLanguage := b.getPreferredLanguage(ctxOptions)
+	localizer := b.container.GetLocalizer(preferredLanguage)
+	return b.SendTextWithPhotoMedia(
+		ctx,
+		ctxOptions.Update.GetChatID(),
+		localizer.LocalizedString("select_sms_service_with_country_markdown"),
+		chooseCountryImageURL,
+		replyMarkup,
+	)
+}
+
+func (b *botController) sendMessageActivationGroup(
+	ctx context.Context,
+	ctxOptions *ContextOptions,
+	activationGroup *domain.ActivationGroup,
+) error {
+	log := b.container.GetLogger()
+	preferredLanguage := b.getPreferredLanguage(ctxOptions)
+	smsHistories, err := b.smsHistoryRepository.FetchByActivationGroupID(ctx, activationGroup.ID)
+	if err != nil {
+		log.Error("fail to fetch sms histories of activation group", logger.FError(err))
+		return b.sendMessageInternalServerError(ctx, ctxOptions)
+	}
+	text := b.formatterWorker.ActivationGroup(preferredLanguage, activationGroup, smsHistories)
+	replyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.ActivationGroupInlineKeyboardMarkup(ctx, smsHistories)
+	if err != nil {
+		log.Error("fail to get activation group inline keyboard", logger.FError(err))
+		return b.sendMessageInternalServerError(ctx, ctxOptions)
+	}
+	sendPhoto := telegram.SendPhoto{
+		ChatID:      activationGroup.ChatID,
+		Caption:     text,
+		Photo:       avatarImageURL,
+		ParseMode:   utils.NewString("MarkdownV2"),
+		ReplyMarkup: replyMarkup,
+	}
+	message, err := b.telegramBotService.SendPhoto(ctx, &sendPhoto)
+	if err != nil {
+		log.Error("fail to send activation group message", logger.FError(err))
+		return err
+	}
+	return b.activationGroupRepository.SetMessageID(ctx, activationGroup.ID, message.ID)
+}
+
 func (b *botController) sendMessageSuccessfullyDeletedInvoice(
-	_ context.Context,
+	ctx context.Context,
 	ctxOptions *ContextOptions,
 ) error {
 	preferredLanguage := b.getPreferredLanguage(ctxOptions)
 	localizer := b.container.GetLocalizer(preferredLanguage)
 	text := localizer.LocalizedString("success_deleted_invoice_markdown")
 	return b.SendTextWithPhotoMedia(
+		ctx,
 		ctxOptions.Update.GetChatID(),
 		text,
 		avatarImageURL,
@@ -186,17 +263,25 @@ func (b *botController) sendMessageSuccessfullyDeletedInvoice(
 	)
 }
 
-func (b *botController) sendMessageSubscription(_ context.Context, ctxOptions *ContextOptions) error {
+func (b *botController) sendMessageSubscription(ctx context.Context, ctxOptions *ContextOptions) error {
 	preferredLanguage := b.getPreferredLanguage(ctxOptions)
 	localizer := b.container.GetLocalizer(preferredLanguage)
+	channels := make([]string, 0, len(ctxOptions.MissingChannels))
+	for _, missingChannel := range ctxOptions.MissingChannels {
+		channels = append(channels, utils.EscapeMarkdownText(missingChannel.Chat))
+	}
 	text := localizer.LocalizedStringWithTemplateData("subscribe_to_channel_markdown", map[string]any{
-		"Channel": "@tonpassnews",
+		"Channel": strings.Join(channels, ", "),
 	})
-	isSubscriptionMemberReplyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.IsSubscriptionMemberInlineKeyboardMarkup()
+	isSubscriptionMemberReplyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.IsSubscriptionMemberInlineKeyboardMarkup(
+		ctx,
+		ctxOptions.MissingChannels,
+	)
 	if err != nil {
 		return err
 	}
 	return b.SendTextWithPhotoMedia(
+		ctx,
 		ctxOptions.Update.GetChatID(),
 		text,
 		avatarImageURL,
@@ -214,6 +299,7 @@ func (b *botController) sendMessageConfirmTouchUpBalance(
 	localizer := b.container.GetLocalizer(preferredLanguage)
 	text := localizer.LocalizedString("crypto_bot_pay_title_markdown")
 	cryptoPayReplyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.CryptoPayBotKeyboardMarkup(
+		ctx,
 		invoice.BotInvoiceURL,
 		invoice.ID,
 	)
@@ -222,6 +308,7 @@ func (b *botController) sendMessageConfirmTouchUpBalance(
 		return b.sendMessageInternalServerError(ctx, ctxOptions)
 	}
 	return b.SendTextWithPhotoMedia(
+		ctx,
 		ctxOptions.Update.GetChatID(),
 		text,
 		avatarImageURL,
@@ -247,22 +334,6 @@ func (b *botController) sendCryptoBotInvoice(ctx context.Context, ctxOptions *Co
 		log.Error("fail to create a invoice", logger.FError(err))
 		return b.sendMessageInternalServerError(ctx, ctxOptions)
 	}
-	if err := b.sessionService.ClearBotStateForUser(ctx, telegramID); err != nil {
-		log.Error(
-			"fail to clear bot state",
-			logger.FError(err),
-			logger.F("telegram_id", telegramID),
-		)
-		return b.editMessageInternalServerError(ctx, ctxOptions)
-	}
-	if err := b.sessionService.ClearString(ctx, service.SelectedCryptoBotPayCurrencyAbbrSessionKey, telegramID); err != nil {
-		log.Error(
-			"fail to clear selected pay currency string",
-			logger.FError(err),
-			logger.F("telegram_id", telegramID),
-		)
-		return b.editMessageInternalServerError(ctx, ctxOptions)
-	}
 	return b.sendMessageConfirmTouchUpBalance(ctx, ctxOptions, invoice)
 }
 
@@ -301,6 +372,7 @@ func (b *botController) sendStripeInvoice(ctx context.Context, ctxOptions *Conte
 		return b.sendMessageInternalServerError(ctx, ctxOptions)
 	}
 	stripePayReplyMarkup, err := ctxOptions.TelegramInlineKeyboardManager.StripeKeyboardMarkup(
+		ctx,
 		*checkoutSession.PaymentLink,
 	)
 	if err != nil {
@@ -309,6 +381,7 @@ func (b *botController) sendStripeInvoice(ctx context.Context, ctxOptions *Conte
 	}
 	text := localizer.LocalizedString("invoice_stripe_title_markdown")
 	return b.SendTextWithPhotoMedia(
+		ctx,
 		ctxOptions.Update.GetChatID(),
 		text,
 		avatarImageURL,
@@ -0,0 +1,358 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-ton-pass-telegram-bot/internal/manager"
+	"go-ton-pass-telegram-bot/internal/model/domain"
+	"go-ton-pass-telegram-bot/internal/model/telegram"
+	"go-ton-pass-telegram-bot/internal/utils"
+	"go-ton-pass-telegram-bot/pkg/fsm"
+	"go-ton-pass-telegram-bot/pkg/logger"
+	"go-ton-pass-telegram-bot/pkg/telegram_bot"
+	"strconv"
+	"strings"
+)
+
+const (
+	// supportLanguage is the language of messages in the support chat
+	supportLanguage               = "en"
+	closeSupportTicketCmdText     = "/close"
+	supportRecentActivationsLimit = 5
+)
+
+// isSupportChatMessage reports whether the message has been written in the support chat, messages there are
+// answers of support agents and never commands to the bot.
+func (b *botController) isSupportChatMessage(update *telegram.Update) bool {
+	supportChatID := b.container.GetConfig().SupportChatID()
+	return supportChatID != 0 && update.Message != nil && update.Message.Chat != nil && update.Message.Chat.ID == supportChatID
+}
+
+// supportCallbackQueryCommandHandler asks the user to write the question, the next messages go into the ticket.
+func (b *botController) supportCallbackQueryCommandHandler(ctx context.Context, ctxOptions *ContextOptions) error {
+	log := b.container.GetLogger()
+	localizer := b.container.GetLocalizer(b.getPreferredLanguage(ctxOptions))
+	supportTicket, err := b.supportTicketRepository.FetchActiveByProfileID(ctx, ctxOptions.Profile.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error("fail to fetch active support ticket", logger.F("profile_id", ctxOptions.Profile.ID), logger.FError(err))
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	telegramID := ctxOptions.Profile.TelegramID
+	data := contactingSupportConversationData{}
+	if err := b.conversation.Start(ctx, telegramID, ctxOptions, contactingSupportConversationState, data); err != nil {
+		log.Error("fail to start contacting support", logger.FError(err))
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	supportKeyboardMarkup, err := ctxOptions.TelegramInlineKeyboardManager.SupportKeyboardMarkup(ctx, supportTicket != nil)
+	if err != nil {
+		log.Error("fail to get a support keyboard markup", logger.FError(err))
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	text := localizer.LocalizedString("support_intro_markdown")
+	if supportTicket != nil {
+		text = localizer.LocalizedStringWithTemplateData("support_active_ticket_markdown", map[string]any{
+			"ID": supportTicket.ID,
+		})
+	}
+	return b.AnswerCallbackQueryWithEditMessageMedia(
+		ctx,
+		ctxOptions.Update.CallbackQuery,
+		text,
+		helpImageURL,
+		supportKeyboardMarkup,
+	)
+}
+
+func (b *botController) closeSupportTicketCallbackQueryCommandHandler(ctx context.Context, ctxOptions *ContextOptions) error {
+	log := b.container.GetLogger()
+	localizer := b.container.GetLocalizer(b.getPreferredLanguage(ctxOptions))
+	callbackQuery := ctxOptions.Update.CallbackQuery
+	supportTicket, err := b.supportTicketRepository.FetchActiveByProfileID(ctx, ctxOptions.Profile.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return b.AnswerCallbackQuery(ctx, callbackQuery, utils.NewString(localizer.LocalizedString("support_no_active_ticket")), false)
+	} else if err != nil {
+		log.Error("fail to fetch active support ticket", logger.F("profile_id", ctxOptions.Profile.ID), logger.FError(err))
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	if err := b.closeSupportTicket(ctx, supportTicket, ctxOptions.Profile); err != nil {
+		log.Error("fail to close support ticket", logger.F("ticket_id", supportTicket.ID), logger.FError(err))
+		return b.editMessageInternalServerError(ctx, ctxOptions)
+	}
+	supportText := b.container.GetLocalizer(supportLanguage).LocalizedStringWithTemplateData("support_ticket_closed_by_user", map[string]any{
+		"ID": supportTicket.ID,
+	})
+	if err := b.sendSupportChatMessage(ctx, supportText, nil); err != nil {
+		log.Error("fail to notify support chat about closed ticket", logger.F("ticket_id", supportTicket.ID), logger.FError(err))
+	}
+	text := localizer.LocalizedStringWithTemplateData("support_ticket_closed", map[string]any{
+		"ID": supportTicket.ID,
+	})
+	return b.AnswerCallbackQuery(ctx, callbackQuery, &text, true)
+}
+
+// contactingSupportBotStageHandler adds the message of the user to the ticket and forwards it to the support chat,
+// the first message of a ticket carries the profile and the last activations of the user. A photo is forwarded
+// with the text as its caption, the profile of a new ticket goes before it, so the caption stays short.
+func (b *botController) contactingSupportBotStageHandler(
+	ctx context.Context,
+	ctxOptions *ContextOptions,
+	_ *contactingSupportConversationData,
+) (fsm.Transition, error) {
+	log := b.container.GetLogger()
+	localizer := b.container.GetLocalizer(b.getPreferredLanguage(ctxOptions))
+	message := ctxOptions.Update.Message
+	var text string
+	if messageText := supportMessageText(message); messageText != nil {
+		text = *messageText
+	}
+	photo := message.LargestPhoto()
+	if b.container.GetConfig().SupportChatID() == 0 {
+		log.Error("support chat isn't configured")
+		return fsm.Finish(), b.sendMessageInternalServerError(ctx, ctxOptions)
+	}
+	profile := ctxOptions.Profile
+	supportTicket, isCreated, err := b.supportTicketRepository.FetchOrCreateActive(ctx, profile.ID)
+	if err != nil {
+		log.Error("fail to fetch or create support ticket", logger.F("profile_id", profile.ID), logger.FError(err))
+		return fsm.Stay(), b.sendMessageInternalServerError(ctx, ctxOptions)
+	}
+	supportLocalizer := b.container.GetLocalizer(supportLanguage)
+	supportText := supportLocalizer.LocalizedStringWithTemplateData("support_ticket_message", map[string]any{
+		"ID":   supportTicket.ID,
+		"User": supportUserTitle(profile),
+		"Text": text,
+	})
+	if isCreated {
+		smsHistories, err := b.smsHistoryRepository.FetchList(ctx, profile.ID, 0, supportRecentActivationsLimit)
+		if err != nil {
+			log.Error("fail to fetch sms histories", logger.F("profile_id", profile.ID), logger.FError(err))
+			return fsm.Stay(), b.sendMessageInternalServerError(ctx, ctxOptions)
+		}
+		adminProfile := b.formatterWorker.AdminProfile(supportLanguage, profile, smsHistories)
+		if photo == nil {
+			supportText = adminProfile + "\n\n" + supportText
+		} else if err := b.sendSupportChatMessage(ctx, adminProfile, nil); err != nil {
+			log.Error("fail to send profile to support chat", logger.F("ticket_id", supportTicket.ID), logger.FError(err))
+			return fsm.Stay(), b.sendMessageInternalServerError(ctx, ctxOptions)
+		}
+	}
+	var supportMessage *telegram.Message
+	if photo != nil {
+		supportMessage, err = b.telegramBotService.SendPhoto(ctx, &telegram.SendPhoto{
+			ChatID:  b.container.GetConfig().SupportChatID(),
+			Photo:   photo.FileID,
+			Caption: supportText,
+		})
+	} else {
+		supportMessage, err = b.telegramBotService.SendMessage(ctx, &telegram.SendResponse{
+			ChatID: b.container.GetConfig().SupportChatID(),
+			Text:   supportText,
+		})
+	}
+	if err != nil {
+		log.Error("fail to forward message to support chat", logger.F("ticket_id", supportTicket.ID), logger.FError(err))
+		return fsm.Stay(), b.sendMessageInternalServerError(ctx, ctxOptions)
+	}
+	_, err = b.supportTicketRepository.CreateMessage(ctx, &domain.SupportMessage{
+		TicketID:         supportTicket.ID,
+		Sender:           domain.UserSupportMessageSender,
+		SenderTelegramID: profile.TelegramID,
+		Text:             text,
+		UserMessageID:    &message.ID,
+		SupportMessageID: &supportMessage.ID,
+	})
+	if err != nil {
+		log.Error("fail to save support message", logger.F("ticket_id", supportTicket.ID), logger.FError(err))
+		return fsm.Stay(), b.sendMessageInternalServerError(ctx, ctxOptions)
+	}
+	if supportTicket.Status != domain.OpenSupportTicketStatus {
+		if err := b.supportTicketRepository.ChangeStatus(ctx, supportTicket.ID, domain.OpenSupportTicketStatus); err != nil {
+			log.Error("fail to reopen support ticket", logger.F("ticket_id", supportTicket.ID), logger.FError(err))
+		}
+	}
+	if !isCreated {
+		return fsm.Stay(), nil
+	}
+	return fsm.Stay(), b.sendMessagePlainText(ctx, localizer.LocalizedStringWithTemplateData("support_ticket_opened", map[string]any{
+		"ID": supportTicket.ID,
+	}), ctxOptions)
+}
+
+// supportMessageText is the text of the message or the caption of its media.
+func supportMessageText(message *telegram.Message) *string {
+	if message.Text != nil {
+		return message.Text
+	}
+	return message.Caption
+}
+
+// supportChatMessageHandler relays answers of support agents, an agent answers replying to a message of the ticket.
+// Text and photos are relayed, the agent is told about other media. Other messages of the support chat are
+// conversations of agents and are skipped.
+func (b *botController) supportChatMessageHandler(ctx context.Context, ctxOptions *ContextOptions) error {
+	log := b.container.GetLogger()
+	message := ctxOptions.Update.Message
+	if message.ReplyToMessage == nil {
+		return nil
+	}
+	supportTicket, err := b.supportTicketRepository.FetchBySupportMessageID(ctx, message.ReplyToMessage.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		log.Error("fail to fetch support ticket by message", logger.F("message_id", message.ReplyToMessage.ID), logger.FError(err))
+		return err
+	}
+	supportLocalizer := b.container.GetLocalizer(supportLanguage)
+	if supportTicket.Status == domain.ClosedSupportTicketStatus {
+		return b.sendSupportChatMessage(ctx, supportLocalizer.LocalizedStringWithTemplateData("support_ticket_already_closed", map[string]any{
+			"ID": supportTicket.ID,
+		}), &message.ID)
+	}
+	profile, err := b.profileRepository.FetchByID(ctx, supportTicket.ProfileID)
+	if err != nil {
+		log.Error("fail to fetch profile of support ticket", logger.F("ticket_id", supportTicket.ID), logger.FError(err))
+		return err
+	}
+	userLanguage := supportLanguage
+	if profile.PreferredLanguage != nil {
+		userLanguage = *profile.PreferredLanguage
+	}
+	userLocalizer := b.container.GetLocalizer(userLanguage)
+	photo := message.LargestPhoto()
+	if message.Text == nil && photo == nil {
+		return b.sendSupportChatMessage(ctx, supportLocalizer.LocalizedStringWithTemplateData("support_reply_media_not_sent", map[string]any{
+			"ID": supportTicket.ID,
+		}), &message.ID)
+	}
+	var text string
+	if messageText := supportMessageText(message); messageText != nil {
+		text = strings.TrimSpace(*messageText)
+	}
+	if command, _, _ := strings.Cut(text, "@"); message.Text != nil && command == closeSupportTicketCmdText {
+		if err := b.closeSupportTicket(ctx, supportTicket, profile); err != nil {
+			log.Error("fail to close support ticket", logger.F("ticket_id", supportTicket.ID), logger.FError(err))
+			return err
+		}
+		userText := userLocalizer.LocalizedStringWithTemplateData("support_ticket_closed_by_agent", map[string]any{
+			"ID": supportTicket.ID,
+		})
+		if _, err := b.sendUserSupportMessage(ctx, profile, userText, nil, nil); err != nil {
+			log.Error("fail to notify user about closed ticket", logger.F("ticket_id", supportTicket.ID), logger.FError(err))
+		}
+		return b.sendSupportChatMessage(ctx, supportLocalizer.LocalizedStringWithTemplateData("support_ticket_closed", map[string]any{
+			"ID": supportTicket.ID,
+		}), &message.ID)
+	}
+	batch := manager.NewCallbackDataBatch(b.telegramBotService)
+	closeSupportTicketButton, err := manager.NewCloseSupportTicketKeyboardButton(batch, userLocalizer)
+	if err != nil {
+		log.Error("fail to create close support ticket button", logger.FError(err))
+		return err
+	}
+	replyMarkup, err := batch.Markup(ctx, [][]telegram.InlineKeyboardButton{{*closeSupportTicketButton}})
+	if err != nil {
+		log.Error("fail to save close support ticket button", logger.FError(err))
+		return err
+	}
+	userText := userLocalizer.LocalizedStringWithTemplateData("support_reply", map[string]any{
+		"ID":   supportTicket.ID,
+		"Text": text,
+	})
+	userMessage, err := b.sendUserSupportMessage(ctx, profile, userText, photo, replyMarkup)
+	if telegram_bot.IsForbidden(err) {
+		if err := b.profileRepository.MarkBotBlocked(ctx, profile.ID); err != nil {
+			log.Error("fail to mark bot as blocked", logger.F("profile_id", profile.ID), logger.FError(err))
+		}
+		return b.sendSupportChatMessage(ctx, supportLocalizer.LocalizedStringWithTemplateData("support_user_blocked_bot", map[string]any{
+			"ID": supportTicket.ID,
+		}), &message.ID)
+	} else if err != nil {
+		log.Error("fail to relay support reply", logger.F("ticket_id", supportTicket.ID), logger.FError(err))
+		return err
+	}
+	_, err = b.supportTicketRepository.CreateMessage(ctx, &domain.SupportMessage{
+		TicketID:         supportTicket.ID,
+		Sender:           domain.AgentSupportMessageSender,
+		SenderTelegramID: ctxOptions.Update.GetTelegramID(),
+		Text:             text,
+		UserMessageID:    &userMessage.ID,
+		SupportMessageID: &message.ID,
+	})
+	if err != nil {
+		log.Error("fail to save support message", logger.F("ticket_id", supportTicket.ID), logger.FError(err))
+		return err
+	}
+	if err := b.supportTicketRepository.ChangeStatus(ctx, supportTicket.ID, domain.PendingSupportTicketStatus); err != nil {
+		log.Error("fail to change support ticket status", logger.F("ticket_id", supportTicket.ID), logger.FError(err))
+		return err
+	}
+	// the answer of the user goes into the ticket, unless the user is busy with something else
+	state, err := b.conversation.Current(ctx, profile.TelegramID)
+	if err != nil || state != "" {
+		return err
+	}
+	return b.conversation.Start(ctx, profile.TelegramID, ctxOptions, contactingSupportConversationState, contactingSupportConversationData{})
+}
+
+func (b *botController) closeSupportTicket(ctx context.Context, supportTicket *domain.SupportTicket, profile *domain.Profile) error {
+	if err := b.supportTicketRepository.ChangeStatus(ctx, supportTicket.ID, domain.ClosedSupportTicketStatus); err != nil {
+		return err
+	}
+	state, err := b.conversation.Current(ctx, profile.TelegramID)
+	if err != nil || state != contactingSupportConversationState {
+		return err
+	}
+	return b.conversation.Reset(ctx, profile.TelegramID)
+}
+
+// sendUserSupportMessage sends the photo with the text as its caption when the photo is given.
+func (b *botController) sendUserSupportMessage(
+	ctx context.Context,
+	profile *domain.Profile,
+	text string,
+	photo *telegram.PhotoSize,
+	replyMarkup *telegram.InlineKeyboardMarkup,
+) (*telegram.Message, error) {
+	if photo != nil {
+		sendPhoto := telegram.SendPhoto{
+			ChatID:  profile.TelegramChatID,
+			Photo:   photo.FileID,
+			Caption: text,
+		}
+		if replyMarkup != nil {
+			sendPhoto.ReplyMarkup = replyMarkup
+		}
+		return b.telegramBotService.SendPhoto(ctx, &sendPhoto)
+	}
+	resp := telegram.SendResponse{
+		ChatID: profile.TelegramChatID,
+		Text:   text,
+	}
+	if replyMarkup != nil {
+		resp.ReplyMarkup = replyMarkup
+	}
+	return b.telegramBotService.SendMessage(ctx, &resp)
+}
+
+func (b *botController) sendSupportChatMessage(ctx context.Context, text string, replyToMessageID *int64) error {
+	resp := telegram.SendResponse{
+		ChatID: b.container.GetConfig().SupportChatID(),
+		Text:   text,
+	}
+	if replyToMessageID != nil {
+		resp.ReplyParameters = &telegram.ReplyParameters{
+			MessageID:                *replyToMessageID,
+			AllowSendingWithoutReply: true,
+		}
+	}
+	_, err := b.telegramBotService.SendMessage(ctx, &resp)
+	return err
+}
+
+func supportUserTitle(profile *domain.Profile) string {
+	if profile.Username != nil {
+		return "@" + *profile.Username
+	}
+	return strconv.FormatInt(profile.TelegramID, 10)
+}
@@ -1,45 +1,50 @@
 package telegram
 
 import (
+	"context"
 	"go-ton-pass-telegram-bot/internal/model/app"
+	"go-ton-pass-telegram-bot/internal/model/domain"
 	"go-ton-pass-telegram-bot/internal/model/telegram"
 	"go-ton-pass-telegram-bot/internal/utils"
 	"go-ton-pass-telegram-bot/pkg/logger"
+	"go-ton-pass-telegram-bot/pkg/telegram_bot"
+	"strconv"
 )
 
 func (b *botController) AnswerCallbackQueryWithEditMessageMedia(
+	ctx context.Context,
 	callbackQuery *telegram.CallbackQuery,
 	text string,
 	photoURL string,
 	replyMarkup any,
 ) error {
 	log := b.container.GetLogger()
-	if err := b.AnswerCallbackQuery(callbackQuery, nil, false); err != nil {
+	if err := b.AnswerCallbackQuery(ctx, callbackQuery, nil, false); err != nil {
 		log.Debug("fail to answer callback query", logger.FError(err))
 		return err
 	}
-	if err := b.EditMessageMedia(callbackQuery, text, photoURL, replyMarkup); err != nil {
+	if err := b.EditMessageMedia(ctx, callbackQuery, text, photoURL, replyMarkup); err != nil {
 		log.Debug("fail to perform EditMessageMedia", logger.FError(err))
 		return err
 	}
 	return nil
 }
 
-func (b *botController) AnswerCallbackQuery(callbackQuery *telegram.CallbackQuery, text *string, showAlert bool) error {
+func (b *botController) AnswerCallbackQuery(ctx context.Context, callbackQuery *telegram.CallbackQuery, text *string, showAlert bool) error {
 	log := b.container.GetLogger()
 	answerCallbackQuery := telegram.AnswerCallbackQuery{
 		ID:        callbackQuery.ID,
 		Text:      text,
 		ShowAlert: showAlert,
 	}
-	if err := b.telegramBotService.SendResponse(answerCallbackQuery, app.AnswerCallbackQueryTelegramMethod); err != nil {
+	if err := b.telegramBotService.AnswerCallbackQuery(ctx, &answerCallbackQuery); err != nil {
 		log.Debug("fail to send a AnswerCallbackQuery to telegram servers", logger.FError(err))
 		return err
 	}
 	return nil
 }
 
-func (b *botController) EditMessageMedia(callbackQuery *telegram.CallbackQuery, text string, photoURL string, replyMarkup any) error {
+func (b *botController) EditMessageMedia(ctx context.Context, callbackQuery *telegram.CallbackQuery, text string, photoURL string, replyMarkup any) error {
 	log := b.container.GetLogger()
 	photoMedia := telegram.InputPhotoMedia{
 		Type:      "photo",
@@ -53,14 +58,15 @@ func (b *botController) EditMessageMedia(callbackQuery *telegram.CallbackQuery,
 		Media:       photoMedia,
 		ReplyMarkup: replyMarkup,
 	}
-	if err := b.telegramBotService.SendResponse(editMessageMedia, app.EditMessageMediaTelegramMethod); err != nil {
+	// pressing the button of the menu already shown edits the message with the same content
+	if _, err := b.telegramBotService.EditMessageMedia(ctx, &editMessageMedia); err != nil && !telegram_bot.IsMessageNotModified(err) {
 		log.Error("fail to edit message media", logger.FError(err))
 		return err
 	}
 	return nil
 }
 
-func (b *botController) SendTextWithPhotoMedia(chatID int64, text string, photoURL string, replyMarkup any) error {
+func (b *botController) SendTextWithPhotoMedia(ctx context.Context, chatID int64, text string, photoURL string, replyMarkup any) error {
 	log := b.container.GetLogger()
 	resp := telegram.SendPhoto{
 		ChatID:      chatID,
@@ -69,7 +75,7 @@ func (b *botController) SendTextWithPhotoMedia(chatID int64, text string, photoU
 		ParseMode:   utils.NewString("MarkdownV2"),
 		ReplyMarkup: replyMarkup,
 	}
-	if err := b.telegramBotService.SendResponse(resp, app.SendPhotoTelegramMethod); err != nil {
+	if _, err := b.telegramBotService.SendPhoto(ctx, &resp); err != nil {
 		log.Debug("fail to send message with photo media", logger.FError(err))
 		return err
 	}
@@ -88,9 +94,53 @@ func (b *botController) getPreferredLanguage(options *ContextOptions) string {
 	return *preferredLanguage
 }
 
-func (b *botController) deleteMessage(deleteMessage *telegram.DeleteMessage) error {
+func (b *botController) fetchFavorites(ctx context.Context, options *ContextOptions) (*app.Favorites, error) {
+	favorites, err := b.favoriteRepository.FetchByProfileID(ctx, options.Profile.ID)
+	if err != nil {
+		return nil, err
+	}
+	var result app.Favorites
+	for _, favorite := range favorites {
+		switch favorite.Type {
+		case domain.ServiceFavoriteType:
+			result.ServiceCodes = append(result.ServiceCodes, favorite.Code)
+		case domain.CountryFavoriteType:
+			countryID, err := strconv.ParseInt(favorite.Code, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			result.CountryIDs = append(result.CountryIDs, countryID)
+		}
+	}
+	return &result, nil
+}
+
+// parsePhoneNumber splits the bought number by the dialing code of the country. The number is already paid for,
+// so a number that can't be parsed is kept whole instead of failing the purchase.
+func (b *botController) parsePhoneNumber(phoneNumber string, countryID int64) app.PhoneNumber {
+	log := b.container.GetLogger()
+	countryDialingCode := ""
+	if dialingCode := b.container.GetCountryDialingCode(countryID); dialingCode != nil {
+		countryDialingCode = dialingCode.DialingCode
+	}
+	parsedPhoneNumber, err := utils.ParsePhoneNumber(phoneNumber, countryDialingCode, b.container.GetDialingCodes())
+	if err != nil {
+		log.Error(
+			"fail to parse phone number, keep it whole",
+			logger.FError(err),
+			logger.F("phone_number", phoneNumber),
+			logger.F("country_id", countryID),
+		)
+		return app.PhoneNumber{
+			ShortPhoneNumber: phoneNumber,
+		}
+	}
+	return *parsedPhoneNumber
+}
+
+func (b *botController) deleteMessage(ctx context.Context, deleteMessage *telegram.DeleteMessage) error {
 	log := b.container.GetLogger()
-	if err := b.telegramBotService.SendResponse(deleteMessage, app.DeleteMessageTelegramMethod); err != nil {
+	if err := b.telegramBotService.DeleteMessage(ctx, deleteMessage); err != nil {
 		log.Error("fail to delete message in bot chat", logger.FError(err))
 		return err
 	}
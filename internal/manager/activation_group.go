@@ -0,0 +1,87 @@
+package manager
+
+import (
+	"context"
+	"go-ton-pass-telegram-bot/internal/container"
+	"go-ton-pass-telegram-bot/internal/model/app"
+	"go-ton-pass-telegram-bot/internal/model/telegram"
+	"go-ton-pass-telegram-bot/internal/repository"
+	"go-ton-pass-telegram-bot/internal/service"
+	"go-ton-pass-telegram-bot/internal/utils"
+	"go-ton-pass-telegram-bot/internal/worker"
+	"go-ton-pass-telegram-bot/pkg/logger"
+)
+
+// ActivationGroupMessenger keeps the grouped activation message in line with the numbers of the group,
+// the webhook of SMS-Activate and postponed workers refresh it the same way.
+type ActivationGroupMessenger interface {
+	Refresh(ctx context.Context, activationGroupID int64, langCode string) error
+}
+
+type activationGroupMessenger struct {
+	container                 container.Container
+	telegramBotService        service.TelegramBotService
+	activationGroupRepository repository.ActivationGroupRepository
+	smsHistoryRepository      repository.SMSHistoryRepository
+	formatterWorker           worker.Formatter
+}
+
+func NewActivationGroupMessenger(
+	container container.Container,
+	telegramBotService service.TelegramBotService,
+	activationGroupRepository repository.ActivationGroupRepository,
+	smsHistoryRepository repository.SMSHistoryRepository,
+) ActivationGroupMessenger {
+	return &activationGroupMessenger{
+		container:                 container,
+		telegramBotService:        telegramBotService,
+		activationGroupRepository: activationGroupRepository,
+		smsHistoryRepository:      smsHistoryRepository,
+		formatterWorker:           worker.NewFormatter(container),
+	}
+}
+
+// Refresh edits the grouped activation message in place with the codes received so far instead of sending
+// a message per number.
+func (a *activationGroupMessenger) Refresh(ctx context.Context, activationGroupID int64, langCode string) error {
+	log := a.container.GetLogger()
+	activationGroup, err := a.activationGroupRepository.FetchByID(ctx, activationGroupID)
+	if err != nil {
+		log.Error("fail to get activation group from db", logger.F("activation_group_id", activationGroupID), logger.FError(err))
+		return err
+	}
+	if activationGroup.MessageID == nil {
+		// the message is sent once the whole group is purchased and already contains the latest state
+		log.Debug("activation group message hasn't been sent yet", logger.F("activation_group_id", activationGroupID))
+		return nil
+	}
+	smsHistories, err := a.smsHistoryRepository.FetchByActivationGroupID(ctx, activationGroupID)
+	if err != nil {
+		log.Error("fail to get sms histories of activation group from db", logger.FError(err))
+		return err
+	}
+	replyMarkup, err := NewActivationGroupInlineKeyboardMarkup(ctx, a.telegramBotService, smsHistories)
+	if err != nil {
+		log.Error("fail to create activation group keyboard", logger.FError(err))
+		return err
+	}
+	editCaptionMessage := telegram.EditCaptionMessage{
+		ChatID:      &activationGroup.ChatID,
+		MessageID:   activationGroup.MessageID,
+		Caption:     utils.NewString(a.formatterWorker.ActivationGroup(langCode, activationGroup, smsHistories)),
+		ParseMode:   utils.NewString("MarkdownV2"),
+		ReplyMarkup: replyMarkup,
+	}
+	err = a.telegramBotService.Enqueue(
+		ctx,
+		app.CodeOutboundPriority,
+		app.EditMessageCaptionOutboundMethod,
+		activationGroup.ChatID,
+		&editCaptionMessage,
+	)
+	if err != nil {
+		log.Error("update activation group message has failed", logger.FError(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"go-ton-pass-telegram-bot/internal/model/app"
+	"go-ton-pass-telegram-bot/internal/model/domain"
+	"go-ton-pass-telegram-bot/internal/model/telegram"
+	"go-ton-pass-telegram-bot/internal/utils"
+	"go-ton-pass-telegram-bot/pkg/localizer"
+)
+
+// NewBroadcastMessage builds the request sending the broadcast to the chat. The admin's preview and every recipient
+// get the same request, a broadcast with a photo is sent as the photo with the text in the caption.
+func NewBroadcastMessage(broadcast *domain.Broadcast, chatID int64) (app.OutboundMethod, any, error) {
+	var entities []telegram.MessageEntity
+	if len(broadcast.Entities) > 0 {
+		if err := json.Unmarshal(broadcast.Entities, &entities); err != nil {
+			return "", nil, err
+		}
+	}
+	var replyMarkup any
+	if len(broadcast.Buttons) > 0 {
+		gridButtons := make([][]telegram.InlineKeyboardButton, 0, len(broadcast.Buttons))
+		for _, button := range broadcast.Buttons {
+			gridButtons = append(gridButtons, []telegram.InlineKeyboardButton{{
+				Text: button.Text,
+				URL:  utils.NewString(button.URL),
+			}})
+		}
+		replyMarkup = telegram.InlineKeyboardMarkup{
+			InlineKeyboard: gridButtons,
+		}
+	}
+	var text string
+	if broadcast.Text != nil {
+		text = *broadcast.Text
+	}
+	if broadcast.Photo != nil {
+		return app.SendPhotoOutboundMethod, telegram.SendPhoto{
+			ChatID:          chatID,
+			Photo:           *broadcast.Photo,
+			Caption:         text,
+			CaptionEntities: entities,
+			ReplyMarkup:     replyMarkup,
+		}, nil
+	}
+	return app.SendMessageOutboundMethod, telegram.SendResponse{
+		ChatID:      chatID,
+		Text:        text,
+		Entities:    entities,
+		ReplyMarkup: replyMarkup,
+	}, nil
+}
+
+// NewBroadcastControlInlineKeyboardMarkup builds buttons the broadcast's status allows, a finished broadcast has none.
+// It takes the localizer of the author, so postponed workers can rebuild it while reporting the progress.
+func NewBroadcastControlInlineKeyboardMarkup(
+	ctx context.Context,
+	callbackDataStore CallbackDataStore,
+	localizer localizer.Localizer,
+	broadcastID int64,
+	status domain.BroadcastStatus,
+) (*telegram.InlineKeyboardMarkup, error) {
+	type controlButton struct {
+		action app.BroadcastAction
+		title  string
+		emoji  string
+	}
+	controlButtons := make([]controlButton, 0, 2)
+	switch status {
+	case domain.DraftBroadcastStatus:
+		controlButtons = append(controlButtons, controlButton{app.StartBroadcastAction, "broadcast_start", "📣"})
+	case domain.RunningBroadcastStatus:
+		controlButtons = append(controlButtons, controlButton{app.PauseBroadcastAction, "broadcast_pause", "⏸"})
+	case domain.PausedBroadcastStatus:
+		controlButtons = append(controlButtons, controlButton{app.ResumeBroadcastAction, "broadcast_resume", "▶️"})
+	}
+	if !status.IsFinished() {
+		controlButtons = append(controlButtons, controlButton{app.CancelBroadcastAction, "broadcast_cancel", "✖️"})
+	}
+	batch := NewCallbackDataBatch(callbackDataStore)
+	buttons := make([]telegram.InlineKeyboardButton, 0, len(controlButtons))
+	for _, controlButton := range controlButtons {
+		button, err := NewTelegramInlineButtonBuilder(batch).
+			SetText(utils.ButtonTitle(localizer.LocalizedString(controlButton.title), controlButton.emoji)).
+			SetCommandName(app.ControlBroadcastQueryCmdText).
+			SetParameters([]any{broadcastID, string(controlButton.action)}).
+			Build()
+		if err != nil {
+			return nil, err
+		}
+		buttons = append(buttons, *button)
+	}
+	return batch.Markup(ctx, [][]telegram.InlineKeyboardButton{buttons})
+}
@@ -0,0 +1,71 @@
+package manager
+
+import (
+	"context"
+	"go-ton-pass-telegram-bot/internal/container"
+	"go-ton-pass-telegram-bot/internal/model/app"
+	"go-ton-pass-telegram-bot/internal/repository"
+	"go-ton-pass-telegram-bot/pkg/logger"
+)
+
+// NumberActivation requests a single number and records it, a number that fails after being requested is cancelled
+// by the activation itself.
+type NumberActivation func(ctx context.Context) error
+
+// NumberPurchase keeps the balance of the profile in line with the numbers it has obtained, the telegram
+// controller buys a single number and bulk numbers the same way.
+type NumberPurchase interface {
+	Purchase(ctx context.Context, telegramID int64, price float64, quantity int, activate NumberActivation) (int, error)
+}
+
+type numberPurchase struct {
+	container         container.Container
+	profileRepository repository.ProfileRepository
+}
+
+func NewNumberPurchase(container container.Container, profileRepository repository.ProfileRepository) NumberPurchase {
+	return &numberPurchase{
+		container:         container,
+		profileRepository: profileRepository,
+	}
+}
+
+// Purchase reserves the price of all numbers at once, activates them one by one until an activation fails and
+// returns the price of the numbers that weren't obtained back to the balance. It returns the number of obtained
+// numbers and the error of the failed activation. The purchase isn't stopped by cancelling ctx, a request given up
+// halfway would keep the money of the user or a number without its check workflow.
+func (n *numberPurchase) Purchase(
+	ctx context.Context,
+	telegramID int64,
+	price float64,
+	quantity int,
+	activate NumberActivation,
+) (int, error) {
+	log := n.container.GetLogger()
+	ctx = context.WithoutCancel(ctx)
+	totalAmount := price * float64(quantity)
+	isDebited, err := n.profileRepository.DebitIfSufficient(ctx, telegramID, totalAmount)
+	if err != nil {
+		log.Error("fail to withdraw money from account", logger.FError(err))
+		return 0, err
+	}
+	if !isDebited {
+		log.Debug("hasn't sufficient funds for numbers", logger.F("quantity", quantity))
+		return 0, app.InsufficientFundsError
+	}
+	obtained := 0
+	var activateErr error
+	for obtained < quantity {
+		if activateErr = activate(ctx); activateErr != nil {
+			break
+		}
+		obtained++
+	}
+	if obtained < quantity {
+		refundAmount := price * float64(quantity-obtained)
+		if err := n.profileRepository.TopUpBalanceByTelegramID(ctx, telegramID, refundAmount); err != nil {
+			log.Error("fail to return amount for missing numbers", logger.F("amount", refundAmount), logger.FError(err))
+		}
+	}
+	return obtained, activateErr
+}
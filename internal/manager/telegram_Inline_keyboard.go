@@ -1,9 +1,12 @@
 package manager
 
 import (
+	"context"
 	"fmt"
+	"go-ton-pass-telegram-bot/internal/config"
 	"go-ton-pass-telegram-bot/internal/container"
 	"go-ton-pass-telegram-bot/internal/model/app"
+	"go-ton-pass-telegram-bot/internal/model/domain"
 	"go-ton-pass-telegram-bot/internal/model/sms"
 	"go-ton-pass-telegram-bot/internal/model/telegram"
 	"go-ton-pass-telegram-bot/internal/utils"
@@ -14,42 +17,62 @@ import (
 
 type TelegramInlineKeyboardManager interface {
 	Set(languageTag string)
-	MainMenuKeyboardMarkup() (*telegram.InlineKeyboardMarkup, error)
-	InitialLanguagesKeyboardMarkup() (*telegram.InlineKeyboardMarkup, error)
-	LanguagesKeyboardMarkup() (*telegram.InlineKeyboardMarkup, error)
-	InitialPreferredCurrenciesKeyboardMarkup() (*telegram.InlineKeyboardMarkup, error)
-	PreferredCurrenciesKeyboardMarkup() (*telegram.InlineKeyboardMarkup, error)
-	CryptoBotPayCurrenciesKeyboardMarkup() (*telegram.InlineKeyboardMarkup, error)
-	MainMenuKeyboardButton() *telegram.InlineKeyboardButton
+	MainMenuKeyboardMarkup(ctx context.Context) (*telegram.InlineKeyboardMarkup, error)
+	InitialLanguagesKeyboardMarkup(ctx context.Context) (*telegram.InlineKeyboardMarkup, error)
+	LanguagesKeyboardMarkup(ctx context.Context) (*telegram.InlineKeyboardMarkup, error)
+	InitialPreferredCurrenciesKeyboardMarkup(ctx context.Context) (*telegram.InlineKeyboardMarkup, error)
+	PreferredCurrenciesKeyboardMarkup(ctx context.Context) (*telegram.InlineKeyboardMarkup, error)
+	CryptoBotPayCurrenciesKeyboardMarkup(ctx context.Context) (*telegram.InlineKeyboardMarkup, error)
+	MainMenuKeyboardButton(batch *CallbackDataBatch) *telegram.InlineKeyboardButton
 	LinkKeyboardButton(text, link string) *telegram.InlineKeyboardButton
-	BackKeyboardMarkup() *telegram.InlineKeyboardMarkup
-	BackKeyboardButton() *telegram.InlineKeyboardButton
-	TopUpBalanceKeyboardMarkup() (*telegram.InlineKeyboardMarkup, error)
-	CryptoPayBotKeyboardMarkup(url string, invoiceID int64) (*telegram.InlineKeyboardMarkup, error)
-	StripeKeyboardMarkup(url string) (*telegram.InlineKeyboardMarkup, error)
-	PageControlKeyboardButtons(commandName string, pagination app.Pagination, leftButtonParameters []any, rightButtonParameters []any) ([]telegram.InlineKeyboardButton, error)
-	ServicesInlineKeyboardMarkup(services []sms.Service, pagination app.Pagination) (*telegram.InlineKeyboardMarkup, error)
-	ServiceCountriesInlineKeyboardMarkup(serviceCode string, preferredCurrency string, pagination app.Pagination, servicePrices []sms.PriceForService, countries []sms.Country) (*telegram.InlineKeyboardMarkup, error)
-	ConfirmationPayInlineKeyboardMarkup(serviceCode string, countryID int64, maxPrice float64) (*telegram.InlineKeyboardMarkup, error)
-	RefundInlineKeyboardMarkup(smsHistoryID int64) (*telegram.InlineKeyboardMarkup, error)
-	EnteringAmountInlineKeyboardMarkup() (*telegram.InlineKeyboardMarkup, error)
-	IsSubscriptionMemberInlineKeyboardMarkup() (*telegram.InlineKeyboardMarkup, error)
-	TelegramStarsPayInlineKeyboardMarkup(stars int64) (*telegram.InlineKeyboardMarkup, error)
+	BackKeyboardMarkup(ctx context.Context) (*telegram.InlineKeyboardMarkup, error)
+	BackKeyboardButton(batch *CallbackDataBatch) *telegram.InlineKeyboardButton
+	TopUpBalanceKeyboardMarkup(ctx context.Context) (*telegram.InlineKeyboardMarkup, error)
+	CryptoPayBotKeyboardMarkup(ctx context.Context, url string, invoiceID int64) (*telegram.InlineKeyboardMarkup, error)
+	StripeKeyboardMarkup(ctx context.Context, url string) (*telegram.InlineKeyboardMarkup, error)
+	PageControlKeyboardButtons(batch *CallbackDataBatch, commandName string, pagination app.Pagination, leftButtonParameters []any, rightButtonParameters []any) ([]telegram.InlineKeyboardButton, error)
+	ServicesInlineKeyboardMarkup(ctx context.Context, services []sms.Service, favorites app.Favorites, pagination app.Pagination) (*telegram.InlineKeyboardMarkup, error)
+	FavoritesInlineKeyboardMarkup(ctx context.Context, services []sms.Service) (*telegram.InlineKeyboardMarkup, error)
+	ServiceCountriesInlineKeyboardMarkup(ctx context.Context, serviceCode string, preferredCurrency string, pagination app.Pagination, servicePrices []sms.PriceForService, countries map[int64]sms.Country, favorites app.Favorites) (*telegram.InlineKeyboardMarkup, error)
+	CheapestPriceCeilingsInlineKeyboardMarkup(ctx context.Context, serviceCode string, preferredCurrency string, servicePrices []sms.PriceForService) (*telegram.InlineKeyboardMarkup, error)
+	ServiceOperatorsInlineKeyboardMarkup(ctx context.Context, serviceCode string, countryID int64, priceInRUB float64, priceWithFee float64, preferredCurrency string, operatorPrices []sms.OperatorPrice) (*telegram.InlineKeyboardMarkup, error)
+	ConfirmationPayInlineKeyboardMarkup(ctx context.Context, serviceCode string, countryID int64, maxPrice float64, operator string, isFavoriteCountry bool) (*telegram.InlineKeyboardMarkup, error)
+	RefundInlineKeyboardMarkup(ctx context.Context, smsHistoryID int64) (*telegram.InlineKeyboardMarkup, error)
+	ActivationGroupInlineKeyboardMarkup(ctx context.Context, smsHistories []domain.SMSHistory) (*telegram.InlineKeyboardMarkup, error)
+	EnteringAmountInlineKeyboardMarkup(ctx context.Context) (*telegram.InlineKeyboardMarkup, error)
+	IsSubscriptionMemberInlineKeyboardMarkup(ctx context.Context, channels []config.RequiredChannel) (*telegram.InlineKeyboardMarkup, error)
+	TelegramStarsPayInlineKeyboardMarkup(ctx context.Context, stars int64) (*telegram.InlineKeyboardMarkup, error)
+	HelpKeyboardMarkup(ctx context.Context) (*telegram.InlineKeyboardMarkup, error)
+	SupportKeyboardMarkup(ctx context.Context, hasActiveTicket bool) (*telegram.InlineKeyboardMarkup, error)
 }
 
+const (
+	favoriteEmoji   = "⭐"
+	unfavoriteEmoji = "✖️"
+)
+
+// maxCheapestPriceCeilings limits how many of the lowest prices are offered as the ceiling of the cheapest number.
+const maxCheapestPriceCeilings = 4
+
 type telegramInlineKeyboardManager struct {
 	container          container.Container
 	localizer          localizer.Localizer
 	formatterWorker    worker.Formatter
 	exchangeRateWorker worker.ExchangeRate
+	callbackDataStore  CallbackDataStore
 }
 
-func NewTelegramInlineKeyboardManager(container container.Container, exchangeRateWorker worker.ExchangeRate) TelegramInlineKeyboardManager {
+func NewTelegramInlineKeyboardManager(
+	container container.Container,
+	exchangeRateWorker worker.ExchangeRate,
+	callbackDataStore CallbackDataStore,
+) TelegramInlineKeyboardManager {
 	return &telegramInlineKeyboardManager{
 		container:          container,
 		localizer:          container.GetLocalizer("en"),
 		formatterWorker:    worker.NewFormatter(container),
 		exchangeRateWorker: exchangeRateWorker,
+		callbackDataStore:  callbackDataStore,
 	}
 }
 
@@ -57,17 +80,18 @@ func (t *telegramInlineKeyboardManager) Set(languageTag string) {
 	t.localizer = t.container.GetLocalizer(languageTag)
 }
 
-func (t *telegramInlineKeyboardManager) MainMenuKeyboardMarkup() (*telegram.InlineKeyboardMarkup, error) {
+func (t *telegramInlineKeyboardManager) MainMenuKeyboardMarkup(ctx context.Context) (*telegram.InlineKeyboardMarkup, error) {
+	batch := NewCallbackDataBatch(t.callbackDataStore)
 	langCode := t.localizer.GetISOLang()
 	flagEmoji := t.container.GetConfig().LanguageByCode(langCode).FlagEmoji
-	balanceInlineKeyboardButton, err := NewTelegramInlineButtonBuilder().
+	balanceInlineKeyboardButton, err := NewTelegramInlineButtonBuilder(batch).
 		SetText(utils.ButtonTitle(t.localizer.LocalizedString("balance"), "💰")).
 		SetCommandName(app.BalanceCallbackQueryCmdText).
 		Build()
 	if err != nil {
 		return nil, err
 	}
-	buyNumberInlineKeyboardButton, err := NewTelegramInlineButtonBuilder().
+	buyNumberInlineKeyboardButton, err := NewTelegramInlineButtonBuilder(batch).
 		SetText(utils.ButtonTitle(t.localizer.LocalizedString("buy_number"), "🛒")).
 		SetCommandName(app.BuyNumberCallbackQueryCmdText).
 		SetParameters([]any{0}).
@@ -75,14 +99,21 @@ func (t *telegramInlineKeyboardManager) MainMenuKeyboardMarkup() (*telegram.Inli
 	if err != nil {
 		return nil, err
 	}
-	helpInlineKeyboardButton, err := NewTelegramInlineButtonBuilder().
+	helpInlineKeyboardButton, err := NewTelegramInlineButtonBuilder(batch).
 		SetText(utils.ButtonTitle(t.localizer.LocalizedString("help"), "❓")).
 		SetCommandName(app.HelpCallbackQueryCmdText).
 		Build()
 	if err != nil {
 		return nil, err
 	}
-	historyInlineKeyboardButton, err := NewTelegramInlineButtonBuilder().
+	favoritesInlineKeyboardButton, err := NewTelegramInlineButtonBuilder(batch).
+		SetText(utils.ButtonTitle(t.localizer.LocalizedString("favorites"), favoriteEmoji)).
+		SetCommandName(app.FavoritesCallbackQueryCmdText).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+	historyInlineKeyboardButton, err := NewTelegramInlineButtonBuilder(batch).
 		SetText(utils.ButtonTitle(t.localizer.LocalizedString("history"), "📖")).
 		SetCommandName(app.HistoryCallbackQueryCmdText).
 		SetParameters([]any{0, 3}).
@@ -90,14 +121,14 @@ func (t *telegramInlineKeyboardManager) MainMenuKeyboardMarkup() (*telegram.Inli
 	if err != nil {
 		return nil, err
 	}
-	languageInlineKeyboardButton, err := NewTelegramInlineButtonBuilder().
+	languageInlineKeyboardButton, err := NewTelegramInlineButtonBuilder(batch).
 		SetText(utils.ButtonTitle(t.localizer.LocalizedString("language"), flagEmoji)).
 		SetCommandName(app.LanguageCallbackQueryCmdText).
 		Build()
 	if err != nil {
 		return nil, err
 	}
-	preferredCurrenciesInlineKeyboardButton, err := NewTelegramInlineButtonBuilder().
+	preferredCurrenciesInlineKeyboardButton, err := NewTelegramInlineButtonBuilder(batch).
 		SetText(utils.ButtonTitle(t.localizer.LocalizedString("currency"), "💵")).
 		SetCommandName(app.PreferredCurrenciesCallbackQueryCmdText).
 		Build()
@@ -106,27 +137,27 @@ func (t *telegramInlineKeyboardManager) MainMenuKeyboardMarkup() (*telegram.Inli
 	}
 	inlineKeyboardButtons := t.getGridInlineKeyboardButton([]telegram.InlineKeyboardButton{
 		*balanceInlineKeyboardButton, *buyNumberInlineKeyboardButton,
-		*helpInlineKeyboardButton, *historyInlineKeyboardButton,
-		*languageInlineKeyboardButton, *preferredCurrenciesInlineKeyboardButton,
+		*favoritesInlineKeyboardButton, *historyInlineKeyboardButton,
+		*helpInlineKeyboardButton, *languageInlineKeyboardButton,
+		*preferredCurrenciesInlineKeyboardButton,
 	}, 2)
-	return &telegram.InlineKeyboardMarkup{
-		InlineKeyboard: inlineKeyboardButtons,
-	}, nil
+	return batch.Markup(ctx, inlineKeyboardButtons)
 }
 
-func (t *telegramInlineKeyboardManager) InitialPreferredCurrenciesKeyboardMarkup() (*telegram.InlineKeyboardMarkup, error) {
-	return t.preparePreferredCurrenciesKeyboardMarkup(app.SelectInitialPreferredCurrencyCallbackQueryCmdText, false)
+func (t *telegramInlineKeyboardManager) InitialPreferredCurrenciesKeyboardMarkup(ctx context.Context) (*telegram.InlineKeyboardMarkup, error) {
+	return t.preparePreferredCurrenciesKeyboardMarkup(ctx, app.SelectInitialPreferredCurrencyCallbackQueryCmdText, false)
 }
 
-func (t *telegramInlineKeyboardManager) PreferredCurrenciesKeyboardMarkup() (*telegram.InlineKeyboardMarkup, error) {
-	return t.preparePreferredCurrenciesKeyboardMarkup(app.SelectPreferredCurrencyCallbackQueryCmdText, true)
+func (t *telegramInlineKeyboardManager) PreferredCurrenciesKeyboardMarkup(ctx context.Context) (*telegram.InlineKeyboardMarkup, error) {
+	return t.preparePreferredCurrenciesKeyboardMarkup(ctx, app.SelectPreferredCurrencyCallbackQueryCmdText, true)
 }
 
-func (t *telegramInlineKeyboardManager) CryptoBotPayCurrenciesKeyboardMarkup() (*telegram.InlineKeyboardMarkup, error) {
+func (t *telegramInlineKeyboardManager) CryptoBotPayCurrenciesKeyboardMarkup(ctx context.Context) (*telegram.InlineKeyboardMarkup, error) {
+	batch := NewCallbackDataBatch(t.callbackDataStore)
 	payCurrencies := t.container.GetConfig().AvailableCryptoBotPayCurrencies()
 	payCurrenciesInlineKeyboardButtons := make([]telegram.InlineKeyboardButton, 0, len(payCurrencies))
 	for _, payCurrency := range payCurrencies {
-		payCurrencyInlineKeyboardButton, err := NewTelegramInlineButtonBuilder().
+		payCurrencyInlineKeyboardButton, err := NewTelegramInlineButtonBuilder(batch).
 			SetCommandName(app.SelectPayCurrencyCallbackQueryCmdText).
 			SetParameters([]any{payCurrency.ABBR}).
 			SetText(utils.ShortCurrencyTextFormat(payCurrency)).
@@ -136,30 +167,24 @@ func (t *telegramInlineKeyboardManager) CryptoBotPayCurrenciesKeyboardMarkup() (
 		}
 		payCurrenciesInlineKeyboardButtons = append(payCurrenciesInlineKeyboardButtons, *payCurrencyInlineKeyboardButton)
 	}
-	backButton := t.BackKeyboardButton()
+	backButton := t.BackKeyboardButton(batch)
 	payCurrenciesInlineKeyboardButtons = append(payCurrenciesInlineKeyboardButtons, *backButton)
-	return &telegram.InlineKeyboardMarkup{
-		InlineKeyboard: t.getGridInlineKeyboardButton(payCurrenciesInlineKeyboardButtons, 2),
-	}, nil
+	return batch.Markup(ctx, t.getGridInlineKeyboardButton(payCurrenciesInlineKeyboardButtons, 2))
 }
 
-func (t *telegramInlineKeyboardManager) ServicesInlineKeyboardMarkup(services []sms.Service, pagination app.Pagination) (*telegram.InlineKeyboardMarkup, error) {
+func (t *telegramInlineKeyboardManager) ServicesInlineKeyboardMarkup(ctx context.Context, services []sms.Service, favorites app.Favorites, pagination app.Pagination) (*telegram.InlineKeyboardMarkup, error) {
+	batch := NewCallbackDataBatch(t.callbackDataStore)
 	log := t.container.GetLogger()
 	columns := 2
-	startIndex := pagination.CurrentPage * pagination.ItemsPerPage
-	endIndex := (pagination.CurrentPage + 1) * pagination.ItemsPerPage
-	if endIndex > len(services) {
-		endIndex = len(services)
-	}
-	if startIndex > len(services) {
-		return nil, app.IndexOutOfRangeError
-	}
-	servicesSlice := services[startIndex:endIndex]
-	buttons := make([]telegram.InlineKeyboardButton, 0, len(servicesSlice))
-	for _, service := range servicesSlice {
-		button, err := NewTelegramInlineButtonBuilder().
+	buttons := make([]telegram.InlineKeyboardButton, 0, len(services))
+	for _, service := range services {
+		text := t.formatterWorker.Service(t.localizer.GetISOLang(), &service, worker.DefaultFormatterType)
+		if favorites.IsFavoriteService(service.Code) {
+			text = utils.ButtonTitle(text, favoriteEmoji)
+		}
+		button, err := NewTelegramInlineButtonBuilder(batch).
 			SetCommandName(app.SelectSMSServiceCallbackQueryCmdText).
-			SetText(t.formatterWorker.Service(&service, worker.DefaultFormatterType)).
+			SetText(text).
 			SetParameters([]any{service.Code, 0}).
 			Build()
 		if err != nil {
@@ -169,28 +194,27 @@ func (t *telegramInlineKeyboardManager) ServicesInlineKeyboardMarkup(services []
 		buttons = append(buttons, *button)
 	}
 	gridButtons := t.getGridInlineKeyboardButton(buttons, columns)
-	pageControlButtons, err := t.PageControlKeyboardButtons(app.BuyNumberCallbackQueryCmdText, pagination, []any{pagination.PrevPage()}, []any{pagination.NextPage()})
+	pageControlButtons, err := t.PageControlKeyboardButtons(batch, app.BuyNumberCallbackQueryCmdText, pagination, []any{pagination.PrevPage()}, []any{pagination.NextPage()})
 	if err != nil {
 		return nil, err
 	}
 	gridButtons = append(gridButtons, pageControlButtons)
-	backButton := t.BackKeyboardButton()
+	backButton := t.BackKeyboardButton(batch)
 	gridButtons = append(gridButtons, []telegram.InlineKeyboardButton{*backButton})
-	return &telegram.InlineKeyboardMarkup{
-		InlineKeyboard: gridButtons,
-	}, nil
+	return batch.Markup(ctx, gridButtons)
 }
 
-func (t *telegramInlineKeyboardManager) InitialLanguagesKeyboardMarkup() (*telegram.InlineKeyboardMarkup, error) {
-	return t.prepareLanguageKeyboardMarkup(app.SelectInitialLanguageCallbackQueryCmdText, false)
+func (t *telegramInlineKeyboardManager) InitialLanguagesKeyboardMarkup(ctx context.Context) (*telegram.InlineKeyboardMarkup, error) {
+	return t.prepareLanguageKeyboardMarkup(ctx, app.SelectInitialLanguageCallbackQueryCmdText, false)
 }
 
-func (t *telegramInlineKeyboardManager) LanguagesKeyboardMarkup() (*telegram.InlineKeyboardMarkup, error) {
-	return t.prepareLanguageKeyboardMarkup(app.SelectLanguageCallbackQueryCmdText, true)
+func (t *telegramInlineKeyboardManager) LanguagesKeyboardMarkup(ctx context.Context) (*telegram.InlineKeyboardMarkup, error) {
+	return t.prepareLanguageKeyboardMarkup(ctx, app.SelectLanguageCallbackQueryCmdText, true)
 }
 
-func (t *telegramInlineKeyboardManager) RefundInlineKeyboardMarkup(smsHistoryID int64) (*telegram.InlineKeyboardMarkup, error) {
-	refundButton, err := NewTelegramInlineButtonBuilder().
+func (t *telegramInlineKeyboardManager) RefundInlineKeyboardMarkup(ctx context.Context, smsHistoryID int64) (*telegram.InlineKeyboardMarkup, error) {
+	batch := NewCallbackDataBatch(t.callbackDataStore)
+	refundButton, err := NewTelegramInlineButtonBuilder(batch).
 		SetText(utils.ButtonTitle(t.localizer.LocalizedString("refund"), "♻️")).
 		SetCommandName(app.RefundAmountFromSMSActivationQueryCmdText).
 		SetParameters([]any{smsHistoryID}).
@@ -199,41 +223,79 @@ func (t *telegramInlineKeyboardManager) RefundInlineKeyboardMarkup(smsHistoryID
 		return nil, err
 	}
 	gridButtons := t.getGridInlineKeyboardButton([]telegram.InlineKeyboardButton{*refundButton}, 1)
-	return &telegram.InlineKeyboardMarkup{
-		InlineKeyboard: gridButtons,
-	}, nil
+	return batch.Markup(ctx, gridButtons)
+}
+
+func (t *telegramInlineKeyboardManager) ActivationGroupInlineKeyboardMarkup(ctx context.Context, smsHistories []domain.SMSHistory) (*telegram.InlineKeyboardMarkup, error) {
+	return NewActivationGroupInlineKeyboardMarkup(ctx, t.callbackDataStore, smsHistories)
+}
+
+// NewActivationGroupInlineKeyboardMarkup builds refund buttons for the numbers of the group that are still waiting
+// for a code. It doesn't depend on the language, so postponed workers can rebuild it while updating the message.
+func NewActivationGroupInlineKeyboardMarkup(
+	ctx context.Context,
+	callbackDataStore CallbackDataStore,
+	smsHistories []domain.SMSHistory,
+) (*telegram.InlineKeyboardMarkup, error) {
+	batch := NewCallbackDataBatch(callbackDataStore)
+	gridButtons := make([][]telegram.InlineKeyboardButton, 0, len(smsHistories))
+	for _, smsHistory := range smsHistories {
+		if app.SMSActivationState(smsHistory.Status) != app.PendingSMSActivateState || smsHistory.SMSCode != nil {
+			continue
+		}
+		phoneNumber := app.PhoneNumber{
+			CountryCode:      smsHistory.PhoneCodeNumber,
+			ShortPhoneNumber: smsHistory.PhoneShortNumber,
+		}
+		refundButton, err := NewTelegramInlineButtonBuilder(batch).
+			SetText(utils.ButtonTitle(phoneNumber.FullNumber(), "❌")).
+			SetCommandName(app.RefundAmountFromSMSActivationQueryCmdText).
+			SetParameters([]any{smsHistory.ID}).
+			Build()
+		if err != nil {
+			return nil, err
+		}
+		gridButtons = append(gridButtons, []telegram.InlineKeyboardButton{*refundButton})
+	}
+	return batch.Markup(ctx, gridButtons)
 }
 
 func (t *telegramInlineKeyboardManager) ServiceCountriesInlineKeyboardMarkup(
+	ctx context.Context,
 	serviceCode string,
 	preferredCurrency string,
 	pagination app.Pagination,
 	servicePrices []sms.PriceForService,
-	countries []sms.Country,
+	countries map[int64]sms.Country,
+	favorites app.Favorites,
 ) (*telegram.InlineKeyboardMarkup, error) {
+	batch := NewCallbackDataBatch(t.callbackDataStore)
 	log := t.container.GetLogger()
 	columns := 1
-	startIndex := pagination.CurrentPage * pagination.ItemsPerPage
-	endIndex := (pagination.CurrentPage + 1) * pagination.ItemsPerPage
-	if endIndex > len(servicePrices) {
-		endIndex = len(servicePrices)
-	}
-	if startIndex > len(servicePrices) {
-		return nil, app.IndexOutOfRangeError
-	}
-	servicePricesSlice := servicePrices[startIndex:endIndex]
-	buttons := make([]telegram.InlineKeyboardButton, 0, len(servicePricesSlice))
-	for _, servicePrice := range servicePricesSlice {
-		filteredCountries := utils.Filter(countries, func(country sms.Country) bool {
-			return country.ID == servicePrice.CountryCode
-		})
-		if len(filteredCountries) == 0 {
+	buttons := make([]telegram.InlineKeyboardButton, 0, len(servicePrices)+1)
+	if pagination.CurrentPage == 0 && pagination.LenItems > 0 {
+		cheapestButton, err := NewTelegramInlineButtonBuilder(batch).
+			SetText(utils.ButtonTitle(t.localizer.LocalizedString("cheapest_available_country"), "💸")).
+			SetCommandName(app.PayCheapestServiceQueryCmdText).
+			SetParameters([]any{serviceCode}).
+			Build()
+		if err != nil {
+			log.Debug("can't create cheapest available button", logger.FError(err))
+		} else {
+			buttons = append(buttons, *cheapestButton)
+		}
+	}
+	for _, servicePrice := range servicePrices {
+		country, ok := countries[servicePrice.CountryCode]
+		if !ok {
 			log.Debug("can't find country by country id", logger.F("country_code", servicePrice.CountryCode))
 			continue
 		}
-		country := filteredCountries[0]
 		priceInRUB := servicePrice.RetailPrice
-		serviceCountry := t.formatterWorker.Country(&country, worker.DefaultFormatterType)
+		serviceCountry := t.formatterWorker.Country(t.localizer.GetISOLang(), &country, worker.DefaultFormatterType)
+		if favorites.IsFavoriteCountry(country.ID) {
+			serviceCountry = utils.ButtonTitle(serviceCountry, favoriteEmoji)
+		}
 		priceInPreferredCurrency, err := t.exchangeRateWorker.ConvertFromRUB(priceInRUB, preferredCurrency)
 		if err != nil {
 			log.Debug("can't convert amount from rub", logger.F("to_currency", preferredCurrency), logger.FError(err))
@@ -245,9 +307,9 @@ func (t *telegramInlineKeyboardManager) ServiceCountriesInlineKeyboardMarkup(
 			serviceCountry,
 			utils.CurrencyAmountTextFormat(priceWithFee, *currency),
 		)
-		button, err := NewTelegramInlineButtonBuilder().
+		button, err := NewTelegramInlineButtonBuilder(batch).
 			SetText(representableText).
-			SetCommandName(app.ConfirmationPayServiceQueryCmdText).
+			SetCommandName(app.SelectOperatorQueryCmdText).
 			SetParameters([]any{serviceCode, country.ID, priceInRUB, priceWithFee}).
 			Build()
 		if err != nil {
@@ -258,6 +320,7 @@ func (t *telegramInlineKeyboardManager) ServiceCountriesInlineKeyboardMarkup(
 	}
 	gridButtons := t.getGridInlineKeyboardButton(buttons, columns)
 	pageControlButtons, err := t.PageControlKeyboardButtons(
+		batch,
 		app.SelectSMSServiceCallbackQueryCmdText,
 		pagination,
 		[]any{serviceCode, pagination.PrevPage()},
@@ -267,18 +330,53 @@ func (t *telegramInlineKeyboardManager) ServiceCountriesInlineKeyboardMarkup(
 		log.Debug("fail to create control keyboard buttons", logger.FError(err))
 	}
 	gridButtons = append(gridButtons, pageControlButtons)
-	backButton := t.BackKeyboardButton()
+	favoriteServiceButton, err := t.toggleFavoriteKeyboardButton(
+		batch,
+		app.ToggleFavoriteServiceQueryCmdText,
+		[]any{serviceCode, pagination.CurrentPage},
+		"favorite_service_add",
+		"favorite_service_remove",
+		favorites.IsFavoriteService(serviceCode),
+	)
+	if err != nil {
+		log.Debug("can't create favorite service button", logger.FError(err))
+	} else {
+		gridButtons = append(gridButtons, []telegram.InlineKeyboardButton{*favoriteServiceButton})
+	}
+	backButton := t.BackKeyboardButton(batch)
 	gridButtons = append(gridButtons, []telegram.InlineKeyboardButton{*backButton})
-	return &telegram.InlineKeyboardMarkup{
-		InlineKeyboard: gridButtons,
-	}, nil
+	return batch.Markup(ctx, gridButtons)
 }
 
-func (t *telegramInlineKeyboardManager) CryptoPayBotKeyboardMarkup(url string, invoiceID int64) (*telegram.InlineKeyboardMarkup, error) {
+func (t *telegramInlineKeyboardManager) FavoritesInlineKeyboardMarkup(ctx context.Context, services []sms.Service) (*telegram.InlineKeyboardMarkup, error) {
+	batch := NewCallbackDataBatch(t.callbackDataStore)
+	log := t.container.GetLogger()
+	columns := 2
+	buttons := make([]telegram.InlineKeyboardButton, 0, len(services))
+	for _, service := range services {
+		button, err := NewTelegramInlineButtonBuilder(batch).
+			SetCommandName(app.SelectSMSServiceCallbackQueryCmdText).
+			SetText(t.formatterWorker.Service(t.localizer.GetISOLang(), &service, worker.DefaultFormatterType)).
+			SetParameters([]any{service.Code, 0}).
+			Build()
+		if err != nil {
+			log.Debug("fail to create inline button for telegram", logger.FError(err))
+			continue
+		}
+		buttons = append(buttons, *button)
+	}
+	gridButtons := t.getGridInlineKeyboardButton(buttons, columns)
+	backButton := t.BackKeyboardButton(batch)
+	gridButtons = append(gridButtons, []telegram.InlineKeyboardButton{*backButton})
+	return batch.Markup(ctx, gridButtons)
+}
+
+func (t *telegramInlineKeyboardManager) CryptoPayBotKeyboardMarkup(ctx context.Context, url string, invoiceID int64) (*telegram.InlineKeyboardMarkup, error) {
+	batch := NewCallbackDataBatch(t.callbackDataStore)
 	log := t.container.GetLogger()
 	columns := 1
 	linkButton := t.LinkKeyboardButton(utils.ButtonTitle(t.localizer.LocalizedString("pay"), "🧾"), url)
-	cancelInvoiceButton, err := NewTelegramInlineButtonBuilder().
+	cancelInvoiceButton, err := NewTelegramInlineButtonBuilder(batch).
 		SetCommandName(app.DeleteCryptoBotInvoiceQueryCmdText).
 		SetText(utils.ButtonTitle(t.localizer.LocalizedString("cancel_invoice"), "❌")).
 		SetParameters([]any{invoiceID}).
@@ -288,12 +386,10 @@ func (t *telegramInlineKeyboardManager) CryptoPayBotKeyboardMarkup(url string, i
 		return nil, err
 	}
 	gridButtons := t.getGridInlineKeyboardButton([]telegram.InlineKeyboardButton{*linkButton, *cancelInvoiceButton}, columns)
-	return &telegram.InlineKeyboardMarkup{
-		InlineKeyboard: gridButtons,
-	}, nil
+	return batch.Markup(ctx, gridButtons)
 }
 
-func (t *telegramInlineKeyboardManager) StripeKeyboardMarkup(url string) (*telegram.InlineKeyboardMarkup, error) {
+func (t *telegramInlineKeyboardManager) StripeKeyboardMarkup(_ context.Context, url string) (*telegram.InlineKeyboardMarkup, error) {
 	columns := 1
 	linkButton := t.LinkKeyboardButton(utils.ButtonTitle(t.localizer.LocalizedString("pay"), "🧾"), url)
 	gridButtons := t.getGridInlineKeyboardButton([]telegram.InlineKeyboardButton{*linkButton}, columns)
@@ -302,12 +398,13 @@ func (t *telegramInlineKeyboardManager) StripeKeyboardMarkup(url string) (*teleg
 	}, nil
 }
 
-func (t *telegramInlineKeyboardManager) TopUpBalanceKeyboardMarkup() (*telegram.InlineKeyboardMarkup, error) {
+func (t *telegramInlineKeyboardManager) TopUpBalanceKeyboardMarkup(ctx context.Context) (*telegram.InlineKeyboardMarkup, error) {
+	batch := NewCallbackDataBatch(t.callbackDataStore)
 	log := t.container.GetLogger()
 	columns := 1
 
 	cryptoBotButtonTitle := utils.ButtonTitle(t.localizer.LocalizedString("crypto_bot"), "🪙")
-	cryptoBotPaymentMethodButton, err := NewTelegramInlineButtonBuilder().
+	cryptoBotPaymentMethodButton, err := NewTelegramInlineButtonBuilder(batch).
 		SetText(cryptoBotButtonTitle).
 		SetCommandName(app.CryptoBotListPayCurrenciesCallbackQueryCmdText).
 		Build()
@@ -317,7 +414,7 @@ func (t *telegramInlineKeyboardManager) TopUpBalanceKeyboardMarkup() (*telegram.
 	}
 
 	telegramStarsButtonTitle := utils.ButtonTitle(t.localizer.LocalizedString("telegram_stars"), "⭐")
-	telegramStarsPaymentMethodButton, err := NewTelegramInlineButtonBuilder().
+	telegramStarsPaymentMethodButton, err := NewTelegramInlineButtonBuilder(batch).
 		SetText(telegramStarsButtonTitle).
 		SetCommandName(app.SelectTelegramStarsCallbackQueryCmdText).
 		Build()
@@ -327,7 +424,7 @@ func (t *telegramInlineKeyboardManager) TopUpBalanceKeyboardMarkup() (*telegram.
 	}
 
 	stripeButtonTitle := utils.ButtonTitle(t.localizer.LocalizedString("stripe"), "💳")
-	stripePaymentMethodButton, err := NewTelegramInlineButtonBuilder().
+	stripePaymentMethodButton, err := NewTelegramInlineButtonBuilder(batch).
 		SetText(stripeButtonTitle).
 		SetCommandName(app.SelectStripeCallbackQueryCmdText).
 		Build()
@@ -336,62 +433,199 @@ func (t *telegramInlineKeyboardManager) TopUpBalanceKeyboardMarkup() (*telegram.
 		return nil, err
 	}
 
-	backButton := t.BackKeyboardButton()
+	backButton := t.BackKeyboardButton(batch)
 	gridButtons := t.getGridInlineKeyboardButton([]telegram.InlineKeyboardButton{
 		*cryptoBotPaymentMethodButton,
 		*telegramStarsPaymentMethodButton,
 		*stripePaymentMethodButton,
 		*backButton,
 	}, columns)
-	return &telegram.InlineKeyboardMarkup{
-		InlineKeyboard: gridButtons,
-	}, nil
+	return batch.Markup(ctx, gridButtons)
 }
 
-func (t *telegramInlineKeyboardManager) MainMenuKeyboardButton() *telegram.InlineKeyboardButton {
-	mainMenuInlineKeyboardButton, _ := NewTelegramInlineButtonBuilder().
+func (t *telegramInlineKeyboardManager) MainMenuKeyboardButton(batch *CallbackDataBatch) *telegram.InlineKeyboardButton {
+	mainMenuInlineKeyboardButton, _ := NewTelegramInlineButtonBuilder(batch).
 		SetText(t.localizer.LocalizedString("back_to_main_menu")).
 		SetCommandName(app.MainMenuCallbackQueryCmdText).
 		Build()
 	return mainMenuInlineKeyboardButton
 }
 
-func (t *telegramInlineKeyboardManager) BackKeyboardMarkup() *telegram.InlineKeyboardMarkup {
-	backButton := t.BackKeyboardButton()
+func (t *telegramInlineKeyboardManager) BackKeyboardMarkup(ctx context.Context) (*telegram.InlineKeyboardMarkup, error) {
+	batch := NewCallbackDataBatch(t.callbackDataStore)
+	backButton := t.BackKeyboardButton(batch)
 	gridButtons := t.getGridInlineKeyboardButton([]telegram.InlineKeyboardButton{*backButton}, 1)
-	return &telegram.InlineKeyboardMarkup{
-		InlineKeyboard: gridButtons,
-	}
+	return batch.Markup(ctx, gridButtons)
 }
 
-func (t *telegramInlineKeyboardManager) BackKeyboardButton() *telegram.InlineKeyboardButton {
-	backInlineKeyboardButton, _ := NewTelegramInlineButtonBuilder().
+func (t *telegramInlineKeyboardManager) BackKeyboardButton(batch *CallbackDataBatch) *telegram.InlineKeyboardButton {
+	backInlineKeyboardButton, _ := NewTelegramInlineButtonBuilder(batch).
 		SetText(utils.ButtonTitle(t.localizer.LocalizedString("back"), "⬅️")).
 		SetCommandName(app.BackQueryCmdText).
 		Build()
 	return backInlineKeyboardButton
 }
 
-func (t *telegramInlineKeyboardManager) ConfirmationPayInlineKeyboardMarkup(serviceCode string, countryID int64, maxPrice float64) (*telegram.InlineKeyboardMarkup, error) {
+// CheapestPriceCeilingsInlineKeyboardMarkup offers the lowest distinct prices of the service, the cheapest number is
+// bought only from countries within the chosen one. Prices are expected in ascending order.
+func (t *telegramInlineKeyboardManager) CheapestPriceCeilingsInlineKeyboardMarkup(
+	ctx context.Context,
+	serviceCode string,
+	preferredCurrency string,
+	servicePrices []sms.PriceForService,
+) (*telegram.InlineKeyboardMarkup, error) {
+	batch := NewCallbackDataBatch(t.callbackDataStore)
+	log := t.container.GetLogger()
+	columns := 2
+	currency := t.container.GetConfig().CurrencyByAbbr(preferredCurrency)
+	if currency == nil {
+		return nil, app.UnknownCurrencyError
+	}
+	buttons := make([]telegram.InlineKeyboardButton, 0, maxCheapestPriceCeilings)
+	for i, servicePrice := range servicePrices {
+		if len(buttons) == maxCheapestPriceCeilings {
+			break
+		}
+		if i > 0 && servicePrices[i-1].RetailPrice == servicePrice.RetailPrice {
+			continue
+		}
+		priceInPreferredCurrency, err := t.exchangeRateWorker.ConvertFromRUB(servicePrice.RetailPrice, preferredCurrency)
+		if err != nil {
+			log.Debug("can't convert amount from rub", logger.F("to_currency", preferredCurrency), logger.FError(err))
+			continue
+		}
+		priceWithFee := t.exchangeRateWorker.PriceWithFee(*priceInPreferredCurrency)
+		button, err := NewTelegramInlineButtonBuilder(batch).
+			SetText(t.localizer.LocalizedStringWithTemplateData("cheapest_price_ceiling", map[string]any{
+				"Price": utils.CurrencyAmountTextFormat(priceWithFee, *currency),
+			})).
+			SetCommandName(app.PayCheapestServiceQueryCmdText).
+			SetParameters([]any{serviceCode, servicePrice.RetailPrice}).
+			Build()
+		if err != nil {
+			log.Debug("can't create button with price ceiling", logger.FError(err))
+			continue
+		}
+		buttons = append(buttons, *button)
+	}
+	gridButtons := t.getGridInlineKeyboardButton(buttons, columns)
+	backButton := t.BackKeyboardButton(batch)
+	gridButtons = append(gridButtons, []telegram.InlineKeyboardButton{*backButton})
+	return batch.Markup(ctx, gridButtons)
+}
+
+func (t *telegramInlineKeyboardManager) ServiceOperatorsInlineKeyboardMarkup(
+	ctx context.Context,
+	serviceCode string,
+	countryID int64,
+	priceInRUB float64,
+	priceWithFee float64,
+	preferredCurrency string,
+	operatorPrices []sms.OperatorPrice,
+) (*telegram.InlineKeyboardMarkup, error) {
+	batch := NewCallbackDataBatch(t.callbackDataStore)
+	log := t.container.GetLogger()
+	columns := 1
+	currency := t.container.GetConfig().CurrencyByAbbr(preferredCurrency)
+	if currency == nil {
+		return nil, app.UnknownCurrencyError
+	}
+	anyOperatorButton, err := NewTelegramInlineButtonBuilder(batch).
+		SetText(fmt.Sprintf("%s | %s",
+			t.localizer.LocalizedString("any_operator"),
+			utils.CurrencyAmountTextFormat(priceWithFee, *currency),
+		)).
+		SetCommandName(app.ConfirmationPayServiceQueryCmdText).
+		SetParameters([]any{serviceCode, countryID, priceInRUB, priceWithFee}).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+	buttons := make([]telegram.InlineKeyboardButton, 0, len(operatorPrices)+1)
+	buttons = append(buttons, *anyOperatorButton)
+	for _, operatorPrice := range operatorPrices {
+		priceInPreferredCurrency, err := t.exchangeRateWorker.ConvertFromRUB(operatorPrice.Cost, preferredCurrency)
+		if err != nil {
+			log.Debug("can't convert amount from rub", logger.F("to_currency", preferredCurrency), logger.FError(err))
+			continue
+		}
+		operatorPriceWithFee := t.exchangeRateWorker.PriceWithFee(*priceInPreferredCurrency)
+		// the price is resolved again on the confirmation screen to keep the callback data within the limit
+		button, err := NewTelegramInlineButtonBuilder(batch).
+			SetText(fmt.Sprintf("%s | %s",
+				utils.ButtonTitle(operatorPrice.Operator, "📶"),
+				utils.CurrencyAmountTextFormat(operatorPriceWithFee, *currency),
+			)).
+			SetCommandName(app.ConfirmationPayOperatorQueryCmdText).
+			SetParameters([]any{serviceCode, countryID, operatorPrice.Operator}).
+			Build()
+		if err != nil {
+			log.Debug("can't create button with operator price", logger.FError(err))
+			continue
+		}
+		buttons = append(buttons, *button)
+	}
+	gridButtons := t.getGridInlineKeyboardButton(buttons, columns)
+	backButton := t.BackKeyboardButton(batch)
+	gridButtons = append(gridButtons, []telegram.InlineKeyboardButton{*backButton})
+	return batch.Markup(ctx, gridButtons)
+}
+
+func (t *telegramInlineKeyboardManager) ConfirmationPayInlineKeyboardMarkup(ctx context.Context, serviceCode string, countryID int64, maxPrice float64, operator string, isFavoriteCountry bool) (*telegram.InlineKeyboardMarkup, error) {
+	batch := NewCallbackDataBatch(t.callbackDataStore)
 	columns := 1
-	confirmPayButton, err := NewTelegramInlineButtonBuilder().
+	confirmPayParameters := []any{serviceCode, countryID, maxPrice}
+	if operator != "" {
+		confirmPayParameters = append(confirmPayParameters, 1, operator)
+	}
+	confirmPayButton, err := NewTelegramInlineButtonBuilder(batch).
 		SetText(utils.ButtonTitle(t.localizer.LocalizedString("confirm"), "✅")).
 		SetCommandName(app.PayServiceCallbackQueryCmdText).
-		SetParameters([]any{serviceCode, countryID, maxPrice}).
+		SetParameters(confirmPayParameters).
 		Build()
 	if err != nil {
 		return nil, err
 	}
-	t.BackKeyboardButton()
-	backButton := t.BackKeyboardButton()
-	gridButtons := t.getGridInlineKeyboardButton([]telegram.InlineKeyboardButton{*confirmPayButton, *backButton}, columns)
-	return &telegram.InlineKeyboardMarkup{
-		InlineKeyboard: gridButtons,
-	}, nil
+	bulkPayButtons := make([]telegram.InlineKeyboardButton, 0, len(app.BulkPurchaseQuantities))
+	for _, quantity := range app.BulkPurchaseQuantities {
+		bulkPayParameters := []any{serviceCode, countryID, maxPrice, quantity}
+		if operator != "" {
+			bulkPayParameters = append(bulkPayParameters, operator)
+		}
+		bulkPayButton, err := NewTelegramInlineButtonBuilder(batch).
+			SetText(t.localizer.LocalizedStringWithTemplateData("confirm_bulk_purchase", map[string]any{
+				"Quantity": quantity,
+			})).
+			SetCommandName(app.PayServiceCallbackQueryCmdText).
+			SetParameters(bulkPayParameters).
+			Build()
+		if err != nil {
+			return nil, err
+		}
+		bulkPayButtons = append(bulkPayButtons, *bulkPayButton)
+	}
+	favoriteCountryButton, err := t.toggleFavoriteKeyboardButton(
+		batch,
+		app.ToggleFavoriteCountryQueryCmdText,
+		[]any{serviceCode, countryID},
+		"favorite_country_add",
+		"favorite_country_remove",
+		isFavoriteCountry,
+	)
+	if err != nil {
+		return nil, err
+	}
+	backButton := t.BackKeyboardButton(batch)
+	gridButtons := t.getGridInlineKeyboardButton([]telegram.InlineKeyboardButton{*confirmPayButton}, columns)
+	gridButtons = append(gridButtons, bulkPayButtons)
+	gridButtons = append(gridButtons, []telegram.InlineKeyboardButton{*favoriteCountryButton})
+	gridButtons = append(gridButtons, []telegram.InlineKeyboardButton{*backButton})
+	return batch.Markup(ctx, gridButtons)
 }
 
-func (t *telegramInlineKeyboardManager) EnteringAmountInlineKeyboardMarkup() (*telegram.InlineKeyboardMarkup, error) {
-	cancelEnterAmountButton, err := NewTelegramInlineButtonBuilder().
+func (t *telegramInlineKeyboardManager) EnteringAmountInlineKeyboardMarkup(ctx context.Context) (*telegram.InlineKeyboardMarkup, error) {
+	batch := NewCallbackDataBatch(t.callbackDataStore)
+	cancelEnterAmountButton, err := NewTelegramInlineButtonBuilder(batch).
 		SetText(utils.ButtonTitle(t.localizer.LocalizedString("cancel"), "❌")).
 		SetCommandName(app.CancelEnterAmountCallbackQueryCmdText).
 		Build()
@@ -401,27 +635,85 @@ func (t *telegramInlineKeyboardManager) EnteringAmountInlineKeyboardMarkup() (*t
 	inlineKeyboardButtons := []telegram.InlineKeyboardButton{
 		*cancelEnterAmountButton,
 	}
-	return &telegram.InlineKeyboardMarkup{
-		InlineKeyboard: t.getGridInlineKeyboardButton(inlineKeyboardButtons, 1),
-	}, nil
+	return batch.Markup(ctx, t.getGridInlineKeyboardButton(inlineKeyboardButtons, 1))
+}
+
+func (t *telegramInlineKeyboardManager) HelpKeyboardMarkup(ctx context.Context) (*telegram.InlineKeyboardMarkup, error) {
+	batch := NewCallbackDataBatch(t.callbackDataStore)
+	supportButton, err := NewTelegramInlineButtonBuilder(batch).
+		SetText(utils.ButtonTitle(t.localizer.LocalizedString("contact_support"), "💬")).
+		SetCommandName(app.SupportCallbackQueryCmdText).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+	gridButtons := t.getGridInlineKeyboardButton([]telegram.InlineKeyboardButton{*supportButton, *t.BackKeyboardButton(batch)}, 1)
+	return batch.Markup(ctx, gridButtons)
 }
 
-func (t *telegramInlineKeyboardManager) IsSubscriptionMemberInlineKeyboardMarkup() (*telegram.InlineKeyboardMarkup, error) {
-	isSubscriptionMemberButton, err := NewTelegramInlineButtonBuilder().
+func (t *telegramInlineKeyboardManager) SupportKeyboardMarkup(ctx context.Context, hasActiveTicket bool) (*telegram.InlineKeyboardMarkup, error) {
+	batch := NewCallbackDataBatch(t.callbackDataStore)
+	inlineKeyboardButtons := make([]telegram.InlineKeyboardButton, 0, 2)
+	if hasActiveTicket {
+		closeSupportTicketButton, err := NewCloseSupportTicketKeyboardButton(batch, t.localizer)
+		if err != nil {
+			return nil, err
+		}
+		inlineKeyboardButtons = append(inlineKeyboardButtons, *closeSupportTicketButton)
+	}
+	inlineKeyboardButtons = append(inlineKeyboardButtons, *t.BackKeyboardButton(batch))
+	return batch.Markup(ctx, t.getGridInlineKeyboardButton(inlineKeyboardButtons, 1))
+}
+
+// NewCloseSupportTicketKeyboardButton is attached to replies of support agents too, they are sent in the language
+// of the user, not of the agent.
+func NewCloseSupportTicketKeyboardButton(
+	batch *CallbackDataBatch,
+	localizer localizer.Localizer,
+) (*telegram.InlineKeyboardButton, error) {
+	return NewTelegramInlineButtonBuilder(batch).
+		SetText(utils.ButtonTitle(localizer.LocalizedString("close_support_ticket"), "✅")).
+		SetCommandName(app.CloseSupportTicketQueryCmdText).
+		Build()
+}
+
+// IsSubscriptionMemberInlineKeyboardMarkup opens the channels that have a public link, the last button checks
+// the subscription again.
+func (t *telegramInlineKeyboardManager) IsSubscriptionMemberInlineKeyboardMarkup(
+	ctx context.Context,
+	channels []config.RequiredChannel,
+) (*telegram.InlineKeyboardMarkup, error) {
+	batch := NewCallbackDataBatch(t.callbackDataStore)
+	buttons := make([]telegram.InlineKeyboardButton, 0, len(channels)+1)
+	for _, channel := range channels {
+		link := channel.Link()
+		if link == nil {
+			continue
+		}
+		channelButton, err := NewTelegramInlineButtonBuilder(nil).
+			SetText(channel.Chat).
+			SetLink(*link).
+			Build()
+		if err != nil {
+			return nil, err
+		}
+		buttons = append(buttons, *channelButton)
+	}
+	isSubscriptionMemberButton, err := NewTelegramInlineButtonBuilder(batch).
 		SetText(utils.ButtonTitle(t.localizer.LocalizedString("verify_subscription"), "✔️")).
 		SetCommandName(app.MainMenuCallbackQueryCmdText).
 		Build()
 	if err != nil {
 		return nil, err
 	}
-	gridButtons := t.getGridInlineKeyboardButton([]telegram.InlineKeyboardButton{*isSubscriptionMemberButton}, 1)
-	return &telegram.InlineKeyboardMarkup{
-		InlineKeyboard: gridButtons,
-	}, nil
+	buttons = append(buttons, *isSubscriptionMemberButton)
+	gridButtons := t.getGridInlineKeyboardButton(buttons, 1)
+	return batch.Markup(ctx, gridButtons)
 }
 
-func (t *telegramInlineKeyboardManager) TelegramStarsPayInlineKeyboardMarkup(stars int64) (*telegram.InlineKeyboardMarkup, error) {
-	payButton, err := NewTelegramInlineButtonBuilder().
+func (t *telegramInlineKeyboardManager) TelegramStarsPayInlineKeyboardMarkup(ctx context.Context, stars int64) (*telegram.InlineKeyboardMarkup, error) {
+	batch := NewCallbackDataBatch(t.callbackDataStore)
+	payButton, err := NewTelegramInlineButtonBuilder(batch).
 		SetText(t.localizer.LocalizedStringWithTemplateData(
 			"pay_telegram_stars",
 			map[string]any{
@@ -433,7 +725,7 @@ func (t *telegramInlineKeyboardManager) TelegramStarsPayInlineKeyboardMarkup(sta
 	if err != nil {
 		return nil, err
 	}
-	cancelInvoiceButton, err := NewTelegramInlineButtonBuilder().
+	cancelInvoiceButton, err := NewTelegramInlineButtonBuilder(batch).
 		SetText(utils.ButtonTitle(t.localizer.LocalizedString("cancel_invoice"), "❌")).
 		SetCommandName(app.CancelPayTelegramStarsCmdText).
 		Build()
@@ -444,13 +736,11 @@ func (t *telegramInlineKeyboardManager) TelegramStarsPayInlineKeyboardMarkup(sta
 		*payButton,
 		*cancelInvoiceButton,
 	}, 1)
-	return &telegram.InlineKeyboardMarkup{
-		InlineKeyboard: gridButtons,
-	}, nil
+	return batch.Markup(ctx, gridButtons)
 }
 
-func (t *telegramInlineKeyboardManager) PageControlKeyboardButtons(commandName string, pagination app.Pagination, leftButtonParameters []any, rightButtonParameters []any) ([]telegram.InlineKeyboardButton, error) {
-	prevButton, err := NewTelegramInlineButtonBuilder().
+func (t *telegramInlineKeyboardManager) PageControlKeyboardButtons(batch *CallbackDataBatch, commandName string, pagination app.Pagination, leftButtonParameters []any, rightButtonParameters []any) ([]telegram.InlineKeyboardButton, error) {
+	prevButton, err := NewTelegramInlineButtonBuilder(batch).
 		SetText(pagination.PreviousTitle()).
 		SetCommandName(commandName).
 		SetParameters(leftButtonParameters).
@@ -458,14 +748,14 @@ func (t *telegramInlineKeyboardManager) PageControlKeyboardButtons(commandName s
 	if err != nil {
 		return nil, err
 	}
-	currentPageButton, err := NewTelegramInlineButtonBuilder().
+	currentPageButton, err := NewTelegramInlineButtonBuilder(batch).
 		SetText(pagination.MidTitle()).
 		SetCommandName(app.EmptyCallbackQueryCmdText).
 		Build()
 	if err != nil {
 		return nil, err
 	}
-	nextButton, err := NewTelegramInlineButtonBuilder().
+	nextButton, err := NewTelegramInlineButtonBuilder(batch).
 		SetText(pagination.NextTitle()).
 		SetCommandName(commandName).
 		SetParameters(rightButtonParameters).
@@ -482,13 +772,32 @@ func (t *telegramInlineKeyboardManager) PageControlKeyboardButtons(commandName s
 }
 
 func (t *telegramInlineKeyboardManager) LinkKeyboardButton(text, link string) *telegram.InlineKeyboardButton {
-	linkInlineKeyboardButton, _ := NewTelegramInlineButtonBuilder().
+	linkInlineKeyboardButton, _ := NewTelegramInlineButtonBuilder(nil).
 		SetLink(link).
 		SetText(text).
 		Build()
 	return linkInlineKeyboardButton
 }
 
+func (t *telegramInlineKeyboardManager) toggleFavoriteKeyboardButton(
+	batch *CallbackDataBatch,
+	commandName string,
+	parameters []any,
+	addKey string,
+	removeKey string,
+	isFavorite bool,
+) (*telegram.InlineKeyboardButton, error) {
+	text := utils.ButtonTitle(t.localizer.LocalizedString(addKey), favoriteEmoji)
+	if isFavorite {
+		text = utils.ButtonTitle(t.localizer.LocalizedString(removeKey), unfavoriteEmoji)
+	}
+	return NewTelegramInlineButtonBuilder(batch).
+		SetText(text).
+		SetCommandName(commandName).
+		SetParameters(parameters).
+		Build()
+}
+
 func (t *telegramInlineKeyboardManager) getGridInlineKeyboardButton(keyboardButtons []telegram.InlineKeyboardButton, columns int) [][]telegram.InlineKeyboardButton {
 	rows := len(keyboardButtons) / columns
 	if len(keyboardButtons)%columns > 0 {
@@ -507,11 +816,12 @@ func (t *telegramInlineKeyboardManager) getGridInlineKeyboardButton(keyboardButt
 	return gridInlineKeyboardButtons
 }
 
-func (t *telegramInlineKeyboardManager) prepareLanguageKeyboardMarkup(commandName string, shouldContainsBack bool) (*telegram.InlineKeyboardMarkup, error) {
+func (t *telegramInlineKeyboardManager) prepareLanguageKeyboardMarkup(ctx context.Context, commandName string, shouldContainsBack bool) (*telegram.InlineKeyboardMarkup, error) {
+	batch := NewCallbackDataBatch(t.callbackDataStore)
 	languages := t.container.GetConfig().AvailableLanguages()
 	buttons := make([]telegram.InlineKeyboardButton, 0, len(languages))
 	for _, language := range languages {
-		button, err := NewTelegramInlineButtonBuilder().
+		button, err := NewTelegramInlineButtonBuilder(batch).
 			SetCommandName(commandName).
 			SetParameters([]any{language.Code}).
 			SetText(utils.LanguageTextFormat(language)).
@@ -522,19 +832,18 @@ func (t *telegramInlineKeyboardManager) prepareLanguageKeyboardMarkup(commandNam
 		buttons = append(buttons, *button)
 	}
 	if shouldContainsBack {
-		backButton := t.BackKeyboardButton()
+		backButton := t.BackKeyboardButton(batch)
 		buttons = append(buttons, *backButton)
 	}
-	return &telegram.InlineKeyboardMarkup{
-		InlineKeyboard: t.getGridInlineKeyboardButton(buttons, 2),
-	}, nil
+	return batch.Markup(ctx, t.getGridInlineKeyboardButton(buttons, 2))
 }
 
-func (t *telegramInlineKeyboardManager) preparePreferredCurrenciesKeyboardMarkup(commandName string, shouldContainsBack bool) (*telegram.InlineKeyboardMarkup, error) {
+func (t *telegramInlineKeyboardManager) preparePreferredCurrenciesKeyboardMarkup(ctx context.Context, commandName string, shouldContainsBack bool) (*telegram.InlineKeyboardMarkup, error) {
+	batch := NewCallbackDataBatch(t.callbackDataStore)
 	preferredCurrencies := t.container.GetConfig().AvailablePreferredCurrencies()
 	buttons := make([]telegram.InlineKeyboardButton, 0, len(preferredCurrencies))
 	for _, currency := range preferredCurrencies {
-		button, err := NewTelegramInlineButtonBuilder().
+		button, err := NewTelegramInlineButtonBuilder(batch).
 			SetCommandName(commandName).
 			SetParameters([]any{currency.ABBR}).
 			SetText(utils.ShortCurrencyTextFormat(currency)).
@@ -545,10 +854,8 @@ func (t *telegramInlineKeyboardManager) preparePreferredCurrenciesKeyboardMarkup
 		buttons = append(buttons, *button)
 	}
 	if shouldContainsBack {
-		backButton := t.BackKeyboardButton()
+		backButton := t.BackKeyboardButton(batch)
 		buttons = append(buttons, *backButton)
 	}
-	return &telegram.InlineKeyboardMarkup{
-		InlineKeyboard: t.getGridInlineKeyboardButton(buttons, 2),
-	}, nil
+	return batch.Markup(ctx, t.getGridInlineKeyboardButton(buttons, 2))
 }
@@ -1,11 +1,67 @@
 package manager
 
 import (
+	"context"
 	"go-ton-pass-telegram-bot/internal/model/app"
 	"go-ton-pass-telegram-bot/internal/model/telegram"
 	"go-ton-pass-telegram-bot/internal/utils"
 )
 
+// CallbackDataStore keeps payloads of buttons on the server, telegram servers see only short tokens instead
+// of them, so payloads aren't limited by 64 bytes of callback_data and can't be forged by users.
+type CallbackDataStore interface {
+	// CallbackDataToken returns the token of the payload without saving it.
+	CallbackDataToken(callbackData app.TelegramCallbackData) (string, error)
+	SaveCallbackData(ctx context.Context, callbackData []app.TelegramCallbackData) error
+}
+
+// CallbackDataBatch collects payloads of the buttons of a keyboard, they are saved at once when the keyboard
+// is built.
+type CallbackDataBatch struct {
+	store        CallbackDataStore
+	callbackData []app.TelegramCallbackData
+}
+
+func NewCallbackDataBatch(store CallbackDataStore) *CallbackDataBatch {
+	return &CallbackDataBatch{
+		store: store,
+	}
+}
+
+func (c *CallbackDataBatch) add(callbackData app.TelegramCallbackData) (string, error) {
+	token, err := c.store.CallbackDataToken(callbackData)
+	if err != nil {
+		return "", err
+	}
+	c.callbackData = append(c.callbackData, callbackData)
+	return token, nil
+}
+
+// Save saves the payloads collected since the last call.
+func (c *CallbackDataBatch) Save(ctx context.Context) error {
+	if len(c.callbackData) == 0 {
+		return nil
+	}
+	if err := c.store.SaveCallbackData(ctx, c.callbackData); err != nil {
+		return err
+	}
+	c.callbackData = nil
+	return nil
+}
+
+// Markup saves the payloads of the buttons and returns the keyboard with them.
+func (c *CallbackDataBatch) Markup(
+	ctx context.Context,
+	inlineKeyboard [][]telegram.InlineKeyboardButton,
+) (*telegram.InlineKeyboardMarkup, error) {
+	if err := c.Save(ctx); err != nil {
+		return nil, err
+	}
+	return &telegram.InlineKeyboardMarkup{
+		InlineKeyboard: inlineKeyboard,
+	}, nil
+}
+
 type TelegramInlineButtonBuilder interface {
 	SetText(text string) TelegramInlineButtonBuilder
 	SetCommandName(commandName string) TelegramInlineButtonBuilder
@@ -16,6 +72,7 @@ type TelegramInlineButtonBuilder interface {
 }
 
 type telegramInlineButtonBuilder struct {
+	batch       *CallbackDataBatch
 	text        *string
 	url         *string
 	commandName *string
@@ -23,8 +80,11 @@ type telegramInlineButtonBuilder struct {
 	parameters  *[]any
 }
 
-func NewTelegramInlineButtonBuilder() TelegramInlineButtonBuilder {
+// NewTelegramInlineButtonBuilder adds the payload of the button to the batch, the batch may be nil for buttons
+// that are links only.
+func NewTelegramInlineButtonBuilder(batch *CallbackDataBatch) TelegramInlineButtonBuilder {
 	return &telegramInlineButtonBuilder{
+		batch:       batch,
 		text:        nil,
 		commandName: nil,
 		parameters:  nil,
@@ -68,15 +128,18 @@ func (t *telegramInlineButtonBuilder) Build() (*telegram.InlineKeyboardButton, e
 		url  = t.url
 	)
 	if t.commandName != nil {
+		if t.batch == nil {
+			return nil, app.RequiredFieldError
+		}
 		callbackData := app.TelegramCallbackData{
 			Name:       *t.commandName,
 			Parameters: t.parameters,
 		}
-		encodedCallbackData, err := utils.EncodeTelegramCallbackData(callbackData)
+		token, err := t.batch.add(callbackData)
 		if err != nil {
 			return nil, err
 		}
-		data = encodedCallbackData
+		data = &token
 	}
 	return &telegram.InlineKeyboardButton{
 		Text: text,
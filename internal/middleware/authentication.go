@@ -34,6 +34,14 @@ func (a *Authentication) Handler(next http.Handler) http.Handler {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
+		supportChatID := a.container.GetConfig().SupportChatID()
+		isSupportChatUpdate := isSupportChatUpdate(update, supportChatID)
+		if !isPrivateChatUpdate(update) && !isSupportChatUpdate {
+			// the bot talks to users in the private chat only, groups other than the support chat are skipped
+			log.Debug("skip update of group", logger.F("chat_id", update.GetChatID()))
+			w.WriteHeader(http.StatusOK)
+			return
+		}
 		profileExist, err := a.profileRepository.ExistsWithTelegramID(ctx, telegramUser.ID)
 		if err != nil {
 			log.Error(
@@ -53,6 +61,21 @@ func (a *Authentication) Handler(next http.Handler) http.Handler {
 			)
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
+		} else if !profileExist && update.MyChatMember != nil {
+			// nothing to track for users the bot has never talked to, e.g. members adding the bot to a group
+			log.Debug("skip chat member update of unknown profile", logger.F("telegram_id", telegramUser.ID))
+			w.WriteHeader(http.StatusOK)
+			return
+		} else if !profileExist && isSupportChatUpdate {
+			// a profile keeps the private chat for broadcasts and answers, so it is recorded once the user writes
+			// to the bot, agents of the support chat are served without one
+			profile := &domain.Profile{
+				TelegramID: telegramUser.ID,
+				Username:   telegramUser.Username,
+			}
+			newCtx := context.WithValue(r.Context(), app.ProfileContextKey, profile)
+			next.ServeHTTP(w, r.WithContext(newCtx))
+			return
 		} else if !profileExist {
 			log.Debug(
 				"record the profile to db",
@@ -87,6 +110,23 @@ func (a *Authentication) Handler(next http.Handler) http.Handler {
 	})
 }
 
+// isSupportChatUpdate reports whether the update is a message of the configured support chat.
+func isSupportChatUpdate(update *telegram.Update, supportChatID int64) bool {
+	return supportChatID != 0 && update.Message != nil && update.Message.Chat != nil && update.Message.Chat.ID == supportChatID
+}
+
+// isPrivateChatUpdate reports whether the update comes from the private chat with the user. Inline queries and
+// payments carry no chat, their answers go to the private chat.
+func isPrivateChatUpdate(update *telegram.Update) bool {
+	var chat *telegram.Chat
+	if update.Message != nil {
+		chat = update.Message.Chat
+	} else if update.CallbackQuery != nil && update.CallbackQuery.Message != nil {
+		chat = update.CallbackQuery.Message.Chat
+	}
+	return chat == nil || chat.Type == "" || chat.Type == telegram.PrivateChatType
+}
+
 func getTelegramUser(update *telegram.Update) (*telegram.User, error) {
 	if update.Message != nil {
 		return update.Message.From, nil
@@ -94,6 +134,10 @@ func getTelegramUser(update *telegram.Update) (*telegram.User, error) {
 		return &update.CallbackQuery.From, nil
 	} else if update.PreCheckoutQuery != nil {
 		return &update.PreCheckoutQuery.From, nil
+	} else if update.InlineQuery != nil {
+		return &update.InlineQuery.From, nil
+	} else if update.MyChatMember != nil {
+		return &update.MyChatMember.From, nil
 	}
 	return nil, app.NilError
 }
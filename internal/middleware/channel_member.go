@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"go-ton-pass-telegram-bot/internal/container"
+	"go-ton-pass-telegram-bot/internal/model/app"
+	"go-ton-pass-telegram-bot/internal/model/telegram"
+	"go-ton-pass-telegram-bot/internal/service"
+	"go-ton-pass-telegram-bot/pkg/logger"
+	"net/http"
+)
+
+// ChannelMember consumes chat_member updates of the required channels, they refresh the cached memberships and
+// never reach the controller: the member may have never talked to the bot.
+type ChannelMember struct {
+	container           container.Container
+	channelSubscription service.ChannelSubscription
+}
+
+func NewChannelMember(
+	container container.Container,
+	channelSubscription service.ChannelSubscription,
+) *ChannelMember {
+	return &ChannelMember{
+		container:           container,
+		channelSubscription: channelSubscription,
+	}
+}
+
+func (c *ChannelMember) Handler(next http.Handler) http.Handler {
+	log := c.container.GetLogger()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		update := r.Context().Value(app.UpdateContextKey).(*telegram.Update)
+		if update.ChatMember == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if err := c.channelSubscription.Refresh(r.Context(), update.ChatMember); err != nil {
+			log.Error("fail to refresh channel membership", logger.FError(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
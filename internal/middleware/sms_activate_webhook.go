@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"github.com/gorilla/mux"
+	"go-ton-pass-telegram-bot/internal/config"
+	"go-ton-pass-telegram-bot/internal/container"
+	"go-ton-pass-telegram-bot/pkg/logger"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type SMSActivateWebhook struct {
+	container container.Container
+}
+
+func NewSMSActivateWebhook(container container.Container) *SMSActivateWebhook {
+	return &SMSActivateWebhook{
+		container: container,
+	}
+}
+
+// Handler rejects requests that don't carry the secret path token or come from outside the allowlist,
+// an empty allowlist rejects every request.
+func (s *SMSActivateWebhook) Handler(next http.Handler) http.Handler {
+	log := s.container.GetLogger()
+	webhookConfig := s.container.GetConfig().SMSActivateWebhook()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := mux.Vars(r)["token"]
+		if webhookConfig.Token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(webhookConfig.Token)) != 1 {
+			log.Error("sms activate webhook has invalid token", logger.F("remote_addr", r.RemoteAddr))
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		remoteIP := clientIP(r, webhookConfig)
+		if remoteIP == nil || !webhookConfig.IsAllowedIP(remoteIP) {
+			log.Error(
+				"sms activate webhook from not allowed ip",
+				logger.F("remote_addr", r.RemoteAddr),
+				logger.F("forwarded_for", r.Header.Values("X-Forwarded-For")),
+			)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientIP is the address of the peer, requests passed by a trusted proxy are attributed to the nearest
+// address of X-Forwarded-For (or X-Real-IP) that isn't a trusted proxy itself.
+func clientIP(r *http.Request, webhookConfig config.SMSActivateWebhook) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	remoteIP := net.ParseIP(host)
+	if remoteIP == nil || !webhookConfig.IsTrustedProxy(remoteIP) {
+		return remoteIP
+	}
+	forwardedFor := strings.Join(r.Header.Values("X-Forwarded-For"), ",")
+	if strings.TrimSpace(forwardedFor) == "" {
+		return net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP")))
+	}
+	forwardedIPs := strings.Split(forwardedFor, ",")
+	for i := len(forwardedIPs) - 1; i >= 0; i-- {
+		forwardedIP := net.ParseIP(strings.TrimSpace(forwardedIPs[i]))
+		if forwardedIP == nil || !webhookConfig.IsTrustedProxy(forwardedIP) {
+			return forwardedIP
+		}
+	}
+	return remoteIP
+}
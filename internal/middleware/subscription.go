@@ -2,42 +2,63 @@ package middleware
 
 import (
 	"context"
+	"go-ton-pass-telegram-bot/internal/config"
 	"go-ton-pass-telegram-bot/internal/container"
 	"go-ton-pass-telegram-bot/internal/model/app"
 	"go-ton-pass-telegram-bot/internal/model/domain"
+	"go-ton-pass-telegram-bot/internal/model/telegram"
 	"go-ton-pass-telegram-bot/internal/service"
 	"go-ton-pass-telegram-bot/pkg/logger"
 	"net/http"
 )
 
 type Subscription struct {
-	container          container.Container
-	telegramBotService service.TelegramBotService
+	container           container.Container
+	channelSubscription service.ChannelSubscription
 }
 
 func NewSubscription(
 	container container.Container,
-	telegramBotService service.TelegramBotService,
+	channelSubscription service.ChannelSubscription,
 ) *Subscription {
 	return &Subscription{
-		container:          container,
-		telegramBotService: telegramBotService,
+		container:           container,
+		channelSubscription: channelSubscription,
 	}
 }
 
+// Handler puts the required channels the user isn't subscribed to in the context, the controller asks the user
+// to subscribe to them.
 func (s *Subscription) Handler(next http.Handler) http.Handler {
 	log := s.container.GetLogger()
-	channelLink := "@tonpassnews"
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		profile := r.Context().Value(app.ProfileContextKey).(*domain.Profile)
-		isChatMember, err := s.telegramBotService.UserIsChatMember(channelLink, profile.TelegramID)
-		if err != nil {
-			log.Error("fail to check is user member of chat", logger.FError(err))
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
+		update := r.Context().Value(app.UpdateContextKey).(*telegram.Update)
+		missingChannels := make([]config.RequiredChannel, 0)
+		if !isExemptFromSubscription(update, s.container.GetConfig().SupportChatID()) {
+			profile := r.Context().Value(app.ProfileContextKey).(*domain.Profile)
+			var err error
+			missingChannels, err = s.channelSubscription.MissingChannels(r.Context(), profile.TelegramID)
+			if err != nil {
+				log.Error("fail to check is user member of required channels", logger.FError(err))
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
 		}
-		isChatMember = true
-		newCtx := context.WithValue(r.Context(), app.IsProfileSubscription, isChatMember)
+		newCtx := context.WithValue(r.Context(), app.MissingRequiredChannelsContextKey, missingChannels)
 		next.ServeHTTP(w, r.WithContext(newCtx))
 	})
 }
+
+// isExemptFromSubscription passes updates that must be answered regardless of the subscription: payments,
+// blocks of the bot, messages of the support chat and inline queries, which would otherwise send
+// the subscription message on every typed letter.
+func isExemptFromSubscription(update *telegram.Update, supportChatID int64) bool {
+	if update.PreCheckoutQuery != nil || update.MyChatMember != nil || update.InlineQuery != nil {
+		return true
+	}
+	message := update.Message
+	if message == nil {
+		return false
+	}
+	return message.SuccessfulPayment != nil || message.RefundedPayment != nil || isSupportChatUpdate(update, supportChatID)
+}
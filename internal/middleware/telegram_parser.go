@@ -40,5 +40,6 @@ func (t *TelegramParser) Handler(next http.Handler) http.Handler {
 }
 
 func isEmpty(update *telegram.Update) bool {
-	return update.Message == nil && update.CallbackQuery == nil && update.PreCheckoutQuery == nil
+	return update.Message == nil && update.CallbackQuery == nil && update.PreCheckoutQuery == nil && update.InlineQuery == nil &&
+		update.MyChatMember == nil && update.ChatMember == nil
 }
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"go-ton-pass-telegram-bot/internal/container"
+	"go-ton-pass-telegram-bot/internal/model/app"
+	"go-ton-pass-telegram-bot/pkg/logger"
+	"net/http"
+)
+
+const telegramSecretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+type TelegramWebhook struct {
+	container container.Container
+}
+
+func NewTelegramWebhook(container container.Container) *TelegramWebhook {
+	return &TelegramWebhook{
+		container: container,
+	}
+}
+
+// Handler rejects updates that don't carry the webhook secret token, so nobody but telegram servers can post
+// updates on behalf of users. Updates polled by the bot itself are let through.
+func (t *TelegramWebhook) Handler(next http.Handler) http.Handler {
+	log := t.container.GetLogger()
+	secretToken := t.container.GetConfig().Telegram().WebhookSecretToken
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isPolled, _ := r.Context().Value(app.PolledUpdateContextKey).(bool); isPolled {
+			next.ServeHTTP(w, r)
+			return
+		}
+		token := r.Header.Get(telegramSecretTokenHeader)
+		if secretToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(secretToken)) != 1 {
+			log.Error("telegram webhook has invalid secret token", logger.F("remote_addr", r.RemoteAddr))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -1,8 +0,0 @@
-package app
-
-type BotState uint
-
-const (
-	IDLEState BotState = iota
-	EnteringAmountCurrencyBotState
-)
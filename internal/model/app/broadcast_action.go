@@ -0,0 +1,12 @@
+package app
+
+// BroadcastAction is a button of the message controlling a broadcast, actions but the start are sent
+// to the running broadcast workflow as signals.
+type BroadcastAction string
+
+const (
+	StartBroadcastAction  BroadcastAction = "start"
+	PauseBroadcastAction  BroadcastAction = "pause"
+	ResumeBroadcastAction BroadcastAction = "resume"
+	CancelBroadcastAction BroadcastAction = "cancel"
+)
@@ -0,0 +1,7 @@
+package app
+
+// BulkPurchaseQuantities are the amounts of numbers offered on the confirmation screen besides a single one.
+var BulkPurchaseQuantities = []int{5, 10}
+
+// MaxBulkPurchaseQuantity caps the quantity accepted from the callback data.
+const MaxBulkPurchaseQuantity = 10
@@ -28,4 +28,13 @@ const (
 	BackCallbackQueryCommand
 	CancelEnterAmountCallbackQueryCommand
 	CancelPayTelegramStarsCallbackQueryCommand
+	PayCheapestServiceCallbackQueryCommand
+	SelectOperatorCallbackQueryCommand
+	FavoritesCallbackQueryCommand
+	ToggleFavoriteServiceCallbackQueryCommand
+	ToggleFavoriteCountryCallbackQueryCommand
+	ControlBroadcastCallbackQueryCommand
+	SupportCallbackQueryCommand
+	CloseSupportTicketCallbackQueryCommand
+	ConfirmationPayOperatorCallbackQueryCommand
 )
@@ -6,5 +6,38 @@ const (
 	NotTelegramCommand TelegramCommand = iota
 	StartTelegramCommand
 	HelpTelegramCommand
+	ServiceTelegramCommand
+	AdminTelegramCommand
 	UnknownTelegramCommand
 )
+
+// ServiceCmdText is followed by a service code and opens the country list of the service.
+const ServiceCmdText = "/service"
+
+// ServiceStartParameterPrefix is followed by a service code in the start parameter of deep links, the link opens
+// the country list of the service the same way as ServiceCmdText.
+const ServiceStartParameterPrefix = "service_"
+
+// AdminCmdText is a command only admins may run, its arguments follow it separated by spaces.
+type AdminCmdText string
+
+const (
+	UserAdminCmdText       AdminCmdText = "/user"
+	CreditAdminCmdText     AdminCmdText = "/credit"
+	DebitAdminCmdText      AdminCmdText = "/debit"
+	ActivationAdminCmdText AdminCmdText = "/activation"
+	StatsAdminCmdText      AdminCmdText = "/stats"
+	BroadcastAdminCmdText  AdminCmdText = "/broadcast"
+)
+
+var AdminCmdTexts = []AdminCmdText{
+	UserAdminCmdText,
+	CreditAdminCmdText,
+	DebitAdminCmdText,
+	ActivationAdminCmdText,
+	StatsAdminCmdText,
+	BroadcastAdminCmdText,
+}
+
+// CancelActivationAdminArgument follows the activation id to force-cancel the activation.
+const CancelActivationAdminArgument = "cancel"
@@ -0,0 +1,6 @@
+package app
+
+type CountryDialingCode struct {
+	DialingCode string `json:"dialingCode"`
+	Grouping    []int  `json:"grouping"`
+}
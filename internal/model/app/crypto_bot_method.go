@@ -6,4 +6,5 @@ const (
 	CreateInvoiceCryptoBotMethod CryptoBotMethod = "createInvoice"
 	ExchangeRateCryptoBotMethod  CryptoBotMethod = "getExchangeRates"
 	DeleteInvoiceCryptoBotMethod CryptoBotMethod = "deleteInvoice"
+	GetBalanceCryptoBotMethod    CryptoBotMethod = "getBalance"
 )
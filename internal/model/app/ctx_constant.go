@@ -1,7 +1,10 @@
 package app
 
 const (
-	UpdateContextKey      = "update_key"
-	ProfileContextKey     = "profile_key"
-	IsProfileSubscription = "is_profile_subscription_key"
+	UpdateContextKey  = "update_key"
+	ProfileContextKey = "profile_key"
+	// MissingRequiredChannelsContextKey keeps the required channels the user isn't subscribed to.
+	MissingRequiredChannelsContextKey = "missing_required_channels_key"
+	// PolledUpdateContextKey marks updates the bot polled itself, they don't carry the webhook secret token.
+	PolledUpdateContextKey = "polled_update_key"
 )
@@ -18,4 +18,10 @@ var (
 	UnknownPhoneNumberFormatError    = errors.New("unknown phone number format")
 	UserNotFoundError                = errors.New("user not found")
 	UnknownCurrencyError             = errors.New("unknown currency")
+	InsufficientFundsError           = errors.New("insufficient funds")
+	DispatcherStoppedError           = errors.New("outbound dispatcher is stopped")
+	PendingBroadcastDeliveriesError  = errors.New("broadcast deliveries wait to be repeated")
+	InvalidWebhookSecretTokenError   = errors.New("webhook secret token must be 1-256 characters of A-Z, a-z, 0-9, _ and -")
+	ServeTelegramUpdateError         = errors.New("telegram update is served with error")
+	ExpiredCallbackDataError         = errors.New("callback data is expired or forged")
 )
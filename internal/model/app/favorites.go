@@ -0,0 +1,25 @@
+package app
+
+// Favorites keeps starred services and countries of a profile in the order they were starred.
+type Favorites struct {
+	ServiceCodes []string
+	CountryIDs   []int64
+}
+
+func (f Favorites) IsFavoriteService(serviceCode string) bool {
+	for _, favoriteServiceCode := range f.ServiceCodes {
+		if favoriteServiceCode == serviceCode {
+			return true
+		}
+	}
+	return false
+}
+
+func (f Favorites) IsFavoriteCountry(countryID int64) bool {
+	for _, favoriteCountryID := range f.CountryIDs {
+		if favoriteCountryID == countryID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OutboundPriority orders messages waiting for the rate limit, a lower value is sent first.
+type OutboundPriority int
+
+const (
+	// CodeOutboundPriority is for received codes and activation updates, users wait for them.
+	CodeOutboundPriority OutboundPriority = iota
+	// TransactionalOutboundPriority is for replies to users and notifications about their money.
+	TransactionalOutboundPriority
+	// MarketingOutboundPriority is for broadcasts, they give way to everything else.
+	MarketingOutboundPriority
+)
+
+var OutboundPriorities = []OutboundPriority{
+	CodeOutboundPriority,
+	TransactionalOutboundPriority,
+	MarketingOutboundPriority,
+}
+
+// OutboundMethod is a telegram method sending or changing a message in a chat, these methods are rate limited.
+type OutboundMethod string
+
+const (
+	SendMessageOutboundMethod        OutboundMethod = "sendMessage"
+	SendPhotoOutboundMethod          OutboundMethod = "sendPhoto"
+	SendInvoiceOutboundMethod        OutboundMethod = "sendInvoice"
+	EditMessageTextOutboundMethod    OutboundMethod = "editMessageText"
+	EditMessageCaptionOutboundMethod OutboundMethod = "editMessageCaption"
+	EditMessageMediaOutboundMethod   OutboundMethod = "editMessageMedia"
+	DeleteMessageOutboundMethod      OutboundMethod = "deleteMessage"
+)
+
+// OutboundMessage is a request to telegram servers waiting in the outbound queue.
+type OutboundMessage struct {
+	ID        string
+	ChatID    int64
+	Priority  OutboundPriority
+	Method    OutboundMethod
+	Payload   json.RawMessage
+	Attempts  int
+	CreatedAt time.Time
+	// NotBefore delays a retry of the message.
+	NotBefore time.Time
+}
@@ -0,0 +1,7 @@
+package app
+
+// Page holds items of a single page together with the pagination over all items.
+type Page[T any] struct {
+	Items      []T
+	Pagination Pagination
+}
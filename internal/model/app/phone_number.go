@@ -1,12 +1,46 @@
 package app
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type PhoneNumber struct {
 	CountryCode      string
 	ShortPhoneNumber string
+	// Grouping holds sizes of digit groups of the national number, the number is shown ungrouped without it
+	Grouping []int
 }
 
 func (p PhoneNumber) FullNumber() string {
 	return fmt.Sprintf("+%s%s", p.CountryCode, p.ShortPhoneNumber)
 }
+
+// NationalNumber is the short phone number split into groups of the country, e.g. 912 345 67 89.
+func (p PhoneNumber) NationalNumber() string {
+	if len(p.Grouping) == 0 {
+		return p.ShortPhoneNumber
+	}
+	digits := p.ShortPhoneNumber
+	groups := make([]string, 0, len(p.Grouping)+1)
+	for _, size := range p.Grouping {
+		if len(digits) == 0 {
+			break
+		}
+		size = min(size, len(digits))
+		groups = append(groups, digits[:size])
+		digits = digits[size:]
+	}
+	if len(digits) > 0 {
+		groups = append(groups, digits)
+	}
+	return strings.Join(groups, " ")
+}
+
+// InternationalNumber is the national number with the dialing code, e.g. +7 912 345 67 89.
+func (p PhoneNumber) InternationalNumber() string {
+	if p.CountryCode == "" {
+		return fmt.Sprintf("+%s", p.NationalNumber())
+	}
+	return fmt.Sprintf("+%s %s", p.CountryCode, p.NationalNumber())
+}
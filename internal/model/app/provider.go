@@ -0,0 +1,11 @@
+package app
+
+type Provider string
+
+const (
+	SMSActivateProvider Provider = "SMS-Activate"
+	CryptoBotProvider   Provider = "CryptoBot"
+)
+
+// Providers are the providers whose balances are monitored.
+var Providers = []Provider{SMSActivateProvider, CryptoBotProvider}
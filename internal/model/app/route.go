@@ -0,0 +1,4 @@
+package app
+
+// TelegramWebhookPath receives updates from telegram servers, polled updates are served on it too.
+const TelegramWebhookPath = "/telegram/handler/webhook"
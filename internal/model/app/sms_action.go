@@ -10,4 +10,6 @@ const (
 	GetTopCountriesByServiceAction           = "getTopCountriesByService"
 	GetActivationStatus                      = "getStatus"
 	SetActivationStatus                      = "setStatus"
+	GetBalanceSMSAction                      = "getBalance"
+	GetOperatorsSMSAction                    = "getOperators"
 )
@@ -0,0 +1,6 @@
+package app
+
+type SMSCodeRule struct {
+	Service string `json:"service"`
+	Pattern string `json:"pattern"`
+}
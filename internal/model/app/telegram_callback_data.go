@@ -22,9 +22,18 @@ const (
 	EmptyCallbackQueryCmdText                          = "empty"
 	DeleteCryptoBotInvoiceQueryCmdText                 = "d_cr_b_inv"
 	ConfirmationPayServiceQueryCmdText                 = "con_s_pay"
+	ConfirmationPayOperatorQueryCmdText                = "con_s_pay_op"
+	PayCheapestServiceQueryCmdText                     = "s_serv_cheap"
+	SelectOperatorQueryCmdText                         = "s_oper"
 	RefundAmountFromSMSActivationQueryCmdText          = "ref_sms_act"
 	BackQueryCmdText                                   = "back"
 	CancelPayTelegramStarsCmdText                      = "c_pay_xtr"
+	FavoritesCallbackQueryCmdText                      = "fav"
+	ToggleFavoriteServiceQueryCmdText                  = "t_fav_serv"
+	ToggleFavoriteCountryQueryCmdText                  = "t_fav_cntr"
+	ControlBroadcastQueryCmdText                       = "ctl_brd"
+	SupportCallbackQueryCmdText                        = "support"
+	CloseSupportTicketQueryCmdText                     = "cl_sup_tck"
 )
 
 type TelegramCallbackData struct {
@@ -74,6 +83,8 @@ func (t *TelegramCallbackData) CallbackQueryCommand() CallbackQueryCommand {
 		return DeleteCryptoBotInvoiceCallbackQueryCommand
 	case ConfirmationPayServiceQueryCmdText:
 		return ConfirmationPayServiceCallbackQueryCommand
+	case ConfirmationPayOperatorQueryCmdText:
+		return ConfirmationPayOperatorCallbackQueryCommand
 	case RefundAmountFromSMSActivationQueryCmdText:
 		return RefundAmountFromSMSActivationCallbackQueryCommand
 	case BackQueryCmdText:
@@ -82,6 +93,22 @@ func (t *TelegramCallbackData) CallbackQueryCommand() CallbackQueryCommand {
 		return CancelEnterAmountCallbackQueryCommand
 	case CancelPayTelegramStarsCmdText:
 		return CancelPayTelegramStarsCallbackQueryCommand
+	case PayCheapestServiceQueryCmdText:
+		return PayCheapestServiceCallbackQueryCommand
+	case SelectOperatorQueryCmdText:
+		return SelectOperatorCallbackQueryCommand
+	case FavoritesCallbackQueryCmdText:
+		return FavoritesCallbackQueryCommand
+	case ToggleFavoriteServiceQueryCmdText:
+		return ToggleFavoriteServiceCallbackQueryCommand
+	case ToggleFavoriteCountryQueryCmdText:
+		return ToggleFavoriteCountryCallbackQueryCommand
+	case ControlBroadcastQueryCmdText:
+		return ControlBroadcastCallbackQueryCommand
+	case SupportCallbackQueryCmdText:
+		return SupportCallbackQueryCommand
+	case CloseSupportTicketQueryCmdText:
+		return CloseSupportTicketCallbackQueryCommand
 	default:
 		return NotCallbackQueryCommand
 	}
@@ -0,0 +1,7 @@
+package bot
+
+type Balance struct {
+	CurrencyCode string `json:"currency_code"`
+	Available    string `json:"available"`
+	OnHold       string `json:"onhold"`
+}
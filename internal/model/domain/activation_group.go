@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+type ActivationGroup struct {
+	ID        int64
+	ProfileID int64
+	ChatID    int64
+	MessageID *int64
+	Quantity  int
+	CreatedAt *time.Time
+	UpdatedAt *time.Time
+}
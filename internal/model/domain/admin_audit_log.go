@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+type AdminAuditLog struct {
+	ID         int64
+	ProfileID  *int64
+	TelegramID int64
+	Command    string
+	Arguments  string
+	// Allowed is false when the user didn't pass the permission check and the command wasn't run
+	Allowed   bool
+	Result    *string
+	CreatedAt *time.Time
+}
@@ -0,0 +1,11 @@
+package domain
+
+type AdminStats struct {
+	ProfilesCount    int64
+	NewProfilesCount int64
+	// BlockedBotProfilesCount is the number of users who have the bot blocked now
+	BlockedBotProfilesCount int64
+	TotalBalance            float64
+	// ActivationCounts keeps the number of activations started in the period by their status
+	ActivationCounts map[string]int64
+}
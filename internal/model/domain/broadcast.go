@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type BroadcastStatus string
+
+const (
+	DraftBroadcastStatus     BroadcastStatus = "draft"
+	RunningBroadcastStatus   BroadcastStatus = "running"
+	PausedBroadcastStatus    BroadcastStatus = "paused"
+	CancelledBroadcastStatus BroadcastStatus = "cancelled"
+	CompletedBroadcastStatus BroadcastStatus = "completed"
+)
+
+func (b BroadcastStatus) IsFinished() bool {
+	return b == CancelledBroadcastStatus || b == CompletedBroadcastStatus
+}
+
+type BroadcastActivity string
+
+const (
+	AnyBroadcastActivity BroadcastActivity = ""
+	// ActiveBroadcastActivity targets profiles that have bought a number within the activity days
+	ActiveBroadcastActivity BroadcastActivity = "active"
+	// InactiveBroadcastActivity targets profiles that haven't bought a number within the activity days
+	InactiveBroadcastActivity BroadcastActivity = "inactive"
+)
+
+// BroadcastSegment selects recipients of the broadcast, an empty list doesn't filter by its field.
+type BroadcastSegment struct {
+	LanguageCodes []string
+	Currencies    []string
+	Activity      BroadcastActivity
+	ActivityDays  int
+}
+
+type BroadcastButton struct {
+	Text string `json:"text"`
+	URL  string `json:"url"`
+}
+
+type Broadcast struct {
+	ID              int64
+	AuthorProfileID *int64
+	Status          BroadcastStatus
+	Text            *string
+	// Photo keeps a file id of the photo on telegram servers, the text is the caption of the photo
+	Photo *string
+	// Entities keeps formatting of the text as telegram servers have sent it
+	Entities         json.RawMessage
+	Buttons          []BroadcastButton
+	Segment          BroadcastSegment
+	ControlChatID    *int64
+	ControlMessageID *int64
+	StartedAt        *time.Time
+	FinishedAt       *time.Time
+	CreatedAt        *time.Time
+	UpdatedAt        *time.Time
+}
+
+type BroadcastDeliveryStatus string
+
+const (
+	PendingBroadcastDeliveryStatus BroadcastDeliveryStatus = "pending"
+	// SendingBroadcastDeliveryStatus is for deliveries taken by a batch, they aren't sent twice
+	SendingBroadcastDeliveryStatus BroadcastDeliveryStatus = "sending"
+	SentBroadcastDeliveryStatus    BroadcastDeliveryStatus = "sent"
+	FailedBroadcastDeliveryStatus  BroadcastDeliveryStatus = "failed"
+	// BlockedBroadcastDeliveryStatus is for users that have blocked the bot
+	BlockedBroadcastDeliveryStatus BroadcastDeliveryStatus = "blocked"
+)
+
+type BroadcastDelivery struct {
+	ID          int64
+	BroadcastID int64
+	ProfileID   int64
+	ChatID      int64
+	Status      BroadcastDeliveryStatus
+	MessageID   *int64
+	Error       *string
+	Attempts    int
+	// NotBefore delays a pending delivery after a failure telegram servers may recover from
+	NotBefore *time.Time
+	// ClaimedAt is when a batch has taken the delivery, a sending delivery claimed long ago is taken again
+	ClaimedAt *time.Time
+	SentAt    *time.Time
+	CreatedAt *time.Time
+}
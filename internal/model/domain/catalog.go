@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+type CatalogService struct {
+	Code           string
+	Name           string
+	PopularityRank *int
+	LastSeenAt     time.Time
+}
+
+type CatalogCountry struct {
+	ID           int64
+	Title        string
+	Visible      int
+	Retry        int
+	Rent         int
+	MultiService int
+	LastSeenAt   time.Time
+}
+
+type CatalogServicePrice struct {
+	ServiceCode string
+	CountryID   int64
+	RetailPrice float64
+	MinPrice    float64
+	Count       int
+	LastSeenAt  time.Time
+}
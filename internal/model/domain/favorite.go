@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+type FavoriteType string
+
+const (
+	ServiceFavoriteType FavoriteType = "service"
+	CountryFavoriteType FavoriteType = "country"
+)
+
+type Favorite struct {
+	ID        int64
+	ProfileID int64
+	Type      FavoriteType
+	// Code keeps a service code or a country id depending on Type
+	Code      string
+	CreatedAt *time.Time
+}
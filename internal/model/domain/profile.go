@@ -2,6 +2,13 @@ package domain
 
 import "time"
 
+type ProfileRole string
+
+const (
+	UserProfileRole  ProfileRole = "user"
+	AdminProfileRole ProfileRole = "admin"
+)
+
 type Profile struct {
 	ID                int64
 	TelegramID        int64
@@ -10,6 +17,15 @@ type Profile struct {
 	PreferredCurrency *string
 	PreferredLanguage *string
 	Balance           float64
-	UpdatedAt         *time.Time
-	CreatedAt         *time.Time
+	Role              ProfileRole
+	// BotBlockedAt is set while the user has the bot blocked, BotUnblockedAt is the last time the user has
+	// started the bot again.
+	BotBlockedAt   *time.Time
+	BotUnblockedAt *time.Time
+	UpdatedAt      *time.Time
+	CreatedAt      *time.Time
+}
+
+func (p *Profile) IsBotBlocked() bool {
+	return p.BotBlockedAt != nil
 }
@@ -0,0 +1,7 @@
+package domain
+
+type ServicePurchaseCount struct {
+	LanguageCode *string
+	ServiceCode  string
+	Count        int64
+}
@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+type SMSActivateUpdate struct {
+	ID           int64
+	ActivationID int64
+	ReceivedAt   string
+	Payload      []byte
+	ProcessedAt  *time.Time
+	CreatedAt    *time.Time
+}
@@ -0,0 +1,41 @@
+package domain
+
+import "time"
+
+type SupportTicketStatus string
+
+const (
+	// OpenSupportTicketStatus is for tickets waiting for an answer of support agents
+	OpenSupportTicketStatus SupportTicketStatus = "open"
+	// PendingSupportTicketStatus is for tickets answered by support agents and waiting for the user
+	PendingSupportTicketStatus SupportTicketStatus = "pending"
+	ClosedSupportTicketStatus  SupportTicketStatus = "closed"
+)
+
+type SupportTicket struct {
+	ID        int64
+	ProfileID int64
+	Status    SupportTicketStatus
+	CreatedAt *time.Time
+	UpdatedAt *time.Time
+	ClosedAt  *time.Time
+}
+
+type SupportMessageSender string
+
+const (
+	UserSupportMessageSender  SupportMessageSender = "user"
+	AgentSupportMessageSender SupportMessageSender = "agent"
+)
+
+type SupportMessage struct {
+	ID               int64
+	TicketID         int64
+	Sender           SupportMessageSender
+	SenderTelegramID int64
+	Text             string
+	// UserMessageID is the message in the chat with the user, SupportMessageID is the one in the support chat
+	UserMessageID    *int64
+	SupportMessageID *int64
+	CreatedAt        *time.Time
+}
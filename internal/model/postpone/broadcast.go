@@ -0,0 +1,8 @@
+package postpone
+
+type Broadcast struct {
+	BroadcastID int64
+	// IsStarted is set once deliveries have been created, the workflow continued as new doesn't create them again.
+	IsStarted bool
+	IsPaused  bool
+}
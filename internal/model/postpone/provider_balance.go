@@ -0,0 +1,12 @@
+package postpone
+
+type ProviderBalance struct {
+	Provider   string
+	Balance    float64
+	MinBalance float64
+	Currency   string
+}
+
+func (p ProviderBalance) IsLow() bool {
+	return p.Balance < p.MinBalance
+}
@@ -0,0 +1,7 @@
+package sms
+
+type OperatorPrice struct {
+	Operator string
+	Cost     float64
+	Count    int
+}
@@ -0,0 +1,21 @@
+package telegram
+
+type AnswerInlineQuery struct {
+	InlineQueryID string                     `json:"inline_query_id"`
+	Results       []InlineQueryResultArticle `json:"results"`
+	CacheTime     int64                      `json:"cache_time"`
+	IsPersonal    bool                       `json:"is_personal"`
+}
+
+type InlineQueryResultArticle struct {
+	Type                string                  `json:"type"`
+	ID                  string                  `json:"id"`
+	Title               string                  `json:"title"`
+	Description         *string                 `json:"description,omitempty"`
+	InputMessageContent InputTextMessageContent `json:"input_message_content"`
+	ReplyMarkup         *InlineKeyboardMarkup   `json:"reply_markup,omitempty"`
+}
+
+type InputTextMessageContent struct {
+	MessageText string `json:"message_text"`
+}
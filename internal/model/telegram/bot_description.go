@@ -0,0 +1,5 @@
+package telegram
+
+type BotDescription struct {
+	Description string `json:"description"`
+}
@@ -0,0 +1,5 @@
+package telegram
+
+type BotName struct {
+	Name string `json:"name"`
+}
@@ -0,0 +1,5 @@
+package telegram
+
+type BotShortDescription struct {
+	ShortDescription string `json:"short_description"`
+}
@@ -1,5 +1,9 @@
 package telegram
 
+const PrivateChatType = "private"
+
 type Chat struct {
-	ID int64 `json:"id"`
+	ID       int64   `json:"id"`
+	Type     string  `json:"type,omitempty"`
+	Username *string `json:"username,omitempty"`
 }
@@ -4,3 +4,13 @@ type ChatMember struct {
 	Status MemberStatus `json:"status"`
 	User   User         `json:"user"`
 }
+
+// IsMember reports whether the user is subscribed to the chat.
+func (c *ChatMember) IsMember() bool {
+	switch c.Status {
+	case MemberMemberStatus, CreatorMemberStatus, AdministratorMemberStatus:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,11 @@
+package telegram
+
+// ChatMemberUpdated is sent when the status of a member changes, in private chats the member is the bot itself:
+// the user has blocked the bot or started it again.
+type ChatMemberUpdated struct {
+	Chat          Chat       `json:"chat"`
+	From          User       `json:"from"`
+	Date          int64      `json:"date"`
+	OldChatMember ChatMember `json:"old_chat_member"`
+	NewChatMember ChatMember `json:"new_chat_member"`
+}
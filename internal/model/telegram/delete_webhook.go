@@ -0,0 +1,5 @@
+package telegram
+
+type DeleteWebhook struct {
+	DropPendingUpdates bool `json:"drop_pending_updates"`
+}
@@ -0,0 +1,5 @@
+package telegram
+
+type GetMyName struct {
+	LanguageCode string `json:"language_code,omitempty"`
+}
@@ -0,0 +1,9 @@
+package telegram
+
+type InlineQuery struct {
+	ID       string  `json:"id"`
+	From     User    `json:"from"`
+	Query    string  `json:"query"`
+	Offset   string  `json:"offset"`
+	ChatType *string `json:"chat_type,omitempty"`
+}
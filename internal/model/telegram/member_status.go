@@ -8,6 +8,7 @@ const (
 	MemberMemberStatus        MemberStatus = "member"
 	CreatorMemberStatus       MemberStatus = "creator"
 	AdministratorMemberStatus MemberStatus = "administrator"
+	RestrictedMemberStatus    MemberStatus = "restricted"
 	LeftMemberStatus          MemberStatus = "left"
 	BannedMemberStatus        MemberStatus = "kicked"
 )
@@ -20,7 +21,8 @@ func (m *MemberStatus) UnmarshalJSON(data []byte) error {
 	data = data[1 : len(data)-1]
 	memberStatus := MemberStatus(data)
 	switch memberStatus {
-	case MemberMemberStatus, CreatorMemberStatus, AdministratorMemberStatus, LeftMemberStatus, BannedMemberStatus:
+	case MemberMemberStatus, CreatorMemberStatus, AdministratorMemberStatus, RestrictedMemberStatus, LeftMemberStatus,
+		BannedMemberStatus:
 		*m = memberStatus
 		return nil
 	default:
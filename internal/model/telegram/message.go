@@ -4,8 +4,21 @@ type Message struct {
 	ID                int64              `json:"message_id"`
 	From              *User              `json:"from"`
 	Text              *string            `json:"text"`
+	Entities          []MessageEntity    `json:"entities,omitempty"`
+	Photo             []PhotoSize        `json:"photo,omitempty"`
+	Caption           *string            `json:"caption,omitempty"`
+	CaptionEntities   []MessageEntity    `json:"caption_entities,omitempty"`
 	Chat              *Chat              `json:"chat"`
+	ReplyToMessage    *Message           `json:"reply_to_message,omitempty"`
 	Date              int64              `json:"date"`
 	SuccessfulPayment *SuccessfulPayment `json:"successful_payment"`
 	RefundedPayment   *RefundedPayment   `json:"refunded_payment"`
 }
+
+// LargestPhoto returns the biggest size of the photo, telegram servers list sizes from the smallest one.
+func (m *Message) LargestPhoto() *PhotoSize {
+	if len(m.Photo) == 0 {
+		return nil
+	}
+	return &m.Photo[len(m.Photo)-1]
+}
@@ -0,0 +1,12 @@
+package telegram
+
+// MessageEntity marks formatting of the text, offsets and lengths are in UTF-16 code units.
+type MessageEntity struct {
+	Type          string  `json:"type"`
+	Offset        int     `json:"offset"`
+	Length        int     `json:"length"`
+	URL           *string `json:"url,omitempty"`
+	User          *User   `json:"user,omitempty"`
+	Language      *string `json:"language,omitempty"`
+	CustomEmojiID *string `json:"custom_emoji_id,omitempty"`
+}
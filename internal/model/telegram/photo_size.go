@@ -0,0 +1,9 @@
+package telegram
+
+type PhotoSize struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	FileSize     *int64 `json:"file_size,omitempty"`
+}
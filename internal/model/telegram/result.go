@@ -1,7 +0,0 @@
-package telegram
-
-type Result[T any] struct {
-	OK          bool   `json:"ok"`
-	Description string `json:"description"`
-	Result      T      `json:"result"`
-}
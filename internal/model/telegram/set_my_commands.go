@@ -1,5 +1,6 @@
 package telegram
 
 type SetMyCommands struct {
-	Commands []BotCommand `json:"commands"`
+	Commands     []BotCommand `json:"commands"`
+	LanguageCode string       `json:"language_code,omitempty"`
 }
@@ -1,5 +1,6 @@
 package telegram
 
 type SetMyDescription struct {
-	Description string `json:"description"`
+	Description  string `json:"description"`
+	LanguageCode string `json:"language_code,omitempty"`
 }
@@ -1,5 +1,6 @@
 package telegram
 
 type SetMyName struct {
-	Name string `json:"name"`
+	Name         string `json:"name"`
+	LanguageCode string `json:"language_code,omitempty"`
 }
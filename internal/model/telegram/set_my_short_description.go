@@ -0,0 +1,6 @@
+package telegram
+
+type SetMyShortDescription struct {
+	ShortDescription string `json:"short_description"`
+	LanguageCode     string `json:"language_code,omitempty"`
+}
@@ -0,0 +1,9 @@
+package telegram
+
+type SetWebhook struct {
+	URL                string   `json:"url"`
+	SecretToken        string   `json:"secret_token,omitempty"`
+	MaxConnections     int      `json:"max_connections,omitempty"`
+	AllowedUpdates     []string `json:"allowed_updates"`
+	DropPendingUpdates bool     `json:"drop_pending_updates,omitempty"`
+}
@@ -1,15 +1,24 @@
 package telegram
 
 type Update struct {
-	ID               int64             `json:"update_id"`
-	Message          *Message          `json:"message,omitempty"`
-	CallbackQuery    *CallbackQuery    `json:"callback_query"`
-	PreCheckoutQuery *PreCheckoutQuery `json:"pre_checkout_query,omitempty"`
+	ID               int64              `json:"update_id"`
+	Message          *Message           `json:"message,omitempty"`
+	CallbackQuery    *CallbackQuery     `json:"callback_query"`
+	PreCheckoutQuery *PreCheckoutQuery  `json:"pre_checkout_query,omitempty"`
+	InlineQuery      *InlineQuery       `json:"inline_query,omitempty"`
+	MyChatMember     *ChatMemberUpdated `json:"my_chat_member,omitempty"`
+	// ChatMember comes from channels the bot administers, it is about other members.
+	ChatMember *ChatMemberUpdated `json:"chat_member,omitempty"`
 }
 
 func (u *Update) GetChatID() int64 {
 	if u.Message != nil {
 		return u.Message.Chat.ID
+	} else if u.InlineQuery != nil {
+		// inline queries aren't bound to a chat, the private chat with the bot shares the id with the user
+		return u.InlineQuery.From.ID
+	} else if u.MyChatMember != nil {
+		return u.MyChatMember.Chat.ID
 	}
 	return u.CallbackQuery.Message.Chat.ID
 }
@@ -19,6 +28,10 @@ func (u *Update) GetTelegramID() int64 {
 		return u.Message.From.ID
 	} else if u.CallbackQuery != nil {
 		return u.CallbackQuery.From.ID
+	} else if u.InlineQuery != nil {
+		return u.InlineQuery.From.ID
+	} else if u.MyChatMember != nil {
+		return u.MyChatMember.From.ID
 	}
 	return u.PreCheckoutQuery.From.ID
 }
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go-ton-pass-telegram-bot/internal/model/domain"
+	"time"
+)
+
+type ActivationGroupRepository interface {
+	Create(ctx context.Context, activationGroup *domain.ActivationGroup) (*int64, error)
+	SetMessageID(ctx context.Context, id int64, messageID int64) error
+	FetchByID(ctx context.Context, id int64) (*domain.ActivationGroup, error)
+}
+
+type activationGroupRepository struct {
+	conn *sql.DB
+}
+
+func NewActivationGroupRepository(conn *sql.DB) ActivationGroupRepository {
+	return &activationGroupRepository{
+		conn: conn,
+	}
+}
+
+func (a *activationGroupRepository) Create(ctx context.Context, activationGroup *domain.ActivationGroup) (*int64, error) {
+	query := "INSERT INTO activation_group (profile_id, chat_id, quantity, created_at) VALUES ($1, $2, $3, $4) RETURNING id;"
+	var id int64
+	err := a.conn.QueryRowContext(
+		ctx,
+		query,
+		activationGroup.ProfileID,
+		activationGroup.ChatID,
+		activationGroup.Quantity,
+		time.Now(),
+	).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (a *activationGroupRepository) SetMessageID(ctx context.Context, id int64, messageID int64) error {
+	query := "UPDATE activation_group SET message_id = $1, updated_at = $2 WHERE id = $3"
+	_, err := a.conn.ExecContext(ctx, query, messageID, time.Now(), id)
+	return err
+}
+
+func (a *activationGroupRepository) FetchByID(ctx context.Context, id int64) (*domain.ActivationGroup, error) {
+	query := "SELECT profile_id, chat_id, message_id, quantity, created_at, updated_at FROM activation_group WHERE id = $1"
+	activationGroup := domain.ActivationGroup{
+		ID: id,
+	}
+	var messageID sql.NullInt64
+	var createdAt sql.NullTime
+	var updatedAt sql.NullTime
+	err := a.conn.QueryRowContext(ctx, query, id).Scan(
+		&activationGroup.ProfileID,
+		&activationGroup.ChatID,
+		&messageID,
+		&activationGroup.Quantity,
+		&createdAt,
+		&updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if messageID.Valid {
+		activationGroup.MessageID = &messageID.Int64
+	}
+	if createdAt.Valid {
+		activationGroup.CreatedAt = &createdAt.Time
+	}
+	if updatedAt.Valid {
+		activationGroup.UpdatedAt = &updatedAt.Time
+	}
+	return &activationGroup, nil
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go-ton-pass-telegram-bot/internal/model/domain"
+	"time"
+)
+
+type AdminAuditLogRepository interface {
+	Create(ctx context.Context, adminAuditLog *domain.AdminAuditLog) (*int64, error)
+}
+
+type adminAuditLogRepository struct {
+	conn *sql.DB
+}
+
+func NewAdminAuditLogRepository(conn *sql.DB) AdminAuditLogRepository {
+	return &adminAuditLogRepository{
+		conn: conn,
+	}
+}
+
+func (a *adminAuditLogRepository) Create(ctx context.Context, adminAuditLog *domain.AdminAuditLog) (*int64, error) {
+	query := "INSERT INTO admin_audit_log (profile_id, telegram_id, command, arguments, allowed, result, created_at) " +
+		"VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id;"
+	var id int64
+	err := a.conn.QueryRowContext(
+		ctx,
+		query,
+		adminAuditLog.ProfileID,
+		adminAuditLog.TelegramID,
+		adminAuditLog.Command,
+		adminAuditLog.Arguments,
+		adminAuditLog.Allowed,
+		adminAuditLog.Result,
+		time.Now(),
+	).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
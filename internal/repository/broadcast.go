@@ -0,0 +1,331 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/lib/pq"
+	"go-ton-pass-telegram-bot/internal/model/domain"
+	"sort"
+	"time"
+)
+
+// broadcastRecipientsCondition selects profiles of the segment, parameters are $1 language codes, $2 currencies,
+// $3 activity and $4 the start of the activity period. Users that have blocked the bot are skipped.
+const broadcastRecipientsCondition = "p.deleted_at IS NULL AND p.bot_blocked_at IS NULL AND p.telegram_chat_id IS NOT NULL " +
+	"AND (COALESCE(cardinality($1::text[]), 0) = 0 OR p.preferred_language = ANY($1::text[])) " +
+	"AND (COALESCE(cardinality($2::text[]), 0) = 0 OR p.preferred_currency = ANY($2::text[])) " +
+	"AND ($3::text = '' OR ($3::text = 'active') = EXISTS(" +
+	"SELECT 1 FROM sms_history h WHERE h.profile_id = p.id AND h.created_at >= $4))"
+
+type BroadcastRepository interface {
+	Create(ctx context.Context, broadcast *domain.Broadcast) (*int64, error)
+	FetchByID(ctx context.Context, id int64) (*domain.Broadcast, error)
+	SetContent(ctx context.Context, broadcast *domain.Broadcast) error
+	SetControlMessage(ctx context.Context, id int64, chatID int64, messageID int64) error
+	ChangeStatus(ctx context.Context, id int64, fromStatuses []domain.BroadcastStatus, status domain.BroadcastStatus) (bool, error)
+	CountRecipients(ctx context.Context, segment domain.BroadcastSegment) (int64, error)
+	CreateDeliveries(ctx context.Context, broadcast *domain.Broadcast) (int64, error)
+	ClaimPendingDeliveries(ctx context.Context, broadcastID int64, claimedBefore time.Time, limit int) ([]domain.BroadcastDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *domain.BroadcastDelivery) error
+	FetchDeliveryCounts(ctx context.Context, broadcastID int64) (map[domain.BroadcastDeliveryStatus]int64, error)
+}
+
+type broadcastRepository struct {
+	conn *sql.DB
+}
+
+func NewBroadcastRepository(conn *sql.DB) BroadcastRepository {
+	return &broadcastRepository{
+		conn: conn,
+	}
+}
+
+func (b *broadcastRepository) Create(ctx context.Context, broadcast *domain.Broadcast) (*int64, error) {
+	query := "INSERT INTO broadcast (author_profile_id, status, language_codes, currencies, activity, activity_days, created_at) " +
+		"VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id;"
+	var id int64
+	err := b.conn.QueryRowContext(
+		ctx,
+		query,
+		broadcast.AuthorProfileID,
+		broadcast.Status,
+		pq.Array(broadcast.Segment.LanguageCodes),
+		pq.Array(broadcast.Segment.Currencies),
+		broadcast.Segment.Activity,
+		broadcast.Segment.ActivityDays,
+		time.Now(),
+	).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (b *broadcastRepository) FetchByID(ctx context.Context, id int64) (*domain.Broadcast, error) {
+	query := "SELECT author_profile_id, status, text, photo, entities, buttons, language_codes, currencies, activity, " +
+		"activity_days, control_chat_id, control_message_id, started_at, finished_at, created_at, updated_at " +
+		"FROM broadcast WHERE id = $1"
+	broadcast := domain.Broadcast{
+		ID:        id,
+		CreatedAt: new(time.Time),
+	}
+	var (
+		authorProfileID  sql.NullInt64
+		text             sql.NullString
+		photo            sql.NullString
+		entities         []byte
+		buttons          []byte
+		activity         sql.NullString
+		activityDays     sql.NullInt64
+		controlChatID    sql.NullInt64
+		controlMessageID sql.NullInt64
+		startedAt        sql.NullTime
+		finishedAt       sql.NullTime
+		updatedAt        sql.NullTime
+	)
+	err := b.conn.QueryRowContext(ctx, query, id).Scan(
+		&authorProfileID,
+		&broadcast.Status,
+		&text,
+		&photo,
+		&entities,
+		&buttons,
+		pq.Array(&broadcast.Segment.LanguageCodes),
+		pq.Array(&broadcast.Segment.Currencies),
+		&activity,
+		&activityDays,
+		&controlChatID,
+		&controlMessageID,
+		&startedAt,
+		&finishedAt,
+		broadcast.CreatedAt,
+		&updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if authorProfileID.Valid {
+		broadcast.AuthorProfileID = &authorProfileID.Int64
+	}
+	if text.Valid {
+		broadcast.Text = &text.String
+	}
+	if photo.Valid {
+		broadcast.Photo = &photo.String
+	}
+	if len(entities) > 0 {
+		broadcast.Entities = entities
+	}
+	if len(buttons) > 0 {
+		if err := json.Unmarshal(buttons, &broadcast.Buttons); err != nil {
+			return nil, err
+		}
+	}
+	broadcast.Segment.Activity = domain.BroadcastActivity(activity.String)
+	broadcast.Segment.ActivityDays = int(activityDays.Int64)
+	if controlChatID.Valid {
+		broadcast.ControlChatID = &controlChatID.Int64
+	}
+	if controlMessageID.Valid {
+		broadcast.ControlMessageID = &controlMessageID.Int64
+	}
+	if startedAt.Valid {
+		broadcast.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		broadcast.FinishedAt = &finishedAt.Time
+	}
+	if updatedAt.Valid {
+		broadcast.UpdatedAt = &updatedAt.Time
+	}
+	return &broadcast, nil
+}
+
+// SetContent saves the text, the photo, the entities and the buttons of the broadcast.
+func (b *broadcastRepository) SetContent(ctx context.Context, broadcast *domain.Broadcast) error {
+	buttons, err := json.Marshal(broadcast.Buttons)
+	if err != nil {
+		return err
+	}
+	var entities []byte
+	if len(broadcast.Entities) > 0 {
+		entities = broadcast.Entities
+	}
+	query := "UPDATE broadcast SET text = $1, photo = $2, entities = $3, buttons = $4, updated_at = $5 WHERE id = $6"
+	_, err = b.conn.ExecContext(ctx, query, broadcast.Text, broadcast.Photo, entities, buttons, time.Now(), broadcast.ID)
+	return err
+}
+
+func (b *broadcastRepository) SetControlMessage(ctx context.Context, id int64, chatID int64, messageID int64) error {
+	query := "UPDATE broadcast SET control_chat_id = $1, control_message_id = $2, updated_at = $3 WHERE id = $4"
+	_, err := b.conn.ExecContext(ctx, query, chatID, messageID, time.Now(), id)
+	return err
+}
+
+// ChangeStatus moves the broadcast to the status only from one of the given statuses, so two admins can't start
+// or finish the same broadcast twice. It reports whether the status has been changed.
+func (b *broadcastRepository) ChangeStatus(
+	ctx context.Context,
+	id int64,
+	fromStatuses []domain.BroadcastStatus,
+	status domain.BroadcastStatus,
+) (bool, error) {
+	statuses := make([]string, 0, len(fromStatuses))
+	for _, fromStatus := range fromStatuses {
+		statuses = append(statuses, string(fromStatus))
+	}
+	now := time.Now()
+	var startedAt, finishedAt *time.Time
+	if status == domain.RunningBroadcastStatus {
+		startedAt = &now
+	}
+	if status.IsFinished() {
+		finishedAt = &now
+	}
+	query := "UPDATE broadcast SET status = $1, started_at = COALESCE(started_at, $2), finished_at = COALESCE(finished_at, $3), " +
+		"updated_at = $4 WHERE id = $5 AND status = ANY($6::text[])"
+	result, err := b.conn.ExecContext(ctx, query, status, startedAt, finishedAt, now, id, pq.Array(statuses))
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+func (b *broadcastRepository) CountRecipients(ctx context.Context, segment domain.BroadcastSegment) (int64, error) {
+	query := "SELECT COUNT(*) FROM profile p WHERE " + broadcastRecipientsCondition
+	var count int64
+	err := b.conn.QueryRowContext(ctx, query, segmentArguments(segment)...).Scan(&count)
+	return count, err
+}
+
+// CreateDeliveries adds a pending delivery for every recipient of the segment, recipients that already have
+// a delivery are kept, so the call can be repeated.
+func (b *broadcastRepository) CreateDeliveries(ctx context.Context, broadcast *domain.Broadcast) (int64, error) {
+	query := "INSERT INTO broadcast_delivery (broadcast_id, profile_id, chat_id, status, created_at) " +
+		"SELECT $5, p.id, p.telegram_chat_id, $6, $7 FROM profile p WHERE " + broadcastRecipientsCondition + " " +
+		"ON CONFLICT (broadcast_id, profile_id) DO NOTHING"
+	arguments := append(
+		segmentArguments(broadcast.Segment),
+		broadcast.ID,
+		domain.PendingBroadcastDeliveryStatus,
+		time.Now(),
+	)
+	result, err := b.conn.ExecContext(ctx, query, arguments...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ClaimPendingDeliveries moves deliveries due to be sent to the sending status and returns them, so a repeated or
+// concurrent batch doesn't send them again. Sending deliveries claimed before claimedBefore are taken again,
+// the batch that has claimed them is lost.
+func (b *broadcastRepository) ClaimPendingDeliveries(
+	ctx context.Context,
+	broadcastID int64,
+	claimedBefore time.Time,
+	limit int,
+) ([]domain.BroadcastDelivery, error) {
+	query := "UPDATE broadcast_delivery SET status = $1, claimed_at = $2 WHERE id IN (" +
+		"SELECT id FROM broadcast_delivery WHERE broadcast_id = $3 AND (" +
+		"(status = $4 AND (not_before IS NULL OR not_before <= $2)) " +
+		"OR (status = $1 AND (claimed_at IS NULL OR claimed_at <= $5))" +
+		") ORDER BY id LIMIT $6 FOR UPDATE SKIP LOCKED" +
+		") RETURNING id, profile_id, chat_id, attempts, claimed_at, created_at"
+	rows, err := b.conn.QueryContext(
+		ctx,
+		query,
+		domain.SendingBroadcastDeliveryStatus,
+		time.Now(),
+		broadcastID,
+		domain.PendingBroadcastDeliveryStatus,
+		claimedBefore,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	deliveries := make([]domain.BroadcastDelivery, 0, limit)
+	for rows.Next() {
+		delivery := domain.BroadcastDelivery{
+			BroadcastID: broadcastID,
+			Status:      domain.SendingBroadcastDeliveryStatus,
+			ClaimedAt:   new(time.Time),
+			CreatedAt:   new(time.Time),
+		}
+		err := rows.Scan(
+			&delivery.ID,
+			&delivery.ProfileID,
+			&delivery.ChatID,
+			&delivery.Attempts,
+			delivery.ClaimedAt,
+			delivery.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].ID < deliveries[j].ID
+	})
+	return deliveries, nil
+}
+
+func (b *broadcastRepository) UpdateDelivery(ctx context.Context, delivery *domain.BroadcastDelivery) error {
+	query := "UPDATE broadcast_delivery SET status = $1, message_id = $2, error = $3, attempts = $4, not_before = $5, " +
+		"sent_at = $6 WHERE id = $7"
+	_, err := b.conn.ExecContext(
+		ctx,
+		query,
+		delivery.Status,
+		delivery.MessageID,
+		delivery.Error,
+		delivery.Attempts,
+		delivery.NotBefore,
+		delivery.SentAt,
+		delivery.ID,
+	)
+	return err
+}
+
+func (b *broadcastRepository) FetchDeliveryCounts(ctx context.Context, broadcastID int64) (map[domain.BroadcastDeliveryStatus]int64, error) {
+	query := "SELECT status, COUNT(*) FROM broadcast_delivery WHERE broadcast_id = $1 GROUP BY status"
+	rows, err := b.conn.QueryContext(ctx, query, broadcastID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	deliveryCounts := make(map[domain.BroadcastDeliveryStatus]int64)
+	for rows.Next() {
+		var status domain.BroadcastDeliveryStatus
+		var count int64
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		deliveryCounts[status] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return deliveryCounts, nil
+}
+
+func segmentArguments(segment domain.BroadcastSegment) []any {
+	activitySince := time.Now().AddDate(0, 0, -segment.ActivityDays)
+	return []any{
+		pq.Array(segment.LanguageCodes),
+		pq.Array(segment.Currencies),
+		string(segment.Activity),
+		activitySince,
+	}
+}
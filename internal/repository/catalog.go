@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go-ton-pass-telegram-bot/internal/model/domain"
+	"time"
+)
+
+// CatalogRepository keeps services, countries and prices of SMS-Activate. Every sync stamps the rows it has seen
+// with the same last_seen_at, services and countries are fetched from the latest sync only, so entries removed
+// by the provider disappear without losing their rows. Prices that are not seen anymore are deleted.
+type CatalogRepository interface {
+	SaveServices(ctx context.Context, services []domain.CatalogService, seenAt time.Time) error
+	SaveCountries(ctx context.Context, countries []domain.CatalogCountry, seenAt time.Time) error
+	SaveServicePrices(ctx context.Context, serviceCode string, servicePrices []domain.CatalogServicePrice, seenAt time.Time) error
+	FetchServices(ctx context.Context) ([]domain.CatalogService, error)
+	FetchCountries(ctx context.Context) ([]domain.CatalogCountry, error)
+	FetchServicePrices(ctx context.Context, serviceCode string) ([]domain.CatalogServicePrice, error)
+	FetchAllServicePrices(ctx context.Context) ([]domain.CatalogServicePrice, error)
+}
+
+type catalogRepository struct {
+	conn *sql.DB
+}
+
+func NewCatalogRepository(conn *sql.DB) CatalogRepository {
+	return &catalogRepository{
+		conn: conn,
+	}
+}
+
+func (c *catalogRepository) SaveServices(ctx context.Context, services []domain.CatalogService, seenAt time.Time) error {
+	query := "INSERT INTO catalog_service (code, name, popularity_rank, last_seen_at, created_at) VALUES ($1, $2, $3, $4, $5) " +
+		"ON CONFLICT (code) DO UPDATE SET name = EXCLUDED.name, popularity_rank = EXCLUDED.popularity_rank, " +
+		"last_seen_at = EXCLUDED.last_seen_at, updated_at = EXCLUDED.created_at;"
+	return c.withTx(ctx, func(tx *sql.Tx) error {
+		return execForEach(ctx, tx, query, len(services), func(idx int) []any {
+			service := services[idx]
+			var popularityRank sql.NullInt64
+			if service.PopularityRank != nil {
+				popularityRank = sql.NullInt64{Int64: int64(*service.PopularityRank), Valid: true}
+			}
+			return []any{service.Code, service.Name, popularityRank, seenAt, time.Now()}
+		})
+	})
+}
+
+func (c *catalogRepository) SaveCountries(ctx context.Context, countries []domain.CatalogCountry, seenAt time.Time) error {
+	query := "INSERT INTO catalog_country (id, title, visible, retry, rent, multi_service, last_seen_at, created_at) " +
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8) " +
+		"ON CONFLICT (id) DO UPDATE SET title = EXCLUDED.title, visible = EXCLUDED.visible, retry = EXCLUDED.retry, " +
+		"rent = EXCLUDED.rent, multi_service = EXCLUDED.multi_service, last_seen_at = EXCLUDED.last_seen_at, " +
+		"updated_at = EXCLUDED.created_at;"
+	return c.withTx(ctx, func(tx *sql.Tx) error {
+		return execForEach(ctx, tx, query, len(countries), func(idx int) []any {
+			country := countries[idx]
+			return []any{
+				country.ID,
+				country.Title,
+				country.Visible,
+				country.Retry,
+				country.Rent,
+				country.MultiService,
+				seenAt,
+				time.Now(),
+			}
+		})
+	})
+}
+
+func (c *catalogRepository) SaveServicePrices(
+	ctx context.Context,
+	serviceCode string,
+	servicePrices []domain.CatalogServicePrice,
+	seenAt time.Time,
+) error {
+	query := "INSERT INTO catalog_service_price (service_code, country_id, retail_price, min_price, count, last_seen_at, created_at) " +
+		"VALUES ($1, $2, $3, $4, $5, $6, $7) " +
+		"ON CONFLICT (service_code, country_id) DO UPDATE SET retail_price = EXCLUDED.retail_price, " +
+		"min_price = EXCLUDED.min_price, count = EXCLUDED.count, last_seen_at = EXCLUDED.last_seen_at, " +
+		"updated_at = EXCLUDED.created_at;"
+	deleteQuery := "DELETE FROM catalog_service_price WHERE service_code = $1 AND last_seen_at < $2"
+	return c.withTx(ctx, func(tx *sql.Tx) error {
+		err := execForEach(ctx, tx, query, len(servicePrices), func(idx int) []any {
+			servicePrice := servicePrices[idx]
+			return []any{
+				serviceCode,
+				servicePrice.CountryID,
+				servicePrice.RetailPrice,
+				servicePrice.MinPrice,
+				servicePrice.Count,
+				seenAt,
+				time.Now(),
+			}
+		})
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, deleteQuery, serviceCode, seenAt)
+		return err
+	})
+}
+
+func (c *catalogRepository) FetchServices(ctx context.Context) ([]domain.CatalogService, error) {
+	query := "SELECT code, name, popularity_rank, last_seen_at FROM catalog_service " +
+		"WHERE last_seen_at = (SELECT MAX(last_seen_at) FROM catalog_service) " +
+		"ORDER BY popularity_rank NULLS LAST, code"
+	rows, err := c.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	services := make([]domain.CatalogService, 0)
+	for rows.Next() {
+		var service domain.CatalogService
+		var popularityRank sql.NullInt64
+		if err := rows.Scan(&service.Code, &service.Name, &popularityRank, &service.LastSeenAt); err != nil {
+			return nil, err
+		}
+		if popularityRank.Valid {
+			rank := int(popularityRank.Int64)
+			service.PopularityRank = &rank
+		}
+		services = append(services, service)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return services, nil
+}
+
+func (c *catalogRepository) FetchCountries(ctx context.Context) ([]domain.CatalogCountry, error) {
+	query := "SELECT id, title, visible, retry, rent, multi_service, last_seen_at FROM catalog_country " +
+		"WHERE last_seen_at = (SELECT MAX(last_seen_at) FROM catalog_country) " +
+		"ORDER BY id"
+	rows, err := c.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	countries := make([]domain.CatalogCountry, 0)
+	for rows.Next() {
+		var country domain.CatalogCountry
+		var visible, retry, rent, multiService sql.NullInt64
+		err := rows.Scan(&country.ID, &country.Title, &visible, &retry, &rent, &multiService, &country.LastSeenAt)
+		if err != nil {
+			return nil, err
+		}
+		country.Visible = int(visible.Int64)
+		country.Retry = int(retry.Int64)
+		country.Rent = int(rent.Int64)
+		country.MultiService = int(multiService.Int64)
+		countries = append(countries, country)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return countries, nil
+}
+
+func (c *catalogRepository) FetchServicePrices(ctx context.Context, serviceCode string) ([]domain.CatalogServicePrice, error) {
+	query := "SELECT country_id, retail_price, min_price, count, last_seen_at FROM catalog_service_price " +
+		"WHERE service_code = $1 ORDER BY country_id"
+	rows, err := c.conn.QueryContext(ctx, query, serviceCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	servicePrices := make([]domain.CatalogServicePrice, 0)
+	for rows.Next() {
+		servicePrice := domain.CatalogServicePrice{
+			ServiceCode: serviceCode,
+		}
+		var retailPrice, minPrice sql.NullFloat64
+		var count sql.NullInt64
+		err := rows.Scan(&servicePrice.CountryID, &retailPrice, &minPrice, &count, &servicePrice.LastSeenAt)
+		if err != nil {
+			return nil, err
+		}
+		servicePrice.RetailPrice = retailPrice.Float64
+		servicePrice.MinPrice = minPrice.Float64
+		servicePrice.Count = int(count.Int64)
+		servicePrices = append(servicePrices, servicePrice)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return servicePrices, nil
+}
+
+func (c *catalogRepository) FetchAllServicePrices(ctx context.Context) ([]domain.CatalogServicePrice, error) {
+	query := "SELECT service_code, country_id, retail_price, min_price, count, last_seen_at FROM catalog_service_price " +
+		"ORDER BY service_code, country_id"
+	rows, err := c.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	servicePrices := make([]domain.CatalogServicePrice, 0)
+	for rows.Next() {
+		var servicePrice domain.CatalogServicePrice
+		var retailPrice, minPrice sql.NullFloat64
+		var count sql.NullInt64
+		err := rows.Scan(
+			&servicePrice.ServiceCode,
+			&servicePrice.CountryID,
+			&retailPrice,
+			&minPrice,
+			&count,
+			&servicePrice.LastSeenAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		servicePrice.RetailPrice = retailPrice.Float64
+		servicePrice.MinPrice = minPrice.Float64
+		servicePrice.Count = int(count.Int64)
+		servicePrices = append(servicePrices, servicePrice)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return servicePrices, nil
+}
+
+// withTx commits when the function succeeds and rolls back otherwise, so a sync never leaves a half saved list.
+func (c *catalogRepository) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := c.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func execForEach(ctx context.Context, tx *sql.Tx, query string, count int, args func(idx int) []any) error {
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for idx := 0; idx < count; idx++ {
+		if _, err := stmt.ExecContext(ctx, args(idx)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
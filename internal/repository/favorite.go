@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go-ton-pass-telegram-bot/internal/model/domain"
+	"time"
+)
+
+type FavoriteRepository interface {
+	Toggle(ctx context.Context, favorite *domain.Favorite) (bool, error)
+	FetchByProfileID(ctx context.Context, profileID int64) ([]domain.Favorite, error)
+}
+
+type favoriteRepository struct {
+	conn *sql.DB
+}
+
+func NewFavoriteRepository(conn *sql.DB) FavoriteRepository {
+	return &favoriteRepository{
+		conn: conn,
+	}
+}
+
+// Toggle removes the favorite when the profile already has it and stores it otherwise,
+// it reports whether the favorite is stored after the call.
+func (f *favoriteRepository) Toggle(ctx context.Context, favorite *domain.Favorite) (bool, error) {
+	deleteQuery := "DELETE FROM favorite WHERE profile_id = $1 AND favorite_type = $2 AND code = $3"
+	result, err := f.conn.ExecContext(ctx, deleteQuery, favorite.ProfileID, favorite.Type, favorite.Code)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rowsAffected > 0 {
+		return false, nil
+	}
+	insertQuery := "INSERT INTO favorite (profile_id, favorite_type, code, created_at) VALUES ($1, $2, $3, $4) " +
+		"ON CONFLICT (profile_id, favorite_type, code) DO NOTHING;"
+	_, err = f.conn.ExecContext(ctx, insertQuery, favorite.ProfileID, favorite.Type, favorite.Code, time.Now())
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (f *favoriteRepository) FetchByProfileID(ctx context.Context, profileID int64) ([]domain.Favorite, error) {
+	query := "SELECT id, favorite_type, code, created_at FROM favorite WHERE profile_id = $1 ORDER BY created_at, id"
+	rows, err := f.conn.QueryContext(ctx, query, profileID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	favorites := make([]domain.Favorite, 0)
+	for rows.Next() {
+		favorite := domain.Favorite{
+			ProfileID: profileID,
+		}
+		var favoriteType string
+		var createdAt sql.NullTime
+		if err := rows.Scan(&favorite.ID, &favoriteType, &favorite.Code, &createdAt); err != nil {
+			return nil, err
+		}
+		favorite.Type = domain.FavoriteType(favoriteType)
+		if createdAt.Valid {
+			favorite.CreatedAt = &createdAt.Time
+		}
+		favorites = append(favorites, favorite)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return favorites, nil
+}
@@ -12,12 +12,18 @@ type ProfileRepository interface {
 	ExistsWithTelegramID(ctx context.Context, telegramID int64) (bool, error)
 	FetchByTelegramID(ctx context.Context, telegramID int64) (*domain.Profile, error)
 	FetchByID(ctx context.Context, id int64) (*domain.Profile, error)
+	FetchByUsername(ctx context.Context, username string) (*domain.Profile, error)
+	FetchStats(ctx context.Context, since time.Time) (*domain.AdminStats, error)
 	SetPreferredCurrency(ctx context.Context, telegramID int64, preferredCurrency string) error
 	SetPreferredLanguage(ctx context.Context, telegramID int64, preferredLanguage string) error
 	TopUpBalanceByTelegramID(ctx context.Context, telegramID int64, amount float64) error
 	TopUpBalanceByProfileID(ctx context.Context, profileID int64, amount float64) error
 	Debit(ctx context.Context, telegramID int64, amount float64) error
+	DebitIfSufficient(ctx context.Context, telegramID int64, amount float64) (bool, error)
 	HasSufficientFunds(ctx context.Context, telegramID int64, amount float64) (bool, error)
+	MarkBotBlocked(ctx context.Context, profileID int64) error
+	MarkBotBlockedByChatID(ctx context.Context, chatID int64) error
+	MarkBotUnblocked(ctx context.Context, profileID int64) error
 }
 type profileRepository struct {
 	conn *sql.DB
@@ -55,7 +61,8 @@ func (p *profileRepository) ExistsWithTelegramID(ctx context.Context, telegramID
 }
 
 func (p *profileRepository) FetchByTelegramID(ctx context.Context, telegramID int64) (*domain.Profile, error) {
-	query := "SELECT id, telegram_chat_id, username, preferred_currency, preferred_language, balance, created_at, updated_at FROM profile WHERE telegram_id = $1"
+	query := "SELECT id, telegram_chat_id, username, preferred_currency, preferred_language, balance, role, " +
+		"bot_blocked_at, bot_unblocked_at, created_at, updated_at FROM profile WHERE telegram_id = $1"
 	row := p.conn.QueryRowContext(ctx, query, telegramID)
 	profile := domain.Profile{
 		TelegramID: telegramID,
@@ -63,6 +70,8 @@ func (p *profileRepository) FetchByTelegramID(ctx context.Context, telegramID in
 	}
 	var preferredCurrency sql.NullString
 	var preferredLanguage sql.NullString
+	var botBlockedAt sql.NullTime
+	var botUnblockedAt sql.NullTime
 	var updatedAt sql.NullTime
 
 	err := row.Scan(
@@ -72,6 +81,9 @@ func (p *profileRepository) FetchByTelegramID(ctx context.Context, telegramID in
 		&preferredCurrency,
 		&preferredLanguage,
 		&profile.Balance,
+		&profile.Role,
+		&botBlockedAt,
+		&botUnblockedAt,
 		&profile.CreatedAt,
 		&updatedAt,
 	)
@@ -81,6 +93,12 @@ func (p *profileRepository) FetchByTelegramID(ctx context.Context, telegramID in
 	if preferredLanguage.Valid {
 		profile.PreferredLanguage = &preferredLanguage.String
 	}
+	if botBlockedAt.Valid {
+		profile.BotBlockedAt = &botBlockedAt.Time
+	}
+	if botUnblockedAt.Valid {
+		profile.BotUnblockedAt = &botUnblockedAt.Time
+	}
 	if updatedAt.Valid {
 		profile.UpdatedAt = &updatedAt.Time
 	}
@@ -88,7 +106,8 @@ func (p *profileRepository) FetchByTelegramID(ctx context.Context, telegramID in
 }
 
 func (p *profileRepository) FetchByID(ctx context.Context, id int64) (*domain.Profile, error) {
-	query := "SELECT telegram_id, telegram_chat_id, username, preferred_currency, preferred_language, balance, created_at, updated_at FROM profile WHERE id = $1"
+	query := "SELECT telegram_id, telegram_chat_id, username, preferred_currency, preferred_language, balance, role, " +
+		"bot_blocked_at, bot_unblocked_at, created_at, updated_at FROM profile WHERE id = $1"
 	row := p.conn.QueryRowContext(ctx, query, id)
 	profile := domain.Profile{
 		ID:        id,
@@ -96,6 +115,8 @@ func (p *profileRepository) FetchByID(ctx context.Context, id int64) (*domain.Pr
 	}
 	var preferredCurrency sql.NullString
 	var preferredLanguage sql.NullString
+	var botBlockedAt sql.NullTime
+	var botUnblockedAt sql.NullTime
 	var updatedAt sql.NullTime
 
 	err := row.Scan(
@@ -105,6 +126,9 @@ func (p *profileRepository) FetchByID(ctx context.Context, id int64) (*domain.Pr
 		&preferredCurrency,
 		&preferredLanguage,
 		&profile.Balance,
+		&profile.Role,
+		&botBlockedAt,
+		&botUnblockedAt,
 		&profile.CreatedAt,
 		&updatedAt,
 	)
@@ -114,12 +138,46 @@ func (p *profileRepository) FetchByID(ctx context.Context, id int64) (*domain.Pr
 	if preferredLanguage.Valid {
 		profile.PreferredLanguage = &preferredLanguage.String
 	}
+	if botBlockedAt.Valid {
+		profile.BotBlockedAt = &botBlockedAt.Time
+	}
+	if botUnblockedAt.Valid {
+		profile.BotUnblockedAt = &botUnblockedAt.Time
+	}
 	if updatedAt.Valid {
 		profile.UpdatedAt = &updatedAt.Time
 	}
 	return &profile, err
 }
 
+func (p *profileRepository) FetchByUsername(ctx context.Context, username string) (*domain.Profile, error) {
+	query := "SELECT id FROM profile WHERE lower(username) = lower($1)"
+	var id int64
+	if err := p.conn.QueryRowContext(ctx, query, username).Scan(&id); err != nil {
+		return nil, err
+	}
+	return p.FetchByID(ctx, id)
+}
+
+// FetchStats fills the profile part of the stats, profiles created after since are counted as new.
+func (p *profileRepository) FetchStats(ctx context.Context, since time.Time) (*domain.AdminStats, error) {
+	query := "SELECT COUNT(*), COUNT(*) FILTER (WHERE created_at >= $1), COUNT(*) FILTER (WHERE bot_blocked_at IS NOT NULL), " +
+		"COALESCE(SUM(balance), 0) FROM profile WHERE deleted_at IS NULL"
+	stats := domain.AdminStats{
+		ActivationCounts: make(map[string]int64),
+	}
+	err := p.conn.QueryRowContext(ctx, query, since).Scan(
+		&stats.ProfilesCount,
+		&stats.NewProfilesCount,
+		&stats.BlockedBotProfilesCount,
+		&stats.TotalBalance,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
 func (p *profileRepository) SetPreferredCurrency(ctx context.Context, telegramID int64, preferredCurrency string) error {
 	query := "UPDATE profile SET preferred_currency = $1, updated_at = $2 WHERE telegram_id = $3"
 	_, err := p.conn.ExecContext(ctx, query, preferredCurrency, time.Now(), telegramID)
@@ -150,6 +208,21 @@ func (p *profileRepository) Debit(ctx context.Context, telegramID int64, amount
 	return err
 }
 
+// DebitIfSufficient withdraws the amount in a single statement only when the balance covers it,
+// so concurrent purchases can't take the balance below zero.
+func (p *profileRepository) DebitIfSufficient(ctx context.Context, telegramID int64, amount float64) (bool, error) {
+	query := "UPDATE profile SET balance = balance - $1, updated_at = $2 WHERE telegram_id = $3 AND balance >= $1"
+	result, err := p.conn.ExecContext(ctx, query, amount, time.Now(), telegramID)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
 func (p *profileRepository) HasSufficientFunds(ctx context.Context, telegramID int64, amount float64) (bool, error) {
 	query := "SELECT balance >= $1 FROM profile WHERE telegram_id = $2"
 	var satisfiesCondition bool
@@ -158,3 +231,26 @@ func (p *profileRepository) HasSufficientFunds(ctx context.Context, telegramID i
 	}
 	return satisfiesCondition, nil
 }
+
+// MarkBotBlocked remembers that the user has blocked the bot, broadcasts skip such profiles.
+// The time of the first failure is kept when the block is reported again.
+func (p *profileRepository) MarkBotBlocked(ctx context.Context, profileID int64) error {
+	query := "UPDATE profile SET bot_blocked_at = COALESCE(bot_blocked_at, $1), updated_at = $1 WHERE id = $2"
+	_, err := p.conn.ExecContext(ctx, query, time.Now(), profileID)
+	return err
+}
+
+// MarkBotBlockedByChatID remembers the block of the profile with the private chat, e.g. after telegram servers
+// have refused a message to it.
+func (p *profileRepository) MarkBotBlockedByChatID(ctx context.Context, chatID int64) error {
+	query := "UPDATE profile SET bot_blocked_at = COALESCE(bot_blocked_at, $1), updated_at = $1 WHERE telegram_chat_id = $2"
+	_, err := p.conn.ExecContext(ctx, query, time.Now(), chatID)
+	return err
+}
+
+// MarkBotUnblocked clears the block once the user has started the bot again.
+func (p *profileRepository) MarkBotUnblocked(ctx context.Context, profileID int64) error {
+	query := "UPDATE profile SET bot_blocked_at = NULL, bot_unblocked_at = $1, updated_at = $1 WHERE id = $2"
+	_, err := p.conn.ExecContext(ctx, query, time.Now(), profileID)
+	return err
+}
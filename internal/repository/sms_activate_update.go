@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-ton-pass-telegram-bot/internal/model/domain"
+	"time"
+)
+
+type SMSActivateUpdateRepository interface {
+	Record(ctx context.Context, update *domain.SMSActivateUpdate) (*domain.SMSActivateUpdate, error)
+	Claim(ctx context.Context, id int64) (bool, error)
+	Release(ctx context.Context, id int64) error
+}
+
+type smsActivateUpdateRepository struct {
+	conn *sql.DB
+}
+
+func NewSMSActivateUpdateRepository(conn *sql.DB) SMSActivateUpdateRepository {
+	return &smsActivateUpdateRepository{
+		conn: conn,
+	}
+}
+
+// Record stores the raw update once per activation id and received time and returns the stored row,
+// a redelivered update comes back as the same row and Claim decides which delivery serves it.
+func (s *smsActivateUpdateRepository) Record(ctx context.Context, update *domain.SMSActivateUpdate) (*domain.SMSActivateUpdate, error) {
+	query := "INSERT INTO sms_activate_update (activation_id, received_at, payload, created_at) VALUES ($1, $2, $3, $4) " +
+		"ON CONFLICT (activation_id, received_at) DO UPDATE SET activation_id = EXCLUDED.activation_id " +
+		"RETURNING id, processed_at, created_at;"
+	recordedUpdate := domain.SMSActivateUpdate{
+		ActivationID: update.ActivationID,
+		ReceivedAt:   update.ReceivedAt,
+		Payload:      update.Payload,
+	}
+	var processedAt sql.NullTime
+	var createdAt sql.NullTime
+	err := s.conn.QueryRowContext(
+		ctx,
+		query,
+		update.ActivationID,
+		update.ReceivedAt,
+		update.Payload,
+		time.Now(),
+	).Scan(&recordedUpdate.ID, &processedAt, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	if processedAt.Valid {
+		recordedUpdate.ProcessedAt = &processedAt.Time
+	}
+	if createdAt.Valid {
+		recordedUpdate.CreatedAt = &createdAt.Time
+	}
+	return &recordedUpdate, nil
+}
+
+// Claim marks the update processed unless it's already claimed, only one of concurrent deliveries of the same
+// update gets true and serves it.
+func (s *smsActivateUpdateRepository) Claim(ctx context.Context, id int64) (bool, error) {
+	query := "UPDATE sms_activate_update SET processed_at = $1 WHERE id = $2 AND processed_at IS NULL RETURNING id;"
+	var claimedID int64
+	err := s.conn.QueryRowContext(ctx, query, time.Now(), id).Scan(&claimedID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Release drops the claim of an update that has failed to be served, so a redelivery serves it again.
+func (s *smsActivateUpdateRepository) Release(ctx context.Context, id int64) error {
+	query := "UPDATE sms_activate_update SET processed_at = NULL WHERE id = $1"
+	_, err := s.conn.ExecContext(ctx, query, id)
+	return err
+}
@@ -15,6 +15,9 @@ type SMSHistoryRepository interface {
 	ReceiveSMSCode(ctx context.Context, smsHistory *domain.SMSHistory) error
 	GetNumberOfRows(ctx context.Context, profileID int64) (*int64, error)
 	FetchList(ctx context.Context, profileID int64, offset int, limit int) ([]domain.SMSHistory, error)
+	FetchByActivationGroupID(ctx context.Context, activationGroupID int64) ([]domain.SMSHistory, error)
+	FetchServicePurchaseCounts(ctx context.Context, since time.Time) ([]domain.ServicePurchaseCount, error)
+	FetchStatusCounts(ctx context.Context, since time.Time) (map[string]int64, error)
 }
 
 type smsHistoryRepository struct {
@@ -29,8 +32,12 @@ func NewSMSHistoryRepository(conn *sql.DB) SMSHistoryRepository {
 
 func (s *smsHistoryRepository) Create(ctx context.Context, smsHistory *domain.SMSHistory) (*int64, error) {
 	query := "INSERT INTO sms_history (profile_id, activation_id, service_code, service_name, country_id, country_name, " +
-		"phone_code_number, phone_short_number, status, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) " +
+		"phone_code_number, phone_short_number, status, activation_group_id, operator, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) " +
 		"RETURNING id;"
+	var activationGroupID sql.NullInt64
+	if smsHistory.ActivationGroupID != nil {
+		activationGroupID = sql.NullInt64{Int64: *smsHistory.ActivationGroupID, Valid: true}
+	}
 	var id int64
 	err := s.conn.QueryRowContext(
 		ctx,
@@ -44,6 +51,8 @@ func (s *smsHistoryRepository) Create(ctx context.Context, smsHistory *domain.SM
 		smsHistory.PhoneCodeNumber,
 		smsHistory.PhoneShortNumber,
 		smsHistory.Status,
+		activationGroupID,
+		smsHistory.Operator,
 		time.Now(),
 	).Scan(&id)
 	if err != nil {
@@ -59,15 +68,17 @@ func (s *smsHistoryRepository) ReceiveSMSCode(ctx context.Context, smsHistory *d
 }
 
 func (s *smsHistoryRepository) GetByActivationID(ctx context.Context, activationID int64) (*domain.SMSHistory, error) {
-	query := "SELECT id, profile_id, service_code, service_name, country_id, country_name, phone_code_number, phone_short_number, status, sms_text, sms_code, received_at, " +
-		"created_at, updated_at, deleted_at FROM sms_history WHERE activation_id = $1"
+	query := "SELECT id, profile_id, service_code, service_name, country_id, country_name, operator, phone_code_number, phone_short_number, status, sms_text, sms_code, received_at, " +
+		"activation_group_id, created_at, updated_at, deleted_at FROM sms_history WHERE activation_id = $1"
 	row := s.conn.QueryRowContext(ctx, query, activationID)
 	smsHistory := domain.SMSHistory{
 		ActivationID: activationID,
 	}
+	var operator sql.NullString
 	var smsText sql.NullString
 	var smsCode sql.NullString
 	var receivedAt sql.NullTime
+	var activationGroupID sql.NullInt64
 	var createdAt sql.NullTime
 	var updatedAt sql.NullTime
 	var deletedAt sql.NullTime
@@ -79,16 +90,24 @@ func (s *smsHistoryRepository) GetByActivationID(ctx context.Context, activation
 		&smsHistory.ServiceName,
 		&smsHistory.CountryID,
 		&smsHistory.CountryName,
+		&operator,
 		&smsHistory.PhoneCodeNumber,
 		&smsHistory.PhoneShortNumber,
 		&smsHistory.Status,
 		&smsText,
 		&smsCode,
 		&receivedAt,
+		&activationGroupID,
 		&createdAt,
 		&updatedAt,
 		&deletedAt,
 	)
+	if activationGroupID.Valid {
+		smsHistory.ActivationGroupID = &activationGroupID.Int64
+	}
+	if operator.Valid {
+		smsHistory.Operator = &operator.String
+	}
 	if smsText.Valid {
 		smsHistory.SMSText = &smsText.String
 	}
@@ -127,7 +146,7 @@ func (s *smsHistoryRepository) GetNumberOfRows(ctx context.Context, profileID in
 }
 
 func (s *smsHistoryRepository) FetchList(ctx context.Context, profileID int64, offset int, limit int) ([]domain.SMSHistory, error) {
-	query := "SELECT id, profile_id, activation_id, service_code, service_name, country_id, country_name, phone_code_number, phone_short_number, status, sms_text, sms_code, received_at, created_at, updated_at, deleted_at " +
+	query := "SELECT id, profile_id, activation_id, service_code, service_name, country_id, country_name, operator, phone_code_number, phone_short_number, status, sms_text, sms_code, received_at, created_at, updated_at, deleted_at " +
 		"FROM sms_history WHERE profile_id = $1  ORDER BY created_at DESC LIMIT $2 OFFSET $3"
 	rows, err := s.conn.QueryContext(ctx, query, profileID, limit, offset)
 	if err != nil {
@@ -137,6 +156,7 @@ func (s *smsHistoryRepository) FetchList(ctx context.Context, profileID int64, o
 	list := make([]domain.SMSHistory, 0, limit)
 	for rows.Next() {
 		smsHistory := domain.SMSHistory{}
+		var operator sql.NullString
 		var smsText sql.NullString
 		var smsCode sql.NullString
 		var receivedAt sql.NullTime
@@ -151,6 +171,7 @@ func (s *smsHistoryRepository) FetchList(ctx context.Context, profileID int64, o
 			&smsHistory.ServiceName,
 			&smsHistory.CountryID,
 			&smsHistory.CountryName,
+			&operator,
 			&smsHistory.PhoneCodeNumber,
 			&smsHistory.PhoneShortNumber,
 			&smsHistory.Status,
@@ -164,6 +185,9 @@ func (s *smsHistoryRepository) FetchList(ctx context.Context, profileID int64, o
 		if err != nil {
 			continue
 		}
+		if operator.Valid {
+			smsHistory.Operator = &operator.String
+		}
 		if smsText.Valid {
 			smsHistory.SMSText = &smsText.String
 		}
@@ -186,3 +210,124 @@ func (s *smsHistoryRepository) FetchList(ctx context.Context, profileID int64, o
 	}
 	return list, nil
 }
+
+func (s *smsHistoryRepository) FetchByActivationGroupID(ctx context.Context, activationGroupID int64) ([]domain.SMSHistory, error) {
+	query := "SELECT id, profile_id, activation_id, service_code, service_name, country_id, country_name, operator, phone_code_number, phone_short_number, status, sms_text, sms_code, received_at, created_at, updated_at, deleted_at " +
+		"FROM sms_history WHERE activation_group_id = $1 ORDER BY id"
+	rows, err := s.conn.QueryContext(ctx, query, activationGroupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := make([]domain.SMSHistory, 0)
+	for rows.Next() {
+		smsHistory := domain.SMSHistory{
+			ActivationGroupID: &activationGroupID,
+		}
+		var operator sql.NullString
+		var smsText sql.NullString
+		var smsCode sql.NullString
+		var receivedAt sql.NullTime
+		var createdAt sql.NullTime
+		var updatedAt sql.NullTime
+		var deletedAt sql.NullTime
+		err := rows.Scan(
+			&smsHistory.ID,
+			&smsHistory.ProfileID,
+			&smsHistory.ActivationID,
+			&smsHistory.ServiceCode,
+			&smsHistory.ServiceName,
+			&smsHistory.CountryID,
+			&smsHistory.CountryName,
+			&operator,
+			&smsHistory.PhoneCodeNumber,
+			&smsHistory.PhoneShortNumber,
+			&smsHistory.Status,
+			&smsText,
+			&smsCode,
+			&receivedAt,
+			&createdAt,
+			&updatedAt,
+			&deletedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if operator.Valid {
+			smsHistory.Operator = &operator.String
+		}
+		if smsText.Valid {
+			smsHistory.SMSText = &smsText.String
+		}
+		if smsCode.Valid {
+			smsHistory.SMSCode = &smsCode.String
+		}
+		if receivedAt.Valid {
+			smsHistory.ReceivedAt = &receivedAt.Time
+		}
+		if createdAt.Valid {
+			smsHistory.CreatedAt = &createdAt.Time
+		}
+		if updatedAt.Valid {
+			smsHistory.UpdatedAt = &updatedAt.Time
+		}
+		if deletedAt.Valid {
+			smsHistory.DeletedAt = &deletedAt.Time
+		}
+		list = append(list, smsHistory)
+	}
+	return list, rows.Err()
+}
+
+// FetchServicePurchaseCounts counts purchased numbers per service and preferred language of buyers since the time,
+// cancelled activations are refunded, so they are not counted.
+func (s *smsHistoryRepository) FetchServicePurchaseCounts(ctx context.Context, since time.Time) ([]domain.ServicePurchaseCount, error) {
+	query := "SELECT p.preferred_language, h.service_code, COUNT(*) FROM sms_history h " +
+		"JOIN profile p ON p.id = h.profile_id " +
+		"WHERE h.created_at >= $1 AND h.service_code IS NOT NULL AND (h.status IS NULL OR h.status <> $2) " +
+		"GROUP BY p.preferred_language, h.service_code"
+	rows, err := s.conn.QueryContext(ctx, query, since, string(app.CancelSMSActivateState))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	servicePurchaseCounts := make([]domain.ServicePurchaseCount, 0)
+	for rows.Next() {
+		var servicePurchaseCount domain.ServicePurchaseCount
+		var languageCode sql.NullString
+		if err := rows.Scan(&languageCode, &servicePurchaseCount.ServiceCode, &servicePurchaseCount.Count); err != nil {
+			return nil, err
+		}
+		if languageCode.Valid {
+			servicePurchaseCount.LanguageCode = &languageCode.String
+		}
+		servicePurchaseCounts = append(servicePurchaseCounts, servicePurchaseCount)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return servicePurchaseCounts, nil
+}
+
+// FetchStatusCounts counts activations started since the time by their status.
+func (s *smsHistoryRepository) FetchStatusCounts(ctx context.Context, since time.Time) (map[string]int64, error) {
+	query := "SELECT COALESCE(status, $2), COUNT(*) FROM sms_history WHERE created_at >= $1 GROUP BY COALESCE(status, $2)"
+	rows, err := s.conn.QueryContext(ctx, query, since, string(app.UnknownSMSActivateState))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	statusCounts := make(map[string]int64)
+	for rows.Next() {
+		var status string
+		var count int64
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		statusCounts[status] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return statusCounts, nil
+}
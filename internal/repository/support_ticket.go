@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-ton-pass-telegram-bot/internal/model/domain"
+	"time"
+)
+
+type SupportTicketRepository interface {
+	FetchOrCreateActive(ctx context.Context, profileID int64) (*domain.SupportTicket, bool, error)
+	FetchActiveByProfileID(ctx context.Context, profileID int64) (*domain.SupportTicket, error)
+	FetchBySupportMessageID(ctx context.Context, supportMessageID int64) (*domain.SupportTicket, error)
+	ChangeStatus(ctx context.Context, id int64, status domain.SupportTicketStatus) error
+	CreateMessage(ctx context.Context, supportMessage *domain.SupportMessage) (*int64, error)
+}
+
+type supportTicketRepository struct {
+	conn *sql.DB
+}
+
+func NewSupportTicketRepository(conn *sql.DB) SupportTicketRepository {
+	return &supportTicketRepository{
+		conn: conn,
+	}
+}
+
+// FetchOrCreateActive returns the ticket of the profile that isn't closed and reports whether it has been created,
+// a profile has one such ticket at most.
+func (s *supportTicketRepository) FetchOrCreateActive(ctx context.Context, profileID int64) (*domain.SupportTicket, bool, error) {
+	query := "INSERT INTO support_ticket (profile_id, status, created_at) VALUES ($1, $2, $3) " +
+		"ON CONFLICT (profile_id) WHERE status <> 'closed' DO NOTHING RETURNING id, created_at;"
+	supportTicket := domain.SupportTicket{
+		ProfileID: profileID,
+		Status:    domain.OpenSupportTicketStatus,
+		CreatedAt: new(time.Time),
+	}
+	err := s.conn.QueryRowContext(ctx, query, profileID, supportTicket.Status, time.Now()).
+		Scan(&supportTicket.ID, supportTicket.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		activeSupportTicket, err := s.FetchActiveByProfileID(ctx, profileID)
+		return activeSupportTicket, false, err
+	} else if err != nil {
+		return nil, false, err
+	}
+	return &supportTicket, true, nil
+}
+
+func (s *supportTicketRepository) FetchActiveByProfileID(ctx context.Context, profileID int64) (*domain.SupportTicket, error) {
+	query := "SELECT id, profile_id, status, created_at, updated_at, closed_at FROM support_ticket " +
+		"WHERE profile_id = $1 AND status <> $2"
+	return s.fetch(ctx, query, profileID, domain.ClosedSupportTicketStatus)
+}
+
+// FetchBySupportMessageID finds the ticket of the message in the support chat, agents answer replying to it.
+func (s *supportTicketRepository) FetchBySupportMessageID(ctx context.Context, supportMessageID int64) (*domain.SupportTicket, error) {
+	query := "SELECT t.id, t.profile_id, t.status, t.created_at, t.updated_at, t.closed_at FROM support_ticket t " +
+		"JOIN support_message m ON m.ticket_id = t.id WHERE m.support_message_id = $1 ORDER BY m.id DESC LIMIT 1"
+	return s.fetch(ctx, query, supportMessageID)
+}
+
+func (s *supportTicketRepository) ChangeStatus(ctx context.Context, id int64, status domain.SupportTicketStatus) error {
+	now := time.Now()
+	var closedAt *time.Time
+	if status == domain.ClosedSupportTicketStatus {
+		closedAt = &now
+	}
+	query := "UPDATE support_ticket SET status = $1, updated_at = $2, closed_at = $3 WHERE id = $4"
+	_, err := s.conn.ExecContext(ctx, query, status, now, closedAt, id)
+	return err
+}
+
+func (s *supportTicketRepository) CreateMessage(ctx context.Context, supportMessage *domain.SupportMessage) (*int64, error) {
+	query := "INSERT INTO support_message (ticket_id, sender, sender_telegram_id, text, user_message_id, " +
+		"support_message_id, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id;"
+	var id int64
+	err := s.conn.QueryRowContext(
+		ctx,
+		query,
+		supportMessage.TicketID,
+		supportMessage.Sender,
+		supportMessage.SenderTelegramID,
+		supportMessage.Text,
+		supportMessage.UserMessageID,
+		supportMessage.SupportMessageID,
+		time.Now(),
+	).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (s *supportTicketRepository) fetch(ctx context.Context, query string, args ...any) (*domain.SupportTicket, error) {
+	supportTicket := domain.SupportTicket{
+		CreatedAt: new(time.Time),
+	}
+	var updatedAt, closedAt sql.NullTime
+	err := s.conn.QueryRowContext(ctx, query, args...).Scan(
+		&supportTicket.ID,
+		&supportTicket.ProfileID,
+		&supportTicket.Status,
+		supportTicket.CreatedAt,
+		&updatedAt,
+		&closedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if updatedAt.Valid {
+		supportTicket.UpdatedAt = &updatedAt.Time
+	}
+	if closedAt.Valid {
+		supportTicket.ClosedAt = &closedAt.Time
+	}
+	return &supportTicket, nil
+}
@@ -7,6 +7,7 @@ import (
 	"go-ton-pass-telegram-bot/internal/controller/sms"
 	telegramController "go-ton-pass-telegram-bot/internal/controller/telegram"
 	"go-ton-pass-telegram-bot/internal/middleware"
+	"go-ton-pass-telegram-bot/internal/model/app"
 	"go-ton-pass-telegram-bot/internal/repository"
 	"go-ton-pass-telegram-bot/internal/service"
 	"go-ton-pass-telegram-bot/internal/service/postpone"
@@ -16,6 +17,7 @@ import (
 
 func PrepareAndConfigureRouter(
 	container container.Container,
+	telegramBotService service.TelegramBotService,
 	sessionService service.SessionService,
 	cacheService service.Cache,
 	smsService service.SMSService,
@@ -24,16 +26,26 @@ func PrepareAndConfigureRouter(
 	smsHistoryRepository repository.SMSHistoryRepository,
 	temporalWorkflowRepository repository.TemporalWorkflowRepository,
 	telegramPaymentRepository repository.TelegramPaymentRepository,
+	smsActivateUpdateRepository repository.SMSActivateUpdateRepository,
+	activationGroupRepository repository.ActivationGroupRepository,
+	favoriteRepository repository.FavoriteRepository,
+	catalogRepository repository.CatalogRepository,
+	adminAuditLogRepository repository.AdminAuditLogRepository,
+	broadcastRepository repository.BroadcastRepository,
+	supportTicketRepository repository.SupportTicketRepository,
 ) http.Handler {
 	router := mux.NewRouter()
-	telegramService := service.NewTelegramBot(container)
 	authenticationMiddleware := middleware.NewAuthentication(container, profileRepository)
-	subscriptionMiddleware := middleware.NewSubscription(container, telegramService)
+	channelSubscription := service.NewChannelSubscription(container, telegramBotService, cacheService)
+	channelMemberMiddleware := middleware.NewChannelMember(container, channelSubscription)
+	subscriptionMiddleware := middleware.NewSubscription(container, channelSubscription)
+	telegramWebhookMiddleware := middleware.NewTelegramWebhook(container)
 	telegramParserMiddleware := middleware.NewTelegramParser(container)
 	cryptoPayBot := service.NewCryptoPayBot(container)
 	exchangeRate := worker.NewExchangeRate(container, cacheService, cryptoPayBot)
 	telegramBotController := telegramController.NewBotController(
 		container,
+		telegramBotService,
 		sessionService,
 		cacheService,
 		smsService,
@@ -44,27 +56,46 @@ func PrepareAndConfigureRouter(
 		exchangeRate,
 		temporalWorkflowRepository,
 		telegramPaymentRepository,
+		activationGroupRepository,
+		favoriteRepository,
+		catalogRepository,
+		adminAuditLogRepository,
+		broadcastRepository,
+		supportTicketRepository,
 	)
 	cryptoController := crypto.NewCryptoController(
 		container,
+		telegramBotService,
 		sessionService,
 		profileRepository,
 	)
 	router.HandleFunc("/ping", PingServe)
-	smsActivateController := sms.NewSMSActivateController(container, profileRepository, smsHistoryRepository)
-	telegramRouter := NewTelegramRouter(container, telegramBotController, exchangeRate)
+	smsActivateController := sms.NewSMSActivateController(
+		container,
+		telegramBotService,
+		profileRepository,
+		smsHistoryRepository,
+		smsActivateUpdateRepository,
+		activationGroupRepository,
+	)
+	smsActivateWebhookMiddleware := middleware.NewSMSActivateWebhook(container)
+	telegramRouter := NewTelegramRouter(container, telegramBotController, exchangeRate, telegramBotService)
 	router.Handle(
-		"/telegram/handler/webhook",
-		telegramParserMiddleware.Handler(
-			authenticationMiddleware.Handler(
-				subscriptionMiddleware.Handler(telegramRouter),
+		app.TelegramWebhookPath,
+		telegramWebhookMiddleware.Handler(
+			telegramParserMiddleware.Handler(
+				channelMemberMiddleware.Handler(
+					authenticationMiddleware.Handler(
+						subscriptionMiddleware.Handler(telegramRouter),
+					),
+				),
 			),
 		),
 	)
 	cryptoRouter := NewCryptoBotRouter(container, cryptoController)
 	router.Handle("/telegram/crypto_bot/webhook", cryptoRouter)
 	smsActivateRouter := NewSMSActivateRouter(container, smsActivateController)
-	router.Handle("/sms_activate/webhook", smsActivateRouter)
+	router.Handle("/sms_activate/webhook/{token}", smsActivateWebhookMiddleware.Handler(smsActivateRouter))
 
 	return router
 }
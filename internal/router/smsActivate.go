@@ -24,13 +24,10 @@ func NewSMSActivateRouter(container container.Container, controller sms.SMSActiv
 }
 
 func (s *SMSActivate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	defer func() {
-		w.WriteHeader(http.StatusInternalServerError)
-	}()
 	log := s.container.GetLogger()
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		log.Fatal("can't read body", logger.FError(err))
+		log.Error("can't read body", logger.FError(err))
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
@@ -38,19 +35,19 @@ func (s *SMSActivate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	var update smsModel.WebhookUpdates
 	if err := json.Unmarshal(body, &update); err != nil {
 		if err := filterSMSActivateErrors(err); err != nil {
-			log.Fatal("fail to decode", logger.FError(err))
+			log.Error("fail to decode", logger.FError(err))
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
 		w.WriteHeader(http.StatusOK)
 		return
 	}
-	err = s.controller.Serve(&update)
-	if err != nil {
-		log.Fatal("controller has failed", logger.FError(err))
+	if err := s.controller.Serve(&update, body); err != nil {
+		log.Error("controller has failed", logger.FError(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	w.WriteHeader(http.StatusOK)
 }
 
 func filterSMSActivateErrors(err error) error {
@@ -1,6 +1,7 @@
 package router
 
 import (
+	"go-ton-pass-telegram-bot/internal/config"
 	"go-ton-pass-telegram-bot/internal/container"
 	telegramController "go-ton-pass-telegram-bot/internal/controller/telegram"
 	"go-ton-pass-telegram-bot/internal/manager"
@@ -15,6 +16,7 @@ import (
 type TelegramRouter struct {
 	container          container.Container
 	exchangeRateWorker worker.ExchangeRate
+	callbackDataStore  manager.CallbackDataStore
 	controller         telegramController.BotController
 }
 
@@ -22,10 +24,12 @@ func NewTelegramRouter(
 	container container.Container,
 	telegramBotController telegramController.BotController,
 	exchangeRateWorker worker.ExchangeRate,
+	callbackDataStore manager.CallbackDataStore,
 ) *TelegramRouter {
 	return &TelegramRouter{
 		container:          container,
 		exchangeRateWorker: exchangeRateWorker,
+		callbackDataStore:  callbackDataStore,
 		controller:         telegramBotController,
 	}
 }
@@ -38,7 +42,7 @@ func (t *TelegramRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	update := r.Context().Value(app.UpdateContextKey).(*telegram.Update)
 	profile := r.Context().Value(app.ProfileContextKey).(*domain.Profile)
-	isMemberSubscription := r.Context().Value(app.IsProfileSubscription).(bool)
+	missingChannels := r.Context().Value(app.MissingRequiredChannelsContextKey).([]config.RequiredChannel)
 
 	var languageTag = "en"
 	if profile.PreferredLanguage != nil {
@@ -47,17 +51,18 @@ func (t *TelegramRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	telegramInlineKeyboardManager := manager.NewTelegramInlineKeyboardManager(
 		t.container,
 		t.exchangeRateWorker,
+		t.callbackDataStore,
 	)
 	telegramInlineKeyboardManager.Set(languageTag)
 	ctxOptions := telegramController.ContextOptions{
 		TelegramInlineKeyboardManager: telegramInlineKeyboardManager,
 		Update:                        update,
 		Profile:                       profile,
-		IsMemberSubscription:          isMemberSubscription,
+		MissingChannels:               missingChannels,
 	}
-	err := t.controller.Serve(&ctxOptions)
+	err := t.controller.Serve(r.Context(), &ctxOptions)
 	if err != nil {
-		log.Fatal("fail to processing message from bot", logger.FError(err))
+		log.Error("fail to processing message from bot", logger.FError(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/redis/go-redis/v9"
 	"go-ton-pass-telegram-bot/internal/container"
@@ -9,30 +10,70 @@ import (
 	"go-ton-pass-telegram-bot/internal/model/sms"
 	"go-ton-pass-telegram-bot/internal/utils"
 	"go-ton-pass-telegram-bot/pkg/logger"
+	"sort"
+	"strings"
 	"time"
 )
 
 type Cache interface {
 	SaveExchangeRate(ctx context.Context, exchangeRates []app.ExchangeRate) error
 	GetExchangeRate(ctx context.Context) (*app.CacheResponse[[]app.ExchangeRate], error)
-	SaveSMSCountries(ctx context.Context, services []sms.Country) error
-	GetSMSCountries(ctx context.Context) (*app.CacheResponse[[]sms.Country], error)
-	SaveSMSServices(ctx context.Context, services []sms.Service) error
-	GetSMSServices(ctx context.Context) (*app.CacheResponse[[]sms.Service], error)
+	SaveSMSOperators(ctx context.Context, countryID int64, operators []string) error
+	GetSMSOperators(ctx context.Context, countryID int64) (*app.CacheResponse[[]string], error)
+	SaveSMSOperatorPrices(ctx context.Context, serviceCode string, countryID int64, operatorPrices []sms.OperatorPrice) error
+	GetSMSOperatorPrices(ctx context.Context, serviceCode string, countryID int64) (*app.CacheResponse[[]sms.OperatorPrice], error)
 	SetLastCallbackQueryCommand(ctx context.Context, callbackQueryCommand app.CallbackQueryCommand, telegramMessagingInfo TelegramMessagingInfo) error
 	GetLastCallbackQueryCommand(ctx context.Context, telegramMessagingInfo TelegramMessagingInfo) (*app.CallbackQueryCommand, error)
 	SaveTelegramCallbackData(ctx context.Context, callbackData []app.TelegramCallbackData, telegramMessagingInfo TelegramMessagingInfo) error
 	GetTelegramCallbackData(ctx context.Context, telegramMessagingInfo TelegramMessagingInfo) ([]app.TelegramCallbackData, error)
+	SwapProviderLowBalance(ctx context.Context, provider string, isLow bool) (bool, error)
+	IsProviderLowBalance(ctx context.Context, provider string) (bool, error)
+	SetPurchasesSuspended(ctx context.Context, isSuspended bool) error
+	IsPurchasesSuspended(ctx context.Context) (bool, error)
+	SaveServicePopularity(ctx context.Context, languageCode string, serviceCodes []string) error
+	GetServicePopularity(ctx context.Context, languageCode string) (*app.CacheResponse[[]string], error)
+	IncrementCatalogVersion(ctx context.Context) (int64, error)
+	GetCatalogVersion(ctx context.Context) (int64, error)
+	SaveTelegramUpdatesOffset(ctx context.Context, offset int64) error
+	GetTelegramUpdatesOffset(ctx context.Context) (int64, error)
+	SaveOutboundMessage(ctx context.Context, outboundMessage app.OutboundMessage, owner string, leaseTTL time.Duration) error
+	DeleteOutboundMessage(ctx context.Context, id string) error
+	ClaimOutboundMessages(ctx context.Context, owner string, leaseTTL time.Duration) ([]app.OutboundMessage, error)
+	LeaseOutboundMessages(ctx context.Context, ids []string, owner string, leaseTTL time.Duration) ([]string, error)
+	ReleaseOutboundMessages(ctx context.Context, ids []string) error
+	SaveCallbackData(ctx context.Context, encodedCallbackData map[string]string, ttl time.Duration) error
+	GetCallbackData(ctx context.Context, token string) (*string, error)
+	SaveChannelMembership(ctx context.Context, chat string, telegramID int64, ttl time.Duration) error
+	HasChannelMembership(ctx context.Context, chat string, telegramID int64) (bool, error)
+	DeleteChannelMembership(ctx context.Context, chat string, telegramID int64) error
 }
 
 const (
 	exchangeRateCacheKey             = "exchangeRateCacheKey"
-	smsCountriesCacheKey             = "smsCountriesCacheKey"
-	smsServicesCacheKey              = "smsServicesCacheKey"
 	telegramCallbackDataCacheKey     = "telegramCallbackDataCacheKey"
 	lastCallbackQueryCommandCacheKey = "lastCallbackQueryCommandCacheKey"
+	providerLowBalanceCacheKey       = "providerLowBalanceCacheKey"
+	purchasesSuspendedCacheKey       = "purchasesSuspendedCacheKey"
+	smsOperatorsCacheKey             = "smsOperatorsCacheKey"
+	smsOperatorPricesCacheKey        = "smsOperatorPricesCacheKey"
+	servicePopularityCacheKey        = "servicePopularityCacheKey"
+	catalogVersionCacheKey           = "catalogVersionCacheKey"
+	telegramUpdatesOffsetCacheKey    = "telegramUpdatesOffsetCacheKey"
+	outboundMessagesCacheKey         = "outboundMessagesCacheKey"
+	outboundMessageLeaseCacheKey     = "outboundMessageLeaseCacheKey"
+	callbackDataCacheKey             = "callbackDataCacheKey"
+	channelMembershipCacheKey        = "channelMembershipCacheKey"
 )
 
+const (
+	smsOperatorsCacheTTL      = 6 * time.Hour
+	smsOperatorPricesCacheTTL = 5 * time.Minute
+	servicePopularityCacheTTL = 24 * time.Hour
+)
+
+// globalServicePopularityLanguageCode is used as the language of the ranking built from all purchases
+const globalServicePopularityLanguageCode = "global"
+
 type cache struct {
 	container container.Container
 	client    *redis.Client
@@ -80,58 +121,64 @@ func (c *cache) GetExchangeRate(ctx context.Context) (*app.CacheResponse[[]app.E
 	return &exchangeRates, nil
 }
 
-func (c *cache) SaveSMSCountries(ctx context.Context, countries []sms.Country) error {
+func (c *cache) SaveSMSOperators(ctx context.Context, countryID int64, operators []string) error {
 	log := c.container.GetLogger()
-	log.Debug("will to save sms countries rate")
-	var cacheResponse app.CacheResponse[[]sms.Country]
-	cacheResponse.Result = countries
+	log.Debug("will save sms operators", logger.F("country_id", countryID))
+	var cacheResponse app.CacheResponse[[]string]
+	cacheResponse.Result = operators
 	cacheResponse.TimeFetched = time.Now()
 	encodedData, err := utils.EncodePayload(&cacheResponse)
 	if err != nil {
 		log.Debug("fail to encode payload", logger.FError(err))
 		return err
 	}
-	return c.client.Set(ctx, smsCountriesCacheKey, encodedData, 0).Err()
+	key := fmt.Sprintf("%s:%d", smsOperatorsCacheKey, countryID)
+	return c.client.Set(ctx, key, encodedData, smsOperatorsCacheTTL).Err()
 }
 
-func (c *cache) GetSMSCountries(ctx context.Context) (*app.CacheResponse[[]sms.Country], error) {
+func (c *cache) GetSMSOperators(ctx context.Context, countryID int64) (*app.CacheResponse[[]string], error) {
 	log := c.container.GetLogger()
-	log.Debug("will get sms countries")
-	encodedText, err := c.client.Get(ctx, smsCountriesCacheKey).Result()
+	log.Debug("will get sms operators", logger.F("country_id", countryID))
+	key := fmt.Sprintf("%s:%d", smsOperatorsCacheKey, countryID)
+	encodedText, err := c.client.Get(ctx, key).Result()
 	if err != nil {
-		log.Debug("fail to get sms countries from cache", logger.FError(err))
+		log.Debug("fail to get sms operators from cache", logger.FError(err))
 		return nil, err
 	}
-	var cacheResponse app.CacheResponse[[]sms.Country]
+	var cacheResponse app.CacheResponse[[]string]
 	if err := utils.DecodePayload(encodedText, &cacheResponse); err != nil {
 		return nil, err
 	}
 	return &cacheResponse, nil
 }
 
-func (c *cache) SaveSMSServices(ctx context.Context, services []sms.Service) error {
+// SaveSMSOperatorPrices keeps prices of operators for a short time, the price to pay is resolved again
+// on the confirmation screen.
+func (c *cache) SaveSMSOperatorPrices(ctx context.Context, serviceCode string, countryID int64, operatorPrices []sms.OperatorPrice) error {
 	log := c.container.GetLogger()
-	log.Debug("will save sms services")
-	var cacheResponse app.CacheResponse[[]sms.Service]
-	cacheResponse.Result = services
+	log.Debug("will save sms operator prices", logger.F("service_code", serviceCode), logger.F("country_id", countryID))
+	var cacheResponse app.CacheResponse[[]sms.OperatorPrice]
+	cacheResponse.Result = operatorPrices
 	cacheResponse.TimeFetched = time.Now()
 	encodedData, err := utils.EncodePayload(&cacheResponse)
 	if err != nil {
 		log.Debug("fail to encode payload", logger.FError(err))
 		return err
 	}
-	return c.client.Set(ctx, smsServicesCacheKey, encodedData, 0).Err()
+	key := fmt.Sprintf("%s:%s:%d", smsOperatorPricesCacheKey, serviceCode, countryID)
+	return c.client.Set(ctx, key, encodedData, smsOperatorPricesCacheTTL).Err()
 }
 
-func (c *cache) GetSMSServices(ctx context.Context) (*app.CacheResponse[[]sms.Service], error) {
+func (c *cache) GetSMSOperatorPrices(ctx context.Context, serviceCode string, countryID int64) (*app.CacheResponse[[]sms.OperatorPrice], error) {
 	log := c.container.GetLogger()
-	log.Debug("will get sms countries")
-	encodedText, err := c.client.Get(ctx, smsServicesCacheKey).Result()
+	log.Debug("will get sms operator prices", logger.F("service_code", serviceCode), logger.F("country_id", countryID))
+	key := fmt.Sprintf("%s:%s:%d", smsOperatorPricesCacheKey, serviceCode, countryID)
+	encodedText, err := c.client.Get(ctx, key).Result()
 	if err != nil {
-		log.Debug("fail to get sms services from cache", logger.FError(err))
+		log.Debug("fail to get sms operator prices from cache", logger.FError(err))
 		return nil, err
 	}
-	var cacheResponse app.CacheResponse[[]sms.Service]
+	var cacheResponse app.CacheResponse[[]sms.OperatorPrice]
 	if err := utils.DecodePayload(encodedText, &cacheResponse); err != nil {
 		return nil, err
 	}
@@ -205,6 +252,42 @@ func (c *cache) GetLastCallbackQueryCommand(ctx context.Context, telegramMessagi
 	return &lastCallbackQueryCommand, nil
 }
 
+// SwapProviderLowBalance stores the low balance flag of the provider and returns the previous one.
+func (c *cache) SwapProviderLowBalance(ctx context.Context, provider string, isLow bool) (bool, error) {
+	key := fmt.Sprintf("%s/%s", providerLowBalanceCacheKey, provider)
+	wasLow, err := c.client.GetSet(ctx, key, isLow).Bool()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	return wasLow, err
+}
+
+func (c *cache) IsProviderLowBalance(ctx context.Context, provider string) (bool, error) {
+	key := fmt.Sprintf("%s/%s", providerLowBalanceCacheKey, provider)
+	isLow, err := c.client.Get(ctx, key).Bool()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	return isLow, err
+}
+
+func (c *cache) SetPurchasesSuspended(ctx context.Context, isSuspended bool) error {
+	log := c.container.GetLogger()
+	log.Debug("will change purchases suspension", logger.F("is_suspended", isSuspended))
+	if !isSuspended {
+		return c.client.Del(ctx, purchasesSuspendedCacheKey).Err()
+	}
+	return c.client.Set(ctx, purchasesSuspendedCacheKey, true, 0).Err()
+}
+
+func (c *cache) IsPurchasesSuspended(ctx context.Context) (bool, error) {
+	isSuspended, err := c.client.Get(ctx, purchasesSuspendedCacheKey).Bool()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	return isSuspended, err
+}
+
 func keyForTelegramMessagingInfo(key string, telegramMessagingInfo TelegramMessagingInfo) string {
 	return fmt.Sprintf(
 		"%s/%d_%d",
@@ -213,3 +296,245 @@ func keyForTelegramMessagingInfo(key string, telegramMessagingInfo TelegramMessa
 		telegramMessagingInfo.MessageID,
 	)
 }
+
+// SaveServicePopularity stores service codes ordered by purchases, an empty language code keeps the global ranking.
+func (c *cache) SaveServicePopularity(ctx context.Context, languageCode string, serviceCodes []string) error {
+	log := c.container.GetLogger()
+	log.Debug("will save service popularity", logger.F("language_code", languageCode))
+	var cacheResponse app.CacheResponse[[]string]
+	cacheResponse.Result = serviceCodes
+	cacheResponse.TimeFetched = time.Now()
+	encodedData, err := utils.EncodePayload(&cacheResponse)
+	if err != nil {
+		log.Debug("fail to encode payload", logger.FError(err))
+		return err
+	}
+	return c.client.Set(ctx, servicePopularityKey(languageCode), encodedData, servicePopularityCacheTTL).Err()
+}
+
+func (c *cache) GetServicePopularity(ctx context.Context, languageCode string) (*app.CacheResponse[[]string], error) {
+	log := c.container.GetLogger()
+	log.Debug("will get service popularity", logger.F("language_code", languageCode))
+	encodedText, err := c.client.Get(ctx, servicePopularityKey(languageCode)).Result()
+	if err != nil {
+		log.Debug("fail to get service popularity from cache", logger.FError(err))
+		return nil, err
+	}
+	var cacheResponse app.CacheResponse[[]string]
+	if err := utils.DecodePayload(encodedText, &cacheResponse); err != nil {
+		return nil, err
+	}
+	return &cacheResponse, nil
+}
+
+func servicePopularityKey(languageCode string) string {
+	if languageCode == "" {
+		languageCode = globalServicePopularityLanguageCode
+	}
+	return fmt.Sprintf("%s:%s", servicePopularityCacheKey, languageCode)
+}
+
+// IncrementCatalogVersion tells every bot instance that the catalog or its ranking has changed
+// and the in-memory catalog index must be rebuilt.
+func (c *cache) IncrementCatalogVersion(ctx context.Context) (int64, error) {
+	log := c.container.GetLogger()
+	log.Debug("will increment catalog version")
+	return c.client.Incr(ctx, catalogVersionCacheKey).Result()
+}
+
+// GetCatalogVersion returns zero until the catalog version has been incremented for the first time.
+func (c *cache) GetCatalogVersion(ctx context.Context) (int64, error) {
+	version, err := c.client.Get(ctx, catalogVersionCacheKey).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return version, err
+}
+
+// SaveTelegramUpdatesOffset keeps the offset of the next update to poll, so a restarted bot doesn't handle
+// updates twice.
+func (c *cache) SaveTelegramUpdatesOffset(ctx context.Context, offset int64) error {
+	return c.client.Set(ctx, telegramUpdatesOffsetCacheKey, offset, 0).Err()
+}
+
+// GetTelegramUpdatesOffset returns zero when no update has been polled yet.
+func (c *cache) GetTelegramUpdatesOffset(ctx context.Context) (int64, error) {
+	offset, err := c.client.Get(ctx, telegramUpdatesOffsetCacheKey).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return offset, err
+}
+
+// SaveOutboundMessage keeps the message until it is sent, so messages queued before a restart aren't lost.
+// The owner leases the message, other dispatchers don't claim it until the lease expires.
+func (c *cache) SaveOutboundMessage(
+	ctx context.Context,
+	outboundMessage app.OutboundMessage,
+	owner string,
+	leaseTTL time.Duration,
+) error {
+	log := c.container.GetLogger()
+	encodedData, err := utils.EncodePayload(&outboundMessage)
+	if err != nil {
+		log.Debug("fail to encode payload", logger.FError(err))
+		return err
+	}
+	_, err = c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, keyForOutboundMessageLease(outboundMessage.ID), owner, leaseTTL)
+		pipe.HSet(ctx, outboundMessagesCacheKey, outboundMessage.ID, encodedData)
+		return nil
+	})
+	return err
+}
+
+func (c *cache) DeleteOutboundMessage(ctx context.Context, id string) error {
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, outboundMessagesCacheKey, id)
+		pipe.Del(ctx, keyForOutboundMessageLease(id))
+		return nil
+	})
+	return err
+}
+
+// claimOutboundMessagesScript leases every persisted message nobody holds and returns ids and payloads
+// of the leased ones. Reading and leasing is one step, so a message deleted once sent isn't claimed again.
+var claimOutboundMessagesScript = redis.NewScript(`
+local claimed = {}
+local messages = redis.call('HGETALL', KEYS[1])
+for i = 1, #messages, 2 do
+	if redis.call('SET', ARGV[1] .. '/' .. messages[i], ARGV[2], 'NX', 'PX', ARGV[3]) then
+		table.insert(claimed, messages[i])
+		table.insert(claimed, messages[i + 1])
+	end
+end
+return claimed
+`)
+
+// leaseOutboundMessagesScript extends leases the owner holds and takes lapsed leases of messages still persisted,
+// it returns ids of messages held by other owners or already deleted.
+var leaseOutboundMessagesScript = redis.NewScript(`
+local lost = {}
+for i = 4, #ARGV do
+	local key = ARGV[1] .. '/' .. ARGV[i]
+	local holder = redis.call('GET', key)
+	if holder == ARGV[2] or (not holder and redis.call('HEXISTS', KEYS[1], ARGV[i]) == 1) then
+		redis.call('SET', key, ARGV[2], 'PX', ARGV[3])
+	else
+		table.insert(lost, ARGV[i])
+	end
+end
+return lost
+`)
+
+// ClaimOutboundMessages leases messages nobody holds, e.g. messages of a stopped dispatcher, and returns them
+// in the order they were queued.
+func (c *cache) ClaimOutboundMessages(ctx context.Context, owner string, leaseTTL time.Duration) ([]app.OutboundMessage, error) {
+	log := c.container.GetLogger()
+	claimedMessages, err := claimOutboundMessagesScript.Run(
+		ctx,
+		c.client,
+		[]string{outboundMessagesCacheKey},
+		outboundMessageLeaseCacheKey,
+		owner,
+		leaseTTL.Milliseconds(),
+	).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+	outboundMessages := make([]app.OutboundMessage, 0, len(claimedMessages)/2)
+	for i := 0; i+1 < len(claimedMessages); i += 2 {
+		id, encodedText := claimedMessages[i], claimedMessages[i+1]
+		var outboundMessage app.OutboundMessage
+		if err := utils.DecodePayload(encodedText, &outboundMessage); err != nil {
+			log.Error("fail to decode outbound message, drop it", logger.F("id", id), logger.FError(err))
+			_ = c.DeleteOutboundMessage(ctx, id)
+			continue
+		}
+		outboundMessages = append(outboundMessages, outboundMessage)
+	}
+	sort.Slice(outboundMessages, func(i, j int) bool {
+		return outboundMessages[i].CreatedAt.Before(outboundMessages[j].CreatedAt)
+	})
+	return outboundMessages, nil
+}
+
+// LeaseOutboundMessages extends leases of messages the owner still holds and returns ids of the messages
+// whose leases are lost, another dispatcher has claimed them or they have been sent.
+func (c *cache) LeaseOutboundMessages(
+	ctx context.Context,
+	ids []string,
+	owner string,
+	leaseTTL time.Duration,
+) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	args := make([]any, 0, len(ids)+3)
+	args = append(args, outboundMessageLeaseCacheKey, owner, leaseTTL.Milliseconds())
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	return leaseOutboundMessagesScript.Run(ctx, c.client, []string{outboundMessagesCacheKey}, args...).StringSlice()
+}
+
+// ReleaseOutboundMessages lets other dispatchers claim the messages right away.
+func (c *cache) ReleaseOutboundMessages(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, keyForOutboundMessageLease(id))
+	}
+	return c.client.Del(ctx, keys...).Err()
+}
+
+func keyForOutboundMessageLease(id string) string {
+	return fmt.Sprintf("%s/%s", outboundMessageLeaseCacheKey, id)
+}
+
+// SaveCallbackData keeps payloads of buttons under their tokens in one pipeline, saving a payload again prolongs
+// the ttl.
+func (c *cache) SaveCallbackData(ctx context.Context, encodedCallbackData map[string]string, ttl time.Duration) error {
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for token, encodedData := range encodedCallbackData {
+			pipe.Set(ctx, fmt.Sprintf("%s/%s", callbackDataCacheKey, token), encodedData, ttl)
+		}
+		return nil
+	})
+	return err
+}
+
+// GetCallbackData returns nil when the token is unknown or has expired.
+func (c *cache) GetCallbackData(ctx context.Context, token string) (*string, error) {
+	key := fmt.Sprintf("%s/%s", callbackDataCacheKey, token)
+	encodedCallbackData, err := c.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &encodedCallbackData, nil
+}
+
+// SaveChannelMembership remembers that the user is subscribed to the channel.
+func (c *cache) SaveChannelMembership(ctx context.Context, chat string, telegramID int64, ttl time.Duration) error {
+	return c.client.Set(ctx, keyForChannelMembership(chat, telegramID), true, ttl).Err()
+}
+
+func (c *cache) HasChannelMembership(ctx context.Context, chat string, telegramID int64) (bool, error) {
+	hasMembership, err := c.client.Get(ctx, keyForChannelMembership(chat, telegramID)).Bool()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	return hasMembership, err
+}
+
+func (c *cache) DeleteChannelMembership(ctx context.Context, chat string, telegramID int64) error {
+	return c.client.Del(ctx, keyForChannelMembership(chat, telegramID)).Err()
+}
+
+func keyForChannelMembership(chat string, telegramID int64) string {
+	return fmt.Sprintf("%s/%s_%d", channelMembershipCacheKey, strings.ToLower(chat), telegramID)
+}
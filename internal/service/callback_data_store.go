@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"go-ton-pass-telegram-bot/internal/container"
+	"go-ton-pass-telegram-bot/internal/model/app"
+	"go-ton-pass-telegram-bot/internal/utils"
+	"go-ton-pass-telegram-bot/pkg/logger"
+	"time"
+)
+
+// callbackDataTokenSize is the number of bytes of the signature a token keeps, 16 characters of callback_data.
+const callbackDataTokenSize = 12
+
+// CallbackDataStore keeps payloads of buttons in redis under tokens, a token is the signature of its payload,
+// so the same button gets the same token and a token that isn't issued by the bot matches nothing.
+// It satisfies manager.CallbackDataStore the keyboards are built with.
+type CallbackDataStore interface {
+	CallbackDataToken(callbackData app.TelegramCallbackData) (string, error)
+	SaveCallbackData(ctx context.Context, callbackData []app.TelegramCallbackData) error
+	LoadCallbackData(ctx context.Context, token string) (*app.TelegramCallbackData, error)
+}
+
+type callbackDataStore struct {
+	container container.Container
+	cache     Cache
+}
+
+func NewCallbackDataStore(container container.Container, cache Cache) CallbackDataStore {
+	return &callbackDataStore{
+		container: container,
+		cache:     cache,
+	}
+}
+
+func (c *callbackDataStore) CallbackDataToken(callbackData app.TelegramCallbackData) (string, error) {
+	log := c.container.GetLogger()
+	encodedCallbackData, err := utils.EncodeTelegramCallbackData(callbackData)
+	if err != nil {
+		log.Error("fail to encode callback data", logger.F("callback_data", callbackData), logger.FError(err))
+		return "", err
+	}
+	return c.sign(*encodedCallbackData), nil
+}
+
+// SaveCallbackData saves payloads of a keyboard in one round trip, it prolongs the ttl of payloads of buttons
+// that are built again.
+func (c *callbackDataStore) SaveCallbackData(ctx context.Context, callbackData []app.TelegramCallbackData) error {
+	log := c.container.GetLogger()
+	encodedCallbackData := make(map[string]string, len(callbackData))
+	for _, data := range callbackData {
+		encodedData, err := utils.EncodeTelegramCallbackData(data)
+		if err != nil {
+			log.Error("fail to encode callback data", logger.F("callback_data", data), logger.FError(err))
+			return err
+		}
+		encodedCallbackData[c.sign(*encodedData)] = *encodedData
+	}
+	ttl := time.Duration(c.container.GetConfig().Telegram().CallbackDataTTLHours) * time.Hour
+	if err := c.cache.SaveCallbackData(ctx, encodedCallbackData, ttl); err != nil {
+		log.Error("fail to save callback data", logger.F("count", len(encodedCallbackData)), logger.FError(err))
+		return err
+	}
+	return nil
+}
+
+// LoadCallbackData returns app.ExpiredCallbackDataError for tokens that have expired or have never been issued.
+func (c *callbackDataStore) LoadCallbackData(ctx context.Context, token string) (*app.TelegramCallbackData, error) {
+	encodedCallbackData, err := c.cache.GetCallbackData(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if encodedCallbackData == nil || !hmac.Equal([]byte(c.sign(*encodedCallbackData)), []byte(token)) {
+		return nil, app.ExpiredCallbackDataError
+	}
+	return utils.DecodeTelegramCallbackData(*encodedCallbackData)
+}
+
+func (c *callbackDataStore) sign(encodedCallbackData string) string {
+	mac := hmac.New(sha256.New, []byte(c.container.GetConfig().Telegram().CallbackDataSecret))
+	mac.Write([]byte(encodedCallbackData))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:callbackDataTokenSize])
+}
@@ -5,13 +5,12 @@ import (
 	"go-ton-pass-telegram-bot/internal/container"
 	"go-ton-pass-telegram-bot/internal/model/app"
 	"go-ton-pass-telegram-bot/internal/model/telegram"
-	"go-ton-pass-telegram-bot/internal/utils"
 	"go-ton-pass-telegram-bot/pkg/logger"
 	"strings"
 )
 
 type CallbackDataStack interface {
-	Push(ctx context.Context, callbackQuery *telegram.CallbackQuery) error
+	Push(ctx context.Context, callbackQuery *telegram.CallbackQuery, callbackData *app.TelegramCallbackData) error
 	Pop(ctx context.Context, callbackQuery *telegram.CallbackQuery) (*app.TelegramCallbackData, error)
 	Top(ctx context.Context, callbackQuery *telegram.CallbackQuery) (*app.TelegramCallbackData, error)
 	DebugListCommands(ctx context.Context, callbackQuery *telegram.CallbackQuery) (*string, error)
@@ -28,22 +27,23 @@ func NewCallbackDataStack(container container.Container, cache Cache) CallbackDa
 	}
 }
 
-func (c *callbackDataStack) Push(ctx context.Context, callbackQuery *telegram.CallbackQuery) error {
+// Push records the parsed payload of the pressed button, callback data of the query holds only its token.
+func (c *callbackDataStack) Push(
+	ctx context.Context,
+	callbackQuery *telegram.CallbackQuery,
+	callbackData *app.TelegramCallbackData,
+) error {
 	log := c.container.GetLogger()
 	chatID := callbackQuery.Message.Chat.ID
 	messageID := callbackQuery.Message.ID
 	telegramMessagingInfo := TelegramMessagingInfo{ChatID: chatID, MessageID: messageID}
-	callbackData, _ := c.cache.GetTelegramCallbackData(ctx, telegramMessagingInfo)
-	if callbackData == nil {
-		callbackData = make([]app.TelegramCallbackData, 0)
+	stackCallbackData, _ := c.cache.GetTelegramCallbackData(ctx, telegramMessagingInfo)
+	if stackCallbackData == nil {
+		stackCallbackData = make([]app.TelegramCallbackData, 0)
 	}
-	item, err := utils.DecodeTelegramCallbackData(callbackQuery.Data)
-	if err != nil {
-		return err
-	}
-	callbackData = append(callbackData, *item)
-	log.Debug("callback will be saved after push operation", logger.F("callback_data", callbackData))
-	return c.cache.SaveTelegramCallbackData(ctx, callbackData, telegramMessagingInfo)
+	stackCallbackData = append(stackCallbackData, *callbackData)
+	log.Debug("callback will be saved after push operation", logger.F("callback_data", stackCallbackData))
+	return c.cache.SaveTelegramCallbackData(ctx, stackCallbackData, telegramMessagingInfo)
 }
 
 func (c *callbackDataStack) Pop(ctx context.Context, callbackQuery *telegram.CallbackQuery) (*app.TelegramCallbackData, error) {
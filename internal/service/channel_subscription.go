@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"go-ton-pass-telegram-bot/internal/config"
+	"go-ton-pass-telegram-bot/internal/container"
+	"go-ton-pass-telegram-bot/internal/model/telegram"
+	"go-ton-pass-telegram-bot/pkg/logger"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChannelSubscription checks that users are subscribed to the required channels of the config. A membership is kept
+// in the cache for a short time, so telegram servers aren't asked on every update, and is refreshed by chat_member
+// updates of the channels.
+type ChannelSubscription interface {
+	// MissingChannels returns the enabled required channels the user isn't subscribed to.
+	MissingChannels(ctx context.Context, telegramID int64) ([]config.RequiredChannel, error)
+	// Refresh updates the cached membership of the user the update is about, updates of other chats are skipped.
+	Refresh(ctx context.Context, chatMemberUpdated *telegram.ChatMemberUpdated) error
+}
+
+type channelSubscription struct {
+	container          container.Container
+	telegramBotService TelegramBotService
+	cache              Cache
+}
+
+func NewChannelSubscription(
+	container container.Container,
+	telegramBotService TelegramBotService,
+	cache Cache,
+) ChannelSubscription {
+	return &channelSubscription{
+		container:          container,
+		telegramBotService: telegramBotService,
+		cache:              cache,
+	}
+}
+
+// MissingChannels doesn't cache that the user isn't subscribed, the user who has just subscribed passes at once.
+// A channel telegram servers fail to check is skipped, so an unavailable channel doesn't lock users out of the bot.
+func (c *channelSubscription) MissingChannels(ctx context.Context, telegramID int64) ([]config.RequiredChannel, error) {
+	log := c.container.GetLogger()
+	subscription := c.container.GetConfig().Subscription()
+	ttl := time.Duration(subscription.MembershipCacheTTLSeconds) * time.Second
+	missingChannels := make([]config.RequiredChannel, 0)
+	for _, requiredChannel := range subscription.EnabledChannels() {
+		hasMembership, err := c.cache.HasChannelMembership(ctx, requiredChannel.Chat, telegramID)
+		if err != nil {
+			log.Error("fail to get channel membership from cache", logger.F("chat", requiredChannel.Chat), logger.FError(err))
+		} else if hasMembership {
+			continue
+		}
+		isChatMember, err := c.telegramBotService.UserIsChatMember(ctx, requiredChannel.Chat, telegramID)
+		if err != nil {
+			log.Error(
+				"fail to check is user member of required channel, skip it",
+				logger.F("chat", requiredChannel.Chat),
+				logger.F("telegram_id", telegramID),
+				logger.FError(err),
+			)
+			continue
+		}
+		if !isChatMember {
+			missingChannels = append(missingChannels, requiredChannel)
+			continue
+		}
+		if err := c.cache.SaveChannelMembership(ctx, requiredChannel.Chat, telegramID, ttl); err != nil {
+			log.Error("fail to save channel membership in cache", logger.F("chat", requiredChannel.Chat), logger.FError(err))
+		}
+	}
+	return missingChannels, nil
+}
+
+func (c *channelSubscription) Refresh(ctx context.Context, chatMemberUpdated *telegram.ChatMemberUpdated) error {
+	log := c.container.GetLogger()
+	subscription := c.container.GetConfig().Subscription()
+	telegramID := chatMemberUpdated.NewChatMember.User.ID
+	for _, requiredChannel := range subscription.RequiredChannels {
+		if !isRequiredChannelChat(requiredChannel, chatMemberUpdated.Chat) {
+			continue
+		}
+		log.Debug(
+			"refresh channel membership",
+			logger.F("chat", requiredChannel.Chat),
+			logger.F("telegram_id", telegramID),
+			logger.F("status", chatMemberUpdated.NewChatMember.Status),
+		)
+		if chatMemberUpdated.NewChatMember.IsMember() {
+			ttl := time.Duration(subscription.MembershipCacheTTLSeconds) * time.Second
+			return c.cache.SaveChannelMembership(ctx, requiredChannel.Chat, telegramID, ttl)
+		}
+		return c.cache.DeleteChannelMembership(ctx, requiredChannel.Chat, telegramID)
+	}
+	return nil
+}
+
+// isRequiredChannelChat matches the channel by its username or its id, whichever the config has.
+func isRequiredChannelChat(requiredChannel config.RequiredChannel, chat telegram.Chat) bool {
+	if username, ok := strings.CutPrefix(requiredChannel.Chat, "@"); ok {
+		return chat.Username != nil && strings.EqualFold(*chat.Username, username)
+	}
+	return requiredChannel.Chat == strconv.FormatInt(chat.ID, 10)
+}
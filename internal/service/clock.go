@@ -0,0 +1,43 @@
+package service
+
+import "time"
+
+// Clock is the time source of the outbound dispatcher, tests replace it to control the rate limits.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is the part of time.Timer the dispatcher waits on.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+type systemClock struct{}
+
+type systemTimer struct {
+	timer *time.Timer
+}
+
+func NewSystemClock() Clock {
+	return systemClock{}
+}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{
+		timer: time.NewTimer(d),
+	}
+}
+
+func (s systemTimer) C() <-chan time.Time {
+	return s.timer.C
+}
+
+func (s systemTimer) Stop() bool {
+	return s.timer.Stop()
+}
@@ -19,6 +19,7 @@ type CryptoPayBot interface {
 	CreateInvoice(currency string, amount float64, payloadData string) (*bot.Invoice, error)
 	RemoveInvoice(invoiceID int64) error
 	FetchExchangeRate() ([]bot.ExchangeRate, error)
+	GetBalance() ([]bot.Balance, error)
 }
 
 type cryptoPayBot struct {
@@ -109,6 +110,31 @@ func (c *cryptoPayBot) FetchExchangeRate() ([]bot.ExchangeRate, error) {
 	return result.Result, nil
 }
 
+func (c *cryptoPayBot) GetBalance() ([]bot.Balance, error) {
+	log := c.container.GetLogger()
+	req, err := c.prepareRequest(app.GetBalanceCryptoBotMethod, url.Values{})
+	if err != nil {
+		log.Error("fail prepare a request", logger.FError(err))
+		return nil, err
+	}
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Error("fail to create a http client", logger.FError(err))
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var result bot.Result[[]bot.Balance]
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		log.Debug("fail to decode", logger.FError(err))
+		return nil, err
+	}
+	if !result.OK {
+		return nil, app.UnknownError
+	}
+	return result.Result, nil
+}
+
 func (c *cryptoPayBot) prepareRequest(method app.CryptoBotMethod, queryParams url.Values) (*http.Request, error) {
 	log := c.container.GetLogger()
 	token := c.container.GetConfig().CryptoBotToken()
@@ -3,6 +3,7 @@ package postpone
 import (
 	"context"
 	"go-ton-pass-telegram-bot/internal/container"
+	"go-ton-pass-telegram-bot/internal/model/app"
 	model "go-ton-pass-telegram-bot/internal/model/postpone"
 	"go-ton-pass-telegram-bot/internal/repository"
 	"go-ton-pass-telegram-bot/internal/service"
@@ -14,30 +15,49 @@ import (
 type Postpone interface {
 	ScheduleCheckSMSActivation(ctx context.Context, telegramID int64, activationID int64, amount float64) (*model.Workflow, error)
 	CancelSMSActivation(ctx context.Context, workflow model.Workflow) error
+	StartBroadcast(ctx context.Context, broadcastID int64) error
+	ControlBroadcast(ctx context.Context, broadcastID int64, action app.BroadcastAction) error
 	Prepare() error
 }
 
 type postpone struct {
-	container            container.Container
-	smsWorker            workflow.SMSActivateWorker
-	profileRepository    repository.ProfileRepository
-	smsHistoryRepository repository.SMSHistoryRepository
+	container               container.Container
+	smsWorker               workflow.SMSActivateWorker
+	providerBalanceWorker   workflow.ProviderBalanceWorker
+	servicePopularityWorker workflow.ServicePopularityWorker
+	catalogSyncWorker       workflow.CatalogSyncWorker
+	broadcastWorker         workflow.BroadcastWorker
+	profileRepository       repository.ProfileRepository
+	smsHistoryRepository    repository.SMSHistoryRepository
 }
 
 func NewPostpone(
 	container container.Container,
+	telegramBotService service.TelegramBotService,
 	client client.Client,
+	cacheService service.Cache,
 	profileRepository repository.ProfileRepository,
 	smsHistoryRepository repository.SMSHistoryRepository,
+	activationGroupRepository repository.ActivationGroupRepository,
+	catalogRepository repository.CatalogRepository,
+	broadcastRepository repository.BroadcastRepository,
 ) Postpone {
-	telegramService := service.NewTelegramBot(container)
 	smsService := service.NewSMSService(container)
-	smsWorker := workflow.NewSMSActivateWorker(container, client, telegramService, smsService, profileRepository, smsHistoryRepository)
+	cryptoPayBot := service.NewCryptoPayBot(container)
+	smsWorker := workflow.NewSMSActivateWorker(container, client, telegramBotService, smsService, profileRepository, smsHistoryRepository, activationGroupRepository)
+	providerBalanceWorker := workflow.NewProviderBalanceWorker(container, client, telegramBotService, smsService, cryptoPayBot, cacheService)
+	servicePopularityWorker := workflow.NewServicePopularityWorker(container, client, cacheService, smsHistoryRepository)
+	catalogSyncWorker := workflow.NewCatalogSyncWorker(container, client, smsService, cacheService, catalogRepository)
+	broadcastWorker := workflow.NewBroadcastWorker(container, client, telegramBotService, profileRepository, broadcastRepository)
 	return &postpone{
-		container:            container,
-		smsWorker:            smsWorker,
-		profileRepository:    profileRepository,
-		smsHistoryRepository: smsHistoryRepository,
+		container:               container,
+		smsWorker:               smsWorker,
+		providerBalanceWorker:   providerBalanceWorker,
+		servicePopularityWorker: servicePopularityWorker,
+		catalogSyncWorker:       catalogSyncWorker,
+		broadcastWorker:         broadcastWorker,
+		profileRepository:       profileRepository,
+		smsHistoryRepository:    smsHistoryRepository,
 	}
 }
 
@@ -61,7 +81,25 @@ func (p *postpone) CancelSMSActivation(ctx context.Context, workflow model.Workf
 	return p.smsWorker.ExecuteCancel(ctx, workflow)
 }
 
+func (p *postpone) StartBroadcast(ctx context.Context, broadcastID int64) error {
+	return p.broadcastWorker.Start(ctx, broadcastID)
+}
+
+func (p *postpone) ControlBroadcast(ctx context.Context, broadcastID int64, action app.BroadcastAction) error {
+	return p.broadcastWorker.Control(ctx, broadcastID, action)
+}
+
 func (p *postpone) Prepare() error {
 	p.smsWorker.Prepare()
-	return nil
+	p.providerBalanceWorker.Prepare()
+	p.servicePopularityWorker.Prepare()
+	p.catalogSyncWorker.Prepare()
+	p.broadcastWorker.Prepare()
+	if err := p.providerBalanceWorker.Schedule(context.Background()); err != nil {
+		return err
+	}
+	if err := p.servicePopularityWorker.Schedule(context.Background()); err != nil {
+		return err
+	}
+	return p.catalogSyncWorker.Schedule(context.Background())
 }
@@ -0,0 +1,290 @@
+package activity
+
+import (
+	"context"
+	"errors"
+	"go-ton-pass-telegram-bot/internal/container"
+	"go-ton-pass-telegram-bot/internal/manager"
+	"go-ton-pass-telegram-bot/internal/model/app"
+	"go-ton-pass-telegram-bot/internal/model/domain"
+	"go-ton-pass-telegram-bot/internal/model/telegram"
+	"go-ton-pass-telegram-bot/internal/repository"
+	"go-ton-pass-telegram-bot/internal/service"
+	"go-ton-pass-telegram-bot/internal/utils"
+	"go-ton-pass-telegram-bot/internal/worker"
+	"go-ton-pass-telegram-bot/pkg/logger"
+	"go-ton-pass-telegram-bot/pkg/telegram_bot"
+	temporal_activity "go.temporal.io/sdk/activity"
+	"sync"
+	"time"
+)
+
+const (
+	// broadcastBatchSize is the number of deliveries sent between checks for pause and cancel.
+	broadcastBatchSize = 100
+	// broadcastSendConcurrency keeps the marketing lane of the dispatcher busy, the dispatcher keeps the rate limits.
+	broadcastSendConcurrency = 10
+	// broadcastRetryDelay is the first pause before a delivery failed for a while is sent again, it doubles
+	// with every attempt.
+	broadcastRetryDelay = 30 * time.Second
+	// broadcastDeliveryMaxAttempts ends repeating a delivery telegram servers keep failing.
+	broadcastDeliveryMaxAttempts = 5
+	// broadcastClaimTimeout outlasts the start to close timeout of a batch, a delivery claimed longer ago is left
+	// in the sending status by a lost batch and is sent again.
+	broadcastClaimTimeout    = 20 * time.Minute
+	defaultBroadcastLanguage = "en"
+)
+
+type BroadcastActivity struct {
+	container           container.Container
+	telegramService     service.TelegramBotService
+	profileRepository   repository.ProfileRepository
+	broadcastRepository repository.BroadcastRepository
+	formatterWorker     worker.Formatter
+}
+
+func NewBroadcastActivity(
+	container container.Container,
+	telegramService service.TelegramBotService,
+	profileRepository repository.ProfileRepository,
+	broadcastRepository repository.BroadcastRepository,
+) *BroadcastActivity {
+	return &BroadcastActivity{
+		container:           container,
+		telegramService:     telegramService,
+		profileRepository:   profileRepository,
+		broadcastRepository: broadcastRepository,
+		formatterWorker:     worker.NewFormatter(container),
+	}
+}
+
+func (b *BroadcastActivity) CreateDeliveries(ctx context.Context, broadcastID int64) (int64, error) {
+	log := b.container.GetLogger()
+	broadcast, err := b.broadcastRepository.FetchByID(ctx, broadcastID)
+	if err != nil {
+		log.Error("fail to fetch broadcast", logger.F("broadcast_id", broadcastID), logger.FError(err))
+		return 0, err
+	}
+	count, err := b.broadcastRepository.CreateDeliveries(ctx, broadcast)
+	if err != nil {
+		log.Error("fail to create broadcast deliveries", logger.F("broadcast_id", broadcastID), logger.FError(err))
+		return 0, err
+	}
+	log.Debug("broadcast deliveries are created", logger.F("broadcast_id", broadcastID), logger.F("count", count))
+	return count, nil
+}
+
+// SendBatch sends the broadcast to the next pending recipients and returns their number, zero means the broadcast
+// has been delivered to everyone. Deliveries failed for a while stay pending and fail the batch, so temporal
+// repeats it.
+func (b *BroadcastActivity) SendBatch(ctx context.Context, broadcastID int64) (int, error) {
+	log := b.container.GetLogger()
+	broadcast, err := b.broadcastRepository.FetchByID(ctx, broadcastID)
+	if err != nil {
+		log.Error("fail to fetch broadcast", logger.F("broadcast_id", broadcastID), logger.FError(err))
+		return 0, err
+	}
+	deliveries, err := b.broadcastRepository.ClaimPendingDeliveries(
+		ctx,
+		broadcastID,
+		time.Now().Add(-broadcastClaimTimeout),
+		broadcastBatchSize,
+	)
+	if err != nil {
+		log.Error("fail to claim pending broadcast deliveries", logger.F("broadcast_id", broadcastID), logger.FError(err))
+		return 0, err
+	}
+	if len(deliveries) == 0 {
+		return 0, b.checkDelayedDeliveries(ctx, broadcastID)
+	}
+	semaphore := make(chan struct{}, broadcastSendConcurrency)
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	var deliveryErrs []error
+	for idx := range deliveries {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(delivery *domain.BroadcastDelivery) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			if err := b.deliver(ctx, broadcast, delivery); err != nil {
+				mutex.Lock()
+				deliveryErrs = append(deliveryErrs, err)
+				mutex.Unlock()
+			}
+			temporal_activity.RecordHeartbeat(ctx, delivery.ID)
+		}(&deliveries[idx])
+	}
+	wg.Wait()
+	if len(deliveryErrs) > 0 {
+		log.Debug(
+			"broadcast deliveries are delayed",
+			logger.F("broadcast_id", broadcastID),
+			logger.F("count", len(deliveryErrs)),
+		)
+		return 0, errors.Join(deliveryErrs...)
+	}
+	return len(deliveries), nil
+}
+
+// checkDelayedDeliveries fails the batch while deliveries wait for their next attempt or are claimed by a lost
+// batch, otherwise the broadcast would be completed without them.
+func (b *BroadcastActivity) checkDelayedDeliveries(ctx context.Context, broadcastID int64) error {
+	log := b.container.GetLogger()
+	deliveryCounts, err := b.broadcastRepository.FetchDeliveryCounts(ctx, broadcastID)
+	if err != nil {
+		log.Error("fail to fetch broadcast delivery counts", logger.F("broadcast_id", broadcastID), logger.FError(err))
+		return err
+	}
+	unfinished := deliveryCounts[domain.PendingBroadcastDeliveryStatus] + deliveryCounts[domain.SendingBroadcastDeliveryStatus]
+	if unfinished > 0 {
+		return app.PendingBroadcastDeliveriesError
+	}
+	return nil
+}
+
+func (b *BroadcastActivity) ChangeStatus(
+	ctx context.Context,
+	broadcastID int64,
+	fromStatuses []domain.BroadcastStatus,
+	status domain.BroadcastStatus,
+) (bool, error) {
+	log := b.container.GetLogger()
+	changed, err := b.broadcastRepository.ChangeStatus(ctx, broadcastID, fromStatuses, status)
+	if err != nil {
+		log.Error(
+			"fail to change broadcast status",
+			logger.F("broadcast_id", broadcastID),
+			logger.F("status", status),
+			logger.FError(err),
+		)
+		return false, err
+	}
+	return changed, nil
+}
+
+// ReportProgress edits the control message of the author with the delivery counts and the buttons
+// the status allows.
+func (b *BroadcastActivity) ReportProgress(ctx context.Context, broadcastID int64) error {
+	log := b.container.GetLogger()
+	broadcast, err := b.broadcastRepository.FetchByID(ctx, broadcastID)
+	if err != nil {
+		log.Error("fail to fetch broadcast", logger.F("broadcast_id", broadcastID), logger.FError(err))
+		return err
+	}
+	if broadcast.ControlChatID == nil || broadcast.ControlMessageID == nil {
+		log.Debug("broadcast has no control message", logger.F("broadcast_id", broadcastID))
+		return nil
+	}
+	deliveryCounts, err := b.broadcastRepository.FetchDeliveryCounts(ctx, broadcastID)
+	if err != nil {
+		log.Error("fail to fetch broadcast delivery counts", logger.F("broadcast_id", broadcastID), logger.FError(err))
+		return err
+	}
+	langCode := b.authorLanguage(ctx, broadcast)
+	replyMarkup, err := manager.NewBroadcastControlInlineKeyboardMarkup(
+		ctx,
+		b.telegramService,
+		b.container.GetLocalizer(langCode),
+		broadcast.ID,
+		broadcast.Status,
+	)
+	if err != nil {
+		log.Error("fail to create broadcast control keyboard", logger.FError(err))
+		return err
+	}
+	editMessage := telegram.EditMessage{
+		ChatID:      broadcast.ControlChatID,
+		MessageID:   broadcast.ControlMessageID,
+		Text:        b.formatterWorker.BroadcastProgress(langCode, broadcast, deliveryCounts),
+		ReplyMarkup: replyMarkup,
+	}
+	return b.telegramService.Enqueue(
+		ctx,
+		app.TransactionalOutboundPriority,
+		app.EditMessageTextOutboundMethod,
+		*broadcast.ControlChatID,
+		&editMessage,
+	)
+}
+
+// deliver records the result of sending. A recipient that has blocked the bot is marked, so later broadcasts
+// skip them. Only refusals of telegram servers are final, other failures keep the delivery pending for a later
+// attempt and are returned.
+func (b *BroadcastActivity) deliver(ctx context.Context, broadcast *domain.Broadcast, delivery *domain.BroadcastDelivery) error {
+	log := b.container.GetLogger()
+	method, payload, err := manager.NewBroadcastMessage(broadcast, delivery.ChatID)
+	if err != nil {
+		log.Error("fail to create broadcast message", logger.F("delivery_id", delivery.ID), logger.FError(err))
+		delivery.Status = domain.FailedBroadcastDeliveryStatus
+		delivery.Error = utils.NewString(err.Error())
+		b.updateDelivery(ctx, delivery)
+		return nil
+	}
+	message, err := b.telegramService.Send(ctx, app.MarketingOutboundPriority, method, delivery.ChatID, payload)
+	now := time.Now()
+	delivery.Attempts++
+	delivery.NotBefore = nil
+	var deliveryErr error
+	switch {
+	case telegram_bot.IsForbidden(err):
+		delivery.Status = domain.BlockedBroadcastDeliveryStatus
+		delivery.Error = utils.NewString(err.Error())
+		delivery.SentAt = &now
+		if err := b.profileRepository.MarkBotBlocked(ctx, delivery.ProfileID); err != nil {
+			log.Error("fail to mark bot as blocked", logger.F("profile_id", delivery.ProfileID), logger.FError(err))
+		}
+	case telegram_bot.IsBadRequest(err):
+		delivery.Status = domain.FailedBroadcastDeliveryStatus
+		delivery.Error = utils.NewString(err.Error())
+		delivery.SentAt = &now
+	case err != nil && delivery.Attempts >= broadcastDeliveryMaxAttempts:
+		log.Error("fail to send broadcast message, drop it", logger.F("delivery_id", delivery.ID), logger.FError(err))
+		delivery.Status = domain.FailedBroadcastDeliveryStatus
+		delivery.Error = utils.NewString(err.Error())
+		delivery.SentAt = &now
+	case err != nil:
+		delay := broadcastRetryDelay << (delivery.Attempts - 1)
+		var telegramErr *telegram_bot.Error
+		if errors.As(err, &telegramErr) && telegramErr.RetryAfter > 0 {
+			delay = max(delay, time.Duration(telegramErr.RetryAfter)*time.Second)
+		}
+		notBefore := now.Add(delay)
+		delivery.Status = domain.PendingBroadcastDeliveryStatus
+		delivery.Error = utils.NewString(err.Error())
+		delivery.NotBefore = &notBefore
+		deliveryErr = err
+	default:
+		delivery.Status = domain.SentBroadcastDeliveryStatus
+		delivery.Error = nil
+		delivery.MessageID = &message.ID
+		delivery.SentAt = &now
+	}
+	b.updateDelivery(ctx, delivery)
+	return deliveryErr
+}
+
+// updateDelivery outlives a cancelled batch, a delivery it fails to record stays in the sending status until
+// the claim timeout.
+func (b *BroadcastActivity) updateDelivery(ctx context.Context, delivery *domain.BroadcastDelivery) {
+	log := b.container.GetLogger()
+	if err := b.broadcastRepository.UpdateDelivery(context.WithoutCancel(ctx), delivery); err != nil {
+		log.Error(
+			"fail to update broadcast delivery",
+			logger.F("delivery_id", delivery.ID),
+			logger.F("status", delivery.Status),
+			logger.FError(err),
+		)
+	}
+}
+
+func (b *BroadcastActivity) authorLanguage(ctx context.Context, broadcast *domain.Broadcast) string {
+	if broadcast.AuthorProfileID == nil {
+		return defaultBroadcastLanguage
+	}
+	profile, err := b.profileRepository.FetchByID(ctx, *broadcast.AuthorProfileID)
+	if err != nil || profile.PreferredLanguage == nil {
+		return defaultBroadcastLanguage
+	}
+	return *profile.PreferredLanguage
+}
@@ -0,0 +1,144 @@
+package activity
+
+import (
+	"context"
+	"errors"
+	"go-ton-pass-telegram-bot/internal/container"
+	"go-ton-pass-telegram-bot/internal/model/domain"
+	"go-ton-pass-telegram-bot/internal/repository"
+	"go-ton-pass-telegram-bot/internal/service"
+	"go-ton-pass-telegram-bot/pkg/logger"
+	"time"
+)
+
+type CatalogSyncActivity struct {
+	container         container.Container
+	smsService        service.SMSService
+	cacheService      service.Cache
+	catalogRepository repository.CatalogRepository
+}
+
+func NewCatalogSyncActivity(
+	container container.Container,
+	smsService service.SMSService,
+	cacheService service.Cache,
+	catalogRepository repository.CatalogRepository,
+) *CatalogSyncActivity {
+	return &CatalogSyncActivity{
+		container:         container,
+		smsService:        smsService,
+		cacheService:      cacheService,
+		catalogRepository: catalogRepository,
+	}
+}
+
+func (c *CatalogSyncActivity) SyncCountries(ctx context.Context) error {
+	log := c.container.GetLogger()
+	countries, err := c.smsService.GetCountries()
+	if err != nil {
+		log.Error("fail to fetch countries from sms activate", logger.FError(err))
+		return err
+	}
+	catalogCountries := make([]domain.CatalogCountry, 0, len(countries))
+	for _, country := range countries {
+		catalogCountries = append(catalogCountries, domain.CatalogCountry{
+			ID:           country.ID,
+			Title:        country.Title,
+			Visible:      country.Visible,
+			Retry:        country.Retry,
+			Rent:         country.Rent,
+			MultiService: country.MultiService,
+		})
+	}
+	if err := c.catalogRepository.SaveCountries(ctx, catalogCountries, time.Now()); err != nil {
+		log.Error("fail to save catalog countries", logger.FError(err))
+		return err
+	}
+	log.Debug("catalog countries are synced", logger.F("countries", len(catalogCountries)))
+	return nil
+}
+
+// SyncServices stores services with their rank in the popularity list of SMS-Activate and returns codes
+// of the stored services.
+func (c *CatalogSyncActivity) SyncServices(ctx context.Context) ([]string, error) {
+	log := c.container.GetLogger()
+	services, err := c.smsService.GetServices()
+	if err != nil {
+		log.Error("fail to fetch services from sms activate", logger.FError(err))
+		return nil, err
+	}
+	popularServiceCodes, err := c.smsService.GetPopularServiceCodeList()
+	if err != nil {
+		log.Error("fail to fetch popular services from sms activate", logger.FError(err))
+		return nil, err
+	}
+	popularityRanks := make(map[string]int, len(popularServiceCodes))
+	for _, serviceCode := range popularServiceCodes {
+		if _, ok := popularityRanks[serviceCode]; ok {
+			continue
+		}
+		popularityRanks[serviceCode] = len(popularityRanks)
+	}
+	catalogServices := make([]domain.CatalogService, 0, len(services))
+	serviceCodes := make([]string, 0, len(services))
+	for _, service := range services {
+		catalogService := domain.CatalogService{
+			Code: service.Code,
+			Name: service.Name,
+		}
+		if popularityRank, ok := popularityRanks[service.Code]; ok {
+			catalogService.PopularityRank = &popularityRank
+		}
+		catalogServices = append(catalogServices, catalogService)
+		serviceCodes = append(serviceCodes, service.Code)
+	}
+	if err := c.catalogRepository.SaveServices(ctx, catalogServices, time.Now()); err != nil {
+		log.Error("fail to save catalog services", logger.FError(err))
+		return nil, err
+	}
+	log.Debug("catalog services are synced", logger.F("services", len(catalogServices)))
+	return serviceCodes, nil
+}
+
+// SyncServicePrices refreshes prices service by service, a failed service keeps its previous prices
+// until the next sync. It is the last step of the sync, so it also bumps the catalog version.
+func (c *CatalogSyncActivity) SyncServicePrices(ctx context.Context, serviceCodes []string) error {
+	log := c.container.GetLogger()
+	failedServices := 0
+	for _, serviceCode := range serviceCodes {
+		if err := c.syncServicePrices(ctx, serviceCode); err != nil {
+			log.Error("fail to sync service prices", logger.F("service_code", serviceCode), logger.FError(err))
+			failedServices++
+		}
+	}
+	if len(serviceCodes) > 0 && failedServices == len(serviceCodes) {
+		return errors.New("fail to sync prices of all services")
+	}
+	if _, err := c.cacheService.IncrementCatalogVersion(ctx); err != nil {
+		log.Error("fail to increment catalog version", logger.FError(err))
+		return err
+	}
+	log.Debug("catalog prices are synced",
+		logger.F("services", len(serviceCodes)),
+		logger.F("failed_services", failedServices),
+	)
+	return nil
+}
+
+func (c *CatalogSyncActivity) syncServicePrices(ctx context.Context, serviceCode string) error {
+	servicePrices, err := c.smsService.GetServicePrices(serviceCode)
+	if err != nil {
+		return err
+	}
+	catalogServicePrices := make([]domain.CatalogServicePrice, 0, len(servicePrices))
+	for _, servicePrice := range servicePrices {
+		catalogServicePrices = append(catalogServicePrices, domain.CatalogServicePrice{
+			ServiceCode: serviceCode,
+			CountryID:   servicePrice.CountryCode,
+			RetailPrice: servicePrice.RetailPrice,
+			MinPrice:    float64(servicePrice.MinPrice),
+			Count:       servicePrice.Count,
+		})
+	}
+	return c.catalogRepository.SaveServicePrices(ctx, serviceCode, catalogServicePrices, time.Now())
+}
@@ -0,0 +1,172 @@
+package activity
+
+import (
+	"context"
+	"expvar"
+	"go-ton-pass-telegram-bot/internal/container"
+	"go-ton-pass-telegram-bot/internal/model/app"
+	"go-ton-pass-telegram-bot/internal/model/postpone"
+	"go-ton-pass-telegram-bot/internal/model/telegram"
+	"go-ton-pass-telegram-bot/internal/service"
+	"go-ton-pass-telegram-bot/pkg/logger"
+	"strconv"
+	"strings"
+)
+
+var (
+	smsActivateBalanceMetric = expvar.NewFloat("sms_activate_balance")
+	cryptoBotBalanceMetric   = expvar.NewMap("crypto_bot_balance")
+)
+
+type ProviderBalanceActivity struct {
+	container       container.Container
+	telegramService service.TelegramBotService
+	smsService      service.SMSService
+	cryptoPayBot    service.CryptoPayBot
+	cacheService    service.Cache
+}
+
+func NewProviderBalanceActivity(
+	container container.Container,
+	telegramService service.TelegramBotService,
+	smsService service.SMSService,
+	cryptoPayBot service.CryptoPayBot,
+	cacheService service.Cache,
+) *ProviderBalanceActivity {
+	return &ProviderBalanceActivity{
+		container:       container,
+		telegramService: telegramService,
+		smsService:      smsService,
+		cryptoPayBot:    cryptoPayBot,
+		cacheService:    cacheService,
+	}
+}
+
+func (p *ProviderBalanceActivity) FetchSMSActivateBalance(_ context.Context) (postpone.ProviderBalance, error) {
+	log := p.container.GetLogger()
+	providerBalance := postpone.ProviderBalance{
+		Provider:   string(app.SMSActivateProvider),
+		MinBalance: p.container.GetConfig().ProviderBalance().SMSActivateMinBalance,
+		Currency:   "RUB",
+	}
+	balance, err := p.smsService.GetBalance()
+	if err != nil {
+		log.Error("fail to get sms activate balance", logger.FError(err))
+		return providerBalance, err
+	}
+	smsActivateBalanceMetric.Set(balance)
+	providerBalance.Balance = balance
+	return providerBalance, nil
+}
+
+func (p *ProviderBalanceActivity) FetchCryptoBotBalance(_ context.Context) (postpone.ProviderBalance, error) {
+	log := p.container.GetLogger()
+	conf := p.container.GetConfig().ProviderBalance()
+	providerBalance := postpone.ProviderBalance{
+		Provider:   string(app.CryptoBotProvider),
+		MinBalance: conf.CryptoBotMinBalance,
+		Currency:   conf.CryptoBotBalanceCurrency,
+	}
+	balances, err := p.cryptoPayBot.GetBalance()
+	if err != nil {
+		log.Error("fail to get crypto bot balance", logger.FError(err))
+		return providerBalance, err
+	}
+	hasCurrency := false
+	for _, balance := range balances {
+		available, err := strconv.ParseFloat(balance.Available, 64)
+		if err != nil {
+			log.Error(
+				"fail to parse crypto bot balance",
+				logger.F("currency_code", balance.CurrencyCode),
+				logger.F("available", balance.Available),
+				logger.FError(err),
+			)
+			return providerBalance, err
+		}
+		metric := new(expvar.Float)
+		metric.Set(available)
+		cryptoBotBalanceMetric.Set(balance.CurrencyCode, metric)
+		if strings.EqualFold(balance.CurrencyCode, providerBalance.Currency) {
+			providerBalance.Balance = available
+			hasCurrency = true
+		}
+	}
+	// a balance missing from the response isn't taken as zero, it would alert about a low balance
+	if !hasCurrency {
+		log.Error("fail to find crypto bot balance of currency", logger.F("currency_code", providerBalance.Currency))
+		return providerBalance, app.UnknownCurrencyError
+	}
+	return providerBalance, nil
+}
+
+// ApplyProviderBalances alerts about balances that have changed their state and suspends purchases while any provider
+// is low. Balances that have failed to be fetched are missing, the last known state of their providers is kept.
+func (p *ProviderBalanceActivity) ApplyProviderBalances(ctx context.Context, providerBalances []postpone.ProviderBalance) error {
+	log := p.container.GetLogger()
+	conf := p.container.GetConfig().ProviderBalance()
+	for _, providerBalance := range providerBalances {
+		isLow := providerBalance.IsLow()
+		log.Debug("provider balance",
+			logger.F("provider", providerBalance.Provider),
+			logger.F("balance", providerBalance.Balance),
+			logger.F("min_balance", providerBalance.MinBalance),
+		)
+		wasLow, err := p.cacheService.SwapProviderLowBalance(ctx, providerBalance.Provider, isLow)
+		if err != nil {
+			log.Error("fail to save provider low balance flag", logger.FError(err))
+			return err
+		}
+		if wasLow == isLow {
+			continue
+		}
+		if err := p.alertAdminChat(ctx, providerBalance, conf.SuspendPurchases); err != nil {
+			log.Error("fail to alert admin chat about provider balance", logger.FError(err))
+			return err
+		}
+	}
+	// a suspension left from the time the option was on is lifted
+	if !conf.SuspendPurchases {
+		return p.cacheService.SetPurchasesSuspended(ctx, false)
+	}
+	hasLowBalance := false
+	for _, provider := range app.Providers {
+		isLow, err := p.cacheService.IsProviderLowBalance(ctx, string(provider))
+		if err != nil {
+			log.Error("fail to get provider low balance flag", logger.F("provider", provider), logger.FError(err))
+			return err
+		}
+		hasLowBalance = hasLowBalance || isLow
+	}
+	return p.cacheService.SetPurchasesSuspended(ctx, hasLowBalance)
+}
+
+func (p *ProviderBalanceActivity) alertAdminChat(ctx context.Context, providerBalance postpone.ProviderBalance, suspendPurchases bool) error {
+	log := p.container.GetLogger()
+	adminChatID := p.container.GetConfig().AdminChatID()
+	if adminChatID == 0 {
+		log.Debug("admin chat is not configured, skip provider balance alert")
+		return nil
+	}
+	localizer := p.container.GetLocalizer("en")
+	templateData := map[string]any{
+		"Provider":   providerBalance.Provider,
+		"Balance":    strconv.FormatFloat(providerBalance.Balance, 'f', 2, 64),
+		"MinBalance": strconv.FormatFloat(providerBalance.MinBalance, 'f', 2, 64),
+		"Currency":   providerBalance.Currency,
+	}
+	var text string
+	if providerBalance.IsLow() {
+		text = localizer.LocalizedStringWithTemplateData("provider_low_balance_alert", templateData)
+		if suspendPurchases {
+			text += "\n" + localizer.LocalizedString("purchases_suspended_alert")
+		}
+	} else {
+		text = localizer.LocalizedStringWithTemplateData("provider_balance_restored_alert", templateData)
+	}
+	sendMessage := telegram.SendResponse{
+		ChatID: adminChatID,
+		Text:   text,
+	}
+	return p.telegramService.Enqueue(ctx, app.TransactionalOutboundPriority, app.SendMessageOutboundMethod, adminChatID, &sendMessage)
+}
@@ -0,0 +1,97 @@
+package activity
+
+import (
+	"context"
+	"go-ton-pass-telegram-bot/internal/container"
+	"go-ton-pass-telegram-bot/internal/repository"
+	"go-ton-pass-telegram-bot/internal/service"
+	"go-ton-pass-telegram-bot/pkg/logger"
+	"sort"
+	"time"
+)
+
+type ServicePopularityActivity struct {
+	container            container.Container
+	cacheService         service.Cache
+	smsHistoryRepository repository.SMSHistoryRepository
+}
+
+func NewServicePopularityActivity(
+	container container.Container,
+	cacheService service.Cache,
+	smsHistoryRepository repository.SMSHistoryRepository,
+) *ServicePopularityActivity {
+	return &ServicePopularityActivity{
+		container:            container,
+		cacheService:         cacheService,
+		smsHistoryRepository: smsHistoryRepository,
+	}
+}
+
+// RefreshServicePopularity ranks services by our own purchases over the sliding window,
+// once for all buyers and once per preferred language, and overwrites the rankings in the cache.
+func (s *ServicePopularityActivity) RefreshServicePopularity(ctx context.Context) error {
+	log := s.container.GetLogger()
+	windowDays := s.container.GetConfig().ServicePopularity().WindowDays
+	since := time.Now().AddDate(0, 0, -windowDays)
+	servicePurchaseCounts, err := s.smsHistoryRepository.FetchServicePurchaseCounts(ctx, since)
+	if err != nil {
+		log.Error("fail to fetch service purchase counts", logger.FError(err))
+		return err
+	}
+	globalCounts := make(map[string]int64)
+	languageCounts := make(map[string]map[string]int64)
+	for _, servicePurchaseCount := range servicePurchaseCounts {
+		globalCounts[servicePurchaseCount.ServiceCode] += servicePurchaseCount.Count
+		if servicePurchaseCount.LanguageCode == nil {
+			continue
+		}
+		languageCode := *servicePurchaseCount.LanguageCode
+		if languageCounts[languageCode] == nil {
+			languageCounts[languageCode] = make(map[string]int64)
+		}
+		languageCounts[languageCode][servicePurchaseCount.ServiceCode] += servicePurchaseCount.Count
+	}
+	if err := s.cacheService.SaveServicePopularity(ctx, "", rankServiceCodes(globalCounts)); err != nil {
+		log.Error("fail to save global service popularity", logger.FError(err))
+		return err
+	}
+	// languages without purchases in the window are ranked empty, so their previous ranking doesn't linger
+	for _, language := range s.container.GetConfig().AvailableLanguages() {
+		if languageCounts[language.Code] == nil {
+			languageCounts[language.Code] = make(map[string]int64)
+		}
+	}
+	for languageCode, counts := range languageCounts {
+		if err := s.cacheService.SaveServicePopularity(ctx, languageCode, rankServiceCodes(counts)); err != nil {
+			log.Error("fail to save service popularity", logger.F("language_code", languageCode), logger.FError(err))
+			return err
+		}
+	}
+	if _, err := s.cacheService.IncrementCatalogVersion(ctx); err != nil {
+		log.Error("fail to increment catalog version", logger.FError(err))
+		return err
+	}
+	log.Debug("service popularity is refreshed",
+		logger.F("window_days", windowDays),
+		logger.F("services", len(globalCounts)),
+		logger.F("languages", len(languageCounts)),
+	)
+	return nil
+}
+
+func rankServiceCodes(counts map[string]int64) []string {
+	serviceCodes := make([]string, 0, len(counts))
+	for serviceCode := range counts {
+		serviceCodes = append(serviceCodes, serviceCode)
+	}
+	sort.Slice(serviceCodes, func(i, j int) bool {
+		lhsCount := counts[serviceCodes[i]]
+		rhsCount := counts[serviceCodes[j]]
+		if lhsCount == rhsCount {
+			return serviceCodes[i] < serviceCodes[j]
+		}
+		return lhsCount > rhsCount
+	})
+	return serviceCodes
+}
@@ -3,6 +3,7 @@ package activity
 import (
 	"context"
 	"go-ton-pass-telegram-bot/internal/container"
+	"go-ton-pass-telegram-bot/internal/manager"
 	"go-ton-pass-telegram-bot/internal/model/app"
 	"go-ton-pass-telegram-bot/internal/model/telegram"
 	"go-ton-pass-telegram-bot/internal/repository"
@@ -17,12 +18,13 @@ const (
 )
 
 type SMSActivity struct {
-	container            container.Container
-	telegramService      service.TelegramBotService
-	smsService           service.SMSService
-	profileRepository    repository.ProfileRepository
-	smsHistoryRepository repository.SMSHistoryRepository
-	formatterWorker      worker.Formatter
+	container                container.Container
+	telegramService          service.TelegramBotService
+	smsService               service.SMSService
+	profileRepository        repository.ProfileRepository
+	smsHistoryRepository     repository.SMSHistoryRepository
+	activationGroupMessenger manager.ActivationGroupMessenger
+	formatterWorker          worker.Formatter
 }
 
 func NewSMSActivity(
@@ -31,14 +33,17 @@ func NewSMSActivity(
 	smsService service.SMSService,
 	profileRepository repository.ProfileRepository,
 	smsHistoryRepository repository.SMSHistoryRepository,
+	activationGroupRepository repository.ActivationGroupRepository,
 ) *SMSActivity {
+	activationGroupMessenger := manager.NewActivationGroupMessenger(container, telegramService, activationGroupRepository, smsHistoryRepository)
 	return &SMSActivity{
-		container:            container,
-		telegramService:      telegramService,
-		smsService:           smsService,
-		profileRepository:    profileRepository,
-		smsHistoryRepository: smsHistoryRepository,
-		formatterWorker:      worker.NewFormatter(container),
+		container:                container,
+		telegramService:          telegramService,
+		smsService:               smsService,
+		profileRepository:        profileRepository,
+		smsHistoryRepository:     smsHistoryRepository,
+		activationGroupMessenger: activationGroupMessenger,
+		formatterWorker:          worker.NewFormatter(container),
 	}
 }
 
@@ -83,12 +88,19 @@ func (s *SMSActivity) RefundTimeOutMessage(ctx context.Context, chatID int64, pr
 		log.Debug("fail to get profile by id", logger.F("profile_id", profileID))
 		return "", err
 	}
+	if profile.IsBotBlocked() {
+		log.Debug("skip message to profile that has blocked the bot", logger.F("profile_id", profileID))
+		return "", nil
+	}
 	langCode := profile.PreferredLanguage
 	smsHistory, err := s.smsHistoryRepository.GetByActivationID(ctx, activationID)
 	if err != nil {
 		log.Debug("fail to get sms history by id", logger.F("activation_id", activationID))
 		return "", err
 	}
+	if smsHistory.ActivationGroupID != nil {
+		return "", s.activationGroupMessenger.Refresh(ctx, *smsHistory.ActivationGroupID, *langCode)
+	}
 	respText := s.formatterWorker.FailSMSActivation(*langCode, smsHistory)
 	replyKeyboardRemove := telegram.ReplyKeyboardRemove{RemoveKeyboard: true}
 	sendPhoto := telegram.SendPhoto{
@@ -98,7 +110,7 @@ func (s *SMSActivity) RefundTimeOutMessage(ctx context.Context, chatID int64, pr
 		ReplyMarkup: replyKeyboardRemove,
 		ParseMode:   utils.NewString("MarkdownV2"),
 	}
-	return "", s.telegramService.SendResponse(sendPhoto, app.SendPhotoTelegramMethod)
+	return "", s.telegramService.Enqueue(ctx, app.TransactionalOutboundPriority, app.SendPhotoOutboundMethod, chatID, &sendPhoto)
 }
 
 func (s *SMSActivity) UserRefundMessage(ctx context.Context, chatID int64, profileID int64, activationID int64) (string, error) {
@@ -108,12 +120,19 @@ func (s *SMSActivity) UserRefundMessage(ctx context.Context, chatID int64, profi
 		log.Debug("fail to get profile by id", logger.F("profile_id", profileID))
 		return "", err
 	}
+	if profile.IsBotBlocked() {
+		log.Debug("skip message to profile that has blocked the bot", logger.F("profile_id", profileID))
+		return "", nil
+	}
 	langCode := profile.PreferredLanguage
 	smsHistory, err := s.smsHistoryRepository.GetByActivationID(ctx, activationID)
 	if err != nil {
 		log.Debug("fail to get sms history by id", logger.F("activation_id", activationID))
 		return "", err
 	}
+	if smsHistory.ActivationGroupID != nil {
+		return "", s.activationGroupMessenger.Refresh(ctx, *smsHistory.ActivationGroupID, *langCode)
+	}
 	respText := s.formatterWorker.ManualCancelActivation(*langCode, smsHistory)
 	replyKeyboardRemove := telegram.ReplyKeyboardRemove{RemoveKeyboard: true}
 	sendPhoto := telegram.SendPhoto{
@@ -123,5 +142,5 @@ func (s *SMSActivity) UserRefundMessage(ctx context.Context, chatID int64, profi
 		ReplyMarkup: replyKeyboardRemove,
 		ParseMode:   utils.NewString("MarkdownV2"),
 	}
-	return "", s.telegramService.SendResponse(sendPhoto, app.SendPhotoTelegramMethod)
+	return "", s.telegramService.Enqueue(ctx, app.TransactionalOutboundPriority, app.SendPhotoOutboundMethod, chatID, &sendPhoto)
 }
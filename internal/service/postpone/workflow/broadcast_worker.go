@@ -0,0 +1,87 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"go-ton-pass-telegram-bot/internal/container"
+	"go-ton-pass-telegram-bot/internal/model/app"
+	"go-ton-pass-telegram-bot/internal/model/postpone"
+	"go-ton-pass-telegram-bot/internal/repository"
+	"go-ton-pass-telegram-bot/internal/service"
+	"go-ton-pass-telegram-bot/internal/service/postpone/workflow/activity"
+	"go-ton-pass-telegram-bot/pkg/logger"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+)
+
+const (
+	BroadcastQueueName = "broadcast"
+	// BroadcastControlSignalName carries app.BroadcastAction to pause, resume or cancel the running broadcast.
+	BroadcastControlSignalName = "broadcast_control"
+)
+
+type BroadcastWorker interface {
+	Start(ctx context.Context, broadcastID int64) error
+	Control(ctx context.Context, broadcastID int64, action app.BroadcastAction) error
+	Prepare()
+}
+
+type broadcastWorker struct {
+	container container.Container
+	client    client.Client
+	activity  *activity.BroadcastActivity
+}
+
+func NewBroadcastWorker(
+	container container.Container,
+	client client.Client,
+	telegramService service.TelegramBotService,
+	profileRepository repository.ProfileRepository,
+	broadcastRepository repository.BroadcastRepository,
+) BroadcastWorker {
+	a := activity.NewBroadcastActivity(container, telegramService, profileRepository, broadcastRepository)
+	return &broadcastWorker{
+		container: container,
+		client:    client,
+		activity:  a,
+	}
+}
+
+func (b *broadcastWorker) Prepare() {
+	w := worker.New(b.client, BroadcastQueueName, worker.Options{})
+	w.RegisterWorkflow(BroadcastWorkflow)
+	w.RegisterActivity(b.activity)
+	go func() {
+		_ = w.Run(worker.InterruptCh())
+	}()
+}
+
+// Start runs the workflow with the id of the broadcast, so the broadcast is never sent by two workflows.
+func (b *broadcastWorker) Start(ctx context.Context, broadcastID int64) error {
+	log := b.container.GetLogger()
+	startWorkflowOptions := client.StartWorkflowOptions{
+		ID:        broadcastWorkflowID(broadcastID),
+		TaskQueue: BroadcastQueueName,
+	}
+	input := postpone.Broadcast{
+		BroadcastID: broadcastID,
+	}
+	workflowRun, err := b.client.ExecuteWorkflow(ctx, startWorkflowOptions, BroadcastWorkflow, input)
+	if err != nil {
+		log.Error("fail to start broadcast workflow", logger.F("broadcast_id", broadcastID), logger.FError(err))
+		return err
+	}
+	log.Debug("broadcast workflow is started",
+		logger.F("workflow_id", workflowRun.GetID()),
+		logger.F("run_id", workflowRun.GetRunID()),
+	)
+	return nil
+}
+
+func (b *broadcastWorker) Control(ctx context.Context, broadcastID int64, action app.BroadcastAction) error {
+	return b.client.SignalWorkflow(ctx, broadcastWorkflowID(broadcastID), "", BroadcastControlSignalName, action)
+}
+
+func broadcastWorkflowID(broadcastID int64) string {
+	return fmt.Sprintf("broadcast_%d", broadcastID)
+}
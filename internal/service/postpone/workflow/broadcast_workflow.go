@@ -0,0 +1,125 @@
+package workflow
+
+import (
+	"go-ton-pass-telegram-bot/internal/model/app"
+	"go-ton-pass-telegram-bot/internal/model/domain"
+	"go-ton-pass-telegram-bot/internal/model/postpone"
+	"go-ton-pass-telegram-bot/internal/service/postpone/workflow/activity"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+	"time"
+)
+
+// broadcastBatchesPerRun keeps the history of the workflow short, the workflow continues as new after these batches.
+const broadcastBatchesPerRun = 50
+
+// BroadcastWorkflow sends the broadcast batch by batch and reports the progress to the author after every batch.
+// Signals are handled between batches, a paused broadcast waits for the next signal.
+func BroadcastWorkflow(ctx workflow.Context, input postpone.Broadcast) (string, error) {
+	retryPolicy := &temporal.RetryPolicy{
+		InitialInterval:    time.Second,
+		BackoffCoefficient: 2.0,
+		MaximumInterval:    100 * time.Second,
+		MaximumAttempts:    500,
+	}
+	options := workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy:         retryPolicy,
+	}
+	ctx = workflow.WithActivityOptions(ctx, options)
+	// a batch waits for the marketing lane of the dispatcher, other messages go before it, and heartbeats
+	// after every delivery
+	sendOptions := options
+	sendOptions.StartToCloseTimeout = 15 * time.Minute
+	sendOptions.HeartbeatTimeout = 2 * time.Minute
+	sendCtx := workflow.WithActivityOptions(ctx, sendOptions)
+	var a *activity.BroadcastActivity
+	if !input.IsStarted {
+		if err := workflow.ExecuteActivity(ctx, a.CreateDeliveries, input.BroadcastID).Get(ctx, nil); err != nil {
+			return "", err
+		}
+		input.IsStarted = true
+		if err := workflow.ExecuteActivity(ctx, a.ReportProgress, input.BroadcastID).Get(ctx, nil); err != nil {
+			return "", err
+		}
+	}
+	signalChannel := workflow.GetSignalChannel(ctx, BroadcastControlSignalName)
+	for batch := 0; ; batch++ {
+		isCancelled, err := handleBroadcastSignals(ctx, signalChannel, &input)
+		if err != nil {
+			return "", err
+		}
+		if isCancelled {
+			return "broadcast is cancelled", nil
+		}
+		if batch == broadcastBatchesPerRun {
+			return "", workflow.NewContinueAsNewError(ctx, BroadcastWorkflow, input)
+		}
+		var processed int
+		if err := workflow.ExecuteActivity(sendCtx, a.SendBatch, input.BroadcastID).Get(ctx, &processed); err != nil {
+			return "", err
+		}
+		if processed == 0 {
+			break
+		}
+		if err := workflow.ExecuteActivity(ctx, a.ReportProgress, input.BroadcastID).Get(ctx, nil); err != nil {
+			return "", err
+		}
+	}
+	fromStatuses := []domain.BroadcastStatus{domain.RunningBroadcastStatus}
+	if err := changeBroadcastStatus(ctx, input.BroadcastID, fromStatuses, domain.CompletedBroadcastStatus); err != nil {
+		return "", err
+	}
+	return "broadcast is completed", nil
+}
+
+// handleBroadcastSignals applies signals received since the last batch, it blocks while the broadcast is paused.
+func handleBroadcastSignals(ctx workflow.Context, signalChannel workflow.ReceiveChannel, input *postpone.Broadcast) (bool, error) {
+	for {
+		var action app.BroadcastAction
+		if input.IsPaused {
+			signalChannel.Receive(ctx, &action)
+		} else if !signalChannel.ReceiveAsync(&action) {
+			return false, nil
+		}
+		switch action {
+		case app.PauseBroadcastAction:
+			if input.IsPaused {
+				continue
+			}
+			fromStatuses := []domain.BroadcastStatus{domain.RunningBroadcastStatus}
+			if err := changeBroadcastStatus(ctx, input.BroadcastID, fromStatuses, domain.PausedBroadcastStatus); err != nil {
+				return false, err
+			}
+			input.IsPaused = true
+		case app.ResumeBroadcastAction:
+			if !input.IsPaused {
+				continue
+			}
+			fromStatuses := []domain.BroadcastStatus{domain.PausedBroadcastStatus}
+			if err := changeBroadcastStatus(ctx, input.BroadcastID, fromStatuses, domain.RunningBroadcastStatus); err != nil {
+				return false, err
+			}
+			input.IsPaused = false
+		case app.CancelBroadcastAction:
+			fromStatuses := []domain.BroadcastStatus{domain.RunningBroadcastStatus, domain.PausedBroadcastStatus}
+			if err := changeBroadcastStatus(ctx, input.BroadcastID, fromStatuses, domain.CancelledBroadcastStatus); err != nil {
+				return false, err
+			}
+			return true, nil
+		}
+	}
+}
+
+func changeBroadcastStatus(
+	ctx workflow.Context,
+	broadcastID int64,
+	fromStatuses []domain.BroadcastStatus,
+	status domain.BroadcastStatus,
+) error {
+	var a *activity.BroadcastActivity
+	if err := workflow.ExecuteActivity(ctx, a.ChangeStatus, broadcastID, fromStatuses, status).Get(ctx, nil); err != nil {
+		return err
+	}
+	return workflow.ExecuteActivity(ctx, a.ReportProgress, broadcastID).Get(ctx, nil)
+}
@@ -0,0 +1,71 @@
+package workflow
+
+import (
+	"context"
+	"go-ton-pass-telegram-bot/internal/container"
+	"go-ton-pass-telegram-bot/internal/repository"
+	"go-ton-pass-telegram-bot/internal/service"
+	"go-ton-pass-telegram-bot/internal/service/postpone/workflow/activity"
+	"go-ton-pass-telegram-bot/pkg/logger"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+)
+
+const (
+	CatalogSyncQueueName           = "catalog_sync"
+	catalogSyncWorkflowID          = "catalog_sync"
+	catalogSyncBootstrapWorkflowID = "catalog_sync_bootstrap"
+)
+
+type CatalogSyncWorker interface {
+	Schedule(ctx context.Context) error
+	Prepare()
+}
+
+type catalogSyncWorker struct {
+	container container.Container
+	client    client.Client
+	activity  *activity.CatalogSyncActivity
+}
+
+func NewCatalogSyncWorker(
+	container container.Container,
+	client client.Client,
+	smsService service.SMSService,
+	cacheService service.Cache,
+	catalogRepository repository.CatalogRepository,
+) CatalogSyncWorker {
+	a := activity.NewCatalogSyncActivity(container, smsService, cacheService, catalogRepository)
+	return &catalogSyncWorker{
+		container: container,
+		client:    client,
+		activity:  a,
+	}
+}
+
+func (c *catalogSyncWorker) Prepare() {
+	w := worker.New(c.client, CatalogSyncQueueName, worker.Options{})
+	w.RegisterWorkflow(CatalogSyncWorkflow)
+	w.RegisterActivity(c.activity)
+	go func() {
+		_ = w.Run(worker.InterruptCh())
+	}()
+}
+
+// Schedule starts the cron workflow and runs one sync right away, since a cron run waits for its schedule.
+func (c *catalogSyncWorker) Schedule(ctx context.Context) error {
+	log := c.container.GetLogger()
+	cronSchedule := c.container.GetConfig().Catalog().SyncCronSchedule
+	if err := scheduleCron(ctx, c.container, c.client, catalogSyncWorkflowID, CatalogSyncQueueName, cronSchedule, CatalogSyncWorkflow); err != nil {
+		return err
+	}
+	bootstrapWorkflowOptions := client.StartWorkflowOptions{
+		ID:        catalogSyncBootstrapWorkflowID,
+		TaskQueue: CatalogSyncQueueName,
+	}
+	if _, err := c.client.ExecuteWorkflow(ctx, bootstrapWorkflowOptions, CatalogSyncWorkflow); err != nil {
+		log.Error("fail to start catalog sync workflow", logger.FError(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package workflow
+
+import (
+	"go-ton-pass-telegram-bot/internal/service/postpone/workflow/activity"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+	"time"
+)
+
+func CatalogSyncWorkflow(ctx workflow.Context) (string, error) {
+	successMsg := "success sync catalog"
+	retryPolicy := &temporal.RetryPolicy{
+		InitialInterval:    time.Second,
+		BackoffCoefficient: 2.0,
+		MaximumInterval:    time.Minute,
+		MaximumAttempts:    5,
+	}
+	options := workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy:         retryPolicy,
+	}
+	listCtx := workflow.WithActivityOptions(ctx, options)
+	var a *activity.CatalogSyncActivity
+	if err := workflow.ExecuteActivity(listCtx, a.SyncCountries).Get(listCtx, nil); err != nil {
+		return "", err
+	}
+	var serviceCodes []string
+	if err := workflow.ExecuteActivity(listCtx, a.SyncServices).Get(listCtx, &serviceCodes); err != nil {
+		return "", err
+	}
+	// prices are requested service by service, so the activity takes much longer than the lists
+	options.StartToCloseTimeout = 30 * time.Minute
+	options.RetryPolicy = &temporal.RetryPolicy{
+		InitialInterval:    time.Minute,
+		BackoffCoefficient: 2.0,
+		MaximumAttempts:    2,
+	}
+	pricesCtx := workflow.WithActivityOptions(ctx, options)
+	if err := workflow.ExecuteActivity(pricesCtx, a.SyncServicePrices, serviceCodes).Get(pricesCtx, nil); err != nil {
+		return "", err
+	}
+	return successMsg, nil
+}
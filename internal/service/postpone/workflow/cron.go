@@ -0,0 +1,84 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"go-ton-pass-telegram-bot/internal/container"
+	"go-ton-pass-telegram-bot/pkg/logger"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+)
+
+// cronScheduleMemoKey keeps the schedule a cron workflow was started with, temporal doesn't expose it otherwise.
+const cronScheduleMemoKey = "cron_schedule"
+
+// scheduleCron starts the cron workflow once. A running workflow with another schedule is terminated and started
+// again, since temporal keeps the schedule of a running workflow id.
+func scheduleCron(
+	ctx context.Context,
+	container container.Container,
+	temporalClient client.Client,
+	workflowID string,
+	taskQueue string,
+	cronSchedule string,
+	workflow interface{},
+) error {
+	log := container.GetLogger()
+	runningCronSchedule, isRunning, err := fetchRunningCronSchedule(ctx, temporalClient, workflowID)
+	if err != nil {
+		log.Error("fail to describe cron workflow", logger.F("workflow_id", workflowID), logger.FError(err))
+		return err
+	}
+	if isRunning && runningCronSchedule != cronSchedule {
+		if err := temporalClient.TerminateWorkflow(ctx, workflowID, "", "cron schedule has changed"); err != nil {
+			log.Error("fail to terminate cron workflow", logger.F("workflow_id", workflowID), logger.FError(err))
+			return err
+		}
+		log.Debug("cron workflow with outdated schedule is terminated",
+			logger.F("workflow_id", workflowID),
+			logger.F("cron_schedule", runningCronSchedule),
+		)
+	}
+	startWorkflowOptions := client.StartWorkflowOptions{
+		ID:           workflowID,
+		TaskQueue:    taskQueue,
+		CronSchedule: cronSchedule,
+		Memo: map[string]interface{}{
+			cronScheduleMemoKey: cronSchedule,
+		},
+	}
+	workflowRun, err := temporalClient.ExecuteWorkflow(ctx, startWorkflowOptions, workflow)
+	if err != nil {
+		log.Error("fail to schedule cron workflow", logger.F("workflow_id", workflowID), logger.FError(err))
+		return err
+	}
+	log.Debug("cron workflow is scheduled",
+		logger.F("workflow_id", workflowRun.GetID()),
+		logger.F("cron_schedule", cronSchedule),
+	)
+	return nil
+}
+
+func fetchRunningCronSchedule(ctx context.Context, temporalClient client.Client, workflowID string) (string, bool, error) {
+	response, err := temporalClient.DescribeWorkflowExecution(ctx, workflowID, "")
+	var notFoundError *serviceerror.NotFound
+	if errors.As(err, &notFoundError) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	info := response.GetWorkflowExecutionInfo()
+	if info.GetStatus() != enums.WORKFLOW_EXECUTION_STATUS_RUNNING {
+		return "", false, nil
+	}
+	var cronSchedule string
+	// workflows started before the memo was kept have no schedule and are restarted once
+	if payload, ok := info.GetMemo().GetFields()[cronScheduleMemoKey]; ok {
+		if err := converter.GetDefaultDataConverter().FromPayload(payload, &cronSchedule); err != nil {
+			return "", false, err
+		}
+	}
+	return cronSchedule, true, nil
+}
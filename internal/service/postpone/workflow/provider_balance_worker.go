@@ -0,0 +1,57 @@
+package workflow
+
+import (
+	"context"
+	"go-ton-pass-telegram-bot/internal/container"
+	"go-ton-pass-telegram-bot/internal/service"
+	"go-ton-pass-telegram-bot/internal/service/postpone/workflow/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+)
+
+const (
+	ProviderBalanceQueueName  = "provider_balance"
+	providerBalanceWorkflowID = "provider_balance_monitor"
+)
+
+type ProviderBalanceWorker interface {
+	Schedule(ctx context.Context) error
+	Prepare()
+}
+
+type providerBalanceWorker struct {
+	container container.Container
+	client    client.Client
+	activity  *activity.ProviderBalanceActivity
+}
+
+func NewProviderBalanceWorker(
+	container container.Container,
+	client client.Client,
+	telegramService service.TelegramBotService,
+	smsService service.SMSService,
+	cryptoPayBot service.CryptoPayBot,
+	cacheService service.Cache,
+) ProviderBalanceWorker {
+	a := activity.NewProviderBalanceActivity(container, telegramService, smsService, cryptoPayBot, cacheService)
+	return &providerBalanceWorker{
+		container: container,
+		client:    client,
+		activity:  a,
+	}
+}
+
+func (p *providerBalanceWorker) Prepare() {
+	w := worker.New(p.client, ProviderBalanceQueueName, worker.Options{})
+	w.RegisterWorkflow(ProviderBalanceWorkflow)
+	w.RegisterActivity(p.activity)
+	go func() {
+		_ = w.Run(worker.InterruptCh())
+	}()
+}
+
+// Schedule starts the cron workflow, see scheduleCron.
+func (p *providerBalanceWorker) Schedule(ctx context.Context) error {
+	cronSchedule := p.container.GetConfig().ProviderBalance().CheckCronSchedule
+	return scheduleCron(ctx, p.container, p.client, providerBalanceWorkflowID, ProviderBalanceQueueName, cronSchedule, ProviderBalanceWorkflow)
+}
@@ -0,0 +1,48 @@
+package workflow
+
+import (
+	"errors"
+	"go-ton-pass-telegram-bot/internal/model/postpone"
+	"go-ton-pass-telegram-bot/internal/service/postpone/workflow/activity"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+	"time"
+)
+
+func ProviderBalanceWorkflow(ctx workflow.Context) (string, error) {
+	successMsg := "success check provider balances"
+	retryPolicy := &temporal.RetryPolicy{
+		InitialInterval:    time.Second,
+		BackoffCoefficient: 2.0,
+		MaximumInterval:    time.Minute,
+		MaximumAttempts:    5,
+	}
+	options := workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy:         retryPolicy,
+	}
+	ctx = workflow.WithActivityOptions(ctx, options)
+	var a *activity.ProviderBalanceActivity
+	// balances are fetched independently, a failing provider doesn't hide the balance of the other one
+	futures := []workflow.Future{
+		workflow.ExecuteActivity(ctx, a.FetchSMSActivateBalance),
+		workflow.ExecuteActivity(ctx, a.FetchCryptoBotBalance),
+	}
+	providerBalances := make([]postpone.ProviderBalance, 0, len(futures))
+	var fetchErr error
+	for _, future := range futures {
+		var providerBalance postpone.ProviderBalance
+		if err := future.Get(ctx, &providerBalance); err != nil {
+			fetchErr = errors.Join(fetchErr, err)
+			continue
+		}
+		providerBalances = append(providerBalances, providerBalance)
+	}
+	if err := workflow.ExecuteActivity(ctx, a.ApplyProviderBalances, providerBalances).Get(ctx, nil); err != nil {
+		return "", err
+	}
+	if fetchErr != nil {
+		return "", fetchErr
+	}
+	return successMsg, nil
+}
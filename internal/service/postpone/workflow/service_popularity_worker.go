@@ -0,0 +1,56 @@
+package workflow
+
+import (
+	"context"
+	"go-ton-pass-telegram-bot/internal/container"
+	"go-ton-pass-telegram-bot/internal/repository"
+	"go-ton-pass-telegram-bot/internal/service"
+	"go-ton-pass-telegram-bot/internal/service/postpone/workflow/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+)
+
+const (
+	ServicePopularityQueueName  = "service_popularity"
+	servicePopularityWorkflowID = "service_popularity_refresh"
+)
+
+type ServicePopularityWorker interface {
+	Schedule(ctx context.Context) error
+	Prepare()
+}
+
+type servicePopularityWorker struct {
+	container container.Container
+	client    client.Client
+	activity  *activity.ServicePopularityActivity
+}
+
+func NewServicePopularityWorker(
+	container container.Container,
+	client client.Client,
+	cacheService service.Cache,
+	smsHistoryRepository repository.SMSHistoryRepository,
+) ServicePopularityWorker {
+	a := activity.NewServicePopularityActivity(container, cacheService, smsHistoryRepository)
+	return &servicePopularityWorker{
+		container: container,
+		client:    client,
+		activity:  a,
+	}
+}
+
+func (s *servicePopularityWorker) Prepare() {
+	w := worker.New(s.client, ServicePopularityQueueName, worker.Options{})
+	w.RegisterWorkflow(ServicePopularityWorkflow)
+	w.RegisterActivity(s.activity)
+	go func() {
+		_ = w.Run(worker.InterruptCh())
+	}()
+}
+
+// Schedule starts the cron workflow, see scheduleCron.
+func (s *servicePopularityWorker) Schedule(ctx context.Context) error {
+	cronSchedule := s.container.GetConfig().ServicePopularity().RefreshCronSchedule
+	return scheduleCron(ctx, s.container, s.client, servicePopularityWorkflowID, ServicePopularityQueueName, cronSchedule, ServicePopularityWorkflow)
+}
@@ -0,0 +1,28 @@
+package workflow
+
+import (
+	"go-ton-pass-telegram-bot/internal/service/postpone/workflow/activity"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+	"time"
+)
+
+func ServicePopularityWorkflow(ctx workflow.Context) (string, error) {
+	successMsg := "success refresh service popularity"
+	retryPolicy := &temporal.RetryPolicy{
+		InitialInterval:    time.Second,
+		BackoffCoefficient: 2.0,
+		MaximumInterval:    time.Minute,
+		MaximumAttempts:    5,
+	}
+	options := workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy:         retryPolicy,
+	}
+	ctx = workflow.WithActivityOptions(ctx, options)
+	var a *activity.ServicePopularityActivity
+	if err := workflow.ExecuteActivity(ctx, a.RefreshServicePopularity).Get(ctx, nil); err != nil {
+		return "", err
+	}
+	return successMsg, nil
+}
@@ -35,8 +35,9 @@ func NewSMSActivateWorker(
 	smsService service.SMSService,
 	profileRepository repository.ProfileRepository,
 	smsHistoryRepository repository.SMSHistoryRepository,
+	activationGroupRepository repository.ActivationGroupRepository,
 ) SMSActivateWorker {
-	a := activity.NewSMSActivity(container, telegramService, smsService, profileRepository, smsHistoryRepository)
+	a := activity.NewSMSActivity(container, telegramService, smsService, profileRepository, smsHistoryRepository, activationGroupRepository)
 	w := smsActivateWorker{
 		container: container,
 		client:    client,
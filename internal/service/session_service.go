@@ -2,28 +2,27 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/redis/go-redis/v9"
 	"go-ton-pass-telegram-bot/internal/container"
 	"go-ton-pass-telegram-bot/internal/model/app"
 	"go-ton-pass-telegram-bot/internal/utils"
+	"go-ton-pass-telegram-bot/pkg/fsm"
 	"go-ton-pass-telegram-bot/pkg/logger"
 	"time"
 )
 
+// SessionService keeps conversations of users with the bot, see fsm.Machine, and short-living values of them.
 type SessionService interface {
-	ClearBotStateForUser(ctx context.Context, userID int64) error
-	GetBotStateForUser(ctx context.Context, userID int64) app.BotState
-	SaveBotStateForUser(ctx context.Context, botState app.BotState, userID int64) error
+	fsm.Storage
 	SaveString(ctx context.Context, key string, value string, userID int64) error
 	GetString(ctx context.Context, key string, userID int64) (*string, error)
 	ClearString(ctx context.Context, key string, userID int64) error
 }
 
-const (
-	SelectedCryptoBotPayCurrencyAbbrSessionKey = "selected_pay_currency"
-	SelectedPaymentMethodSessionKey            = "selected_payment_method"
-)
+const conversationSessionKey = "conversation"
 
 type sessionService struct {
 	container container.Container
@@ -37,45 +36,54 @@ func NewSessionService(container container.Container, client *redis.Client) Sess
 	}
 }
 
-func (s *sessionService) GetBotStateForUser(ctx context.Context, userID int64) app.BotState {
+// LoadConversation returns nil when the user has no conversation or it has been dropped by the ttl.
+func (s *sessionService) LoadConversation(ctx context.Context, userID int64) (*fsm.Session, error) {
 	log := s.container.GetLogger()
-	key := keyForBotState("bot_state", userID)
-	value, err := s.client.Get(ctx, key).Int64()
-	if err != nil {
-		log.Debug("get BotState failed", logger.F("key", key))
-		return app.IDLEState
+	key := keyForSession(conversationSessionKey, userID)
+	encodedSession, err := s.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	} else if err != nil {
+		log.Debug("get conversation failed", logger.F("key", key), logger.FError(err))
+		return nil, err
 	}
-
-	log.Debug("get BotState", logger.F("bot state", app.BotState(value)))
-	return app.BotState(value)
+	var session fsm.Session
+	if err := json.Unmarshal(encodedSession, &session); err != nil {
+		return nil, err
+	}
+	log.Debug("get conversation", logger.F("state", session.State))
+	return &session, nil
 }
 
-func (s *sessionService) SaveBotStateForUser(ctx context.Context, botState app.BotState, userID int64) error {
+func (s *sessionService) SaveConversation(ctx context.Context, userID int64, session *fsm.Session, ttl time.Duration) error {
 	log := s.container.GetLogger()
-	key := keyForBotState("bot_state", userID)
-	ttl := 5 * time.Minute
-	log.Debug("will save bot state", logger.F("userID", userID), logger.F("bot state", botState))
-	return s.client.Set(ctx, key, int64(botState), ttl).Err()
+	key := keyForSession(conversationSessionKey, userID)
+	encodedSession, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	log.Debug("will save conversation", logger.F("userID", userID), logger.F("state", session.State))
+	return s.client.Set(ctx, key, encodedSession, ttl).Err()
 }
 
-func (s *sessionService) ClearBotStateForUser(ctx context.Context, userID int64) error {
+func (s *sessionService) ClearConversation(ctx context.Context, userID int64) error {
 	log := s.container.GetLogger()
-	log.Debug("will clear bot state", logger.F("userID", userID))
-	key := keyForBotState("bot_state", userID)
+	log.Debug("will clear conversation", logger.F("userID", userID))
+	key := keyForSession(conversationSessionKey, userID)
 	return s.client.Del(ctx, key).Err()
 }
 
 func (s *sessionService) SaveString(ctx context.Context, key string, value string, userID int64) error {
 	log := s.container.GetLogger()
 	log.Debug("will save string", logger.F("userID", userID), logger.F("value", value), logger.F("key", key))
-	transformedKey := keyForBotState(key, userID)
+	transformedKey := keyForSession(key, userID)
 	ttl := 60 * time.Minute
 	return s.client.Set(ctx, transformedKey, value, ttl).Err()
 }
 
 func (s *sessionService) GetString(ctx context.Context, key string, userID int64) (*string, error) {
 	log := s.container.GetLogger()
-	transformedKey := keyForBotState(key, userID)
+	transformedKey := keyForSession(key, userID)
 	value, err := s.client.Get(ctx, transformedKey).Result()
 	log.Debug("get string", logger.F("userID", userID), logger.F("value", value), logger.F("key", key))
 	if err != nil {
@@ -88,11 +96,11 @@ func (s *sessionService) GetString(ctx context.Context, key string, userID int64
 
 func (s *sessionService) ClearString(ctx context.Context, key string, userID int64) error {
 	log := s.container.GetLogger()
-	log.Debug("will clear bot state", logger.F("userID", userID))
-	transformedKey := keyForBotState(key, userID)
+	log.Debug("will clear string", logger.F("userID", userID))
+	transformedKey := keyForSession(key, userID)
 	return s.client.Del(ctx, transformedKey).Err()
 }
 
-func keyForBotState(key string, userID int64) string {
+func keyForSession(key string, userID int64) string {
 	return fmt.Sprintf("%s/%d", key, userID)
 }
@@ -24,9 +24,12 @@ type SMSService interface {
 	GetCountries() ([]sms.Country, error)
 	GetServicePrices(code string) ([]sms.PriceForService, error)
 	GetPopularServiceCodeList() ([]string, error)
-	RequestNumber(serviceCode string, countryNumber int64, maxPrice float64) (*sms.RequestedNumber, error)
+	GetOperators(countryNumber int64) ([]string, error)
+	GetOperatorPrice(serviceCode string, countryNumber int64, operator string) (*sms.OperatorPrice, error)
+	RequestNumber(serviceCode string, countryNumber int64, operator string, maxPrice float64) (*sms.RequestedNumber, error)
 	GetStatus(activationID int64) (app.SMSActivationState, error)
 	CancelActivation(activationID int64) error
+	GetBalance() (float64, error)
 }
 
 type smsService struct {
@@ -135,11 +138,80 @@ func (s *smsService) GetServicePrices(serviceCode string) ([]sms.PriceForService
 	return priceForServices, nil
 }
 
-func (s *smsService) RequestNumber(serviceCode string, countryNumber int64, maxPrice float64) (*sms.RequestedNumber, error) {
+func (s *smsService) GetOperators(countryNumber int64) ([]string, error) {
+	type Response struct {
+		Status           string              `json:"status"`
+		CountryOperators map[string][]string `json:"countryOperators"`
+	}
+	urlValues := url.Values{}
+	urlValues.Set("country", strconv.FormatInt(countryNumber, 10))
+	req, err := s.prepareRequest(app.GetOperatorsSMSAction, urlValues)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	response := Response{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		// the provider answers with a plain text status when the country has no operators
+		if smsErr := sms.DecodeError(string(body)); smsErr != nil {
+			return nil, *smsErr
+		}
+		return nil, err
+	}
+	operators := response.CountryOperators[strconv.FormatInt(countryNumber, 10)]
+	return utils.Filter(operators, func(operator string) bool {
+		return operator != "" && operator != "any"
+	}), nil
+}
+
+// GetOperatorPrice returns the price of the service for numbers of the operator in the country.
+func (s *smsService) GetOperatorPrice(serviceCode string, countryNumber int64, operator string) (*sms.OperatorPrice, error) {
+	urlValues := url.Values{}
+	urlValues.Set("service", serviceCode)
+	urlValues.Set("country", strconv.FormatInt(countryNumber, 10))
+	urlValues.Set("operator", operator)
+	req, err := s.prepareRequest(app.GetPricesSMSAction, urlValues)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var result map[string]map[string]sms.ServicePrice
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	servicePrice, ok := result[strconv.FormatInt(countryNumber, 10)][serviceCode]
+	if !ok {
+		return nil, app.EmptyValueError
+	}
+	return &sms.OperatorPrice{
+		Operator: operator,
+		Cost:     servicePrice.Cost,
+		Count:    servicePrice.Count,
+	}, nil
+}
+
+func (s *smsService) RequestNumber(serviceCode string, countryNumber int64, operator string, maxPrice float64) (*sms.RequestedNumber, error) {
 	log := s.container.GetLogger()
 	urlValues := url.Values{}
 	urlValues.Set("service", serviceCode)
 	urlValues.Set("country", strconv.FormatInt(countryNumber, 10))
+	if operator != "" {
+		urlValues.Set("operator", operator)
+	}
 	urlValues.Set("useCashBack", "true")
 	maxPriceInText := strconv.FormatFloat(maxPrice, 'f', 2, 64)
 	urlValues.Set("maxPrice", maxPriceInText)
@@ -166,8 +238,9 @@ func (s *smsService) RequestNumber(serviceCode string, countryNumber int64, maxP
 	err, errInfo := s.handleRequestNumberError(body)
 	if strings.EqualFold(err.Error(), sms.WrongMaxPriceErrorName) && errInfo != nil {
 		correctedPrice := errInfo["min"].(float64)
-		if math.Abs(correctedPrice-maxPrice) > 0.1 {
-			return s.RequestNumber(serviceCode, countryNumber, correctedPrice)
+		// the number is repeated only for a lower price, the profile has been charged for maxPrice
+		if correctedPrice < maxPrice && math.Abs(correctedPrice-maxPrice) > 0.1 {
+			return s.RequestNumber(serviceCode, countryNumber, operator, correctedPrice)
 		}
 		return nil, err
 	}
@@ -220,6 +293,34 @@ func (s *smsService) CancelActivation(activationID int64) error {
 	return nil
 }
 
+func (s *smsService) GetBalance() (float64, error) {
+	log := s.container.GetLogger()
+	req, err := s.prepareRequest(app.GetBalanceSMSAction, url.Values{})
+	if err != nil {
+		return 0, err
+	}
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+	text := strings.TrimSpace(string(body))
+	log.Debug("get response from GetBalance endpoint", logger.F("response", text))
+	balanceText, ok := strings.CutPrefix(text, "ACCESS_BALANCE:")
+	if !ok {
+		if err := sms.DecodeError(text); err != nil {
+			return 0, *err
+		}
+		return 0, app.UnknownError
+	}
+	return strconv.ParseFloat(balanceText, 64)
+}
+
 func (s *smsService) prepareRequest(smsAction app.SMSAction, queryParams url.Values) (*http.Request, error) {
 	log := s.container.GetLogger()
 	apiKey := s.container.GetConfig().SMSKey()
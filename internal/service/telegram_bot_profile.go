@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-ton-pass-telegram-bot/internal/container"
+	"go-ton-pass-telegram-bot/internal/model/telegram"
+	"go-ton-pass-telegram-bot/pkg/logger"
+	"go-ton-pass-telegram-bot/pkg/telegram_bot"
+	"slices"
+)
+
+// defaultProfileLanguageCode is the language shown to users whose language has no translation,
+// it is pushed with the empty language code.
+const defaultProfileLanguageCode = "en"
+
+// TelegramBotProfile keeps the commands, descriptions and the name of the bot in line with locales.
+type TelegramBotProfile interface {
+	// Sync compares the profile on telegram servers with locales for every localized language
+	// and sends only what differs, setMyName and friends are heavily rate limited.
+	Sync(ctx context.Context) error
+}
+
+type telegramBotProfile struct {
+	container container.Container
+	client    telegram_bot.Client
+}
+
+func NewTelegramBotProfile(container container.Container, client telegram_bot.Client) TelegramBotProfile {
+	return &telegramBotProfile{
+		container: container,
+		client:    client,
+	}
+}
+
+func (t *telegramBotProfile) Sync(ctx context.Context) error {
+	var errs []error
+	for _, languageCode := range t.languageCodes() {
+		errs = append(
+			errs,
+			t.syncCommands(ctx, languageCode),
+			t.syncDescription(ctx, languageCode),
+			t.syncShortDescription(ctx, languageCode),
+			t.syncName(ctx, languageCode),
+		)
+	}
+	return errors.Join(errs...)
+}
+
+// languageCodes starts with the empty code of the default language followed by every localized one.
+func (t *telegramBotProfile) languageCodes() []string {
+	languageCodes := []string{""}
+	for _, language := range t.container.GetConfig().AvailableLanguages() {
+		languageCodes = append(languageCodes, language.Code)
+	}
+	return languageCodes
+}
+
+func (t *telegramBotProfile) syncCommands(ctx context.Context, languageCode string) error {
+	log := t.container.GetLogger()
+	commands, err := t.client.GetMyCommands(ctx, &telegram.GetMyCommands{LanguageCode: languageCode})
+	if err != nil {
+		log.Error("fail to get bot commands", logger.F("language_code", languageCode), logger.FError(err))
+		return err
+	}
+	setMyCommands := t.setMyCommands(languageCode)
+	if slices.Equal(commands, setMyCommands.Commands) {
+		return nil
+	}
+	if err := t.client.SetMyCommands(ctx, setMyCommands); err != nil {
+		log.Error("fail to set bot commands", logger.F("language_code", languageCode), logger.FError(err))
+		return err
+	}
+	log.Debug("bot commands are updated", logger.F("language_code", languageCode))
+	return nil
+}
+
+func (t *telegramBotProfile) syncDescription(ctx context.Context, languageCode string) error {
+	log := t.container.GetLogger()
+	description, err := t.client.GetMyDescription(ctx, &telegram.GetMyDescription{LanguageCode: languageCode})
+	if err != nil {
+		log.Error("fail to get bot description", logger.F("language_code", languageCode), logger.FError(err))
+		return err
+	}
+	setMyDescription := telegram.SetMyDescription{
+		Description:  t.localizedString(languageCode, "bot_description"),
+		LanguageCode: languageCode,
+	}
+	if description.Description == setMyDescription.Description {
+		return nil
+	}
+	if err := t.client.SetMyDescription(ctx, &setMyDescription); err != nil {
+		log.Error("fail to set bot description", logger.F("language_code", languageCode), logger.FError(err))
+		return err
+	}
+	log.Debug("bot description is updated", logger.F("language_code", languageCode))
+	return nil
+}
+
+func (t *telegramBotProfile) syncShortDescription(ctx context.Context, languageCode string) error {
+	log := t.container.GetLogger()
+	shortDescription, err := t.client.GetMyShortDescription(
+		ctx,
+		&telegram.GetMyShortDescription{LanguageCode: languageCode},
+	)
+	if err != nil {
+		log.Error("fail to get bot short description", logger.F("language_code", languageCode), logger.FError(err))
+		return err
+	}
+	setMyShortDescription := telegram.SetMyShortDescription{
+		ShortDescription: t.localizedString(languageCode, "bot_short_description"),
+		LanguageCode:     languageCode,
+	}
+	if shortDescription.ShortDescription == setMyShortDescription.ShortDescription {
+		return nil
+	}
+	if err := t.client.SetMyShortDescription(ctx, &setMyShortDescription); err != nil {
+		log.Error("fail to set bot short description", logger.F("language_code", languageCode), logger.FError(err))
+		return err
+	}
+	log.Debug("bot short description is updated", logger.F("language_code", languageCode))
+	return nil
+}
+
+func (t *telegramBotProfile) syncName(ctx context.Context, languageCode string) error {
+	log := t.container.GetLogger()
+	name, err := t.client.GetMyName(ctx, &telegram.GetMyName{LanguageCode: languageCode})
+	if err != nil {
+		log.Error("fail to get bot name", logger.F("language_code", languageCode), logger.FError(err))
+		return err
+	}
+	setMyName := telegram.SetMyName{
+		Name:         t.localizedString(languageCode, "bot_name"),
+		LanguageCode: languageCode,
+	}
+	if name.Name == setMyName.Name {
+		return nil
+	}
+	if err := t.client.SetMyName(ctx, &setMyName); err != nil {
+		log.Error("fail to set bot name", logger.F("language_code", languageCode), logger.FError(err))
+		return err
+	}
+	log.Debug("bot name is updated", logger.F("language_code", languageCode))
+	return nil
+}
+
+func (t *telegramBotProfile) setMyCommands(languageCode string) *telegram.SetMyCommands {
+	return &telegram.SetMyCommands{
+		Commands: []telegram.BotCommand{
+			{
+				Command:     "start",
+				Description: t.localizedString(languageCode, "start_cmd_short_description"),
+			},
+			{
+				Command:     "help",
+				Description: t.localizedString(languageCode, "help_cmd_short_description"),
+			},
+		},
+		LanguageCode: languageCode,
+	}
+}
+
+func (t *telegramBotProfile) localizedString(languageCode string, key string) string {
+	if languageCode == "" {
+		languageCode = defaultProfileLanguageCode
+	}
+	return t.container.GetLocalizer(languageCode).LocalizedString(key)
+}
@@ -1,30 +1,29 @@
 package service
 
 import (
-	"bytes"
-	"encoding/json"
-	"fmt"
+	"context"
 	"go-ton-pass-telegram-bot/internal/container"
 	"go-ton-pass-telegram-bot/internal/model/app"
 	"go-ton-pass-telegram-bot/internal/model/telegram"
+	"go-ton-pass-telegram-bot/internal/repository"
 	"go-ton-pass-telegram-bot/internal/utils"
 	"go-ton-pass-telegram-bot/pkg/logger"
-	"io"
-	"net/http"
+	"go-ton-pass-telegram-bot/pkg/telegram_bot"
 	"strings"
 )
 
+// TelegramBotService sends requests to telegram servers through the outbound dispatcher and parses updates.
 type TelegramBotService interface {
+	TelegramDispatcher
+	CallbackDataStore
 	ParseTelegramCommand(update *telegram.Update) (app.TelegramCommand, error)
-	ParseTelegramCallbackData(callbackQuery *telegram.CallbackQuery) (*app.TelegramCallbackData, error)
-	SendResponse(model any, method app.TelegramMethod) error
-	UserIsChatMember(chatID string, telegramID int64) (bool, error)
-	GetSetMyCommands() *telegram.SetMyCommands
-	GetSetMyDescription() *telegram.SetMyDescription
-	GetSetMyName() *telegram.SetMyName
+	ParseTelegramCallbackData(ctx context.Context, callbackQuery *telegram.CallbackQuery) (*app.TelegramCallbackData, error)
+	UserIsChatMember(ctx context.Context, chatID string, telegramID int64) (bool, error)
 }
 
 type telegramBotService struct {
+	TelegramDispatcher
+	CallbackDataStore
 	container container.Container
 }
 
@@ -33,16 +32,41 @@ const (
 	helpCmdText  = "/help"
 )
 
-const baseTelegramAPI = "https://api.telegram.org/bot"
-
-func NewTelegramBot(container container.Container) TelegramBotService {
+// NewTelegramBot must be created once, its dispatcher keeps the rate limits of the bot.
+func NewTelegramBot(
+	container container.Container,
+	cache Cache,
+	profileRepository repository.ProfileRepository,
+) TelegramBotService {
 	return &telegramBotService{
-		container: container,
+		TelegramDispatcher: NewTelegramDispatcher(
+			container,
+			NewTelegramBotClient(container),
+			cache,
+			profileRepository,
+			NewSystemClock(),
+		),
+		CallbackDataStore: NewCallbackDataStore(container, cache),
+		container:         container,
 	}
 }
 
-func (t *telegramBotService) ParseTelegramCallbackData(callbackQuery *telegram.CallbackQuery) (*app.TelegramCallbackData, error) {
-	telegramCallbackData, err := utils.DecodeTelegramCallbackData(callbackQuery.Data)
+// NewTelegramBotClient sends requests straight to telegram servers, it is meant for commands run once.
+func NewTelegramBotClient(container container.Container) telegram_bot.Client {
+	config := container.GetConfig()
+	return telegram_bot.NewClient(telegram_bot.Options{
+		BaseURL:     config.Telegram().BotAPIURL,
+		Token:       config.TelegramBotToken(),
+		Environment: telegram_bot.Environment(config.Telegram().Environment),
+	})
+}
+
+// ParseTelegramCallbackData loads the payload of the button by the token in callback data.
+func (t *telegramBotService) ParseTelegramCallbackData(
+	ctx context.Context,
+	callbackQuery *telegram.CallbackQuery,
+) (*app.TelegramCallbackData, error) {
+	telegramCallbackData, err := t.LoadCallbackData(ctx, callbackQuery.Data)
 	if err != nil {
 		return nil, err
 	}
@@ -57,128 +81,23 @@ func (t *telegramBotService) ParseTelegramCommand(update *telegram.Update) (app.
 	return parseTelegramCommand(text)
 }
 
-func (t *telegramBotService) GetSetMyCommands() *telegram.SetMyCommands {
-	startBotCommand := telegram.BotCommand{
-		Command:     "start",
-		Description: t.container.GetLocalizer("en").LocalizedString("start_cmd_short_description_cmd"),
-	}
-	helpBotCommand := telegram.BotCommand{
-		Command:     "help",
-		Description: t.container.GetLocalizer("en").LocalizedString("help_cmd_short_description_cmd"),
-	}
-	return &telegram.SetMyCommands{Commands: []telegram.BotCommand{
-		startBotCommand,
-		helpBotCommand,
-	}}
-}
-
-func (t *telegramBotService) GetSetMyDescription() *telegram.SetMyDescription {
-	return &telegram.SetMyDescription{
-		Description: t.container.GetLocalizer("en").LocalizedString("bot_description"),
-	}
-}
-
-func (t *telegramBotService) GetSetMyName() *telegram.SetMyName {
-	return &telegram.SetMyName{
-		Name: t.container.GetLocalizer("en").LocalizedString("bot_name"),
-	}
-}
-
-func (t *telegramBotService) prepareRequest(method app.TelegramMethod, model any) (*http.Request, error) {
-	config := t.container.GetConfig()
-	log := t.container.GetLogger()
-	telegramBotToken := config.TelegramBotToken()
-	path := fmt.Sprintf("%s%s/test/%s", baseTelegramAPI, telegramBotToken, method)
-	jsonData, err := json.Marshal(model)
-	if err != nil {
-		log.Error("fail to marshal json model", logger.FError(err))
-		return nil, err
-	}
-	log.Debug("will send response to telegram servers",
-		logger.F("message", string(jsonData)),
-		logger.F("path", path),
-	)
-	bodyBuffer := bytes.NewReader(jsonData)
-	req, err := http.NewRequest(http.MethodPost, path, bodyBuffer)
-	if req == nil {
-		log.Error("fail to create request for telegram bot service", logger.FError(err))
-		return nil, app.NilError
-	}
-	req.Header.Set("Content-Type", "application/json")
-	if err != nil {
-		log.Error("fail to create request", logger.FError(err))
-		return nil, err
-	}
-	return req, nil
-}
-
-func (t *telegramBotService) SendResponse(model any, method app.TelegramMethod) error {
-	log := t.container.GetLogger()
-	req, err := t.prepareRequest(method, model)
-	if err != nil {
-		log.Error("fail to prepare request", logger.FError(err))
-		return err
-	}
-	c := &http.Client{}
-	resp, err := c.Do(req)
-	if err != nil {
-		log.Error("fail to perform request", logger.FError(err))
-		return err
-	}
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Error("fail to read body from telegram server", logger.FError(err))
-		return err
-	}
-	var result *telegram.Result[any]
-	if err := json.Unmarshal(body, &result); err != nil {
-		log.Error("fail to decode body from telegram server", logger.FError(err))
-		return err
-	}
-	if !result.OK {
-		log.Debug("telegram server return without status code ok",
-			logger.F("description", result.Description),
-			logger.F("json", string(body)),
-		)
-		return nil
-	}
-	return nil
-}
-
-func (t *telegramBotService) UserIsChatMember(chatID string, telegramID int64) (bool, error) {
+// UserIsChatMember treats a user telegram servers don't know in the chat as not a member.
+func (t *telegramBotService) UserIsChatMember(ctx context.Context, chatID string, telegramID int64) (bool, error) {
 	log := t.container.GetLogger()
 	getChatMember := telegram.GetChatMember{
 		ChatID: chatID,
 		UserID: telegramID,
 	}
-	req, err := t.prepareRequest(app.GetChatMemberTelegramMethod, getChatMember)
-	if err != nil {
-		log.Error("fail to create request", logger.FError(err))
-		return false, err
-	}
-	c := &http.Client{}
-	resp, err := c.Do(req)
-	defer func() {
-		if resp != nil {
-			_ = resp.Body.Close()
-		}
-	}()
-	if err != nil {
-		log.Error("fail to get perform response", logger.FError(err))
-		return false, err
-	}
-	var result telegram.Result[telegram.ChatMember]
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		log.Error("fail to decode ChatMember from response", logger.FError(err))
-		return false, err
-	}
-	log.Debug("get result from telegram for check is user a chat member", logger.F("telegram_id", telegramID), logger.F("status", result.Result.Status))
-	switch result.Result.Status {
-	case telegram.MemberMemberStatus, telegram.CreatorMemberStatus, telegram.AdministratorMemberStatus:
-		return true, nil
-	default:
+	chatMember, err := t.GetChatMember(ctx, &getChatMember)
+	if telegram_bot.IsBadRequest(err) {
+		log.Debug("telegram servers don't know the user in the chat", logger.F("telegram_id", telegramID), logger.FError(err))
 		return false, nil
+	} else if err != nil {
+		log.Error("fail to get chat member", logger.FError(err))
+		return false, err
 	}
+	log.Debug("get result from telegram for check is user a chat member", logger.F("telegram_id", telegramID), logger.F("status", chatMember.Status))
+	return chatMember.IsMember(), nil
 }
 
 func parseTelegramCommand(text string) (app.TelegramCommand, error) {
@@ -190,6 +109,12 @@ func parseTelegramCommand(text string) (app.TelegramCommand, error) {
 	default:
 		break
 	}
+	if strings.HasPrefix(text, app.ServiceCmdText+" ") || strings.HasPrefix(text, startCmdText+" "+app.ServiceStartParameterPrefix) {
+		return app.ServiceTelegramCommand, nil
+	}
+	if fields := strings.Fields(text); len(fields) > 0 && utils.ContainsValue(app.AdminCmdTexts, app.AdminCmdText(fields[0])) {
+		return app.AdminTelegramCommand, nil
+	}
 	if strings.HasPrefix(text, "/") {
 		return app.UnknownTelegramCommand, app.NotSupportedTelegramCommandError
 	}
@@ -0,0 +1,647 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"go-ton-pass-telegram-bot/internal/container"
+	"go-ton-pass-telegram-bot/internal/model/app"
+	"go-ton-pass-telegram-bot/internal/model/telegram"
+	"go-ton-pass-telegram-bot/internal/repository"
+	"go-ton-pass-telegram-bot/internal/utils"
+	"go-ton-pass-telegram-bot/pkg/logger"
+	"go-ton-pass-telegram-bot/pkg/telegram_bot"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// outboundRetryDelay is the first pause before an enqueued message is sent again, it doubles with every attempt.
+	outboundRetryDelay = 2 * time.Second
+	// chatBucketBurst lets a reply of a few messages go at once, a chat still averages the chat rate.
+	chatBucketBurst = 3
+	// outboundLeaseTTL is how long other dispatchers leave an enqueued message to its owner, the owner renews
+	// the lease more often and claims messages of stopped dispatchers at the same time.
+	outboundLeaseTTL     = time.Minute
+	outboundLeaseRenewal = outboundLeaseTTL / 3
+)
+
+// TelegramDispatcher sends messages within the limits of telegram servers: about 30 messages per second
+// for the bot and 1 per second for a chat. Methods sending or changing messages wait in priority lanes,
+// the rest of the client goes straight to telegram servers.
+type TelegramDispatcher interface {
+	telegram_bot.Client
+	// Enqueue persists the message and returns, it is sent in background and repeated on failures.
+	Enqueue(ctx context.Context, priority app.OutboundPriority, method app.OutboundMethod, chatID int64, payload any) error
+	// Send queues the message in the lane of the priority and waits until it is sent, it isn't repeated on failures.
+	Send(
+		ctx context.Context,
+		priority app.OutboundPriority,
+		method app.OutboundMethod,
+		chatID int64,
+		payload any,
+	) (*telegram.Message, error)
+	// Run sends queued messages until the context is done. Messages persisted by stopped dispatchers are claimed,
+	// so each of them is sent by one dispatcher.
+	Run(ctx context.Context) error
+}
+
+type telegramDispatcher struct {
+	telegram_bot.Client
+	container         container.Container
+	cache             Cache
+	profileRepository repository.ProfileRepository
+	clock             Clock
+	owner             string
+	globalBucket      *tokenBucket
+	chatRate          float64
+	maxAttempts       int
+	mutex             sync.Mutex
+	lanes             map[app.OutboundPriority][]*outboundDelivery
+	chatBuckets       map[int64]*tokenBucket
+	inFlightChats     map[int64]bool
+	leasedIDs         map[string]bool
+	wakeup            chan struct{}
+	isStopped         bool
+}
+
+// outboundDelivery is a queued message, a message sent on behalf of a waiting caller carries its context
+// and the channel for the result.
+type outboundDelivery struct {
+	message app.OutboundMessage
+	ctx     context.Context
+	result  chan outboundResult
+}
+
+type outboundResult struct {
+	message *telegram.Message
+	err     error
+}
+
+// NewTelegramDispatcher marks profiles blocked when telegram servers refuse messages to their chats.
+func NewTelegramDispatcher(
+	container container.Container,
+	client telegram_bot.Client,
+	cache Cache,
+	profileRepository repository.ProfileRepository,
+	clock Clock,
+) TelegramDispatcher {
+	telegramConfig := container.GetConfig().Telegram()
+	now := clock.Now()
+	return &telegramDispatcher{
+		Client:            client,
+		container:         container,
+		cache:             cache,
+		profileRepository: profileRepository,
+		clock:             clock,
+		owner:             uuid.NewString(),
+		globalBucket:      newTokenBucket(telegramConfig.OutboundGlobalRate, math.Max(telegramConfig.OutboundGlobalRate, 1), now),
+		chatRate:          telegramConfig.OutboundChatRate,
+		maxAttempts:       telegramConfig.OutboundMaxAttempts,
+		lanes:             make(map[app.OutboundPriority][]*outboundDelivery),
+		chatBuckets:       make(map[int64]*tokenBucket),
+		inFlightChats:     make(map[int64]bool),
+		leasedIDs:         make(map[string]bool),
+		wakeup:            make(chan struct{}, 1),
+	}
+}
+
+func (t *telegramDispatcher) SendMessage(ctx context.Context, sendMessage *telegram.SendResponse) (*telegram.Message, error) {
+	return t.send(ctx, app.SendMessageOutboundMethod, sendMessage.ChatID, sendMessage)
+}
+
+func (t *telegramDispatcher) SendPhoto(ctx context.Context, sendPhoto *telegram.SendPhoto) (*telegram.Message, error) {
+	return t.send(ctx, app.SendPhotoOutboundMethod, sendPhoto.ChatID, sendPhoto)
+}
+
+func (t *telegramDispatcher) SendInvoice(ctx context.Context, sendInvoice *telegram.SendInvoice) (*telegram.Message, error) {
+	return t.send(ctx, app.SendInvoiceOutboundMethod, sendInvoice.ChatID, sendInvoice)
+}
+
+func (t *telegramDispatcher) EditMessageText(ctx context.Context, editMessage *telegram.EditMessage) (*telegram.Message, error) {
+	return t.send(ctx, app.EditMessageTextOutboundMethod, chatIDOf(editMessage.ChatID), editMessage)
+}
+
+func (t *telegramDispatcher) EditMessageCaption(
+	ctx context.Context,
+	editCaptionMessage *telegram.EditCaptionMessage,
+) (*telegram.Message, error) {
+	return t.send(ctx, app.EditMessageCaptionOutboundMethod, chatIDOf(editCaptionMessage.ChatID), editCaptionMessage)
+}
+
+func (t *telegramDispatcher) EditMessageMedia(
+	ctx context.Context,
+	editMessageMedia *telegram.EditMessageMedia,
+) (*telegram.Message, error) {
+	return t.send(ctx, app.EditMessageMediaOutboundMethod, chatIDOf(editMessageMedia.ChatID), editMessageMedia)
+}
+
+func (t *telegramDispatcher) DeleteMessage(ctx context.Context, deleteMessage *telegram.DeleteMessage) error {
+	_, err := t.send(ctx, app.DeleteMessageOutboundMethod, deleteMessage.ChatID, deleteMessage)
+	return err
+}
+
+func (t *telegramDispatcher) Enqueue(
+	ctx context.Context,
+	priority app.OutboundPriority,
+	method app.OutboundMethod,
+	chatID int64,
+	payload any,
+) error {
+	log := t.container.GetLogger()
+	outboundMessage, err := newOutboundMessage(priority, method, chatID, payload)
+	if err != nil {
+		log.Error("fail to create outbound message", logger.FError(err))
+		return err
+	}
+	if err := t.cache.SaveOutboundMessage(ctx, *outboundMessage, t.owner, outboundLeaseTTL); err != nil {
+		log.Error("fail to save outbound message", logger.F("chat_id", chatID), logger.FError(err))
+		return err
+	}
+	t.push(&outboundDelivery{
+		message: *outboundMessage,
+	})
+	return nil
+}
+
+func (t *telegramDispatcher) Run(ctx context.Context) error {
+	log := t.container.GetLogger()
+	restoredMessages, err := t.claim(ctx)
+	if err != nil {
+		log.Error("fail to claim outbound messages", logger.FError(err))
+		return err
+	}
+	log.Debug("start dispatching outbound messages", logger.F("restored_messages", restoredMessages))
+	renewedAt := t.clock.Now()
+	for ctx.Err() == nil {
+		if t.clock.Now().Sub(renewedAt) >= outboundLeaseRenewal {
+			t.renewLeases(ctx)
+			renewedAt = t.clock.Now()
+		}
+		delivery, wait := t.next(t.clock.Now())
+		if delivery == nil {
+			timer := t.clock.NewTimer(min(wait, outboundLeaseRenewal))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+			case <-t.wakeup:
+				timer.Stop()
+			case <-timer.C():
+			}
+			continue
+		}
+		go t.deliver(context.WithoutCancel(ctx), delivery)
+	}
+	releasedIDs := t.stop()
+	if err := t.cache.ReleaseOutboundMessages(context.WithoutCancel(ctx), releasedIDs); err != nil {
+		log.Error("fail to release outbound messages", logger.FError(err))
+	}
+	log.Debug("stop dispatching outbound messages")
+	return nil
+}
+
+// claim takes messages persisted by stopped dispatchers and returns how many of them are queued.
+func (t *telegramDispatcher) claim(ctx context.Context) (int, error) {
+	outboundMessages, err := t.cache.ClaimOutboundMessages(ctx, t.owner, outboundLeaseTTL)
+	if err != nil {
+		return 0, err
+	}
+	claimedMessages := 0
+	for _, outboundMessage := range outboundMessages {
+		// a message whose lease has lapsed while it was queued here is already in the lanes
+		if t.isLeased(outboundMessage.ID) {
+			continue
+		}
+		t.push(&outboundDelivery{
+			message: outboundMessage,
+		})
+		claimedMessages++
+	}
+	return claimedMessages, nil
+}
+
+// renewLeases keeps enqueued messages of the dispatcher from being claimed by others and claims messages
+// of dispatchers stopped since the last renewal. Messages whose leases have been taken by another dispatcher
+// are left to it.
+func (t *telegramDispatcher) renewLeases(ctx context.Context) {
+	log := t.container.GetLogger()
+	t.mutex.Lock()
+	leasedIDs := make([]string, 0, len(t.leasedIDs))
+	for id := range t.leasedIDs {
+		leasedIDs = append(leasedIDs, id)
+	}
+	t.mutex.Unlock()
+	lostIDs, err := t.cache.LeaseOutboundMessages(ctx, leasedIDs, t.owner, outboundLeaseTTL)
+	if err != nil {
+		log.Error("fail to renew outbound message leases", logger.FError(err))
+		return
+	}
+	if len(lostIDs) > 0 {
+		log.Debug("outbound message leases are lost", logger.F("lost_messages", len(lostIDs)))
+		t.drop(lostIDs)
+	}
+	if claimedMessages, err := t.claim(ctx); err != nil {
+		log.Error("fail to claim outbound messages", logger.FError(err))
+	} else if claimedMessages > 0 {
+		log.Debug("outbound messages are claimed", logger.F("claimed_messages", claimedMessages))
+	}
+}
+
+// drop forgets enqueued messages another dispatcher sends, a message being sent is only let go.
+func (t *telegramDispatcher) drop(ids []string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	isDropped := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		isDropped[id] = true
+		delete(t.leasedIDs, id)
+	}
+	for priority, lane := range t.lanes {
+		t.lanes[priority] = utils.Filter(lane, func(delivery *outboundDelivery) bool {
+			return delivery.result != nil || !isDropped[delivery.message.ID]
+		})
+	}
+}
+
+func (t *telegramDispatcher) Send(
+	ctx context.Context,
+	priority app.OutboundPriority,
+	method app.OutboundMethod,
+	chatID int64,
+	payload any,
+) (*telegram.Message, error) {
+	outboundMessage, err := newOutboundMessage(priority, method, chatID, payload)
+	if err != nil {
+		return nil, err
+	}
+	delivery := outboundDelivery{
+		message: *outboundMessage,
+		ctx:     ctx,
+		result:  make(chan outboundResult, 1),
+	}
+	t.push(&delivery)
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-delivery.result:
+		return result.message, result.err
+	}
+}
+
+// send queues the message in the transactional lane and waits until it is sent, the caller is a reply to a user.
+func (t *telegramDispatcher) send(
+	ctx context.Context,
+	method app.OutboundMethod,
+	chatID int64,
+	payload any,
+) (*telegram.Message, error) {
+	return t.Send(ctx, app.TransactionalOutboundPriority, method, chatID, payload)
+}
+
+func (t *telegramDispatcher) push(delivery *outboundDelivery) {
+	t.queue(delivery, false)
+}
+
+// requeue puts a repeated message ahead of the later messages of its chat.
+func (t *telegramDispatcher) requeue(delivery *outboundDelivery) {
+	t.queue(delivery, true)
+}
+
+func (t *telegramDispatcher) queue(delivery *outboundDelivery, isRepeated bool) {
+	t.mutex.Lock()
+	if t.isStopped {
+		// an enqueued message is persisted and waits for another dispatcher once its lease expires
+		delete(t.leasedIDs, delivery.message.ID)
+		t.mutex.Unlock()
+		if delivery.result != nil {
+			delivery.result <- outboundResult{err: app.DispatcherStoppedError}
+		}
+		return
+	}
+	priority := delivery.message.Priority
+	if delivery.result == nil {
+		t.leasedIDs[delivery.message.ID] = true
+	}
+	if isRepeated {
+		t.lanes[priority] = append([]*outboundDelivery{delivery}, t.lanes[priority]...)
+	} else {
+		t.lanes[priority] = append(t.lanes[priority], delivery)
+	}
+	t.mutex.Unlock()
+	select {
+	case t.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// next takes the first message of the highest priority lane the limits allow to send now. Messages of a chat
+// keep their order, so a chat waiting for its limit or for a message being sent is skipped in the lanes behind.
+// When nothing can be sent, it returns how long to wait.
+func (t *telegramDispatcher) next(now time.Time) (*outboundDelivery, time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for _, priority := range app.OutboundPriorities {
+		// messages of callers that have given up are dropped without a result
+		t.lanes[priority] = utils.Filter(t.lanes[priority], func(delivery *outboundDelivery) bool {
+			return delivery.ctx == nil || delivery.ctx.Err() == nil
+		})
+	}
+	if globalWait := t.globalBucket.wait(now); globalWait > 0 {
+		return nil, globalWait
+	}
+	wait := time.Hour
+	waitingChats := make(map[int64]bool)
+	for _, priority := range app.OutboundPriorities {
+		lane := t.lanes[priority]
+		for idx, delivery := range lane {
+			chatID := delivery.message.ChatID
+			if waitingChats[chatID] || t.inFlightChats[chatID] {
+				continue
+			}
+			if notBeforeWait := delivery.message.NotBefore.Sub(now); notBeforeWait > 0 {
+				waitingChats[chatID] = true
+				wait = min(wait, notBeforeWait)
+				continue
+			}
+			chatBucket := t.chatBucket(chatID, now)
+			if chatBucket != nil {
+				if chatWait := chatBucket.wait(now); chatWait > 0 {
+					waitingChats[chatID] = true
+					wait = min(wait, chatWait)
+					continue
+				}
+				chatBucket.take(now)
+			}
+			t.globalBucket.take(now)
+			if chatID != 0 {
+				t.inFlightChats[chatID] = true
+			}
+			t.lanes[priority] = append(lane[:idx:idx], lane[idx+1:]...)
+			return delivery, 0
+		}
+	}
+	t.pruneChatBuckets(now)
+	return nil, wait
+}
+
+// chatBucket returns nil for messages without a chat, e.g. edits of inline messages.
+func (t *telegramDispatcher) chatBucket(chatID int64, now time.Time) *tokenBucket {
+	if chatID == 0 {
+		return nil
+	}
+	chatBucket, ok := t.chatBuckets[chatID]
+	if !ok {
+		chatBucket = newTokenBucket(t.chatRate, chatBucketBurst, now)
+		t.chatBuckets[chatID] = chatBucket
+	}
+	return chatBucket
+}
+
+func (t *telegramDispatcher) pruneChatBuckets(now time.Time) {
+	for chatID, chatBucket := range t.chatBuckets {
+		if chatBucket.wait(now) == 0 && chatBucket.tokens >= chatBucket.burst {
+			delete(t.chatBuckets, chatID)
+		}
+	}
+}
+
+func (t *telegramDispatcher) deliver(ctx context.Context, delivery *outboundDelivery) {
+	log := t.container.GetLogger()
+	outboundMessage := delivery.message
+	defer t.complete(outboundMessage.ChatID)
+	message, err := t.execute(ctx, outboundMessage)
+	var telegramErr *telegram_bot.Error
+	if errors.As(err, &telegramErr) && telegramErr.RetryAfter > 0 {
+		t.blockChat(outboundMessage.ChatID, time.Duration(telegramErr.RetryAfter)*time.Second)
+	}
+	if telegram_bot.IsForbidden(err) {
+		t.markBotBlocked(ctx, outboundMessage.ChatID)
+	}
+	if delivery.result != nil {
+		delivery.result <- outboundResult{
+			message: message,
+			err:     err,
+		}
+		return
+	}
+	if err != nil && isRetryableOutboundError(err) && outboundMessage.Attempts+1 < t.maxAttempts {
+		if !t.isLeased(outboundMessage.ID) {
+			// the lease has been taken by another dispatcher, it repeats the message
+			return
+		}
+		outboundMessage.Attempts++
+		delay := outboundRetryDelay << (outboundMessage.Attempts - 1)
+		if telegramErr != nil && telegramErr.RetryAfter > 0 {
+			delay = max(delay, time.Duration(telegramErr.RetryAfter)*time.Second)
+		}
+		outboundMessage.NotBefore = t.clock.Now().Add(delay)
+		log.Debug(
+			"fail to send outbound message, retry later",
+			logger.F("id", outboundMessage.ID),
+			logger.F("attempts", outboundMessage.Attempts),
+			logger.FError(err),
+		)
+		if err := t.cache.SaveOutboundMessage(ctx, outboundMessage, t.owner, outboundLeaseTTL); err != nil {
+			log.Error("fail to save outbound message", logger.F("id", outboundMessage.ID), logger.FError(err))
+		}
+		t.requeue(&outboundDelivery{
+			message: outboundMessage,
+		})
+		return
+	}
+	if err != nil && !telegram_bot.IsMessageNotModified(err) && !telegram_bot.IsForbidden(err) {
+		log.Error(
+			"fail to send outbound message, drop it",
+			logger.F("id", outboundMessage.ID),
+			logger.F("method", outboundMessage.Method),
+			logger.F("chat_id", outboundMessage.ChatID),
+			logger.FError(err),
+		)
+	}
+	if err := t.cache.DeleteOutboundMessage(ctx, outboundMessage.ID); err != nil {
+		log.Error("fail to delete outbound message", logger.F("id", outboundMessage.ID), logger.FError(err))
+	}
+	t.mutex.Lock()
+	delete(t.leasedIDs, outboundMessage.ID)
+	t.mutex.Unlock()
+}
+
+// markBotBlocked remembers that the user of the chat has blocked the bot, messages to the chat are final failures.
+func (t *telegramDispatcher) markBotBlocked(ctx context.Context, chatID int64) {
+	if chatID == 0 {
+		return
+	}
+	if err := t.profileRepository.MarkBotBlockedByChatID(ctx, chatID); err != nil {
+		log := t.container.GetLogger()
+		log.Error("fail to mark bot blocked", logger.F("chat_id", chatID), logger.FError(err))
+	}
+}
+
+func (t *telegramDispatcher) isLeased(id string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.leasedIDs[id]
+}
+
+// complete lets the next message of the chat go once the previous one is sent or queued again.
+func (t *telegramDispatcher) complete(chatID int64) {
+	t.mutex.Lock()
+	delete(t.inFlightChats, chatID)
+	t.mutex.Unlock()
+	select {
+	case t.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// stop fails messages of waiting callers and returns queued enqueued messages, their leases are released
+// for other dispatchers. Messages being sent keep their leases until they expire.
+func (t *telegramDispatcher) stop() []string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.isStopped = true
+	releasedIDs := make([]string, 0)
+	for priority, lane := range t.lanes {
+		for _, delivery := range lane {
+			if delivery.result != nil {
+				delivery.result <- outboundResult{err: app.DispatcherStoppedError}
+			} else {
+				releasedIDs = append(releasedIDs, delivery.message.ID)
+				delete(t.leasedIDs, delivery.message.ID)
+			}
+		}
+		delete(t.lanes, priority)
+	}
+	return releasedIDs
+}
+
+// blockChat holds messages of the chat after telegram servers have asked to wait.
+func (t *telegramDispatcher) blockChat(chatID int64, delay time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	now := t.clock.Now()
+	if chatBucket := t.chatBucket(chatID, now); chatBucket != nil {
+		chatBucket.block(now, delay)
+	}
+}
+
+func (t *telegramDispatcher) execute(ctx context.Context, outboundMessage app.OutboundMessage) (*telegram.Message, error) {
+	switch outboundMessage.Method {
+	case app.SendMessageOutboundMethod:
+		return executeOutbound(ctx, outboundMessage, t.Client.SendMessage)
+	case app.SendPhotoOutboundMethod:
+		return executeOutbound(ctx, outboundMessage, t.Client.SendPhoto)
+	case app.SendInvoiceOutboundMethod:
+		return executeOutbound(ctx, outboundMessage, t.Client.SendInvoice)
+	case app.EditMessageTextOutboundMethod:
+		return executeOutbound(ctx, outboundMessage, t.Client.EditMessageText)
+	case app.EditMessageCaptionOutboundMethod:
+		return executeOutbound(ctx, outboundMessage, t.Client.EditMessageCaption)
+	case app.EditMessageMediaOutboundMethod:
+		return executeOutbound(ctx, outboundMessage, t.Client.EditMessageMedia)
+	case app.DeleteMessageOutboundMethod:
+		return executeOutbound(ctx, outboundMessage, func(ctx context.Context, deleteMessage *telegram.DeleteMessage) (*telegram.Message, error) {
+			return nil, t.Client.DeleteMessage(ctx, deleteMessage)
+		})
+	default:
+		return nil, app.UnknownValueError
+	}
+}
+
+func executeOutbound[T any](
+	ctx context.Context,
+	outboundMessage app.OutboundMessage,
+	method func(ctx context.Context, request *T) (*telegram.Message, error),
+) (*telegram.Message, error) {
+	var request T
+	if err := json.Unmarshal(outboundMessage.Payload, &request); err != nil {
+		return nil, err
+	}
+	return method(ctx, &request)
+}
+
+func newOutboundMessage(
+	priority app.OutboundPriority,
+	method app.OutboundMethod,
+	chatID int64,
+	payload any,
+) (*app.OutboundMessage, error) {
+	encodedPayload, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &app.OutboundMessage{
+		ID:        uuid.NewString(),
+		ChatID:    chatID,
+		Priority:  priority,
+		Method:    method,
+		Payload:   encodedPayload,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// isRetryableOutboundError keeps enqueued messages on failures of telegram servers, flood errors and network
+// errors, the client has already repeated the short ones. A chat which has blocked the bot is never repeated.
+func isRetryableOutboundError(err error) bool {
+	if telegram_bot.IsForbidden(err) {
+		return false
+	}
+	var telegramErr *telegram_bot.Error
+	if errors.As(err, &telegramErr) {
+		return telegramErr.IsRetryable()
+	}
+	return true
+}
+
+func chatIDOf(chatID *int64) int64 {
+	if chatID == nil {
+		return 0
+	}
+	return *chatID
+}
+
+// tokenBucket refills rate tokens per second up to burst, a message takes a token.
+type tokenBucket struct {
+	rate      float64
+	burst     float64
+	tokens    float64
+	updatedAt time.Time
+}
+
+func newTokenBucket(rate float64, burst float64, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		rate:      rate,
+		burst:     burst,
+		tokens:    burst,
+		updatedAt: now,
+	}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if now.After(b.updatedAt) {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.updatedAt).Seconds()*b.rate)
+		b.updatedAt = now
+	}
+}
+
+// wait returns how long to wait for a token, zero when a token is available.
+func (b *tokenBucket) wait(now time.Time) time.Duration {
+	b.refill(now)
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+func (b *tokenBucket) take(now time.Time) {
+	b.refill(now)
+	b.tokens--
+}
+
+// block empties the bucket, so the next token is available after the delay.
+func (b *tokenBucket) block(now time.Time, delay time.Duration) {
+	b.refill(now)
+	b.tokens = min(b.tokens, 1-delay.Seconds()*b.rate)
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-ton-pass-telegram-bot/internal/container"
+	"go-ton-pass-telegram-bot/internal/model/app"
+	"go-ton-pass-telegram-bot/internal/model/telegram"
+	"go-ton-pass-telegram-bot/pkg/logger"
+	"net/http"
+	"time"
+)
+
+// telegramPollingRetryDelay is the pause after a failed poll, so an unavailable Bot API isn't hammered.
+const telegramPollingRetryDelay = 3 * time.Second
+
+type TelegramPolling interface {
+	Run(ctx context.Context) error
+}
+
+type telegramPolling struct {
+	container          container.Container
+	telegramBotService TelegramBotService
+	cache              Cache
+	handler            http.Handler
+}
+
+// NewTelegramPolling feeds polled updates to the handler as if telegram servers posted them to the webhook,
+// so they pass the same middlewares and router.
+func NewTelegramPolling(
+	container container.Container,
+	telegramBotService TelegramBotService,
+	cache Cache,
+	handler http.Handler,
+) TelegramPolling {
+	return &telegramPolling{
+		container:          container,
+		telegramBotService: telegramBotService,
+		cache:              cache,
+		handler:            handler,
+	}
+}
+
+// Run polls updates until the context is done. The update being handled is finished before it returns
+// and the offset is persisted after every update, including updates that fail to be served.
+func (t *telegramPolling) Run(ctx context.Context) error {
+	log := t.container.GetLogger()
+	deleteWebhook := telegram.DeleteWebhook{
+		DropPendingUpdates: false,
+	}
+	// telegram servers refuse to return updates while a webhook is set
+	if err := t.telegramBotService.DeleteWebhook(ctx, &deleteWebhook); err != nil {
+		log.Error("fail to delete webhook", logger.FError(err))
+		return err
+	}
+	offset, err := t.cache.GetTelegramUpdatesOffset(ctx)
+	if err != nil {
+		log.Error("fail to get telegram updates offset", logger.FError(err))
+		return err
+	}
+	timeout := t.container.GetConfig().Telegram().PollingTimeoutSecs
+	// polled updates are the same as the webhook receives, chat_member ones aren't sent unless asked for
+	allowedUpdates := t.container.GetConfig().Telegram().WebhookAllowedUpdates
+	log.Debug("start polling telegram updates", logger.F("offset", offset), logger.F("timeout", timeout))
+	for ctx.Err() == nil {
+		getUpdates := telegram.GetUpdates{
+			Offset:         offset,
+			Timeout:        timeout,
+			AllowedUpdates: allowedUpdates,
+		}
+		updates, err := t.telegramBotService.GetUpdates(ctx, &getUpdates)
+		if ctx.Err() != nil {
+			break
+		}
+		if err != nil {
+			log.Error("fail to get telegram updates", logger.FError(err))
+			select {
+			case <-ctx.Done():
+			case <-time.After(telegramPollingRetryDelay):
+			}
+			continue
+		}
+		previousUpdateID := offset - 1
+		for _, update := range updates {
+			updateID, err := parseTelegramUpdateID(update)
+			if err != nil {
+				// update ids increase sequentially, so the unreadable update is skipped as the one after the previous
+				updateID = previousUpdateID + 1
+				log.Error("fail to read telegram update id, skip the update", logger.F("update_id", updateID), logger.FError(err))
+			} else if err := t.serveUpdate(context.WithoutCancel(ctx), update); err != nil {
+				log.Error("fail to serve telegram update", logger.F("update_id", updateID), logger.FError(err))
+			}
+			previousUpdateID = updateID
+			offset = updateID + 1
+			if err := t.cache.SaveTelegramUpdatesOffset(context.WithoutCancel(ctx), offset); err != nil {
+				log.Error("fail to save telegram updates offset", logger.F("offset", offset), logger.FError(err))
+			}
+		}
+	}
+	log.Debug("stop polling telegram updates", logger.F("offset", offset))
+	return nil
+}
+
+// parseTelegramUpdateID reads only update_id, so the offset moves past updates whose other fields can't be decoded.
+func parseTelegramUpdateID(update json.RawMessage) (int64, error) {
+	var updateHeader struct {
+		ID json.Number `json:"update_id"`
+	}
+	if err := json.Unmarshal(update, &updateHeader); err != nil {
+		return 0, err
+	}
+	return updateHeader.ID.Int64()
+}
+
+// serveUpdate passes the update to the same handler as the webhook, a failure of the handler is returned
+// as ServeTelegramUpdateError with the status code.
+func (t *telegramPolling) serveUpdate(ctx context.Context, update json.RawMessage) error {
+	ctx = context.WithValue(ctx, app.PolledUpdateContextKey, true)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, app.TelegramWebhookPath, bytes.NewReader(update))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	w := pollingResponseWriter{
+		header:     make(http.Header),
+		statusCode: http.StatusOK,
+	}
+	t.handler.ServeHTTP(&w, req)
+	if w.statusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%w: status code %d", app.ServeTelegramUpdateError, w.statusCode)
+	}
+	return nil
+}
+
+// pollingResponseWriter keeps only the status code, nobody reads a response to a polled update.
+type pollingResponseWriter struct {
+	header      http.Header
+	statusCode  int
+	wroteHeader bool
+}
+
+func (p *pollingResponseWriter) Header() http.Header {
+	return p.header
+}
+
+func (p *pollingResponseWriter) Write(data []byte) (int, error) {
+	p.WriteHeader(http.StatusOK)
+	return len(data), nil
+}
+
+func (p *pollingResponseWriter) WriteHeader(statusCode int) {
+	if p.wroteHeader {
+		return
+	}
+	p.wroteHeader = true
+	p.statusCode = statusCode
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"go-ton-pass-telegram-bot/internal/model/domain"
+	"net/url"
+	"strings"
+	"unicode/utf16"
+)
+
+const broadcastButtonSeparator = "|"
+
+// SplitBroadcastButtons cuts the trailing lines written as `Title | https://url` off the broadcast text and turns
+// them into buttons in the same order. Lines in the middle of the text are kept as they are.
+func SplitBroadcastButtons(text string) (string, []domain.BroadcastButton) {
+	lines := strings.Split(text, "\n")
+	buttons := make([]domain.BroadcastButton, 0)
+	for len(lines) > 0 {
+		button, ok := parseBroadcastButton(lines[len(lines)-1])
+		if !ok {
+			break
+		}
+		buttons = append([]domain.BroadcastButton{*button}, buttons...)
+		lines = lines[:len(lines)-1]
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), " \n"), buttons
+}
+
+// UTF16Length counts the text in UTF-16 code units, Telegram measures offsets of message entities in them.
+func UTF16Length(text string) int {
+	return len(utf16.Encode([]rune(text)))
+}
+
+func parseBroadcastButton(line string) (*domain.BroadcastButton, bool) {
+	title, rawURL, ok := strings.Cut(line, broadcastButtonSeparator)
+	if !ok {
+		return nil, false
+	}
+	title = strings.TrimSpace(title)
+	rawURL = strings.TrimSpace(rawURL)
+	if title == "" {
+		return nil, false
+	}
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || (parsedURL.Scheme != "https" && parsedURL.Scheme != "http" && parsedURL.Scheme != "tg") {
+		return nil, false
+	}
+	if parsedURL.Host == "" && parsedURL.Scheme != "tg" {
+		return nil, false
+	}
+	return &domain.BroadcastButton{
+		Text: title,
+		URL:  rawURL,
+	}, true
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"go-ton-pass-telegram-bot/internal/model/app"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	prefixedSMSCodeRegexp     = regexp.MustCompile(`\b[A-Z]{1,4}-(\d{4,8})\b`)
+	numericSMSCodeRegexp      = regexp.MustCompile(`\b\d{4,8}\b`)
+	groupedSMSCodeRegexp      = regexp.MustCompile(`\b\d{3}[ -]\d{3}\b`)
+	alphanumericSMSCodeRegexp = regexp.MustCompile(`\b[A-Za-z0-9]{5,10}\b`)
+	smsCodeKeywordRegexp      = regexp.MustCompile(`(?i)code|pin|otp|password|код|пароль|kód|heslo`)
+)
+
+// CompileSMSCodeRules groups the compiled rule patterns by service code.
+// Every pattern must have a capturing group around the code.
+func CompileSMSCodeRules(rules []app.SMSCodeRule) (map[string][]*regexp.Regexp, error) {
+	compiledRules := make(map[string][]*regexp.Regexp)
+	for _, rule := range rules {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		if pattern.NumSubexp() < 1 {
+			return nil, app.RequiredFieldError
+		}
+		compiledRules[rule.Service] = append(compiledRules[rule.Service], pattern)
+	}
+	return compiledRules, nil
+}
+
+// IsSuspiciousSMSCode reports whether the code reported by the provider can't be trusted as is.
+func IsSuspiciousSMSCode(code string, text string) bool {
+	if len(code) < 3 || len(code) > 10 {
+		return true
+	}
+	for _, r := range code {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return true
+		}
+	}
+	if text == "" {
+		return false
+	}
+	return !strings.Contains(normalizeSMSCode(text), code)
+}
+
+// ExtractSMSCode finds the verification code in the SMS text. The service rules are tried first,
+// then the default heuristic. It returns an empty string when nothing looks like a code.
+func ExtractSMSCode(text string, rules []*regexp.Regexp) string {
+	for _, rule := range rules {
+		if match := rule.FindStringSubmatch(text); match != nil {
+			return normalizeSMSCode(match[1])
+		}
+	}
+	if match := prefixedSMSCodeRegexp.FindStringSubmatch(text); match != nil {
+		return match[1]
+	}
+	if code := closestToKeyword(text, numericSMSCodeRegexp.FindAllStringIndex(text, -1)); code != "" {
+		return code
+	}
+	if code := closestToKeyword(text, groupedSMSCodeRegexp.FindAllStringIndex(text, -1)); code != "" {
+		return normalizeSMSCode(code)
+	}
+	alphanumericIndexes := Filter(alphanumericSMSCodeRegexp.FindAllStringIndex(text, -1), func(index []int) bool {
+		candidate := text[index[0]:index[1]]
+		return strings.ContainsAny(candidate, "0123456789") && strings.IndexFunc(candidate, unicode.IsLetter) >= 0
+	})
+	return closestToKeyword(text, alphanumericIndexes)
+}
+
+// closestToKeyword prefers the first candidate after a keyword like "code" and falls back to the first one.
+func closestToKeyword(text string, indexes [][]int) string {
+	if len(indexes) == 0 {
+		return ""
+	}
+	if keywordIndex := smsCodeKeywordRegexp.FindStringIndex(text); keywordIndex != nil {
+		for _, index := range indexes {
+			if index[0] >= keywordIndex[1] {
+				return text[index[0]:index[1]]
+			}
+		}
+	}
+	return text[indexes[0][0]:indexes[0][1]]
+}
+
+func normalizeSMSCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '-' {
+			return -1
+		}
+		return r
+	}, code)
+}
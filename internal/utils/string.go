@@ -1,5 +1,68 @@
 package utils
 
+import (
+	"strings"
+	"unicode/utf8"
+)
+
 func NewString(text string) *string {
 	return &text
 }
+
+// FuzzyMatchScore rates how well the query matches the text ignoring case. The exact match scores the highest,
+// then prefix, substring and finally a subsequence of the query characters. It returns -1 when nothing matches.
+func FuzzyMatchScore(query string, text string) int {
+	query = strings.ToLower(strings.TrimSpace(query))
+	text = strings.ToLower(text)
+	if query == "" || text == "" {
+		return -1
+	}
+	if query == text {
+		return 1000
+	}
+	// distances are counted in characters, so names in any alphabet are ranked alike
+	if strings.HasPrefix(text, query) {
+		return 800 - min(utf8.RuneCountInString(text)-utf8.RuneCountInString(query), 100)
+	}
+	if idx := strings.Index(text, query); idx != -1 {
+		return 600 - min(utf8.RuneCountInString(text[:idx]), 100)
+	}
+	queryRunes := []rune(query)
+	matched := 0
+	gaps := 0
+	lastIdx := -1
+	for idx, r := range []rune(text) {
+		if matched == len(queryRunes) {
+			break
+		}
+		if r != queryRunes[matched] {
+			continue
+		}
+		if lastIdx != -1 {
+			gaps += idx - lastIdx - 1
+		}
+		lastIdx = idx
+		matched++
+	}
+	if matched < len(queryRunes) {
+		return -1
+	}
+	return 300 - min(gaps, 200)
+}
+
+// ISOCodeFromFlagEmoji reads the ISO 3166-1 alpha-2 code out of the regional indicator symbols of the flag.
+func ISOCodeFromFlagEmoji(flag string) *string {
+	const regionalIndicatorA = 0x1F1E6
+	runes := []rune(flag)
+	if len(runes) != 2 {
+		return nil
+	}
+	isoCode := make([]rune, 0, len(runes))
+	for _, r := range runes {
+		if r < regionalIndicatorA || r > regionalIndicatorA+'Z'-'A' {
+			return nil
+		}
+		isoCode = append(isoCode, 'A'+r-regionalIndicatorA)
+	}
+	return NewString(string(isoCode))
+}
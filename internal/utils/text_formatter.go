@@ -3,7 +3,6 @@ package utils
 import (
 	"fmt"
 	"go-ton-pass-telegram-bot/internal/model/app"
-	"regexp"
 	"strings"
 )
 
@@ -36,19 +35,29 @@ func Equal(lhs string, rhs string) bool {
 	return strings.EqualFold(lhs, rhs)
 }
 
-func PhoneNumberTitle(number string) string {
-	return fmt.Sprintf("+%s", number)
-}
-
-func ParsePhoneNumber(phoneNumber string) (*app.PhoneNumber, error) {
-	re := regexp.MustCompile(`^\+(\d+)(\d{10,})$`)
-	matches := re.FindStringSubmatch(phoneNumber)
-	if len(matches) == 0 {
+// ParsePhoneNumber splits the number into the dialing code and the national number. The dialing code of the country
+// is tried first, then the longest of the known dialing codes the number starts with.
+func ParsePhoneNumber(phoneNumber string, countryDialingCode string, dialingCodes []string) (*app.PhoneNumber, error) {
+	digits := strings.TrimPrefix(strings.TrimSpace(phoneNumber), "+")
+	if digits == "" || strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }) != -1 {
+		return nil, app.UnknownPhoneNumberFormatError
+	}
+	dialingCode := ""
+	if countryDialingCode != "" && strings.HasPrefix(digits, countryDialingCode) {
+		dialingCode = countryDialingCode
+	} else {
+		for _, code := range dialingCodes {
+			if len(code) > len(dialingCode) && strings.HasPrefix(digits, code) {
+				dialingCode = code
+			}
+		}
+	}
+	if dialingCode == "" || len(digits) == len(dialingCode) {
 		return nil, app.UnknownPhoneNumberFormatError
 	}
 	return &app.PhoneNumber{
-		CountryCode:      matches[1],
-		ShortPhoneNumber: matches[2],
+		CountryCode:      dialingCode,
+		ShortPhoneNumber: digits[len(dialingCode):],
 	}, nil
 }
 
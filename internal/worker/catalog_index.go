@@ -0,0 +1,300 @@
+package worker
+
+import (
+	"go-ton-pass-telegram-bot/internal/model/app"
+	"go-ton-pass-telegram-bot/internal/model/sms"
+	"sort"
+)
+
+// serviceRankFusionConstant damps the head of every ranking, so a service placed first in a single ranking
+// doesn't outrun a service placed high in all of them.
+const serviceRankFusionConstant = 60
+
+// CatalogIndexSource is everything the catalog index is built from.
+type CatalogIndexSource struct {
+	Version int64
+	// Services go in the catalog order, it is the order of services missing in every ranking.
+	Services []sms.Service
+	// PopularServiceCodes is the popularity list of SMS-Activate.
+	PopularServiceCodes   []string
+	PreferredServiceCodes []string
+	// ServicePopularity keeps our own sales ranking by language code, an empty code keeps the global ranking.
+	ServicePopularity      map[string][]string
+	Countries              []sms.Country
+	PreferredCountryTitles []string
+	ServicePrices          map[string][]sms.PriceForService
+}
+
+// CatalogIndex keeps the catalog in the order menus show it. It is built once per catalog version, so a page
+// of services or countries is served without sorting and its cost doesn't grow with the catalog.
+// The index is shared between requests and must not be modified.
+type CatalogIndex struct {
+	version         int64
+	services        []sms.Service
+	serviceIndexes  map[string]int
+	countries       []sms.Country
+	countryIndexes  map[int64]int
+	countriesByID   map[int64]sms.Country
+	orderedServices map[string]orderedList[string, sms.Service]
+	orderedPrices   map[string]orderedList[int64, sms.PriceForService]
+}
+
+// orderedList keeps items in their order with the position of every item by its key.
+type orderedList[K comparable, T any] struct {
+	items     []T
+	positions map[K]int
+}
+
+func newOrderedList[K comparable, T any](items []T, keyOf func(T) K) orderedList[K, T] {
+	positions := make(map[K]int, len(items))
+	for idx, item := range items {
+		positions[keyOf(item)] = idx
+	}
+	return orderedList[K, T]{
+		items:     items,
+		positions: positions,
+	}
+}
+
+func NewCatalogIndex(source CatalogIndexSource) *CatalogIndex {
+	catalogIndex := CatalogIndex{
+		version:         source.Version,
+		services:        source.Services,
+		serviceIndexes:  make(map[string]int, len(source.Services)),
+		countries:       source.Countries,
+		countryIndexes:  make(map[int64]int, len(source.Countries)),
+		countriesByID:   make(map[int64]sms.Country, len(source.Countries)),
+		orderedServices: make(map[string]orderedList[string, sms.Service], len(source.ServicePopularity)+1),
+		orderedPrices:   make(map[string]orderedList[int64, sms.PriceForService], len(source.ServicePrices)),
+	}
+	for idx, service := range source.Services {
+		catalogIndex.serviceIndexes[service.Code] = idx
+	}
+	for idx, country := range source.Countries {
+		catalogIndex.countryIndexes[country.ID] = idx
+		catalogIndex.countriesByID[country.ID] = country
+	}
+	languageCodes := []string{""}
+	for languageCode := range source.ServicePopularity {
+		if languageCode != "" {
+			languageCodes = append(languageCodes, languageCode)
+		}
+	}
+	for _, languageCode := range languageCodes {
+		rankedServiceCodes := append(
+			BlendServiceRankings(
+				source.PreferredServiceCodes,
+				source.ServicePopularity[languageCode],
+				source.ServicePopularity[""],
+			),
+			source.PopularServiceCodes...,
+		)
+		catalogIndex.orderedServices[languageCode] = newOrderedList(
+			catalogIndex.orderServices(rankedServiceCodes),
+			func(service sms.Service) string { return service.Code },
+		)
+	}
+	preferredTitleRanks := make(map[string]int, len(source.PreferredCountryTitles))
+	for rank, title := range source.PreferredCountryTitles {
+		if _, ok := preferredTitleRanks[title]; !ok {
+			preferredTitleRanks[title] = rank
+		}
+	}
+	preferredCountryRanks := make(map[int64]int, len(preferredTitleRanks))
+	for _, country := range source.Countries {
+		if rank, ok := preferredTitleRanks[country.Title]; ok {
+			preferredCountryRanks[country.ID] = rank
+		}
+	}
+	for serviceCode, servicePrices := range source.ServicePrices {
+		catalogIndex.orderedPrices[serviceCode] = newOrderedList(
+			orderServicePrices(servicePrices, preferredCountryRanks),
+			func(servicePrice sms.PriceForService) int64 { return servicePrice.CountryCode },
+		)
+	}
+	return &catalogIndex
+}
+
+func (c *CatalogIndex) Version() int64 {
+	return c.version
+}
+
+func (c *CatalogIndex) Services() []sms.Service {
+	return c.services
+}
+
+func (c *CatalogIndex) Countries() []sms.Country {
+	return c.countries
+}
+
+func (c *CatalogIndex) CountriesByID() map[int64]sms.Country {
+	return c.countriesByID
+}
+
+func (c *CatalogIndex) Service(serviceCode string) (*sms.Service, bool) {
+	idx, ok := c.serviceIndexes[serviceCode]
+	if !ok {
+		return nil, false
+	}
+	service := c.services[idx]
+	return &service, true
+}
+
+func (c *CatalogIndex) Country(countryID int64) (*sms.Country, bool) {
+	idx, ok := c.countryIndexes[countryID]
+	if !ok {
+		return nil, false
+	}
+	country := c.countries[idx]
+	return &country, true
+}
+
+// OrderedServices returns a page of services with favorite services pinned on top. An unknown language
+// falls back to the global ranking.
+func (c *CatalogIndex) OrderedServices(
+	languageCode string,
+	favorites app.Favorites,
+	currentPage int,
+	itemsPerPage int,
+) (*app.Page[sms.Service], error) {
+	orderedServices, ok := c.orderedServices[languageCode]
+	if !ok {
+		orderedServices = c.orderedServices[""]
+	}
+	return pinnedPage(orderedServices, favorites.ServiceCodes, currentPage, itemsPerPage)
+}
+
+// OrderedServicePrices returns a page of prices of the service with favorite countries pinned on top.
+// A service without prices gives an empty page.
+func (c *CatalogIndex) OrderedServicePrices(
+	serviceCode string,
+	favorites app.Favorites,
+	currentPage int,
+	itemsPerPage int,
+) (*app.Page[sms.PriceForService], error) {
+	return pinnedPage(c.orderedPrices[serviceCode], favorites.CountryIDs, currentPage, itemsPerPage)
+}
+
+// orderServices puts services of the rankings first in the order of the first appearance, the rest keep
+// the catalog order.
+func (c *CatalogIndex) orderServices(rankedServiceCodes []string) []sms.Service {
+	orderedServices := make([]sms.Service, 0, len(c.services))
+	isOrdered := make(map[string]bool, len(c.services))
+	for _, serviceCode := range rankedServiceCodes {
+		idx, ok := c.serviceIndexes[serviceCode]
+		if !ok || isOrdered[serviceCode] {
+			continue
+		}
+		isOrdered[serviceCode] = true
+		orderedServices = append(orderedServices, c.services[idx])
+	}
+	for _, service := range c.services {
+		if isOrdered[service.Code] {
+			continue
+		}
+		isOrdered[service.Code] = true
+		orderedServices = append(orderedServices, service)
+	}
+	return orderedServices
+}
+
+// orderServicePrices keeps prices with numbers on sale, preferred countries go first and then the retail price.
+func orderServicePrices(servicePrices []sms.PriceForService, preferredCountryRanks map[int64]int) []sms.PriceForService {
+	orderedServicePrices := make([]sms.PriceForService, 0, len(servicePrices))
+	for _, servicePrice := range servicePrices {
+		if servicePrice.MinPrice <= 0 {
+			continue
+		}
+		orderedServicePrices = append(orderedServicePrices, servicePrice)
+	}
+	sort.Slice(orderedServicePrices, func(i, j int) bool {
+		lhsServicePrice := orderedServicePrices[i]
+		rhsServicePrice := orderedServicePrices[j]
+		lhsRank, isLhsPreferred := preferredCountryRanks[lhsServicePrice.CountryCode]
+		rhsRank, isRhsPreferred := preferredCountryRanks[rhsServicePrice.CountryCode]
+		if isLhsPreferred != isRhsPreferred {
+			return isLhsPreferred
+		}
+		if isLhsPreferred && lhsRank != rhsRank {
+			return lhsRank < rhsRank
+		}
+		if lhsServicePrice.RetailPrice == rhsServicePrice.RetailPrice {
+			return lhsServicePrice.CountryCode < rhsServicePrice.CountryCode
+		}
+		return lhsServicePrice.RetailPrice < rhsServicePrice.RetailPrice
+	})
+	return orderedServicePrices
+}
+
+// pinnedPage cuts a page out of the list as if pinned items were moved on top of it. Only pinned items and items
+// of the page are visited, so the cost of a page doesn't depend on the length of the list.
+func pinnedPage[K comparable, T any](
+	list orderedList[K, T],
+	pinnedKeys []K,
+	currentPage int,
+	itemsPerPage int,
+) (*app.Page[T], error) {
+	pinnedPositions := make([]int, 0, len(pinnedKeys))
+	isPinned := make(map[int]bool, len(pinnedKeys))
+	for _, pinnedKey := range pinnedKeys {
+		position, ok := list.positions[pinnedKey]
+		if !ok || isPinned[position] {
+			continue
+		}
+		isPinned[position] = true
+		pinnedPositions = append(pinnedPositions, position)
+	}
+	lenItems := len(list.items)
+	startIndex := currentPage * itemsPerPage
+	if startIndex < 0 || startIndex > lenItems {
+		return nil, app.IndexOutOfRangeError
+	}
+	endIndex := min(startIndex+itemsPerPage, lenItems)
+	items := make([]T, 0, endIndex-startIndex)
+	for idx := startIndex; idx < min(endIndex, len(pinnedPositions)); idx++ {
+		items = append(items, list.items[pinnedPositions[idx]])
+	}
+	if endIndex > len(pinnedPositions) {
+		sortedPinnedPositions := append([]int{}, pinnedPositions...)
+		sort.Ints(sortedPinnedPositions)
+		// the first item of the page behind the pinned ones skips every pinned item placed before it
+		position := max(startIndex-len(pinnedPositions), 0)
+		for _, pinnedPosition := range sortedPinnedPositions {
+			if pinnedPosition <= position {
+				position++
+			}
+		}
+		for len(items) < endIndex-startIndex {
+			if !isPinned[position] {
+				items = append(items, list.items[position])
+			}
+			position++
+		}
+	}
+	return &app.Page[T]{
+		Items: items,
+		Pagination: app.Pagination{
+			CurrentPage:  currentPage,
+			LenItems:     lenItems,
+			ItemsPerPage: itemsPerPage,
+		},
+	}, nil
+}
+
+// BlendServiceRankings merges rankings with reciprocal rank fusion, equal scores keep the order of the first appearance.
+func BlendServiceRankings(rankings ...[]string) []string {
+	scores := make(map[string]float64)
+	serviceCodes := make([]string, 0)
+	for _, ranking := range rankings {
+		for idx, serviceCode := range ranking {
+			if _, ok := scores[serviceCode]; !ok {
+				serviceCodes = append(serviceCodes, serviceCode)
+			}
+			scores[serviceCode] += 1 / float64(serviceRankFusionConstant+idx+1)
+		}
+	}
+	sort.SliceStable(serviceCodes, func(i, j int) bool {
+		return scores[serviceCodes[i]] > scores[serviceCodes[j]]
+	})
+	return serviceCodes
+}
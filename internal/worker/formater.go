@@ -17,15 +17,21 @@ const (
 )
 
 type Formatter interface {
-	Country(country *sms.Country, formatterType FormatterType) string
-	Service(service *sms.Service, formatterType FormatterType) string
+	Country(langCode string, country *sms.Country, formatterType FormatterType) string
+	Service(langCode string, service *sms.Service, formatterType FormatterType) string
 	SHSHistories(langCode string, smsHistories []domain.SMSHistory) string
 	SMSHistory(langCode string, smsHistory domain.SMSHistory) string
-	ConfirmationPay(langCode string, service *sms.Service, country *sms.Country, amount float64, preferredCurrency app.Currency) string
+	ConfirmationPay(langCode string, service *sms.Service, country *sms.Country, operator string, amount float64, preferredCurrency app.Currency) string
 	StartSMSActivation(langCode string, smsHistory *domain.SMSHistory) string
 	CompleteSMSActivation(langCode string, smsHistory *domain.SMSHistory) string
 	FailSMSActivation(langCode string, smsHistory *domain.SMSHistory) string
 	ManualCancelActivation(langCode string, smsHistory *domain.SMSHistory) string
+	ActivationGroup(langCode string, activationGroup *domain.ActivationGroup, smsHistories []domain.SMSHistory) string
+	Favorites(langCode string, services []sms.Service, countries []sms.Country) string
+	AdminProfile(langCode string, profile *domain.Profile, smsHistories []domain.SMSHistory) string
+	AdminActivation(langCode string, smsHistory *domain.SMSHistory) string
+	AdminStats(langCode string, stats *domain.AdminStats) string
+	BroadcastProgress(langCode string, broadcast *domain.Broadcast, deliveryCounts map[domain.BroadcastDeliveryStatus]int64) string
 }
 
 type formatter struct {
@@ -39,12 +45,12 @@ func NewFormatter(container container.Container) Formatter {
 	return &f
 }
 
-func (f *formatter) Country(country *sms.Country, _ FormatterType) string {
-	return f.representableCountry(country.Title, country.ID)
+func (f *formatter) Country(langCode string, country *sms.Country, _ FormatterType) string {
+	return f.representableCountry(langCode, country.Title, country.ID)
 }
 
-func (f *formatter) Service(service *sms.Service, _ FormatterType) string {
-	return f.representableService(service.Name, service.Code)
+func (f *formatter) Service(langCode string, service *sms.Service, _ FormatterType) string {
+	return f.representableService(langCode, service.Name, service.Code)
 }
 
 func (f *formatter) SHSHistories(langCode string, smsHistories []domain.SMSHistory) string {
@@ -67,21 +73,18 @@ func (f *formatter) SMSHistory(langCode string, smsHistory domain.SMSHistory) st
 	localizer := f.container.GetLocalizer(langCode)
 	newLine := "\n"
 	stringBuilder := strings.Builder{}
-	phoneNumber := app.PhoneNumber{
-		CountryCode:      smsHistory.PhoneCodeNumber,
-		ShortPhoneNumber: smsHistory.PhoneShortNumber,
-	}
+	phoneNumber := f.phoneNumber(smsHistory)
 	localPhoneNumberRow := localizer.LocalizedStringWithTemplateData("sms_activation_short_phone_number_markdown", map[string]any{
-		"PhoneNumber": utils.EscapeMarkdownText(phoneNumber.ShortPhoneNumber),
+		"PhoneNumber": utils.EscapeMarkdownText(phoneNumber.NationalNumber()),
 	})
 	internationPhoneNumberRow := localizer.LocalizedStringWithTemplateData("sms_activation_full_phone_number_markdown", map[string]any{
-		"PhoneNumber": utils.EscapeMarkdownText(phoneNumber.FullNumber()),
+		"PhoneNumber": utils.EscapeMarkdownText(phoneNumber.InternationalNumber()),
 	})
 	serviceRow := localizer.LocalizedStringWithTemplateData("sms_activation_service_markdown", map[string]any{
-		"Service": f.representableService(smsHistory.ServiceName, smsHistory.ServiceCode),
+		"Service": f.representableService(langCode, smsHistory.ServiceName, smsHistory.ServiceCode),
 	})
 	countryRow := localizer.LocalizedStringWithTemplateData("sms_activation_country_markdown", map[string]any{
-		"Country": f.representableCountry(smsHistory.CountryName, smsHistory.CountryID),
+		"Country": f.representableCountry(langCode, smsHistory.CountryName, smsHistory.CountryID),
 	})
 
 	stringBuilder.WriteString(localPhoneNumberRow)
@@ -136,19 +139,19 @@ func (f *formatter) Status(state app.SMSActivationState) string {
 	return "Unknown"
 }
 
-func (f *formatter) ConfirmationPay(langCode string, service *sms.Service, country *sms.Country, amount float64, preferredCurrency app.Currency) string {
+func (f *formatter) ConfirmationPay(langCode string, service *sms.Service, country *sms.Country, operator string, amount float64, preferredCurrency app.Currency) string {
 	localizer := f.container.GetLocalizer(langCode)
 	newLine := "\n"
 	stringBuilder := strings.Builder{}
 	title := localizer.LocalizedString("confirm_sms_activation_title_markdown")
 	selectedService := localizer.LocalizedStringWithTemplateData("confirm_sms_activation_selected_service_markdown", map[string]any{
-		"ServiceName": utils.EscapeMarkdownText(f.Service(service, DefaultFormatterType)),
+		"ServiceName": utils.EscapeMarkdownText(f.Service(langCode, service, DefaultFormatterType)),
 	})
 	priceForService := localizer.LocalizedStringWithTemplateData("confirm_sms_activation_price_for_service_markdown", map[string]any{
 		"Price": utils.EscapeMarkdownText(utils.CurrencyAmountTextFormat(amount, preferredCurrency)),
 	})
 	selectedCountry := localizer.LocalizedStringWithTemplateData("confirm_sms_activation_selected_country_markdown", map[string]any{
-		"Country": utils.EscapeMarkdownText(f.Country(country, DefaultFormatterType)),
+		"Country": utils.EscapeMarkdownText(f.Country(langCode, country, DefaultFormatterType)),
 	})
 	footer := localizer.LocalizedString("confirm_sms_activation_footer_markdown")
 	stringBuilder.WriteString(title)
@@ -160,6 +163,13 @@ func (f *formatter) ConfirmationPay(langCode string, service *sms.Service, count
 	stringBuilder.WriteString(newLine)
 	stringBuilder.WriteString(selectedCountry)
 	stringBuilder.WriteString(newLine)
+	if operator != "" {
+		selectedOperator := localizer.LocalizedStringWithTemplateData("confirm_sms_activation_selected_operator_markdown", map[string]any{
+			"Operator": utils.EscapeMarkdownText(operator),
+		})
+		stringBuilder.WriteString(selectedOperator)
+		stringBuilder.WriteString(newLine)
+	}
 	stringBuilder.WriteString(newLine)
 	stringBuilder.WriteString(footer)
 	return stringBuilder.String()
@@ -169,22 +179,19 @@ func (f *formatter) StartSMSActivation(langCode string, smsHistory *domain.SMSHi
 	localizer := f.container.GetLocalizer(langCode)
 	newLine := "\n"
 	stringBuilder := strings.Builder{}
-	phoneNumber := app.PhoneNumber{
-		CountryCode:      smsHistory.PhoneCodeNumber,
-		ShortPhoneNumber: smsHistory.PhoneShortNumber,
-	}
+	phoneNumber := f.phoneNumber(*smsHistory)
 	title := localizer.LocalizedString("start_sms_activation_title_markdown")
 	localPhoneNumber := localizer.LocalizedStringWithTemplateData("sms_activation_short_phone_number_markdown", map[string]any{
-		"PhoneNumber": utils.EscapeMarkdownText(phoneNumber.ShortPhoneNumber),
+		"PhoneNumber": utils.EscapeMarkdownText(phoneNumber.NationalNumber()),
 	})
 	internationPhoneNumber := localizer.LocalizedStringWithTemplateData("sms_activation_full_phone_number_markdown", map[string]any{
-		"PhoneNumber": utils.EscapeMarkdownText(phoneNumber.FullNumber()),
+		"PhoneNumber": utils.EscapeMarkdownText(phoneNumber.InternationalNumber()),
 	})
 	selectedService := localizer.LocalizedStringWithTemplateData("sms_activation_service_markdown", map[string]any{
-		"Service": f.representableService(smsHistory.ServiceName, smsHistory.ServiceCode),
+		"Service": f.representableService(langCode, smsHistory.ServiceName, smsHistory.ServiceCode),
 	})
 	selectedCountry := localizer.LocalizedStringWithTemplateData("sms_activation_country_markdown", map[string]any{
-		"Country": f.representableCountry(smsHistory.CountryName, smsHistory.CountryID),
+		"Country": f.representableCountry(langCode, smsHistory.CountryName, smsHistory.CountryID),
 	})
 	stringBuilder.WriteString(title)
 	stringBuilder.WriteString(newLine)
@@ -203,22 +210,19 @@ func (f *formatter) CompleteSMSActivation(langCode string, smsHistory *domain.SM
 	localizer := f.container.GetLocalizer(langCode)
 	newLine := "\n"
 	stringBuilder := strings.Builder{}
-	phoneNumber := app.PhoneNumber{
-		CountryCode:      smsHistory.PhoneCodeNumber,
-		ShortPhoneNumber: smsHistory.PhoneShortNumber,
-	}
+	phoneNumber := f.phoneNumber(*smsHistory)
 	title := localizer.LocalizedString("success_received_sms_code_markdown")
 	localPhoneNumber := localizer.LocalizedStringWithTemplateData("sms_activation_short_phone_number_markdown", map[string]any{
-		"PhoneNumber": utils.EscapeMarkdownText(phoneNumber.ShortPhoneNumber),
+		"PhoneNumber": utils.EscapeMarkdownText(phoneNumber.NationalNumber()),
 	})
 	internationPhoneNumber := localizer.LocalizedStringWithTemplateData("sms_activation_full_phone_number_markdown", map[string]any{
-		"PhoneNumber": utils.EscapeMarkdownText(phoneNumber.FullNumber()),
+		"PhoneNumber": utils.EscapeMarkdownText(phoneNumber.InternationalNumber()),
 	})
 	selectedService := localizer.LocalizedStringWithTemplateData("sms_activation_service_markdown", map[string]any{
-		"Service": f.representableService(smsHistory.ServiceName, smsHistory.ServiceCode),
+		"Service": f.representableService(langCode, smsHistory.ServiceName, smsHistory.ServiceCode),
 	})
 	selectedCountry := localizer.LocalizedStringWithTemplateData("sms_activation_country_markdown", map[string]any{
-		"Country": f.representableCountry(smsHistory.CountryName, smsHistory.CountryID),
+		"Country": f.representableCountry(langCode, smsHistory.CountryName, smsHistory.CountryID),
 	})
 	smsCode := localizer.LocalizedStringWithTemplateData("sms_activation_code_markdown", map[string]any{
 		"SMSCode": utils.EscapeMarkdownText(*smsHistory.SMSCode),
@@ -246,23 +250,20 @@ func (f *formatter) FailSMSActivation(langCode string, smsHistory *domain.SMSHis
 	localizer := f.container.GetLocalizer(langCode)
 	newLine := "\n"
 	stringBuilder := strings.Builder{}
-	phoneNumber := app.PhoneNumber{
-		CountryCode:      smsHistory.PhoneCodeNumber,
-		ShortPhoneNumber: smsHistory.PhoneShortNumber,
-	}
+	phoneNumber := f.phoneNumber(*smsHistory)
 	title := localizer.LocalizedString("not_receive_sms_code_title_markdown")
 	footer := localizer.LocalizedString("not_receive_sms_code_footer_markdown")
 	localPhoneNumber := localizer.LocalizedStringWithTemplateData("sms_activation_short_phone_number_markdown", map[string]any{
-		"PhoneNumber": utils.EscapeMarkdownText(phoneNumber.ShortPhoneNumber),
+		"PhoneNumber": utils.EscapeMarkdownText(phoneNumber.NationalNumber()),
 	})
 	internationPhoneNumber := localizer.LocalizedStringWithTemplateData("sms_activation_full_phone_number_markdown", map[string]any{
-		"PhoneNumber": utils.EscapeMarkdownText(phoneNumber.FullNumber()),
+		"PhoneNumber": utils.EscapeMarkdownText(phoneNumber.InternationalNumber()),
 	})
 	selectedService := localizer.LocalizedStringWithTemplateData("sms_activation_service_markdown", map[string]any{
-		"Service": f.representableService(smsHistory.ServiceName, smsHistory.ServiceCode),
+		"Service": f.representableService(langCode, smsHistory.ServiceName, smsHistory.ServiceCode),
 	})
 	selectedCountry := localizer.LocalizedStringWithTemplateData("sms_activation_country_markdown", map[string]any{
-		"Country": f.representableCountry(smsHistory.CountryName, smsHistory.CountryID),
+		"Country": f.representableCountry(langCode, smsHistory.CountryName, smsHistory.CountryID),
 	})
 	startAt := localizer.LocalizedStringWithTemplateData("sms_activation_start_at_markdown", map[string]any{
 		"StartDate": utils.EscapeMarkdownText(smsHistory.CreatedAt.Format(utils.FullDateFormat)),
@@ -289,23 +290,20 @@ func (f *formatter) ManualCancelActivation(langCode string, smsHistory *domain.S
 	localizer := f.container.GetLocalizer(langCode)
 	newLine := "\n"
 	stringBuilder := strings.Builder{}
-	phoneNumber := app.PhoneNumber{
-		CountryCode:      smsHistory.PhoneCodeNumber,
-		ShortPhoneNumber: smsHistory.PhoneShortNumber,
-	}
+	phoneNumber := f.phoneNumber(*smsHistory)
 	title := localizer.LocalizedString("cancel_sms_activation_title_markdown")
 	footer := localizer.LocalizedString("cancel_sms_activation_footer_markdown")
 	localPhoneNumber := localizer.LocalizedStringWithTemplateData("sms_activation_short_phone_number_markdown", map[string]any{
-		"PhoneNumber": utils.EscapeMarkdownText(phoneNumber.ShortPhoneNumber),
+		"PhoneNumber": utils.EscapeMarkdownText(phoneNumber.NationalNumber()),
 	})
 	internationPhoneNumber := localizer.LocalizedStringWithTemplateData("sms_activation_full_phone_number_markdown", map[string]any{
-		"PhoneNumber": utils.EscapeMarkdownText(phoneNumber.FullNumber()),
+		"PhoneNumber": utils.EscapeMarkdownText(phoneNumber.InternationalNumber()),
 	})
 	selectedService := localizer.LocalizedStringWithTemplateData("sms_activation_service_markdown", map[string]any{
-		"Service": f.representableService(smsHistory.ServiceName, smsHistory.ServiceCode),
+		"Service": f.representableService(langCode, smsHistory.ServiceName, smsHistory.ServiceCode),
 	})
 	selectedCountry := localizer.LocalizedStringWithTemplateData("sms_activation_country_markdown", map[string]any{
-		"Country": f.representableCountry(smsHistory.CountryName, smsHistory.CountryID),
+		"Country": f.representableCountry(langCode, smsHistory.CountryName, smsHistory.CountryID),
 	})
 	stringBuilder.WriteString(title)
 	stringBuilder.WriteString(newLine)
@@ -323,22 +321,246 @@ func (f *formatter) ManualCancelActivation(langCode string, smsHistory *domain.S
 	return stringBuilder.String()
 }
 
-func (f *formatter) representableCountry(countryName string, countryID int64) string {
+func (f *formatter) ActivationGroup(langCode string, activationGroup *domain.ActivationGroup, smsHistories []domain.SMSHistory) string {
+	localizer := f.container.GetLocalizer(langCode)
+	newLine := "\n"
+	stringBuilder := strings.Builder{}
+	title := localizer.LocalizedStringWithTemplateData("activation_group_title_markdown", map[string]any{
+		"Obtained":  len(smsHistories),
+		"Requested": activationGroup.Quantity,
+	})
+	stringBuilder.WriteString(title)
+	stringBuilder.WriteString(newLine)
+	stringBuilder.WriteString(newLine)
+	if len(smsHistories) > 0 {
+		selectedService := localizer.LocalizedStringWithTemplateData("sms_activation_service_markdown", map[string]any{
+			"Service": f.representableService(langCode, smsHistories[0].ServiceName, smsHistories[0].ServiceCode),
+		})
+		selectedCountry := localizer.LocalizedStringWithTemplateData("sms_activation_country_markdown", map[string]any{
+			"Country": f.representableCountry(langCode, smsHistories[0].CountryName, smsHistories[0].CountryID),
+		})
+		stringBuilder.WriteString(selectedService)
+		stringBuilder.WriteString(newLine)
+		stringBuilder.WriteString(selectedCountry)
+		stringBuilder.WriteString(newLine)
+		stringBuilder.WriteString(newLine)
+	}
+	for idx, smsHistory := range smsHistories {
+		phoneNumber := f.phoneNumber(smsHistory)
+		var state string
+		switch {
+		case smsHistory.SMSCode != nil:
+			state = fmt.Sprintf("✅ `%s`", utils.EscapeMarkdownText(*smsHistory.SMSCode))
+		case app.SMSActivationState(smsHistory.Status) == app.PendingSMSActivateState:
+			state = localizer.LocalizedString("activation_group_pending_state_markdown")
+		default:
+			state = localizer.LocalizedString("activation_group_cancel_state_markdown")
+		}
+		item := localizer.LocalizedStringWithTemplateData("activation_group_item_markdown", map[string]any{
+			"Index":       idx + 1,
+			"PhoneNumber": utils.EscapeMarkdownText(phoneNumber.InternationalNumber()),
+			"State":       state,
+		})
+		stringBuilder.WriteString(item)
+		stringBuilder.WriteString(newLine)
+	}
+	stringBuilder.WriteString(newLine)
+	if len(smsHistories) < activationGroup.Quantity {
+		stringBuilder.WriteString(localizer.LocalizedString("activation_group_partial_markdown"))
+		stringBuilder.WriteString(newLine)
+	}
+	stringBuilder.WriteString(localizer.LocalizedString("activation_group_footer_markdown"))
+	return stringBuilder.String()
+}
+
+func (f *formatter) Favorites(langCode string, services []sms.Service, countries []sms.Country) string {
+	localizer := f.container.GetLocalizer(langCode)
+	if len(services) == 0 && len(countries) == 0 {
+		return localizer.LocalizedString("favorites_empty_markdown")
+	}
+	newLine := "\n"
+	stringBuilder := strings.Builder{}
+	stringBuilder.WriteString(localizer.LocalizedString("favorites_title_markdown"))
+	stringBuilder.WriteString(newLine)
+	if len(services) > 0 {
+		stringBuilder.WriteString(newLine)
+		stringBuilder.WriteString(localizer.LocalizedString("favorites_services_markdown"))
+		stringBuilder.WriteString(newLine)
+		for _, service := range services {
+			stringBuilder.WriteString(utils.EscapeMarkdownText(f.Service(langCode, &service, DefaultFormatterType)))
+			stringBuilder.WriteString(newLine)
+		}
+	}
+	if len(countries) > 0 {
+		stringBuilder.WriteString(newLine)
+		stringBuilder.WriteString(localizer.LocalizedString("favorites_countries_markdown"))
+		stringBuilder.WriteString(newLine)
+		for _, country := range countries {
+			stringBuilder.WriteString(utils.EscapeMarkdownText(f.Country(langCode, &country, DefaultFormatterType)))
+			stringBuilder.WriteString(newLine)
+		}
+	}
+	return stringBuilder.String()
+}
+
+// phoneNumber groups digits of the number the way the country does, when the number was split by its dialing code.
+// AdminProfile is plain text, admin texts quote user input that would otherwise need escaping.
+func (f *formatter) AdminProfile(langCode string, profile *domain.Profile, smsHistories []domain.SMSHistory) string {
+	localizer := f.container.GetLocalizer(langCode)
+	newLine := "\n"
+	stringBuilder := strings.Builder{}
+	emptyValue := "-"
+	username, preferredLanguage, preferredCurrency, botBlockedAt := emptyValue, emptyValue, emptyValue, emptyValue
+	if profile.Username != nil {
+		username = "@" + *profile.Username
+	}
+	if profile.PreferredLanguage != nil {
+		preferredLanguage = *profile.PreferredLanguage
+	}
+	if profile.PreferredCurrency != nil {
+		preferredCurrency = *profile.PreferredCurrency
+	}
+	if profile.BotBlockedAt != nil {
+		botBlockedAt = profile.BotBlockedAt.Format(utils.FullDateFormat)
+	}
+	stringBuilder.WriteString(localizer.LocalizedStringWithTemplateData("admin_profile", map[string]any{
+		"Username":     username,
+		"TelegramID":   profile.TelegramID,
+		"ProfileID":    profile.ID,
+		"Role":         profile.Role,
+		"Language":     preferredLanguage,
+		"Currency":     preferredCurrency,
+		"Balance":      fmt.Sprintf("%.2f", profile.Balance),
+		"BotBlockedAt": botBlockedAt,
+		"CreatedAt":    profile.CreatedAt.Format(utils.FullDateFormat),
+	}))
+	stringBuilder.WriteString(newLine)
+	stringBuilder.WriteString(newLine)
+	if len(smsHistories) == 0 {
+		stringBuilder.WriteString(localizer.LocalizedString("admin_no_activations"))
+		return stringBuilder.String()
+	}
+	stringBuilder.WriteString(localizer.LocalizedString("admin_recent_activations"))
+	for _, smsHistory := range smsHistories {
+		stringBuilder.WriteString(newLine)
+		stringBuilder.WriteString(localizer.LocalizedStringWithTemplateData("admin_activation_row", map[string]any{
+			"ActivationID": smsHistory.ActivationID,
+			"Service":      smsHistory.ServiceName,
+			"Country":      smsHistory.CountryName,
+			"PhoneNumber":  f.phoneNumber(smsHistory).InternationalNumber(),
+			"Status":       f.Status(app.SMSActivationState(smsHistory.Status)),
+			"CreatedAt":    smsHistory.CreatedAt.Format(utils.FullDateFormat),
+		}))
+	}
+	return stringBuilder.String()
+}
+
+func (f *formatter) AdminActivation(langCode string, smsHistory *domain.SMSHistory) string {
+	localizer := f.container.GetLocalizer(langCode)
+	smsCode := "-"
+	if smsHistory.SMSCode != nil {
+		smsCode = *smsHistory.SMSCode
+	}
+	return localizer.LocalizedStringWithTemplateData("admin_activation", map[string]any{
+		"ActivationID": smsHistory.ActivationID,
+		"ProfileID":    smsHistory.ProfileID,
+		"Service":      smsHistory.ServiceName,
+		"Country":      smsHistory.CountryName,
+		"PhoneNumber":  f.phoneNumber(*smsHistory).InternationalNumber(),
+		"Status":       f.Status(app.SMSActivationState(smsHistory.Status)),
+		"SMSCode":      smsCode,
+		"CreatedAt":    smsHistory.CreatedAt.Format(utils.FullDateFormat),
+	})
+}
+
+func (f *formatter) AdminStats(langCode string, stats *domain.AdminStats) string {
+	localizer := f.container.GetLocalizer(langCode)
+	newLine := "\n"
+	stringBuilder := strings.Builder{}
+	var activationsCount int64
+	for _, count := range stats.ActivationCounts {
+		activationsCount += count
+	}
+	stringBuilder.WriteString(localizer.LocalizedStringWithTemplateData("admin_stats", map[string]any{
+		"ProfilesCount":           stats.ProfilesCount,
+		"NewProfilesCount":        stats.NewProfilesCount,
+		"BlockedBotProfilesCount": stats.BlockedBotProfilesCount,
+		"TotalBalance":            fmt.Sprintf("%.2f", stats.TotalBalance),
+		"ActivationsCount":        activationsCount,
+	}))
+	for _, state := range []app.SMSActivationState{
+		app.PendingSMSActivateState,
+		app.DoneSMSActivateState,
+		app.CancelSMSActivateState,
+		app.UnknownSMSActivateState,
+	} {
+		count, ok := stats.ActivationCounts[string(state)]
+		if !ok {
+			continue
+		}
+		stringBuilder.WriteString(newLine)
+		stringBuilder.WriteString(localizer.LocalizedStringWithTemplateData("admin_stats_activation_status", map[string]any{
+			"Status": f.Status(state),
+			"Count":  count,
+		}))
+	}
+	return stringBuilder.String()
+}
+
+// BroadcastProgress describes the broadcast for the control message of its author, it is plain text.
+func (f *formatter) BroadcastProgress(
+	langCode string,
+	broadcast *domain.Broadcast,
+	deliveryCounts map[domain.BroadcastDeliveryStatus]int64,
+) string {
+	localizer := f.container.GetLocalizer(langCode)
+	var recipientsCount int64
+	for _, count := range deliveryCounts {
+		recipientsCount += count
+	}
+	return localizer.LocalizedStringWithTemplateData("broadcast_progress", map[string]any{
+		"ID":              broadcast.ID,
+		"Status":          localizer.LocalizedString("broadcast_status_" + string(broadcast.Status)),
+		"RecipientsCount": recipientsCount,
+		"SentCount":       deliveryCounts[domain.SentBroadcastDeliveryStatus],
+		"BlockedCount":    deliveryCounts[domain.BlockedBroadcastDeliveryStatus],
+		"FailedCount":     deliveryCounts[domain.FailedBroadcastDeliveryStatus],
+		"PendingCount":    deliveryCounts[domain.PendingBroadcastDeliveryStatus] + deliveryCounts[domain.SendingBroadcastDeliveryStatus],
+	})
+}
+
+func (f *formatter) phoneNumber(smsHistory domain.SMSHistory) app.PhoneNumber {
+	phoneNumber := app.PhoneNumber{
+		CountryCode:      smsHistory.PhoneCodeNumber,
+		ShortPhoneNumber: smsHistory.PhoneShortNumber,
+	}
+	countryDialingCode := f.container.GetCountryDialingCode(smsHistory.CountryID)
+	if countryDialingCode != nil && countryDialingCode.DialingCode == phoneNumber.CountryCode {
+		phoneNumber.Grouping = countryDialingCode.Grouping
+	}
+	return phoneNumber
+}
+
+func (f *formatter) representableCountry(langCode string, countryName string, countryID int64) string {
 	var title string
 	name := f.container.GetRepresentableCountryName(countryID)
 	if name == nil {
 		name = &countryName
 	}
 	flag := f.container.GetFlagEmoji(*name)
+	localizedName := f.container.GetLocalizedCountryName(langCode, *name)
+	if localizedName == nil {
+		localizedName = name
+	}
 	if flag != nil {
-		title = fmt.Sprintf("%s %s", *flag, *name)
+		title = fmt.Sprintf("%s %s", *flag, *localizedName)
 	} else {
-		title = countryName
+		title = *localizedName
 	}
 	return title
 }
 
-func (f *formatter) representableService(serviceName string, serviceCode string) string {
+func (f *formatter) representableService(langCode string, serviceName string, serviceCode string) string {
 	var (
 		name  string
 		emoji string
@@ -351,5 +573,8 @@ func (f *formatter) representableService(serviceName string, serviceCode string)
 		name = serviceName
 		emoji = "🌐"
 	}
+	if localizedName := f.container.GetLocalizedServiceName(langCode, serviceCode); localizedName != nil {
+		name = *localizedName
+	}
 	return fmt.Sprintf("%s %s", emoji, name)
 }
@@ -5,216 +5,402 @@ import (
 	"go-ton-pass-telegram-bot/internal/container"
 	"go-ton-pass-telegram-bot/internal/model/app"
 	"go-ton-pass-telegram-bot/internal/model/sms"
+	"go-ton-pass-telegram-bot/internal/repository"
 	"go-ton-pass-telegram-bot/internal/service"
 	"go-ton-pass-telegram-bot/internal/utils"
 	"go-ton-pass-telegram-bot/pkg/logger"
 	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type SMSActivate interface {
-	GetOrderedServices() ([]sms.Service, error)
+	GetOrderedServices(languageCode string, favorites app.Favorites, currentPage int, itemsPerPage int) (*app.Page[sms.Service], error)
 	GetService(serviceCode string) (*sms.Service, error)
-	GetPriceForService(serviceCode string) ([]sms.PriceForService, error)
+	GetPriceForService(serviceCode string, favorites app.Favorites, currentPage int, itemsPerPage int) (*app.Page[sms.PriceForService], error)
+	GetCheapestPricesForService(serviceCode string) ([]sms.PriceForService, error)
 	GetCountries() ([]sms.Country, error)
+	GetCountriesByID() (map[int64]sms.Country, error)
 	GetServices() ([]sms.Service, error)
 	GetCountry(countryID int64) (*sms.Country, error)
+	GetOperators(countryID int64) ([]string, error)
+	SearchServices(query string, languageCode string, limit int) ([]sms.Service, error)
+	GetOperatorPrices(serviceCode string, countryID int64) ([]sms.OperatorPrice, error)
+	GetOperatorPrice(serviceCode string, countryID int64, operator string) (*sms.OperatorPrice, error)
 }
 
+// catalogVersionCheckInterval limits how often the catalog version is read from the cache,
+// so a catalog change reaches menus within this interval.
+const catalogVersionCheckInterval = 5 * time.Second
+
+// operatorPricesConcurrency limits requests to SMS-Activate made at once while pricing operators of a country.
+const operatorPricesConcurrency = 4
+
 type smsActivate struct {
-	container    container.Container
-	smsService   service.SMSService
-	cache        service.Cache
-	serviceOrder []string
+	container             container.Container
+	smsService            service.SMSService
+	cache                 service.Cache
+	catalogRepository     repository.CatalogRepository
+	catalogIndex          atomic.Pointer[CatalogIndex]
+	catalogIndexCheckedAt atomic.Int64
+	catalogIndexMutex     sync.Mutex
 }
 
-func NewSMSActivate(container container.Container, smsService service.SMSService, cache service.Cache) SMSActivate {
+func NewSMSActivate(
+	container container.Container,
+	smsService service.SMSService,
+	cache service.Cache,
+	catalogRepository repository.CatalogRepository,
+) SMSActivate {
 	return &smsActivate{
-		container:    container,
-		smsService:   smsService,
-		cache:        cache,
-		serviceOrder: make([]string, 0),
+		container:         container,
+		smsService:        smsService,
+		cache:             cache,
+		catalogRepository: catalogRepository,
 	}
 }
 
-func (s *smsActivate) GetOrderedServices() ([]sms.Service, error) {
-	log := s.container.GetLogger()
-	popularServiceCodes, err := s.smsService.GetPopularServiceCodeList()
+// GetOrderedServices pins favorite services on top, then goes the static preferred order blended with our own
+// sales ranking for the language and across all users, and finally the popularity list of SMS-Activate.
+func (s *smsActivate) GetOrderedServices(
+	languageCode string,
+	favorites app.Favorites,
+	currentPage int,
+	itemsPerPage int,
+) (*app.Page[sms.Service], error) {
+	catalogIndex, err := s.getCatalogIndex()
 	if err != nil {
 		return nil, err
 	}
-	preferredServiceCodesOrder := s.container.GetPreferredServiceCodesOrder()
-	for _, item := range preferredServiceCodesOrder {
-		idx := utils.FirstIndexOf(popularServiceCodes, item)
-		if idx == -1 {
-			continue
-		}
-		popularServiceCodes = append(popularServiceCodes[:idx], popularServiceCodes[idx+1:]...)
+	return catalogIndex.OrderedServices(languageCode, favorites, currentPage, itemsPerPage)
+}
+
+func (s *smsActivate) GetService(serviceCode string) (*sms.Service, error) {
+	catalogIndex, err := s.getCatalogIndex()
+	if err != nil {
+		return nil, err
 	}
-	popularServiceCodes = append(preferredServiceCodesOrder, popularServiceCodes...)
-	log.Debug("got popular services", logger.F("popularServiceCodes", popularServiceCodes))
-	allServices, err := s.GetServices()
+	foundService, ok := catalogIndex.Service(serviceCode)
+	if !ok {
+		return nil, app.NilError
+	}
+	return foundService, nil
+}
+
+// GetPriceForService pins favorite countries on top, then goes the static preferred order and the retail price.
+func (s *smsActivate) GetPriceForService(
+	serviceCode string,
+	favorites app.Favorites,
+	currentPage int,
+	itemsPerPage int,
+) (*app.Page[sms.PriceForService], error) {
+	catalogIndex, err := s.getCatalogIndex()
 	if err != nil {
 		return nil, err
 	}
-	sort.Slice(allServices, func(i, j int) bool {
-		lhsService := allServices[i]
-		rhsService := allServices[j]
-		lhsPopularServiceCodeIndex := utils.FirstIndexOf(popularServiceCodes, lhsService.Code)
-		rhsPopularServiceCodeIndex := utils.FirstIndexOf(popularServiceCodes, rhsService.Code)
-		if lhsPopularServiceCodeIndex == -1 && rhsPopularServiceCodeIndex == -1 {
-			return false
-		}
-		if lhsPopularServiceCodeIndex == -1 {
-			return false
-		}
-		if rhsPopularServiceCodeIndex == -1 {
-			return true
-		}
-		return lhsPopularServiceCodeIndex < rhsPopularServiceCodeIndex
-	})
-	return allServices, nil
+	return catalogIndex.OrderedServicePrices(serviceCode, favorites, currentPage, itemsPerPage)
 }
 
-func (s *smsActivate) GetService(serviceCode string) (*sms.Service, error) {
-	allServices, err := s.GetServices()
+// GetCountries reads countries of the catalog, they are kept up to date by the catalog sync workflow.
+func (s *smsActivate) GetCountries() ([]sms.Country, error) {
+	catalogIndex, err := s.getCatalogIndex()
 	if err != nil {
 		return nil, err
 	}
-	filteredServices := utils.Filter(allServices, func(service sms.Service) bool {
-		return service.Code == serviceCode
-	})
-	if len(filteredServices) == 0 {
-		return nil, app.NilError
+	return catalogIndex.Countries(), nil
+}
+
+func (s *smsActivate) GetCountriesByID() (map[int64]sms.Country, error) {
+	catalogIndex, err := s.getCatalogIndex()
+	if err != nil {
+		return nil, err
 	}
-	foundService := filteredServices[0]
-	return &foundService, nil
+	return catalogIndex.CountriesByID(), nil
 }
 
-func (s *smsActivate) GetPriceForService(serviceCode string) ([]sms.PriceForService, error) {
-	preferredCountryCodesOrder := s.container.GetPreferredCountryCodesOrder()
-	countries, err := s.GetCountries()
+// GetServices reads services of the catalog, they are kept up to date by the catalog sync workflow.
+func (s *smsActivate) GetServices() ([]sms.Service, error) {
+	catalogIndex, err := s.getCatalogIndex()
 	if err != nil {
 		return nil, err
 	}
+	return catalogIndex.Services(), nil
+}
+
+func (s *smsActivate) GetCountry(countryID int64) (*sms.Country, error) {
+	catalogIndex, err := s.getCatalogIndex()
+	if err != nil {
+		return nil, err
+	}
+	foundCountry, ok := catalogIndex.Country(countryID)
+	if !ok {
+		return nil, app.NilError
+	}
+	return foundCountry, nil
+}
+
+func (s *smsActivate) GetCheapestPricesForService(serviceCode string) ([]sms.PriceForService, error) {
 	servicePrices, err := s.smsService.GetServicePrices(serviceCode)
 	if err != nil {
 		return nil, err
 	}
 	servicePrices = utils.Filter(servicePrices, func(servicePrice sms.PriceForService) bool {
-		return servicePrice.MinPrice > 0
+		return servicePrice.RetailPrice > 0 && servicePrice.Count > 0
 	})
-	countryMap := make(map[int64]sms.Country)
-	for _, country := range countries {
-		countryMap[country.ID] = country
-	}
-	sort.Slice(servicePrices, func(i, j int) bool {
-		lhsServicePrice := servicePrices[i]
-		rhsServicePrice := servicePrices[j]
-		lhsCountry := countryMap[lhsServicePrice.CountryCode]
-		rhsCountry := countryMap[rhsServicePrice.CountryCode]
-
-		lhsIndexInServiceCodesOrder := utils.FirstIndexOf(preferredCountryCodesOrder, lhsCountry.Title)
-		rhsIndexInServiceCodesOrder := utils.FirstIndexOf(preferredCountryCodesOrder, rhsCountry.Title)
-		if lhsIndexInServiceCodesOrder != -1 && rhsIndexInServiceCodesOrder == -1 {
-			return true
-		} else if lhsIndexInServiceCodesOrder != -1 && rhsIndexInServiceCodesOrder != -1 {
-			return lhsIndexInServiceCodesOrder < rhsIndexInServiceCodesOrder
-		} else if lhsIndexInServiceCodesOrder == -1 && rhsIndexInServiceCodesOrder != -1 {
-			return false
+	sort.SliceStable(servicePrices, func(i, j int) bool {
+		if servicePrices[i].RetailPrice == servicePrices[j].RetailPrice {
+			return servicePrices[i].CountryCode < servicePrices[j].CountryCode
 		}
-
-		// left preferred sort from sms-activate service
-		if lhsServicePrice.RetailPrice == rhsServicePrice.RetailPrice {
-			return lhsServicePrice.CountryCode < rhsServicePrice.CountryCode
-		}
-
-		return lhsServicePrice.RetailPrice < rhsServicePrice.RetailPrice
+		return servicePrices[i].RetailPrice < servicePrices[j].RetailPrice
 	})
 	return servicePrices, nil
 }
 
-func (s *smsActivate) GetCountries() ([]sms.Country, error) {
-	go func() {
-		ctx := context.Background()
-		_ = s.UpToCountries(ctx)
-	}()
+func (s *smsActivate) GetOperators(countryID int64) ([]string, error) {
+	log := s.container.GetLogger()
 	ctx := context.Background()
-	cacheResponse, err := s.cache.GetSMSCountries(ctx)
+	cacheResponse, err := s.cache.GetSMSOperators(ctx, countryID)
 	if err == nil {
 		return cacheResponse.Result, nil
 	}
-	countries, err := s.smsService.GetCountries()
+	operators, err := s.smsService.GetOperators(countryID)
 	if err != nil {
 		return nil, err
 	}
-	return countries, nil
+	if err := s.cache.SaveSMSOperators(ctx, countryID, operators); err != nil {
+		log.Debug("fail to save sms operators", logger.FError(err))
+	}
+	return operators, nil
 }
 
-func (s *smsActivate) GetServices() ([]sms.Service, error) {
-	go func() {
-		ctx := context.Background()
-		_ = s.UpToServices(ctx)
-	}()
+// GetOperatorPrices returns prices of operators that have numbers for the service, the cheapest first.
+// SMS-Activate prices one operator per request, so operators are priced concurrently and the result is cached
+// for a short time.
+func (s *smsActivate) GetOperatorPrices(serviceCode string, countryID int64) ([]sms.OperatorPrice, error) {
+	log := s.container.GetLogger()
 	ctx := context.Background()
-	cacheResponse, err := s.cache.GetSMSServices(ctx)
+	cacheResponse, err := s.cache.GetSMSOperatorPrices(ctx, serviceCode, countryID)
 	if err == nil {
 		return cacheResponse.Result, nil
 	}
-	services, err := s.smsService.GetServices()
+	operators, err := s.GetOperators(countryID)
+	if err != nil {
+		return nil, err
+	}
+	fetchedOperatorPrices := make([]*sms.OperatorPrice, len(operators))
+	semaphore := make(chan struct{}, operatorPricesConcurrency)
+	var wg sync.WaitGroup
+	for i, operator := range operators {
+		wg.Add(1)
+		go func(i int, operator string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			operatorPrice, err := s.GetOperatorPrice(serviceCode, countryID, operator)
+			if err != nil {
+				log.Debug("fail to get operator price", logger.F("operator", operator), logger.FError(err))
+				return
+			}
+			fetchedOperatorPrices[i] = operatorPrice
+		}(i, operator)
+	}
+	wg.Wait()
+	operatorPrices := make([]sms.OperatorPrice, 0, len(operators))
+	for _, operatorPrice := range fetchedOperatorPrices {
+		if operatorPrice != nil {
+			operatorPrices = append(operatorPrices, *operatorPrice)
+		}
+	}
+	sort.SliceStable(operatorPrices, func(i, j int) bool {
+		return operatorPrices[i].Cost < operatorPrices[j].Cost
+	})
+	if err := s.cache.SaveSMSOperatorPrices(ctx, serviceCode, countryID, operatorPrices); err != nil {
+		log.Debug("fail to save sms operator prices", logger.FError(err))
+	}
+	return operatorPrices, nil
+}
+
+func (s *smsActivate) GetOperatorPrice(serviceCode string, countryID int64, operator string) (*sms.OperatorPrice, error) {
+	operatorPrice, err := s.smsService.GetOperatorPrice(serviceCode, countryID, operator)
 	if err != nil {
 		return nil, err
 	}
-	return services, nil
+	if operatorPrice.Count == 0 || operatorPrice.Cost <= 0 {
+		return nil, app.EmptyValueError
+	}
+	return operatorPrice, nil
 }
 
-func (s *smsActivate) GetCountry(countryID int64) (*sms.Country, error) {
-	countries, err := s.GetCountries()
+// SearchServices fuzzy matches the query against names of all services, including names in the language,
+// and their codes, the best matches first. An empty query returns services in the same order as the services list.
+func (s *smsActivate) SearchServices(query string, languageCode string, limit int) ([]sms.Service, error) {
+	if strings.TrimSpace(query) == "" {
+		page, err := s.GetOrderedServices(languageCode, app.Favorites{}, 0, limit)
+		if err != nil {
+			return nil, err
+		}
+		return page.Items, nil
+	}
+	services, err := s.GetServices()
 	if err != nil {
 		return nil, err
 	}
-	filteredCountries := utils.Filter(countries, func(country sms.Country) bool {
-		return country.ID == countryID
+	type scoredService struct {
+		service sms.Service
+		score   int
+	}
+	scoredServices := make([]scoredService, 0)
+	for _, service := range services {
+		score := max(utils.FuzzyMatchScore(query, service.Name), utils.FuzzyMatchScore(query, service.Code))
+		if extraService := s.container.GetExtraService(service.Code); extraService != nil {
+			score = max(score, utils.FuzzyMatchScore(query, extraService.Name))
+		}
+		if localizedName := s.container.GetLocalizedServiceName(languageCode, service.Code); localizedName != nil {
+			score = max(score, utils.FuzzyMatchScore(query, *localizedName))
+		}
+		if score < 0 {
+			continue
+		}
+		scoredServices = append(scoredServices, scoredService{service: service, score: score})
+	}
+	sort.SliceStable(scoredServices, func(i, j int) bool {
+		if scoredServices[i].score == scoredServices[j].score {
+			return scoredServices[i].service.Name < scoredServices[j].service.Name
+		}
+		return scoredServices[i].score > scoredServices[j].score
 	})
-	if len(filteredCountries) == 0 {
-		return nil, app.NilError
+	foundServices := make([]sms.Service, 0, min(limit, len(scoredServices)))
+	for _, scoredService := range scoredServices[:min(limit, len(scoredServices))] {
+		foundServices = append(foundServices, scoredService.service)
 	}
-	foundCountry := filteredCountries[0]
-	return &foundCountry, nil
+	return foundServices, nil
 }
 
-func (s *smsActivate) UpToCountries(ctx context.Context) error {
-	response, err := s.cache.GetSMSCountries(ctx)
+// getServicePopularity returns service codes ranked by our own purchases, an empty language code gives the ranking
+// across all users. The ranking is refreshed by the service popularity workflow, until then it is empty.
+func (s *smsActivate) getServicePopularity(languageCode string) []string {
+	log := s.container.GetLogger()
+	cacheResponse, err := s.cache.GetServicePopularity(context.Background(), languageCode)
 	if err != nil {
-		return err
+		log.Debug("service popularity is unavailable", logger.F("language_code", languageCode), logger.FError(err))
+		return nil
 	}
-	var timeFetched time.Time
-	if response != nil {
-		timeFetched = response.TimeFetched
-	} else {
-		timeFetched = time.Now()
+	return cacheResponse.Result
+}
+
+// getCatalogIndex returns the catalog index of the current catalog version. The version is checked at most once
+// per catalogVersionCheckInterval and the index is rebuilt only when the version has changed, if the rebuild fails
+// menus keep the previous index.
+func (s *smsActivate) getCatalogIndex() (*CatalogIndex, error) {
+	log := s.container.GetLogger()
+	catalogIndex := s.catalogIndex.Load()
+	if catalogIndex != nil && !s.isCatalogVersionCheckDue() {
+		return catalogIndex, nil
 	}
-	if timeFetched.Add(24*time.Hour).Compare(time.Now()) <= 0 {
-		return nil
+	s.catalogIndexMutex.Lock()
+	defer s.catalogIndexMutex.Unlock()
+	catalogIndex = s.catalogIndex.Load()
+	if catalogIndex != nil && !s.isCatalogVersionCheckDue() {
+		return catalogIndex, nil
+	}
+	refreshedCatalogIndex, err := s.refreshCatalogIndex(context.Background(), catalogIndex)
+	s.catalogIndexCheckedAt.Store(time.Now().UnixNano())
+	if err != nil {
+		if catalogIndex == nil {
+			return nil, err
+		}
+		log.Error("fail to refresh catalog index, keep the previous one", logger.FError(err))
+		return catalogIndex, nil
 	}
-	countries, err := s.smsService.GetCountries()
-	return s.cache.SaveSMSCountries(ctx, countries)
+	return refreshedCatalogIndex, nil
 }
 
-func (s *smsActivate) UpToServices(ctx context.Context) error {
-	response, err := s.cache.GetSMSServices(ctx)
+func (s *smsActivate) refreshCatalogIndex(ctx context.Context, catalogIndex *CatalogIndex) (*CatalogIndex, error) {
+	log := s.container.GetLogger()
+	version, err := s.cache.GetCatalogVersion(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	var timeFetched time.Time
-	if response != nil {
-		timeFetched = response.TimeFetched
-	} else {
-		timeFetched = time.Now()
+	if catalogIndex != nil && catalogIndex.Version() == version {
+		return catalogIndex, nil
 	}
-	if timeFetched.Add(24*time.Hour).Compare(time.Now()) <= 0 {
-		return nil
+	catalogIndex, err = s.buildCatalogIndex(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+	log.Debug("catalog index is rebuilt", logger.F("version", version))
+	s.catalogIndex.Store(catalogIndex)
+	return catalogIndex, nil
+}
+
+func (s *smsActivate) isCatalogVersionCheckDue() bool {
+	checkedAt := time.Unix(0, s.catalogIndexCheckedAt.Load())
+	return time.Since(checkedAt) >= catalogVersionCheckInterval
+}
+
+func (s *smsActivate) buildCatalogIndex(ctx context.Context, version int64) (*CatalogIndex, error) {
+	catalogServices, err := s.catalogRepository.FetchServices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	catalogCountries, err := s.catalogRepository.FetchCountries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	catalogServicePrices, err := s.catalogRepository.FetchAllServicePrices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	services := make([]sms.Service, 0, len(catalogServices))
+	popularServiceCodes := make([]string, 0, len(catalogServices))
+	for _, catalogService := range catalogServices {
+		services = append(services, sms.Service{
+			Code: catalogService.Code,
+			Name: catalogService.Name,
+		})
+		if catalogService.PopularityRank != nil {
+			popularServiceCodes = append(popularServiceCodes, catalogService.Code)
+		}
+	}
+	countries := make([]sms.Country, 0, len(catalogCountries))
+	for _, catalogCountry := range catalogCountries {
+		countries = append(countries, sms.Country{
+			ID:           catalogCountry.ID,
+			Title:        catalogCountry.Title,
+			Visible:      catalogCountry.Visible,
+			Retry:        catalogCountry.Retry,
+			Rent:         catalogCountry.Rent,
+			MultiService: catalogCountry.MultiService,
+		})
+	}
+	servicePrices := make(map[string][]sms.PriceForService)
+	for _, catalogServicePrice := range catalogServicePrices {
+		servicePrices[catalogServicePrice.ServiceCode] = append(
+			servicePrices[catalogServicePrice.ServiceCode],
+			sms.PriceForService{
+				RetailPrice: catalogServicePrice.RetailPrice,
+				CountryCode: catalogServicePrice.CountryID,
+				MinPrice:    sms.PriceFiled(catalogServicePrice.MinPrice),
+				Count:       catalogServicePrice.Count,
+			},
+		)
+	}
+	servicePopularity := map[string][]string{
+		"": s.getServicePopularity(""),
+	}
+	for _, language := range s.container.GetConfig().AvailableLanguages() {
+		servicePopularity[language.Code] = s.getServicePopularity(language.Code)
 	}
-	services, err := s.smsService.GetServices()
-	return s.cache.SaveSMSServices(ctx, services)
+	return NewCatalogIndex(CatalogIndexSource{
+		Version:                version,
+		Services:               services,
+		PopularServiceCodes:    popularServiceCodes,
+		PreferredServiceCodes:  s.container.GetPreferredServiceCodesOrder(),
+		ServicePopularity:      servicePopularity,
+		Countries:              countries,
+		PreferredCountryTitles: s.container.GetPreferredCountryCodesOrder(),
+		ServicePrices:          servicePrices,
+	}), nil
 }
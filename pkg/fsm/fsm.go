@@ -0,0 +1,193 @@
+// Package fsm runs conversations with users as finite state machines, a conversation waits for the input
+// of the user in one of its states and moves to the next state once the input is handled.
+package fsm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// StateID names a state, it is persisted, so it must not change between releases.
+type StateID string
+
+// expiredSessionGrace keeps a session after its timeout, so the late input gets the answer of the state
+// instead of being taken as a message out of any conversation.
+const expiredSessionGrace = time.Hour
+
+var (
+	UnknownStateError = errors.New("unknown state of conversation")
+)
+
+// Session is the persisted conversation of a user, data is the typed context of the state encoded as json.
+type Session struct {
+	State     StateID         `json:"state"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	ExpiresAt *time.Time      `json:"expires_at,omitempty"`
+}
+
+// Storage keeps a session per user, LoadConversation returns nil when the user has no conversation.
+type Storage interface {
+	LoadConversation(ctx context.Context, userID int64) (*Session, error)
+	SaveConversation(ctx context.Context, userID int64, session *Session, ttl time.Duration) error
+	ClearConversation(ctx context.Context, userID int64) error
+}
+
+// Machine routes the input of users to the states their conversations are in. Env is what handlers of states
+// get along with the context, e.g. the update being served.
+type Machine[Env any] interface {
+	// Start moves the user into the state with the data and prompts the user with the entry of the state.
+	Start(ctx context.Context, userID int64, env Env, state StateID, data any) error
+	// Current returns the state of the conversation, the empty id means the user has none.
+	Current(ctx context.Context, userID int64) (StateID, error)
+	// Handle passes the input to the state of the conversation and reports whether there has been one.
+	Handle(ctx context.Context, userID int64, env Env) (bool, error)
+	// Interrupt cancels the conversation unless the state keeps it on the event.
+	Interrupt(ctx context.Context, userID int64, env Env, event string) error
+	// Cancel ends the conversation with the cancel handler of its state.
+	Cancel(ctx context.Context, userID int64, env Env) error
+	// Reset ends the conversation without calling any handler.
+	Reset(ctx context.Context, userID int64) error
+}
+
+type machine[Env any] struct {
+	storage Storage
+	states  map[StateID]State[Env]
+}
+
+func NewMachine[Env any](storage Storage, states ...State[Env]) Machine[Env] {
+	m := machine[Env]{
+		storage: storage,
+		states:  make(map[StateID]State[Env], len(states)),
+	}
+	for _, state := range states {
+		m.states[state.ID()] = state
+	}
+	return &m
+}
+
+func (m *machine[Env]) Start(ctx context.Context, userID int64, env Env, state StateID, data any) error {
+	encodedData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return m.enter(ctx, userID, env, state, encodedData)
+}
+
+func (m *machine[Env]) Current(ctx context.Context, userID int64) (StateID, error) {
+	session, err := m.storage.LoadConversation(ctx, userID)
+	if err != nil || session == nil || isExpired(session) {
+		return "", err
+	}
+	return session.State, nil
+}
+
+func (m *machine[Env]) Handle(ctx context.Context, userID int64, env Env) (bool, error) {
+	session, state, err := m.load(ctx, userID)
+	if err != nil || session == nil {
+		return false, err
+	}
+	if isExpired(session) {
+		if err := m.storage.ClearConversation(ctx, userID); err != nil {
+			return false, err
+		}
+		return state.expire(ctx, env, session.Data)
+	}
+	transition, encodedData, err := state.handle(ctx, env, session.Data)
+	if err != nil {
+		return true, err
+	}
+	switch {
+	case transition.finish:
+		return true, m.storage.ClearConversation(ctx, userID)
+	case transition.state != "":
+		nextEncodedData, err := json.Marshal(transition.data)
+		if err != nil {
+			return true, err
+		}
+		return true, m.enter(ctx, userID, env, transition.state, nextEncodedData)
+	default:
+		return true, m.save(ctx, userID, state, encodedData)
+	}
+}
+
+func (m *machine[Env]) Interrupt(ctx context.Context, userID int64, env Env, event string) error {
+	session, state, err := m.load(ctx, userID)
+	if err != nil || session == nil {
+		return err
+	}
+	if !isExpired(session) && state.keeps(event) {
+		return nil
+	}
+	return m.cancel(ctx, userID, env, session, state)
+}
+
+func (m *machine[Env]) Cancel(ctx context.Context, userID int64, env Env) error {
+	session, state, err := m.load(ctx, userID)
+	if err != nil || session == nil {
+		return err
+	}
+	return m.cancel(ctx, userID, env, session, state)
+}
+
+func (m *machine[Env]) Reset(ctx context.Context, userID int64) error {
+	return m.storage.ClearConversation(ctx, userID)
+}
+
+// load drops sessions of states that aren't registered anymore.
+func (m *machine[Env]) load(ctx context.Context, userID int64) (*Session, State[Env], error) {
+	session, err := m.storage.LoadConversation(ctx, userID)
+	if err != nil || session == nil {
+		return nil, nil, err
+	}
+	state, ok := m.states[session.State]
+	if !ok {
+		return nil, nil, m.storage.ClearConversation(ctx, userID)
+	}
+	return session, state, nil
+}
+
+func (m *machine[Env]) enter(ctx context.Context, userID int64, env Env, stateID StateID, encodedData json.RawMessage) error {
+	state, ok := m.states[stateID]
+	if !ok {
+		return UnknownStateError
+	}
+	if err := m.save(ctx, userID, state, encodedData); err != nil {
+		return err
+	}
+	if err := state.enter(ctx, env, encodedData); err != nil {
+		return errors.Join(err, m.storage.ClearConversation(ctx, userID))
+	}
+	return nil
+}
+
+// save prolongs the timeout of the state with every input.
+func (m *machine[Env]) save(ctx context.Context, userID int64, state State[Env], encodedData json.RawMessage) error {
+	session := Session{
+		State: state.ID(),
+		Data:  encodedData,
+	}
+	var ttl time.Duration
+	if timeout := state.timeout(); timeout > 0 {
+		expiresAt := time.Now().Add(timeout)
+		session.ExpiresAt = &expiresAt
+		ttl = timeout + expiredSessionGrace
+	}
+	return m.storage.SaveConversation(ctx, userID, &session, ttl)
+}
+
+// cancel doesn't call the handler of an expired state, the user has left it long ago.
+func (m *machine[Env]) cancel(ctx context.Context, userID int64, env Env, session *Session, state State[Env]) error {
+	if err := m.storage.ClearConversation(ctx, userID); err != nil {
+		return err
+	}
+	if isExpired(session) {
+		return nil
+	}
+	return state.cancel(ctx, env, session.Data)
+}
+
+func isExpired(session *Session) bool {
+	return session.ExpiresAt != nil && time.Now().After(*session.ExpiresAt)
+}
@@ -0,0 +1,162 @@
+package fsm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+)
+
+// ValidationError is returned by validators for the input the user is asked to send again.
+type ValidationError struct {
+	Reason string
+}
+
+func (v *ValidationError) Error() string {
+	return v.Reason
+}
+
+// Transition is the result of handling the input: stay in the state, move to the next one or finish.
+type Transition struct {
+	state  StateID
+	data   any
+	finish bool
+}
+
+// Stay keeps the state, changes the handler made to the data are saved.
+func Stay() Transition {
+	return Transition{}
+}
+
+// Next moves the conversation to the state with the data of its type.
+func Next(state StateID, data any) Transition {
+	return Transition{state: state, data: data}
+}
+
+// Finish ends the conversation.
+func Finish() Transition {
+	return Transition{finish: true}
+}
+
+// StateConfig declares a state whose context has the type Data, handlers but Handle are optional.
+type StateConfig[Env any, Data any] struct {
+	ID StateID
+	// Timeout ends the conversation when the user sends nothing for so long, zero keeps it forever.
+	Timeout time.Duration
+	// KeepOn lists events that don't interrupt the conversation.
+	KeepOn []string
+	// Enter prompts the user once the conversation moves into the state.
+	Enter func(ctx context.Context, env Env, data *Data) error
+	// Validate checks the input before it is handled, a ValidationError is passed to Invalid and the state is kept.
+	Validate func(ctx context.Context, env Env, data *Data) error
+	Invalid  func(ctx context.Context, env Env, data *Data, err *ValidationError) error
+	Handle   func(ctx context.Context, env Env, data *Data) (Transition, error)
+	// Cancel is called when the user leaves the conversation before it is finished.
+	Cancel func(ctx context.Context, env Env, data *Data) error
+	// Expire answers the input that comes after the timeout, without it such input is not taken as one
+	// of the conversation.
+	Expire func(ctx context.Context, env Env, data *Data) error
+}
+
+// State is a state registered in a machine, it hides the type of its data.
+type State[Env any] interface {
+	ID() StateID
+	timeout() time.Duration
+	keeps(event string) bool
+	enter(ctx context.Context, env Env, encodedData json.RawMessage) error
+	handle(ctx context.Context, env Env, encodedData json.RawMessage) (Transition, json.RawMessage, error)
+	cancel(ctx context.Context, env Env, encodedData json.RawMessage) error
+	expire(ctx context.Context, env Env, encodedData json.RawMessage) (bool, error)
+}
+
+type state[Env any, Data any] struct {
+	config StateConfig[Env, Data]
+}
+
+func NewState[Env any, Data any](config StateConfig[Env, Data]) State[Env] {
+	return &state[Env, Data]{
+		config: config,
+	}
+}
+
+func (s *state[Env, Data]) ID() StateID {
+	return s.config.ID
+}
+
+func (s *state[Env, Data]) timeout() time.Duration {
+	return s.config.Timeout
+}
+
+func (s *state[Env, Data]) keeps(event string) bool {
+	return slices.Contains(s.config.KeepOn, event)
+}
+
+func (s *state[Env, Data]) enter(ctx context.Context, env Env, encodedData json.RawMessage) error {
+	if s.config.Enter == nil {
+		return nil
+	}
+	data, err := s.decode(encodedData)
+	if err != nil {
+		return err
+	}
+	return s.config.Enter(ctx, env, data)
+}
+
+func (s *state[Env, Data]) handle(
+	ctx context.Context,
+	env Env,
+	encodedData json.RawMessage,
+) (Transition, json.RawMessage, error) {
+	data, err := s.decode(encodedData)
+	if err != nil {
+		return Transition{}, nil, err
+	}
+	if s.config.Validate != nil {
+		var validationErr *ValidationError
+		if err := s.config.Validate(ctx, env, data); errors.As(err, &validationErr) && s.config.Invalid != nil {
+			return Stay(), encodedData, s.config.Invalid(ctx, env, data, validationErr)
+		} else if err != nil {
+			return Transition{}, nil, err
+		}
+	}
+	transition, err := s.config.Handle(ctx, env, data)
+	if err != nil {
+		return Transition{}, nil, err
+	}
+	encodedData, err = json.Marshal(data)
+	return transition, encodedData, err
+}
+
+func (s *state[Env, Data]) cancel(ctx context.Context, env Env, encodedData json.RawMessage) error {
+	if s.config.Cancel == nil {
+		return nil
+	}
+	data, err := s.decode(encodedData)
+	if err != nil {
+		return err
+	}
+	return s.config.Cancel(ctx, env, data)
+}
+
+func (s *state[Env, Data]) expire(ctx context.Context, env Env, encodedData json.RawMessage) (bool, error) {
+	if s.config.Expire == nil {
+		return false, nil
+	}
+	data, err := s.decode(encodedData)
+	if err != nil {
+		return true, err
+	}
+	return true, s.config.Expire(ctx, env, data)
+}
+
+func (s *state[Env, Data]) decode(encodedData json.RawMessage) (*Data, error) {
+	var data Data
+	if len(encodedData) == 0 {
+		return &data, nil
+	}
+	if err := json.Unmarshal(encodedData, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
@@ -0,0 +1,364 @@
+package telegram_bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-ton-pass-telegram-bot/internal/model/telegram"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Environment selects telegram servers, the test environment keeps bots and users apart from the production one.
+type Environment string
+
+const (
+	ProductionEnvironment Environment = "production"
+	TestEnvironment       Environment = "test"
+)
+
+const (
+	DefaultBaseURL       = "https://api.telegram.org/bot"
+	defaultMaxAttempts   = 3
+	defaultRetryDelay    = time.Second
+	defaultMaxRetryDelay = 10 * time.Second
+)
+
+type Client interface {
+	SendMessage(ctx context.Context, sendMessage *telegram.SendResponse) (*telegram.Message, error)
+	SendPhoto(ctx context.Context, sendPhoto *telegram.SendPhoto) (*telegram.Message, error)
+	SendInvoice(ctx context.Context, sendInvoice *telegram.SendInvoice) (*telegram.Message, error)
+	EditMessageText(ctx context.Context, editMessage *telegram.EditMessage) (*telegram.Message, error)
+	EditMessageCaption(ctx context.Context, editCaptionMessage *telegram.EditCaptionMessage) (*telegram.Message, error)
+	EditMessageMedia(ctx context.Context, editMessageMedia *telegram.EditMessageMedia) (*telegram.Message, error)
+	DeleteMessage(ctx context.Context, deleteMessage *telegram.DeleteMessage) error
+	AnswerCallbackQuery(ctx context.Context, answerCallbackQuery *telegram.AnswerCallbackQuery) error
+	AnswerPreCheckoutQuery(ctx context.Context, answerPreCheckoutQuery *telegram.AnswerPreCheckoutQuery) error
+	AnswerInlineQuery(ctx context.Context, answerInlineQuery *telegram.AnswerInlineQuery) error
+	GetChatMember(ctx context.Context, getChatMember *telegram.GetChatMember) (*telegram.ChatMember, error)
+	SetMyCommands(ctx context.Context, setMyCommands *telegram.SetMyCommands) error
+	SetMyDescription(ctx context.Context, setMyDescription *telegram.SetMyDescription) error
+	SetMyShortDescription(ctx context.Context, setMyShortDescription *telegram.SetMyShortDescription) error
+	SetMyName(ctx context.Context, setMyName *telegram.SetMyName) error
+	GetMyCommands(ctx context.Context, getMyCommands *telegram.GetMyCommands) ([]telegram.BotCommand, error)
+	GetMyDescription(ctx context.Context, getMyDescription *telegram.GetMyDescription) (*telegram.BotDescription, error)
+	GetMyShortDescription(
+		ctx context.Context,
+		getMyShortDescription *telegram.GetMyShortDescription,
+	) (*telegram.BotShortDescription, error)
+	GetMyName(ctx context.Context, getMyName *telegram.GetMyName) (*telegram.BotName, error)
+	GetMe(ctx context.Context) (*telegram.User, error)
+	GetUpdates(ctx context.Context, getUpdates *telegram.GetUpdates) ([]json.RawMessage, error)
+	SetWebhook(ctx context.Context, setWebhook *telegram.SetWebhook, certificatePath string) error
+	DeleteWebhook(ctx context.Context, deleteWebhook *telegram.DeleteWebhook) error
+}
+
+// Options configure the client, zero values fall back to defaults.
+type Options struct {
+	BaseURL     string
+	Token       string
+	Environment Environment
+	HTTPClient  *http.Client
+	// MaxAttempts bounds how many times a request is sent when telegram servers ask to repeat it.
+	MaxAttempts int
+	// RetryDelay is the first pause after a failure of telegram servers, it doubles with every attempt.
+	RetryDelay time.Duration
+	// MaxRetryDelay bounds a pause, a flood error asking to wait longer is returned to the caller instead.
+	MaxRetryDelay time.Duration
+}
+
+type client struct {
+	options Options
+}
+
+func NewClient(options Options) Client {
+	if options.BaseURL == "" {
+		options.BaseURL = DefaultBaseURL
+	}
+	if options.Environment == "" {
+		options.Environment = ProductionEnvironment
+	}
+	if options.HTTPClient == nil {
+		options.HTTPClient = &http.Client{}
+	}
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = defaultMaxAttempts
+	}
+	if options.RetryDelay <= 0 {
+		options.RetryDelay = defaultRetryDelay
+	}
+	if options.MaxRetryDelay <= 0 {
+		options.MaxRetryDelay = defaultMaxRetryDelay
+	}
+	return &client{
+		options: options,
+	}
+}
+
+func (c *client) SendMessage(ctx context.Context, sendMessage *telegram.SendResponse) (*telegram.Message, error) {
+	return call[*telegram.Message](ctx, c, "sendMessage", sendMessage)
+}
+
+func (c *client) SendPhoto(ctx context.Context, sendPhoto *telegram.SendPhoto) (*telegram.Message, error) {
+	return call[*telegram.Message](ctx, c, "sendPhoto", sendPhoto)
+}
+
+func (c *client) SendInvoice(ctx context.Context, sendInvoice *telegram.SendInvoice) (*telegram.Message, error) {
+	return call[*telegram.Message](ctx, c, "sendInvoice", sendInvoice)
+}
+
+func (c *client) EditMessageText(ctx context.Context, editMessage *telegram.EditMessage) (*telegram.Message, error) {
+	return call[*telegram.Message](ctx, c, "editMessageText", editMessage)
+}
+
+func (c *client) EditMessageCaption(
+	ctx context.Context,
+	editCaptionMessage *telegram.EditCaptionMessage,
+) (*telegram.Message, error) {
+	return call[*telegram.Message](ctx, c, "editMessageCaption", editCaptionMessage)
+}
+
+func (c *client) EditMessageMedia(
+	ctx context.Context,
+	editMessageMedia *telegram.EditMessageMedia,
+) (*telegram.Message, error) {
+	return call[*telegram.Message](ctx, c, "editMessageMedia", editMessageMedia)
+}
+
+func (c *client) DeleteMessage(ctx context.Context, deleteMessage *telegram.DeleteMessage) error {
+	_, err := call[bool](ctx, c, "deleteMessage", deleteMessage)
+	return err
+}
+
+func (c *client) AnswerCallbackQuery(ctx context.Context, answerCallbackQuery *telegram.AnswerCallbackQuery) error {
+	_, err := call[bool](ctx, c, "answerCallbackQuery", answerCallbackQuery)
+	return err
+}
+
+func (c *client) AnswerPreCheckoutQuery(
+	ctx context.Context,
+	answerPreCheckoutQuery *telegram.AnswerPreCheckoutQuery,
+) error {
+	_, err := call[bool](ctx, c, "answerPreCheckoutQuery", answerPreCheckoutQuery)
+	return err
+}
+
+func (c *client) AnswerInlineQuery(ctx context.Context, answerInlineQuery *telegram.AnswerInlineQuery) error {
+	_, err := call[bool](ctx, c, "answerInlineQuery", answerInlineQuery)
+	return err
+}
+
+func (c *client) GetChatMember(ctx context.Context, getChatMember *telegram.GetChatMember) (*telegram.ChatMember, error) {
+	return call[*telegram.ChatMember](ctx, c, "getChatMember", getChatMember)
+}
+
+func (c *client) SetMyCommands(ctx context.Context, setMyCommands *telegram.SetMyCommands) error {
+	_, err := call[bool](ctx, c, "setMyCommands", setMyCommands)
+	return err
+}
+
+func (c *client) SetMyDescription(ctx context.Context, setMyDescription *telegram.SetMyDescription) error {
+	_, err := call[bool](ctx, c, "setMyDescription", setMyDescription)
+	return err
+}
+
+func (c *client) SetMyShortDescription(ctx context.Context, setMyShortDescription *telegram.SetMyShortDescription) error {
+	_, err := call[bool](ctx, c, "setMyShortDescription", setMyShortDescription)
+	return err
+}
+
+func (c *client) SetMyName(ctx context.Context, setMyName *telegram.SetMyName) error {
+	_, err := call[bool](ctx, c, "setMyName", setMyName)
+	return err
+}
+
+func (c *client) GetMyCommands(ctx context.Context, getMyCommands *telegram.GetMyCommands) ([]telegram.BotCommand, error) {
+	return call[[]telegram.BotCommand](ctx, c, "getMyCommands", getMyCommands)
+}
+
+func (c *client) GetMyDescription(
+	ctx context.Context,
+	getMyDescription *telegram.GetMyDescription,
+) (*telegram.BotDescription, error) {
+	return call[*telegram.BotDescription](ctx, c, "getMyDescription", getMyDescription)
+}
+
+func (c *client) GetMyShortDescription(
+	ctx context.Context,
+	getMyShortDescription *telegram.GetMyShortDescription,
+) (*telegram.BotShortDescription, error) {
+	return call[*telegram.BotShortDescription](ctx, c, "getMyShortDescription", getMyShortDescription)
+}
+
+func (c *client) GetMyName(ctx context.Context, getMyName *telegram.GetMyName) (*telegram.BotName, error) {
+	return call[*telegram.BotName](ctx, c, "getMyName", getMyName)
+}
+
+func (c *client) GetMe(ctx context.Context) (*telegram.User, error) {
+	return call[*telegram.User](ctx, c, "getMe", struct{}{})
+}
+
+// GetUpdates returns updates undecoded, so every update can be handled the same way as the one delivered
+// to the webhook.
+func (c *client) GetUpdates(ctx context.Context, getUpdates *telegram.GetUpdates) ([]json.RawMessage, error) {
+	return call[[]json.RawMessage](ctx, c, "getUpdates", getUpdates)
+}
+
+// SetWebhook uploads a self-signed certificate when its path is given, so telegram servers trust it.
+func (c *client) SetWebhook(ctx context.Context, setWebhook *telegram.SetWebhook, certificatePath string) error {
+	if certificatePath == "" {
+		_, err := call[bool](ctx, c, "setWebhook", setWebhook)
+		return err
+	}
+	body, contentType, err := multipartBody(setWebhook, "certificate", certificatePath)
+	if err != nil {
+		return err
+	}
+	var result bool
+	return c.do(ctx, "setWebhook", body, contentType, &result)
+}
+
+func (c *client) DeleteWebhook(ctx context.Context, deleteWebhook *telegram.DeleteWebhook) error {
+	_, err := call[bool](ctx, c, "deleteWebhook", deleteWebhook)
+	return err
+}
+
+// response is the envelope of every answer of telegram servers.
+type response struct {
+	OK          bool                         `json:"ok"`
+	Result      json.RawMessage              `json:"result"`
+	ErrorCode   int                          `json:"error_code"`
+	Description string                       `json:"description"`
+	Parameters  *telegram.ResponseParameters `json:"parameters"`
+}
+
+func call[T any](ctx context.Context, c *client, method string, request any) (T, error) {
+	var result T
+	body, err := json.Marshal(request)
+	if err != nil {
+		return result, err
+	}
+	err = c.do(ctx, method, body, "application/json", &result)
+	return result, err
+}
+
+// do sends the request and decodes the result, flood errors and failures of telegram servers are repeated.
+func (c *client) do(ctx context.Context, method string, body []byte, contentType string, result any) error {
+	for attempt := 1; ; attempt++ {
+		err := c.send(ctx, method, body, contentType, result)
+		telegramErr, ok := err.(*Error)
+		if !ok || !telegramErr.IsRetryable() || attempt >= c.options.MaxAttempts {
+			return err
+		}
+		delay := c.options.RetryDelay << (attempt - 1)
+		if telegramErr.RetryAfter > 0 {
+			delay = time.Duration(telegramErr.RetryAfter) * time.Second
+		}
+		if delay > c.options.MaxRetryDelay {
+			return err
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *client) send(ctx context.Context, method string, body []byte, contentType string, result any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.methodURL(method), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := c.options.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	var envelope response
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		if resp.StatusCode >= http.StatusInternalServerError {
+			// a proxy in front of telegram servers answers without the envelope
+			return &Error{
+				Method:      method,
+				ErrorCode:   resp.StatusCode,
+				Description: http.StatusText(resp.StatusCode),
+			}
+		}
+		return err
+	}
+	if !envelope.OK {
+		telegramErr := Error{
+			Method:      method,
+			ErrorCode:   envelope.ErrorCode,
+			Description: envelope.Description,
+		}
+		if telegramErr.ErrorCode == 0 {
+			telegramErr.ErrorCode = resp.StatusCode
+		}
+		if envelope.Parameters != nil {
+			telegramErr.RetryAfter = envelope.Parameters.RetryAfter
+		}
+		return &telegramErr
+	}
+	return json.Unmarshal(envelope.Result, result)
+}
+
+func (c *client) methodURL(method string) string {
+	if c.options.Environment == TestEnvironment {
+		return fmt.Sprintf("%s%s/test/%s", c.options.BaseURL, c.options.Token, method)
+	}
+	return fmt.Sprintf("%s%s/%s", c.options.BaseURL, c.options.Token, method)
+}
+
+// multipartBody sends fields of the request as form fields, non-string values are json encoded as telegram
+// servers expect, and attaches the file under the field name.
+func multipartBody(request any, fileField string, filePath string) ([]byte, string, error) {
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return nil, "", err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(jsonData, &fields); err != nil {
+		return nil, "", err
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, value := range fields {
+		var stringValue string
+		if err := json.Unmarshal(value, &stringValue); err != nil {
+			stringValue = string(value)
+		}
+		if err := writer.WriteField(name, stringValue); err != nil {
+			return nil, "", err
+		}
+	}
+	fileWriter, err := writer.CreateFormFile(fileField, filepath.Base(filePath))
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := io.Copy(fileWriter, file); err != nil {
+		return nil, "", err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return body.Bytes(), writer.FormDataContentType(), nil
+}
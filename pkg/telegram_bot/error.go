@@ -0,0 +1,51 @@
+package telegram_bot
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Error is an answer of telegram servers with `ok: false`.
+type Error struct {
+	Method      string
+	ErrorCode   int
+	Description string
+	// RetryAfter is the number of seconds to wait before the request is repeated, it is set for flood errors.
+	RetryAfter int
+}
+
+func (e *Error) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("telegram %s: %d %s (retry after %ds)", e.Method, e.ErrorCode, e.Description, e.RetryAfter)
+	}
+	return fmt.Sprintf("telegram %s: %d %s", e.Method, e.ErrorCode, e.Description)
+}
+
+// IsRetryable reports whether the request may succeed being repeated: flood errors and failures of telegram servers.
+func (e *Error) IsRetryable() bool {
+	return e.ErrorCode == http.StatusTooManyRequests || e.ErrorCode >= http.StatusInternalServerError
+}
+
+// IsMessageNotModified reports whether an edit has failed because the message already has the same content.
+func IsMessageNotModified(err error) bool {
+	var telegramErr *Error
+	if !errors.As(err, &telegramErr) {
+		return false
+	}
+	return telegramErr.ErrorCode == http.StatusBadRequest &&
+		strings.Contains(telegramErr.Description, "message is not modified")
+}
+
+// IsBadRequest reports whether telegram servers have refused the request as invalid, e.g. for an unknown chat or user.
+func IsBadRequest(err error) bool {
+	var telegramErr *Error
+	return errors.As(err, &telegramErr) && telegramErr.ErrorCode == http.StatusBadRequest
+}
+
+// IsForbidden reports whether the bot may not write to the chat, e.g. the user has blocked the bot or deleted the account.
+func IsForbidden(err error) bool {
+	var telegramErr *Error
+	return errors.As(err, &telegramErr) && telegramErr.ErrorCode == http.StatusForbidden
+}
@@ -1,8 +0,0 @@
-#!/usr/bin/env bash
-
-source ../.env
-WEB_HOOK_PATH="/telegram/handler/webhook"
-HOST="https://6f387cda86a4.ngrok-free.app"
-URL="https://api.telegram.org/bot${TELEGRAM_BOT_TOKEN}/test/setWebhook?url=${HOST}${WEB_HOOK_PATH}"
-echo "perform request: ${URL}"
-curl -X POST "${URL}"
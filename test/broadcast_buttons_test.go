@@ -0,0 +1,43 @@
+package test
+
+import (
+	"go-ton-pass-telegram-bot/internal/utils"
+	"testing"
+)
+
+func TestSplitBroadcastButtons(t *testing.T) {
+	t.Run("trailing button lines", func(t *testing.T) {
+		text, buttons := utils.SplitBroadcastButtons("New services are available!\n\nOpen | https://t.me/bot\nNews | https://example.com/news")
+		if text != "New services are available!" {
+			t.Errorf("unexpected text: %q", text)
+		}
+		if len(buttons) != 2 {
+			t.Fatalf("unexpected buttons count: %v", len(buttons))
+		}
+		if buttons[0].Text != "Open" || buttons[0].URL != "https://t.me/bot" {
+			t.Errorf("unexpected first button: %+v", buttons[0])
+		}
+		if buttons[1].Text != "News" || buttons[1].URL != "https://example.com/news" {
+			t.Errorf("unexpected second button: %+v", buttons[1])
+		}
+	})
+	t.Run("lines in the middle are kept", func(t *testing.T) {
+		original := "Prices | https://example.com\nare lower this week"
+		text, buttons := utils.SplitBroadcastButtons(original)
+		if text != original || len(buttons) != 0 {
+			t.Errorf("unexpected split: %q %+v", text, buttons)
+		}
+	})
+	t.Run("not a link", func(t *testing.T) {
+		original := "Balance | top up now"
+		text, buttons := utils.SplitBroadcastButtons(original)
+		if text != original || len(buttons) != 0 {
+			t.Errorf("unexpected split: %q %+v", text, buttons)
+		}
+	})
+	t.Run("utf-16 length", func(t *testing.T) {
+		if length := utils.UTF16Length("Hi 👋"); length != 5 {
+			t.Errorf("unexpected length: %v", length)
+		}
+	})
+}
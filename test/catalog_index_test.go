@@ -0,0 +1,324 @@
+package test
+
+import (
+	"fmt"
+	"go-ton-pass-telegram-bot/internal/model/app"
+	"go-ton-pass-telegram-bot/internal/model/sms"
+	"go-ton-pass-telegram-bot/internal/utils"
+	"go-ton-pass-telegram-bot/internal/worker"
+	"sort"
+	"testing"
+)
+
+const (
+	benchmarkServices  = 2000
+	benchmarkCountries = 200
+)
+
+func TestCatalogIndexPages(t *testing.T) {
+	source := newCatalogIndexSource(50, 30)
+	catalogIndex := worker.NewCatalogIndex(source)
+	favorites := app.Favorites{
+		ServiceCodes: []string{"service_40", "unknown", "service_3", "service_25"},
+		CountryIDs:   []int64{27, 2, 999},
+	}
+	t.Run("services are ordered by the blended rankings", func(t *testing.T) {
+		// en: 5 is first preferred and second of en, 3 is second of preferred and of all languages,
+		// 21 and 20 head a single ranking, 12 is third preferred, then sales and the rest of the catalog
+		expectedServices := catalogServices(source.Services, "service_5", "service_3", "service_21", "service_20",
+			"service_12", "service_10", "service_11", "service_2")
+		page, err := catalogIndex.OrderedServices("en", app.Favorites{}, 0, len(source.Services))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if fmt.Sprint(page.Items) != fmt.Sprint(expectedServices) {
+			t.Errorf("unexpected services: %v", page.Items)
+		}
+		// an unknown language falls back to the ranking of all languages counted twice
+		expectedServices = catalogServices(source.Services, "service_3", "service_20", "service_5", "service_12",
+			"service_10", "service_11", "service_2")
+		page, err = catalogIndex.OrderedServices("unknown", app.Favorites{}, 0, len(source.Services))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if fmt.Sprint(page.Items) != fmt.Sprint(expectedServices) {
+			t.Errorf("unexpected services of unknown language: %v", page.Items)
+		}
+	})
+	t.Run("services pages match the sorted list", func(t *testing.T) {
+		expectedServices := catalogServices(source.Services, "service_40", "service_3", "service_25", "service_5",
+			"service_21", "service_20", "service_12", "service_10", "service_11", "service_2")
+		for _, itemsPerPage := range []int{1, 7, 16, 50} {
+			services := make([]sms.Service, 0, len(expectedServices))
+			for currentPage := 0; currentPage*itemsPerPage < len(expectedServices); currentPage++ {
+				page, err := catalogIndex.OrderedServices("en", favorites, currentPage, itemsPerPage)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if page.Pagination.LenItems != len(expectedServices) {
+					t.Fatalf("unexpected len items: %d", page.Pagination.LenItems)
+				}
+				services = append(services, page.Items...)
+			}
+			if fmt.Sprint(services) != fmt.Sprint(expectedServices) {
+				t.Errorf("unexpected services with %d items per page: %v", itemsPerPage, services)
+			}
+		}
+	})
+	t.Run("service prices pages match the sorted list", func(t *testing.T) {
+		expectedServicePrices := sortServicePricesByRanking(source, "service_0", favorites)
+		for _, itemsPerPage := range []int{1, 4, 10, 30} {
+			servicePrices := make([]sms.PriceForService, 0, len(expectedServicePrices))
+			for currentPage := 0; currentPage*itemsPerPage < len(expectedServicePrices); currentPage++ {
+				page, err := catalogIndex.OrderedServicePrices("service_0", favorites, currentPage, itemsPerPage)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				servicePrices = append(servicePrices, page.Items...)
+			}
+			if fmt.Sprint(servicePrices) != fmt.Sprint(expectedServicePrices) {
+				t.Errorf("unexpected service prices with %d items per page: %v", itemsPerPage, servicePrices)
+			}
+		}
+	})
+	t.Run("page out of range", func(t *testing.T) {
+		if _, err := catalogIndex.OrderedServices("en", favorites, 100, 16); err != app.IndexOutOfRangeError {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+	t.Run("unknown service gives an empty page", func(t *testing.T) {
+		page, err := catalogIndex.OrderedServicePrices("unknown", favorites, 0, 10)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(page.Items) != 0 || page.Pagination.LenItems != 0 {
+			t.Errorf("unexpected page: %v", page)
+		}
+	})
+}
+
+func TestBlendServiceRankings(t *testing.T) {
+	testCases := []struct {
+		name     string
+		rankings [][]string
+		expected []string
+	}{
+		{
+			name:     "no rankings",
+			rankings: nil,
+			expected: []string{},
+		},
+		{
+			name:     "single ranking keeps its order",
+			rankings: [][]string{{"a", "b", "c"}},
+			expected: []string{"a", "b", "c"},
+		},
+		{
+			name:     "service of both rankings goes above heads of one",
+			rankings: [][]string{{"a", "b"}, {"c", "b"}},
+			expected: []string{"b", "a", "c"},
+		},
+		{
+			name:     "head of two rankings goes above second of two rankings",
+			rankings: [][]string{{"a", "b"}, {"b", "a"}, {"a"}},
+			expected: []string{"a", "b"},
+		},
+		{
+			name:     "equal scores keep the first appearance",
+			rankings: [][]string{{"a", "c"}, {"b", "d"}},
+			expected: []string{"a", "b", "c", "d"},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			blended := worker.BlendServiceRankings(testCase.rankings...)
+			if fmt.Sprint(blended) != fmt.Sprint(testCase.expected) {
+				t.Errorf("unexpected ranking: %v", blended)
+			}
+		})
+	}
+}
+
+func BenchmarkSortedServicesPage(b *testing.B) {
+	source := newCatalogIndexSource(benchmarkServices, benchmarkCountries)
+	favorites := benchmarkFavorites()
+	rankedServiceCodes := append(
+		append(append([]string{}, source.PreferredServiceCodes...), source.ServicePopularity["en"]...),
+		source.PopularServiceCodes...,
+	)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		services := sortServicesByRanking(source.Services, rankedServiceCodes, favorites)
+		_ = services[16:32]
+	}
+}
+
+func BenchmarkCatalogIndexServicesPage(b *testing.B) {
+	catalogIndex := worker.NewCatalogIndex(newCatalogIndexSource(benchmarkServices, benchmarkCountries))
+	favorites := benchmarkFavorites()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := catalogIndex.OrderedServices("en", favorites, 1, 16); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSortedServicePricesPage(b *testing.B) {
+	source := newCatalogIndexSource(benchmarkServices, benchmarkCountries)
+	favorites := benchmarkFavorites()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		servicePrices := sortServicePricesByRanking(source, "service_0", favorites)
+		_ = servicePrices[10:20]
+	}
+}
+
+func BenchmarkCatalogIndexServicePricesPage(b *testing.B) {
+	catalogIndex := worker.NewCatalogIndex(newCatalogIndexSource(benchmarkServices, benchmarkCountries))
+	favorites := benchmarkFavorites()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := catalogIndex.OrderedServicePrices("service_0", favorites, 1, 10); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCatalogIndexBuild(b *testing.B) {
+	source := newCatalogIndexSource(benchmarkServices, benchmarkCountries)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		worker.NewCatalogIndex(source)
+	}
+}
+
+func benchmarkFavorites() app.Favorites {
+	return app.Favorites{
+		ServiceCodes: []string{"service_1500", "service_7", "service_900"},
+		CountryIDs:   []int64{150, 3},
+	}
+}
+
+// newCatalogIndexSource makes a catalog where every service is sold in every country.
+func newCatalogIndexSource(servicesCount int, countriesCount int) worker.CatalogIndexSource {
+	services := make([]sms.Service, 0, servicesCount)
+	for idx := 0; idx < servicesCount; idx++ {
+		services = append(services, sms.Service{
+			Code: fmt.Sprintf("service_%d", idx),
+			Name: fmt.Sprintf("Service %d", idx),
+		})
+	}
+	countries := make([]sms.Country, 0, countriesCount)
+	for idx := 0; idx < countriesCount; idx++ {
+		countries = append(countries, sms.Country{
+			ID:    int64(idx),
+			Title: fmt.Sprintf("Country %d", idx),
+		})
+	}
+	servicePrices := make(map[string][]sms.PriceForService, servicesCount)
+	for serviceIdx, service := range services {
+		for _, country := range countries {
+			servicePrices[service.Code] = append(servicePrices[service.Code], sms.PriceForService{
+				RetailPrice: float64((serviceIdx*7 + int(country.ID)*13) % 97),
+				CountryCode: country.ID,
+				MinPrice:    sms.PriceFiled(country.ID % 5),
+				Count:       10,
+			})
+		}
+	}
+	return worker.CatalogIndexSource{
+		Services:              services,
+		PopularServiceCodes:   []string{"service_10", "service_11", "service_2"},
+		PreferredServiceCodes: []string{"service_5", "service_3", "service_12"},
+		ServicePopularity: map[string][]string{
+			"":   {"service_20", "service_3"},
+			"en": {"service_21", "service_5"},
+		},
+		Countries:              countries,
+		PreferredCountryTitles: []string{"Country 4", "Country 9", "Country 1"},
+		ServicePrices:          servicePrices,
+	}
+}
+
+// catalogServices puts the services of the codes first, the rest keep the catalog order.
+func catalogServices(services []sms.Service, serviceCodes ...string) []sms.Service {
+	orderedServices := make([]sms.Service, 0, len(services))
+	for _, serviceCode := range serviceCodes {
+		for _, service := range services {
+			if service.Code == serviceCode {
+				orderedServices = append(orderedServices, service)
+			}
+		}
+	}
+	for _, service := range services {
+		if !utils.ContainsValue(serviceCodes, service.Code) {
+			orderedServices = append(orderedServices, service)
+		}
+	}
+	return orderedServices
+}
+
+// sortServicesByRanking pins favorites and sorts the whole catalog by the ranking for every request,
+// as menus did before the catalog index.
+func sortServicesByRanking(
+	services []sms.Service,
+	rankedServiceCodes []string,
+	favorites app.Favorites,
+) []sms.Service {
+	rankedServiceCodes = append(
+		append([]string{}, favorites.ServiceCodes...),
+		utils.Filter(rankedServiceCodes, func(serviceCode string) bool {
+			return !favorites.IsFavoriteService(serviceCode)
+		})...,
+	)
+	services = append([]sms.Service{}, services...)
+	sort.SliceStable(services, func(i, j int) bool {
+		lhsIndex := utils.FirstIndexOf(rankedServiceCodes, services[i].Code)
+		rhsIndex := utils.FirstIndexOf(rankedServiceCodes, services[j].Code)
+		if lhsIndex == -1 || rhsIndex == -1 {
+			return lhsIndex != -1
+		}
+		return lhsIndex < rhsIndex
+	})
+	return services
+}
+
+func sortServicePricesByRanking(
+	source worker.CatalogIndexSource,
+	serviceCode string,
+	favorites app.Favorites,
+) []sms.PriceForService {
+	countryTitles := make(map[int64]string, len(source.Countries))
+	for _, country := range source.Countries {
+		countryTitles[country.ID] = country.Title
+	}
+	servicePrices := utils.Filter(source.ServicePrices[serviceCode], func(servicePrice sms.PriceForService) bool {
+		return servicePrice.MinPrice > 0
+	})
+	rank := func(servicePrice sms.PriceForService) (int, int) {
+		return utils.FirstIndexOf(favorites.CountryIDs, servicePrice.CountryCode),
+			utils.FirstIndexOf(source.PreferredCountryTitles, countryTitles[servicePrice.CountryCode])
+	}
+	sort.Slice(servicePrices, func(i, j int) bool {
+		lhsFavoriteIndex, lhsPreferredIndex := rank(servicePrices[i])
+		rhsFavoriteIndex, rhsPreferredIndex := rank(servicePrices[j])
+		if lhsFavoriteIndex != rhsFavoriteIndex {
+			if lhsFavoriteIndex == -1 || rhsFavoriteIndex == -1 {
+				return lhsFavoriteIndex != -1
+			}
+			return lhsFavoriteIndex < rhsFavoriteIndex
+		}
+		if lhsPreferredIndex != rhsPreferredIndex {
+			if lhsPreferredIndex == -1 || rhsPreferredIndex == -1 {
+				return lhsPreferredIndex != -1
+			}
+			return lhsPreferredIndex < rhsPreferredIndex
+		}
+		if servicePrices[i].RetailPrice == servicePrices[j].RetailPrice {
+			return servicePrices[i].CountryCode < servicePrices[j].CountryCode
+		}
+		return servicePrices[i].RetailPrice < servicePrices[j].RetailPrice
+	})
+	return servicePrices
+}
@@ -0,0 +1,199 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"go-ton-pass-telegram-bot/pkg/fsm"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type memoryConversationStorage struct {
+	sessions map[int64]fsm.Session
+}
+
+func (m *memoryConversationStorage) LoadConversation(_ context.Context, userID int64) (*fsm.Session, error) {
+	session, ok := m.sessions[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &session, nil
+}
+
+func (m *memoryConversationStorage) SaveConversation(_ context.Context, userID int64, session *fsm.Session, _ time.Duration) error {
+	m.sessions[userID] = *session
+	return nil
+}
+
+func (m *memoryConversationStorage) ClearConversation(_ context.Context, userID int64) error {
+	delete(m.sessions, userID)
+	return nil
+}
+
+// conversationEnv is the input of the user and the answers of the bot.
+type conversationEnv struct {
+	input   string
+	answers []string
+}
+
+type amountConversationData struct {
+	Currency string `json:"currency"`
+	Attempts int    `json:"attempts"`
+}
+
+type confirmConversationData struct {
+	Amount int `json:"amount"`
+}
+
+const (
+	amountConversationState  fsm.StateID = "amount"
+	confirmConversationState fsm.StateID = "confirm"
+	keepConversationEvent                = "refresh"
+)
+
+func newTestConversation(storage fsm.Storage, cancelled *bool) fsm.Machine[*conversationEnv] {
+	return fsm.NewMachine[*conversationEnv](
+		storage,
+		fsm.NewState(fsm.StateConfig[*conversationEnv, amountConversationData]{
+			ID:      amountConversationState,
+			Timeout: time.Minute,
+			KeepOn:  []string{keepConversationEvent},
+			Enter: func(_ context.Context, env *conversationEnv, data *amountConversationData) error {
+				env.answers = append(env.answers, "enter amount in "+data.Currency)
+				return nil
+			},
+			Validate: func(_ context.Context, env *conversationEnv, _ *amountConversationData) error {
+				if _, err := strconv.Atoi(env.input); err != nil {
+					return &fsm.ValidationError{Reason: "not a number"}
+				}
+				return nil
+			},
+			Invalid: func(_ context.Context, env *conversationEnv, _ *amountConversationData, err *fsm.ValidationError) error {
+				env.answers = append(env.answers, err.Reason)
+				return nil
+			},
+			Handle: func(_ context.Context, env *conversationEnv, data *amountConversationData) (fsm.Transition, error) {
+				amount, _ := strconv.Atoi(env.input)
+				if amount <= 0 {
+					data.Attempts++
+					return fsm.Stay(), nil
+				}
+				return fsm.Next(confirmConversationState, confirmConversationData{Amount: amount}), nil
+			},
+			Cancel: func(_ context.Context, _ *conversationEnv, _ *amountConversationData) error {
+				*cancelled = true
+				return nil
+			},
+			Expire: func(_ context.Context, env *conversationEnv, _ *amountConversationData) error {
+				env.answers = append(env.answers, "expired")
+				return nil
+			},
+		}),
+		fsm.NewState(fsm.StateConfig[*conversationEnv, confirmConversationData]{
+			ID: confirmConversationState,
+			Enter: func(_ context.Context, env *conversationEnv, data *confirmConversationData) error {
+				env.answers = append(env.answers, "confirm "+strconv.Itoa(data.Amount))
+				return nil
+			},
+			Handle: func(_ context.Context, env *conversationEnv, _ *confirmConversationData) (fsm.Transition, error) {
+				if env.input != "yes" {
+					return fsm.Stay(), errors.New("not confirmed")
+				}
+				return fsm.Finish(), nil
+			},
+		}),
+	)
+}
+
+func TestConversationMachine(t *testing.T) {
+	const userID int64 = 1
+	ctx := context.Background()
+	t.Run("from entry to finish", func(t *testing.T) {
+		storage := &memoryConversationStorage{sessions: make(map[int64]fsm.Session)}
+		var cancelled bool
+		conversation := newTestConversation(storage, &cancelled)
+		env := &conversationEnv{}
+		if err := conversation.Start(ctx, userID, env, amountConversationState, amountConversationData{Currency: "USD"}); err != nil {
+			t.Fatalf("fail to start: %v", err)
+		}
+		for _, input := range []string{"ten", "0", "10", "no", "yes"} {
+			env.input = input
+			if handled, err := conversation.Handle(ctx, userID, env); !handled {
+				t.Fatalf("input %q isn't handled", input)
+			} else if err != nil && input != "no" {
+				t.Fatalf("fail to handle %q: %v", input, err)
+			}
+			if input == "0" && string(storage.sessions[userID].Data) != `{"currency":"USD","attempts":1}` {
+				t.Errorf("unexpected data: %s", storage.sessions[userID].Data)
+			}
+			if input == "no" && storage.sessions[userID].State != confirmConversationState {
+				t.Errorf("failed input has changed the state: %v", storage.sessions[userID].State)
+			}
+		}
+		expectedAnswers := []string{"enter amount in USD", "not a number", "confirm 10"}
+		if len(env.answers) != len(expectedAnswers) {
+			t.Fatalf("unexpected answers: %v", env.answers)
+		}
+		for i := range expectedAnswers {
+			if env.answers[i] != expectedAnswers[i] {
+				t.Errorf("unexpected answer %v: %q", i, env.answers[i])
+			}
+		}
+		if state, _ := conversation.Current(ctx, userID); state != "" || cancelled {
+			t.Errorf("conversation isn't finished: %q %v", state, cancelled)
+		}
+		if handled, _ := conversation.Handle(ctx, userID, env); handled {
+			t.Error("input out of conversation is handled")
+		}
+	})
+	t.Run("interrupt", func(t *testing.T) {
+		storage := &memoryConversationStorage{sessions: make(map[int64]fsm.Session)}
+		var cancelled bool
+		conversation := newTestConversation(storage, &cancelled)
+		env := &conversationEnv{}
+		_ = conversation.Start(ctx, userID, env, amountConversationState, amountConversationData{Currency: "USD"})
+		if err := conversation.Interrupt(ctx, userID, env, keepConversationEvent); err != nil || cancelled {
+			t.Fatalf("kept event interrupts conversation: %v", err)
+		}
+		if err := conversation.Interrupt(ctx, userID, env, "menu"); err != nil || !cancelled {
+			t.Fatalf("conversation isn't cancelled: %v", err)
+		}
+		if state, _ := conversation.Current(ctx, userID); state != "" {
+			t.Errorf("unexpected state: %q", state)
+		}
+	})
+	t.Run("timeout", func(t *testing.T) {
+		storage := &memoryConversationStorage{sessions: make(map[int64]fsm.Session)}
+		var cancelled bool
+		conversation := newTestConversation(storage, &cancelled)
+		env := &conversationEnv{input: "10"}
+		_ = conversation.Start(ctx, userID, env, amountConversationState, amountConversationData{Currency: "USD"})
+		session := storage.sessions[userID]
+		expiresAt := time.Now().Add(-time.Second)
+		session.ExpiresAt = &expiresAt
+		storage.sessions[userID] = session
+		if state, _ := conversation.Current(ctx, userID); state != "" {
+			t.Errorf("expired conversation is current: %q", state)
+		}
+		if handled, err := conversation.Handle(ctx, userID, env); !handled || err != nil {
+			t.Fatalf("expired input isn't answered: %v", err)
+		}
+		if env.answers[len(env.answers)-1] != "expired" || len(storage.sessions) != 0 || cancelled {
+			t.Errorf("unexpected expiration: %v", env.answers)
+		}
+	})
+	t.Run("unknown state", func(t *testing.T) {
+		storage := &memoryConversationStorage{sessions: make(map[int64]fsm.Session)}
+		var cancelled bool
+		conversation := newTestConversation(storage, &cancelled)
+		err := conversation.Start(ctx, userID, &conversationEnv{}, "removed", nil)
+		if !errors.Is(err, fsm.UnknownStateError) {
+			t.Errorf("unexpected error: %v", err)
+		}
+		storage.sessions[userID] = fsm.Session{State: "removed"}
+		if handled, err := conversation.Handle(ctx, userID, &conversationEnv{}); handled || err != nil || len(storage.sessions) != 0 {
+			t.Errorf("session of unknown state isn't dropped: %v", err)
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package test
+
+import (
+	"go-ton-pass-telegram-bot/internal/utils"
+	"testing"
+)
+
+func TestFuzzyMatchScore(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		text  string
+		score int
+	}{
+		{name: "empty query", query: "", text: "Telegram", score: -1},
+		{name: "blank query", query: "   ", text: "Telegram", score: -1},
+		{name: "empty text", query: "tele", text: "", score: -1},
+		{name: "exact ignoring case and spaces", query: " TeleGram ", text: "Telegram", score: 1000},
+		{name: "prefix", query: "tele", text: "Telegram", score: 796},
+		{name: "substring", query: "gram", text: "Telegram", score: 596},
+		{name: "missing letter", query: "telgram", text: "Telegram", score: 299},
+		{name: "missing letters", query: "wtsp", text: "WhatsApp", score: 297},
+		{name: "swapped letters", query: "teelgram", text: "Telegram", score: -1},
+		{name: "unrelated", query: "xyz", text: "Telegram", score: -1},
+		{name: "longer than text", query: "telegrams", text: "Telegram", score: -1},
+		{name: "cyrillic", query: "вк", text: "ВКонтакте", score: 793},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if score := utils.FuzzyMatchScore(tt.query, tt.text); score != tt.score {
+				t.Errorf("FuzzyMatchScore(%q, %q) = %v, expected %v", tt.query, tt.text, score, tt.score)
+			}
+		})
+	}
+}
+
+func TestFuzzyMatchScoreOrdering(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		better string
+		worse  string
+	}{
+		{name: "exact over prefix", query: "vk", better: "VK", worse: "VKontakte"},
+		{name: "prefix over substring", query: "tele", better: "Telegram", worse: "Hotel Telemundo"},
+		{name: "substring over typo", query: "gram", better: "Instagram", worse: "Google Maps Rambler"},
+		{name: "shorter prefix match", query: "tele", better: "Telegram", worse: "Telegraph Messenger"},
+		{name: "earlier substring", query: "pay", better: "Apple Pay", worse: "Samsung Pay"},
+		{name: "typo with fewer gaps", query: "tlgrm", better: "Telegram", worse: "Tele Gold Room"},
+		{name: "any match over none", query: "wtsp", better: "WhatsApp", worse: "Viber"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			better := utils.FuzzyMatchScore(tt.query, tt.better)
+			worse := utils.FuzzyMatchScore(tt.query, tt.worse)
+			if better <= worse {
+				t.Errorf("%q scores %v against %q, not above %v of %q", tt.query, better, tt.better, worse, tt.worse)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"go-ton-pass-telegram-bot/internal/container"
+	"go-ton-pass-telegram-bot/internal/manager"
+	"go-ton-pass-telegram-bot/internal/model/app"
+	"go-ton-pass-telegram-bot/internal/repository"
+	"go-ton-pass-telegram-bot/pkg/logger"
+	"testing"
+)
+
+func TestNumberPurchase(t *testing.T) {
+	t.Run("cancelled request still refunds the numbers that weren't obtained", func(t *testing.T) {
+		profiles := &fakeBalanceProfiles{balance: 10}
+		purchase := newNumberPurchase(profiles)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		activations := 0
+		obtained, err := purchase.Purchase(ctx, 1, 2, 3, func(ctx context.Context) error {
+			activations++
+			if activations == 1 {
+				// the user has gone after the first number is requested, the activation goes on regardless
+				cancel()
+				return ctx.Err()
+			}
+			return errors.New("no numbers")
+		})
+		if err == nil || err.Error() != "no numbers" {
+			t.Fatalf("expected the error of the second activation, got %v", err)
+		}
+		if obtained != 1 {
+			t.Fatalf("expected 1 obtained number, got %d", obtained)
+		}
+		if profiles.balance != 8 {
+			t.Fatalf("expected the price of 2 numbers returned to the balance 8, got %v", profiles.balance)
+		}
+		if profiles.cancelledCalls > 0 {
+			t.Fatalf("expected the balance changed on a context that isn't cancelled, %d calls were cancelled", profiles.cancelledCalls)
+		}
+	})
+
+	t.Run("cancelled request keeps obtained numbers charged", func(t *testing.T) {
+		profiles := &fakeBalanceProfiles{balance: 10}
+		purchase := newNumberPurchase(profiles)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		obtained, err := purchase.Purchase(ctx, 1, 2, 2, func(ctx context.Context) error {
+			cancel()
+			return ctx.Err()
+		})
+		if err != nil {
+			t.Fatalf("expected the purchase to finish, got %v", err)
+		}
+		if obtained != 2 {
+			t.Fatalf("expected 2 obtained numbers, got %d", obtained)
+		}
+		if profiles.balance != 6 {
+			t.Fatalf("expected balance 6, got %v", profiles.balance)
+		}
+	})
+
+	t.Run("insufficient funds requests no numbers", func(t *testing.T) {
+		profiles := &fakeBalanceProfiles{balance: 3}
+		purchase := newNumberPurchase(profiles)
+		obtained, err := purchase.Purchase(context.Background(), 1, 2, 2, func(ctx context.Context) error {
+			t.Fatal("expected no activation")
+			return nil
+		})
+		if !errors.Is(err, app.InsufficientFundsError) {
+			t.Fatalf("expected insufficient funds, got %v", err)
+		}
+		if obtained != 0 || profiles.balance != 3 {
+			t.Fatalf("expected nothing obtained and balance 3, got %d and %v", obtained, profiles.balance)
+		}
+	})
+}
+
+func newNumberPurchase(profiles repository.ProfileRepository) manager.NumberPurchase {
+	box := container.NewContainer(logger.NewLogger(logger.DEV, logger.LevelFatal), nil, nil)
+	return manager.NewNumberPurchase(box, profiles)
+}
+
+// fakeBalanceProfiles keeps a single balance and counts calls made on a cancelled context.
+type fakeBalanceProfiles struct {
+	repository.ProfileRepository
+	balance        float64
+	cancelledCalls int
+}
+
+func (f *fakeBalanceProfiles) DebitIfSufficient(ctx context.Context, _ int64, amount float64) (bool, error) {
+	if ctx.Err() != nil {
+		f.cancelledCalls++
+		return false, ctx.Err()
+	}
+	if f.balance < amount {
+		return false, nil
+	}
+	f.balance -= amount
+	return true, nil
+}
+
+func (f *fakeBalanceProfiles) TopUpBalanceByTelegramID(ctx context.Context, _ int64, amount float64) error {
+	if ctx.Err() != nil {
+		f.cancelledCalls++
+		return ctx.Err()
+	}
+	f.balance += amount
+	return nil
+}
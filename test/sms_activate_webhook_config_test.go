@@ -0,0 +1,45 @@
+package test
+
+import (
+	"go-ton-pass-telegram-bot/internal/config"
+	"net"
+	"testing"
+)
+
+func TestParseSMSActivateWebhookConfig(t *testing.T) {
+	t.Run("allowlist and proxies", func(t *testing.T) {
+		t.Setenv("SMS_ACTIVATE_WEBHOOK_ALLOWED_IPS", "188.42.218.183, 142.91.156.0/24")
+		t.Setenv("SMS_ACTIVATE_WEBHOOK_TRUSTED_PROXIES", "10.0.0.0/8,::1")
+		webhookConfig, err := config.ParseSMSActivateWebhookConfig()
+		if err != nil {
+			t.Fatalf("fail to parse sms activate webhook config: %v", err)
+		}
+		for ip, isAllowed := range map[string]bool{"188.42.218.183": true, "142.91.156.119": true, "188.42.218.184": false} {
+			if webhookConfig.IsAllowedIP(net.ParseIP(ip)) != isAllowed {
+				t.Errorf("unexpected allowance of %v", ip)
+			}
+		}
+		for ip, isTrusted := range map[string]bool{"10.1.2.3": true, "::1": true, "188.42.218.183": false} {
+			if webhookConfig.IsTrustedProxy(net.ParseIP(ip)) != isTrusted {
+				t.Errorf("unexpected trust of %v", ip)
+			}
+		}
+	})
+	t.Run("empty allowlist", func(t *testing.T) {
+		t.Setenv("SMS_ACTIVATE_WEBHOOK_ALLOWED_IPS", "")
+		t.Setenv("SMS_ACTIVATE_WEBHOOK_TRUSTED_PROXIES", "")
+		webhookConfig, err := config.ParseSMSActivateWebhookConfig()
+		if err != nil {
+			t.Fatalf("fail to parse sms activate webhook config: %v", err)
+		}
+		if webhookConfig.IsAllowedIP(net.ParseIP("188.42.218.183")) {
+			t.Error("empty allowlist allows updates")
+		}
+	})
+	t.Run("invalid network", func(t *testing.T) {
+		t.Setenv("SMS_ACTIVATE_WEBHOOK_ALLOWED_IPS", "188.42.218")
+		if _, err := config.ParseSMSActivateWebhookConfig(); err == nil {
+			t.Error("invalid network is parsed")
+		}
+	})
+}
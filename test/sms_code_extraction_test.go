@@ -0,0 +1,77 @@
+package test
+
+import (
+	"go-ton-pass-telegram-bot/internal/model/app"
+	"go-ton-pass-telegram-bot/internal/utils"
+	"testing"
+)
+
+func TestSMSCodeExtraction(t *testing.T) {
+	var smsCodeRules = make([]app.SMSCodeRule, 0)
+	if err := utils.UnmarshalFromFile("../jsons/sms_code_rules.json", &smsCodeRules); err != nil {
+		t.Fatalf("fail to read sms code rules: %v", err)
+	}
+	compiledRules, err := utils.CompileSMSCodeRules(smsCodeRules)
+	if err != nil {
+		t.Fatalf("fail to compile sms code rules: %v", err)
+	}
+	corpus := []struct {
+		service string
+		text    string
+		code    string
+	}{
+		{"go", "G-482913 is your Google verification code.", "482913"},
+		{"go", "G-104857 — ваш код подтверждения Google.", "104857"},
+		{"fb", "FB-58213 is your Facebook confirmation code", "58213"},
+		{"fb", "Your Facebook code is 73124890. Don't share it.", "73124890"},
+		{"wa", "Your WhatsApp code: 512-804\nYou can also tap on this link to verify your phone: v.whatsapp.com/512804\nDon't share this code with others", "512804"},
+		{"wa", "Код WhatsApp: 331-097. Не сообщайте этот код другим", "331097"},
+		{"ig", "Use 638 291 to verify your Instagram account.", "638291"},
+		{"ig", "638291 is your Instagram code. Don't share it.", "638291"},
+		{"tg", "Telegram code: 71824\n\nYou can also tap on this link to log in:\nhttps://t.me/login/71824", "71824"},
+		{"tg", "Код подтверждения Telegram: 40291. Никому не давайте код.", "40291"},
+		{"tw", "Your X confirmation code is k7w3pqz2. Don't reply to this message with your code.", "k7w3pqz2"},
+		{"tw", "Your Twitter confirmation code is 482015.", "482015"},
+		{"ds", "Your Discord security code is: 907312", "907312"},
+		{"mm", "Microsoft account security code: 8134", "8134"},
+		{"mm", "Use 55210437 as Microsoft account security code", "55210437"},
+		{"am", "201948 is your Amazon OTP. Do not share it with anyone.", "201948"},
+		{"ts", "PayPal: Your security code is: 648102. Your code expires in 10 minutes.", "648102"},
+		{"dr", "Your OpenAI API verification code is: 318604", "318604"},
+		{"vk", "VK: 582103 — код подтверждения. Никому не сообщайте.", "582103"},
+		{"lf", "[TikTok] 841 207 is your verification code, valid for 5 minutes.", "841207"},
+		{"oi", "Your Tinder code is 771024 - Don't share", "771024"},
+		{"ub", "Your Uber code: 4829. Never share this code.", "4829"},
+		{"mb", "Yahoo: Your verification code is TYKB8R2", "TYKB8R2"},
+		{"ot", "Ваш код для входа: 55812. Действует 10 минут.", "55812"},
+		{"ot", "Steam: your login code is FK4R9", "FK4R9"},
+		{"ot", "Thanks for signing up!", ""},
+	}
+	for _, item := range corpus {
+		code := utils.ExtractSMSCode(item.text, compiledRules[item.service])
+		if code != item.code {
+			t.Errorf("service %s: unexpected code %q, want %q in %q", item.service, code, item.code, item.text)
+		}
+	}
+}
+
+func TestSuspiciousSMSCode(t *testing.T) {
+	cases := []struct {
+		code       string
+		text       string
+		suspicious bool
+	}{
+		{"482913", "G-482913 is your Google verification code.", false},
+		{"", "G-482913 is your Google verification code.", true},
+		{"G-482913", "G-482913 is your Google verification code.", true},
+		{"512 804", "Your WhatsApp code: 512-804", true},
+		{"512804", "Your WhatsApp code: 512-804", false},
+		{"999999", "Your code is 123456", true},
+		{"k7w3pqz2", "", false},
+	}
+	for _, item := range cases {
+		if suspicious := utils.IsSuspiciousSMSCode(item.code, item.text); suspicious != item.suspicious {
+			t.Errorf("code %q: unexpected suspicious flag %v", item.code, suspicious)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package test
+
+import (
+	"go-ton-pass-telegram-bot/internal/config"
+	"testing"
+)
+
+func TestParseSubscriptionConfig(t *testing.T) {
+	t.Run("channels with switches", func(t *testing.T) {
+		t.Setenv("REQUIRED_CHANNELS", " @tonpassnews , -1001234567890:on, @archive:off")
+		t.Setenv("REQUIRED_CHANNELS_CACHE_TTL_SECONDS", "")
+		subscription, err := config.ParseSubscriptionConfig()
+		if err != nil {
+			t.Fatalf("fail to parse subscription config: %v", err)
+		}
+		if len(subscription.RequiredChannels) != 3 {
+			t.Fatalf("unexpected channels: %+v", subscription.RequiredChannels)
+		}
+		enabledChannels := subscription.EnabledChannels()
+		if len(enabledChannels) != 2 || enabledChannels[0].Chat != "@tonpassnews" || enabledChannels[1].Chat != "-1001234567890" {
+			t.Errorf("unexpected enabled channels: %+v", enabledChannels)
+		}
+		if link := enabledChannels[0].Link(); link == nil || *link != "https://t.me/tonpassnews" {
+			t.Errorf("unexpected link: %v", link)
+		}
+		if link := enabledChannels[1].Link(); link != nil {
+			t.Errorf("channel without username has link: %v", *link)
+		}
+		if subscription.MembershipCacheTTLSeconds != 300 {
+			t.Errorf("unexpected cache ttl: %v", subscription.MembershipCacheTTLSeconds)
+		}
+	})
+	t.Run("no channels", func(t *testing.T) {
+		t.Setenv("REQUIRED_CHANNELS", "")
+		subscription, err := config.ParseSubscriptionConfig()
+		if err != nil || len(subscription.EnabledChannels()) != 0 {
+			t.Errorf("unexpected subscription config: %+v %v", subscription, err)
+		}
+	})
+	t.Run("unknown switch", func(t *testing.T) {
+		t.Setenv("REQUIRED_CHANNELS", "@tonpassnews:maybe")
+		if _, err := config.ParseSubscriptionConfig(); err == nil {
+			t.Error("unknown switch is accepted")
+		}
+	})
+}
@@ -0,0 +1,221 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-ton-pass-telegram-bot/internal/model/telegram"
+	"go-ton-pass-telegram-bot/pkg/telegram_bot"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const fakeBotToken = "123:fake"
+
+// fakeBotAPI serves the Bot API with the handler and counts requests.
+type fakeBotAPI struct {
+	server   *httptest.Server
+	requests atomic.Int32
+}
+
+func newFakeBotAPI(t *testing.T, handler func(w http.ResponseWriter, r *http.Request, attempt int)) *fakeBotAPI {
+	fake := fakeBotAPI{}
+	fake.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r, int(fake.requests.Add(1)))
+	}))
+	t.Cleanup(fake.server.Close)
+	return &fake
+}
+
+func (f *fakeBotAPI) client(environment telegram_bot.Environment) telegram_bot.Client {
+	return telegram_bot.NewClient(telegram_bot.Options{
+		BaseURL:       f.server.URL + "/bot",
+		Token:         fakeBotToken,
+		Environment:   environment,
+		MaxAttempts:   3,
+		RetryDelay:    time.Millisecond,
+		MaxRetryDelay: 2 * time.Second,
+	})
+}
+
+func writeBotAPIResponse(w http.ResponseWriter, statusCode int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_, _ = w.Write([]byte(body))
+}
+
+func TestTelegramBotClientSendMessage(t *testing.T) {
+	for _, environment := range []telegram_bot.Environment{telegram_bot.ProductionEnvironment, telegram_bot.TestEnvironment} {
+		expectedPath := "/bot" + fakeBotToken + "/sendMessage"
+		if environment == telegram_bot.TestEnvironment {
+			expectedPath = "/bot" + fakeBotToken + "/test/sendMessage"
+		}
+		fake := newFakeBotAPI(t, func(w http.ResponseWriter, r *http.Request, _ int) {
+			if r.URL.Path != expectedPath {
+				t.Errorf("unexpected path: %s", r.URL.Path)
+			}
+			var sendMessage telegram.SendResponse
+			if err := json.NewDecoder(r.Body).Decode(&sendMessage); err != nil {
+				t.Errorf("fail to decode request: %v", err)
+			}
+			if sendMessage.ChatID != 42 || sendMessage.Text != "hello" {
+				t.Errorf("unexpected request: %+v", sendMessage)
+			}
+			writeBotAPIResponse(w, http.StatusOK, `{"ok":true,"result":{"message_id":7,"chat":{"id":42}}}`)
+		})
+		message, err := fake.client(environment).SendMessage(context.Background(), &telegram.SendResponse{
+			ChatID: 42,
+			Text:   "hello",
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if message.ID != 7 {
+			t.Errorf("unexpected message id: %d", message.ID)
+		}
+	}
+}
+
+func TestTelegramBotClientErrors(t *testing.T) {
+	t.Run("bad request is returned without retry", func(t *testing.T) {
+		fake := newFakeBotAPI(t, func(w http.ResponseWriter, _ *http.Request, _ int) {
+			writeBotAPIResponse(w, http.StatusBadRequest, `{"ok":false,"error_code":400,"description":"Bad Request: message is not modified"}`)
+		})
+		_, err := fake.client(telegram_bot.ProductionEnvironment).EditMessageMedia(context.Background(), &telegram.EditMessageMedia{})
+		var telegramErr *telegram_bot.Error
+		if !errors.As(err, &telegramErr) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if telegramErr.ErrorCode != 400 || telegramErr.Method != "editMessageMedia" {
+			t.Errorf("unexpected error: %+v", telegramErr)
+		}
+		if !telegram_bot.IsMessageNotModified(err) || !telegram_bot.IsBadRequest(err) {
+			t.Errorf("error isn't recognized: %v", err)
+		}
+		if fake.requests.Load() != 1 {
+			t.Errorf("unexpected requests: %d", fake.requests.Load())
+		}
+	})
+	t.Run("flood error is retried after the delay", func(t *testing.T) {
+		fake := newFakeBotAPI(t, func(w http.ResponseWriter, _ *http.Request, attempt int) {
+			if attempt == 1 {
+				writeBotAPIResponse(w, http.StatusTooManyRequests, `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 1","parameters":{"retry_after":1}}`)
+				return
+			}
+			writeBotAPIResponse(w, http.StatusOK, `{"ok":true,"result":true}`)
+		})
+		startedAt := time.Now()
+		err := fake.client(telegram_bot.ProductionEnvironment).AnswerCallbackQuery(context.Background(), &telegram.AnswerCallbackQuery{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if elapsed := time.Since(startedAt); elapsed < time.Second {
+			t.Errorf("retry_after isn't respected: %v", elapsed)
+		}
+		if fake.requests.Load() != 2 {
+			t.Errorf("unexpected requests: %d", fake.requests.Load())
+		}
+	})
+	t.Run("flood error with a long delay is returned", func(t *testing.T) {
+		fake := newFakeBotAPI(t, func(w http.ResponseWriter, _ *http.Request, _ int) {
+			writeBotAPIResponse(w, http.StatusTooManyRequests, `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 35","parameters":{"retry_after":35}}`)
+		})
+		err := fake.client(telegram_bot.ProductionEnvironment).DeleteMessage(context.Background(), &telegram.DeleteMessage{})
+		var telegramErr *telegram_bot.Error
+		if !errors.As(err, &telegramErr) || telegramErr.RetryAfter != 35 {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if fake.requests.Load() != 1 {
+			t.Errorf("unexpected requests: %d", fake.requests.Load())
+		}
+	})
+	t.Run("server errors are retried", func(t *testing.T) {
+		fake := newFakeBotAPI(t, func(w http.ResponseWriter, _ *http.Request, attempt int) {
+			if attempt < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+				_, _ = w.Write([]byte("<html>502 Bad Gateway</html>"))
+				return
+			}
+			writeBotAPIResponse(w, http.StatusOK, `{"ok":true,"result":{"status":"member","user":{"id":1}}}`)
+		})
+		chatMember, err := fake.client(telegram_bot.ProductionEnvironment).GetChatMember(context.Background(), &telegram.GetChatMember{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if chatMember.Status != telegram.MemberMemberStatus {
+			t.Errorf("unexpected status: %s", chatMember.Status)
+		}
+	})
+	t.Run("server errors stop after max attempts", func(t *testing.T) {
+		fake := newFakeBotAPI(t, func(w http.ResponseWriter, _ *http.Request, _ int) {
+			writeBotAPIResponse(w, http.StatusInternalServerError, `{"ok":false,"error_code":500,"description":"Internal Server Error"}`)
+		})
+		_, err := fake.client(telegram_bot.ProductionEnvironment).SendPhoto(context.Background(), &telegram.SendPhoto{})
+		var telegramErr *telegram_bot.Error
+		if !errors.As(err, &telegramErr) || telegramErr.ErrorCode != 500 {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if fake.requests.Load() != 3 {
+			t.Errorf("unexpected requests: %d", fake.requests.Load())
+		}
+	})
+}
+
+func TestTelegramBotClientSetWebhookWithCertificate(t *testing.T) {
+	certificatePath := filepath.Join(t.TempDir(), "certificate.crt")
+	if err := os.WriteFile(certificatePath, []byte("certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeBotAPI(t, func(w http.ResponseWriter, r *http.Request, _ int) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("fail to parse multipart form: %v", err)
+		}
+		if r.FormValue("url") != "https://example.com/hook" || r.FormValue("secret_token") != "secret" {
+			t.Errorf("unexpected form: %v", r.MultipartForm.Value)
+		}
+		if r.FormValue("allowed_updates") != `["message","callback_query"]` || r.FormValue("max_connections") != "10" {
+			t.Errorf("unexpected form: %v", r.MultipartForm.Value)
+		}
+		if _, header, err := r.FormFile("certificate"); err != nil || header.Filename != "certificate.crt" {
+			t.Errorf("unexpected certificate: %v", err)
+		}
+		writeBotAPIResponse(w, http.StatusOK, `{"ok":true,"result":true}`)
+	})
+	err := fake.client(telegram_bot.ProductionEnvironment).SetWebhook(context.Background(), &telegram.SetWebhook{
+		URL:            "https://example.com/hook",
+		SecretToken:    "secret",
+		MaxConnections: 10,
+		AllowedUpdates: []string{"message", "callback_query"},
+	}, certificatePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestTelegramBotClientGetMyCommands(t *testing.T) {
+	fake := newFakeBotAPI(t, func(w http.ResponseWriter, r *http.Request, _ int) {
+		var getMyCommands telegram.GetMyCommands
+		if err := json.NewDecoder(r.Body).Decode(&getMyCommands); err != nil {
+			t.Errorf("fail to decode request: %v", err)
+		}
+		if getMyCommands.LanguageCode != "uk" {
+			t.Errorf("unexpected request: %+v", getMyCommands)
+		}
+		writeBotAPIResponse(w, http.StatusOK, `{"ok":true,"result":[{"command":"start","description":"Start"}]}`)
+	})
+	commands, err := fake.client(telegram_bot.ProductionEnvironment).GetMyCommands(
+		context.Background(),
+		&telegram.GetMyCommands{LanguageCode: "uk"},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(commands) != 1 || commands[0].Command != "start" || commands[0].Description != "Start" {
+		t.Errorf("unexpected commands: %+v", commands)
+	}
+}
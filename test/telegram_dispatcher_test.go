@@ -0,0 +1,515 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go-ton-pass-telegram-bot/internal/config"
+	"go-ton-pass-telegram-bot/internal/container"
+	"go-ton-pass-telegram-bot/internal/model/app"
+	"go-ton-pass-telegram-bot/internal/model/telegram"
+	"go-ton-pass-telegram-bot/internal/repository"
+	"go-ton-pass-telegram-bot/internal/service"
+	"go-ton-pass-telegram-bot/pkg/logger"
+	"go-ton-pass-telegram-bot/pkg/telegram_bot"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// outboundLeaseTTL mirrors the lease of the dispatcher, it renews leases every third of it.
+const outboundLeaseTTL = time.Minute
+
+func TestTelegramDispatcherLimits(t *testing.T) {
+	t.Run("chat gets a burst of 3 messages, then 1 per second", func(t *testing.T) {
+		fixture := newDispatcherFixture(t, 30, 1)
+		fixture.start(t)
+		for _, text := range []string{"1", "2", "3", "4", "5"} {
+			fixture.enqueue(t, 1, text)
+		}
+		fixture.client.waitSent(t, 3)
+		fixture.clock.waitTimer(t, time.Second)
+		fixture.clock.Advance(time.Second)
+		fixture.client.waitSent(t, 4)
+		fixture.clock.waitTimer(t, time.Second)
+		fixture.clock.Advance(time.Second)
+		fixture.client.waitSent(t, 5)
+		expected := "[1@0s 2@0s 3@0s 4@1s 5@2s]"
+		if sent := fixture.client.history(1); sent != expected {
+			t.Errorf("unexpected messages of the chat: %s", sent)
+		}
+	})
+	t.Run("bot spaces messages of all chats by the global rate", func(t *testing.T) {
+		fixture := newDispatcherFixture(t, 2, 10)
+		fixture.start(t)
+		for chatID := int64(1); chatID <= 4; chatID++ {
+			fixture.enqueue(t, chatID, fmt.Sprint(chatID))
+		}
+		fixture.client.waitSent(t, 2)
+		fixture.clock.waitTimer(t, 500*time.Millisecond)
+		if sent := fixture.client.count(); sent != 2 {
+			t.Fatalf("unexpected messages before the global bucket is refilled: %d", sent)
+		}
+		fixture.clock.Advance(500 * time.Millisecond)
+		fixture.client.waitSent(t, 3)
+		fixture.clock.waitTimer(t, 500*time.Millisecond)
+		fixture.clock.Advance(500 * time.Millisecond)
+		fixture.client.waitSent(t, 4)
+		if sent := fixture.client.history(3) + fixture.client.history(4); sent != "[3@500ms][4@1s]" {
+			t.Errorf("unexpected messages after the global bucket is refilled: %s", sent)
+		}
+	})
+}
+
+func TestTelegramDispatcherRetries(t *testing.T) {
+	t.Run("repeated message keeps its place in the chat", func(t *testing.T) {
+		fixture := newDispatcherFixture(t, 30, 10)
+		fixture.client.fail("a", &telegram_bot.Error{
+			Method:      "sendMessage",
+			ErrorCode:   http.StatusInternalServerError,
+			Description: "Internal Server Error",
+		})
+		fixture.start(t)
+		fixture.enqueue(t, 1, "a")
+		fixture.enqueue(t, 1, "b")
+		fixture.enqueue(t, 2, "c")
+		fixture.client.waitSent(t, 2)
+		fixture.clock.waitTimer(t, 2*time.Second)
+		if sent := fixture.client.history(1); sent != "[a@0s]" {
+			t.Fatalf("unexpected messages of the chat before the retry: %s", sent)
+		}
+		if sent := fixture.client.history(2); sent != "[c@0s]" {
+			t.Errorf("unexpected messages of another chat: %s", sent)
+		}
+		fixture.clock.Advance(2 * time.Second)
+		fixture.client.waitSent(t, 4)
+		if sent := fixture.client.history(1); sent != "[a@0s a@2s b@2s]" {
+			t.Errorf("unexpected messages of the chat after the retry: %s", sent)
+		}
+	})
+	t.Run("refused chat is marked blocked and isn't repeated", func(t *testing.T) {
+		fixture := newDispatcherFixture(t, 30, 10)
+		fixture.client.fail("a", &telegram_bot.Error{
+			Method:      "sendMessage",
+			ErrorCode:   http.StatusForbidden,
+			Description: "Forbidden: bot was blocked by the user",
+		})
+		fixture.start(t)
+		fixture.enqueue(t, 3, "a")
+		fixture.client.waitSent(t, 1)
+		// a repeated message would wake the dispatcher after the retry delay instead of the lease renewal
+		fixture.clock.waitTimer(t, outboundLeaseTTL/3)
+		if sent := fixture.client.history(3); sent != "[a@0s]" {
+			t.Errorf("unexpected messages of the refused chat: %s", sent)
+		}
+		if blockedChatIDs := fixture.profiles.blocked(); fmt.Sprint(blockedChatIDs) != "[3]" {
+			t.Errorf("unexpected blocked chats: %v", blockedChatIDs)
+		}
+		fixture.redis.FastForward(outboundLeaseTTL)
+		if messages := fixture.claim(t, "other"); len(messages) != 0 {
+			t.Errorf("refused message is kept: %v", messages)
+		}
+	})
+}
+
+func TestTelegramDispatcherLeases(t *testing.T) {
+	t.Run("messages of a stopped dispatcher are sent", func(t *testing.T) {
+		fixture := newDispatcherFixture(t, 30, 10)
+		fixture.save(t, newOutboundMessage(t, 1, "restored"), "stopped")
+		fixture.redis.FastForward(outboundLeaseTTL)
+		fixture.start(t)
+		fixture.client.waitSent(t, 1)
+		if sent := fixture.client.history(1); sent != "[restored@0s]" {
+			t.Errorf("unexpected messages of the chat: %s", sent)
+		}
+	})
+	t.Run("message claimed by another dispatcher is dropped", func(t *testing.T) {
+		fixture := newDispatcherFixture(t, 30, 1)
+		fixture.start(t)
+		for _, text := range []string{"1", "2", "3", "4"} {
+			fixture.enqueue(t, 1, text)
+		}
+		fixture.client.waitSent(t, 3)
+		fixture.clock.waitTimer(t, time.Second)
+		fixture.redis.FastForward(outboundLeaseTTL)
+		if messages := fixture.claim(t, "other"); len(messages) != 1 {
+			t.Fatalf("unexpected claimed messages: %v", messages)
+		}
+		// the renewal comes before the next message, so the lost lease is seen before the message is due
+		fixture.clock.Advance(outboundLeaseTTL / 3)
+		fixture.clock.waitTimer(t, outboundLeaseTTL/3)
+		if sent := fixture.client.history(1); sent != "[1@0s 2@0s 3@0s]" {
+			t.Errorf("message of another dispatcher is sent: %s", sent)
+		}
+	})
+}
+
+func TestOutboundMessageLeases(t *testing.T) {
+	ctx := context.Background()
+	t.Run("held message is claimed once its lease lapses", func(t *testing.T) {
+		fixture := newDispatcherFixture(t, 30, 1)
+		fixture.save(t, newOutboundMessage(t, 1, "a"), "a")
+		if messages := fixture.claim(t, "b"); len(messages) != 0 {
+			t.Fatalf("held message is claimed: %v", messages)
+		}
+		fixture.redis.FastForward(outboundLeaseTTL)
+		if messages := fixture.claim(t, "b"); len(messages) != 1 {
+			t.Fatalf("unexpected claimed messages: %v", messages)
+		}
+		if messages := fixture.claim(t, "c"); len(messages) != 0 {
+			t.Errorf("message is claimed twice: %v", messages)
+		}
+	})
+	t.Run("lease taken by another owner is lost", func(t *testing.T) {
+		fixture := newDispatcherFixture(t, 30, 1)
+		outboundMessage := newOutboundMessage(t, 1, "a")
+		fixture.save(t, outboundMessage, "a")
+		fixture.redis.FastForward(outboundLeaseTTL)
+		fixture.claim(t, "b")
+		lostIDs, err := fixture.cache.LeaseOutboundMessages(ctx, []string{outboundMessage.ID}, "a", outboundLeaseTTL)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if fmt.Sprint(lostIDs) != fmt.Sprint([]string{outboundMessage.ID}) {
+			t.Errorf("unexpected lost leases of the previous owner: %v", lostIDs)
+		}
+		lostIDs, err = fixture.cache.LeaseOutboundMessages(ctx, []string{outboundMessage.ID}, "b", outboundLeaseTTL)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(lostIDs) != 0 {
+			t.Errorf("unexpected lost leases of the new owner: %v", lostIDs)
+		}
+	})
+	t.Run("lapsed lease nobody has claimed is renewed", func(t *testing.T) {
+		fixture := newDispatcherFixture(t, 30, 1)
+		outboundMessage := newOutboundMessage(t, 1, "a")
+		fixture.save(t, outboundMessage, "a")
+		fixture.redis.FastForward(outboundLeaseTTL)
+		lostIDs, err := fixture.cache.LeaseOutboundMessages(ctx, []string{outboundMessage.ID}, "a", outboundLeaseTTL)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(lostIDs) != 0 {
+			t.Errorf("unexpected lost leases: %v", lostIDs)
+		}
+		if messages := fixture.claim(t, "b"); len(messages) != 0 {
+			t.Errorf("renewed message is claimed: %v", messages)
+		}
+	})
+	t.Run("deleted message is neither claimed nor leased", func(t *testing.T) {
+		fixture := newDispatcherFixture(t, 30, 1)
+		outboundMessage := newOutboundMessage(t, 1, "a")
+		fixture.save(t, outboundMessage, "a")
+		if err := fixture.cache.DeleteOutboundMessage(ctx, outboundMessage.ID); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if messages := fixture.claim(t, "b"); len(messages) != 0 {
+			t.Errorf("deleted message is claimed: %v", messages)
+		}
+		lostIDs, err := fixture.cache.LeaseOutboundMessages(ctx, []string{outboundMessage.ID}, "a", outboundLeaseTTL)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(lostIDs) != 1 {
+			t.Errorf("lease of the deleted message isn't lost: %v", lostIDs)
+		}
+	})
+	t.Run("released message is claimed at once", func(t *testing.T) {
+		fixture := newDispatcherFixture(t, 30, 1)
+		outboundMessage := newOutboundMessage(t, 1, "a")
+		fixture.save(t, outboundMessage, "a")
+		if err := fixture.cache.ReleaseOutboundMessages(ctx, []string{outboundMessage.ID}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if messages := fixture.claim(t, "b"); len(messages) != 1 {
+			t.Errorf("unexpected claimed messages: %v", messages)
+		}
+	})
+}
+
+// dispatcherFixture runs a dispatcher on a fake clock, a fake client and an in-memory redis.
+type dispatcherFixture struct {
+	dispatcher service.TelegramDispatcher
+	cache      service.Cache
+	redis      *miniredis.Miniredis
+	clock      *fakeClock
+	client     *fakeOutboundClient
+	profiles   *fakeBlockedProfiles
+}
+
+func newDispatcherFixture(t *testing.T, globalRate float64, chatRate float64) *dispatcherFixture {
+	redisServer := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	t.Cleanup(func() {
+		_ = redisClient.Close()
+	})
+	box := container.NewContainer(logger.NewLogger(logger.DEV, logger.LevelFatal), &dispatcherConfig{
+		telegram: config.Telegram{
+			OutboundGlobalRate:  globalRate,
+			OutboundChatRate:    chatRate,
+			OutboundMaxAttempts: 5,
+		},
+	}, nil)
+	clock := fakeClock{
+		startedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		timers:    make(map[*fakeTimer]bool),
+	}
+	clock.now = clock.startedAt
+	client := fakeOutboundClient{
+		clock:    &clock,
+		failures: make(map[string][]error),
+	}
+	profiles := fakeBlockedProfiles{}
+	cache := service.NewCache(box, redisClient)
+	return &dispatcherFixture{
+		dispatcher: service.NewTelegramDispatcher(box, &client, cache, &profiles, &clock),
+		cache:      cache,
+		redis:      redisServer,
+		clock:      &clock,
+		client:     &client,
+		profiles:   &profiles,
+	}
+}
+
+func (d *dispatcherFixture) start(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- d.dispatcher.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	// the dispatcher has claimed persisted messages and sent what it could once it sleeps
+	d.clock.waitTimer(t, 0)
+}
+
+func (d *dispatcherFixture) enqueue(t *testing.T, chatID int64, text string) {
+	sendMessage := telegram.SendResponse{ChatID: chatID, Text: text}
+	err := d.dispatcher.Enqueue(
+		context.Background(),
+		app.TransactionalOutboundPriority,
+		app.SendMessageOutboundMethod,
+		chatID,
+		&sendMessage,
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func (d *dispatcherFixture) save(t *testing.T, outboundMessage app.OutboundMessage, owner string) {
+	if err := d.cache.SaveOutboundMessage(context.Background(), outboundMessage, owner, outboundLeaseTTL); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func (d *dispatcherFixture) claim(t *testing.T, owner string) []app.OutboundMessage {
+	outboundMessages, err := d.cache.ClaimOutboundMessages(context.Background(), owner, outboundLeaseTTL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return outboundMessages
+}
+
+func newOutboundMessage(t *testing.T, chatID int64, text string) app.OutboundMessage {
+	payload, err := json.Marshal(telegram.SendResponse{ChatID: chatID, Text: text})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return app.OutboundMessage{
+		ID:        uuid.NewString(),
+		ChatID:    chatID,
+		Priority:  app.TransactionalOutboundPriority,
+		Method:    app.SendMessageOutboundMethod,
+		Payload:   payload,
+		CreatedAt: time.Now(),
+	}
+}
+
+// dispatcherConfig has only the telegram part of the config the dispatcher reads.
+type dispatcherConfig struct {
+	config.Config
+	telegram config.Telegram
+}
+
+func (d *dispatcherConfig) Telegram() config.Telegram {
+	return d.telegram
+}
+
+// fakeClock moves only when the test advances it, a timer fires once the clock reaches its deadline.
+type fakeClock struct {
+	mutex     sync.Mutex
+	startedAt time.Time
+	now       time.Time
+	timers    map[*fakeTimer]bool
+}
+
+type fakeTimer struct {
+	clock    *fakeClock
+	duration time.Duration
+	deadline time.Time
+	c        chan time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) NewTimer(d time.Duration) service.Timer {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	timer := fakeTimer{
+		clock:    f,
+		duration: d,
+		deadline: f.now.Add(d),
+		c:        make(chan time.Time, 1),
+	}
+	if d <= 0 {
+		timer.c <- f.now
+	} else {
+		f.timers[&timer] = true
+	}
+	return &timer
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.now = f.now.Add(d)
+	for timer := range f.timers {
+		if !timer.deadline.After(f.now) {
+			timer.c <- f.now
+			delete(f.timers, timer)
+		}
+	}
+}
+
+// waitTimer waits until the dispatcher sleeps on a single timer of the duration, zero means any duration.
+// The timer has to stay for a moment, the dispatcher may still be woken by a message being completed.
+func (f *fakeClock) waitTimer(t *testing.T, d time.Duration) {
+	t.Helper()
+	var sleepingSince time.Time
+	eventually(t, func() bool {
+		f.mutex.Lock()
+		isSleeping := len(f.timers) == 1
+		for timer := range f.timers {
+			isSleeping = isSleeping && (d == 0 || timer.duration == d)
+		}
+		f.mutex.Unlock()
+		if !isSleeping {
+			sleepingSince = time.Time{}
+			return false
+		}
+		if sleepingSince.IsZero() {
+			sleepingSince = time.Now()
+		}
+		return time.Since(sleepingSince) >= 10*time.Millisecond
+	}, fmt.Sprintf("dispatcher doesn't sleep for %s", d))
+}
+
+func (f *fakeTimer) C() <-chan time.Time {
+	return f.c
+}
+
+func (f *fakeTimer) Stop() bool {
+	f.clock.mutex.Lock()
+	defer f.clock.mutex.Unlock()
+	isActive := f.clock.timers[f]
+	delete(f.clock.timers, f)
+	return isActive
+}
+
+// fakeOutboundClient records sent messages with the time of the fake clock and fails messages
+// with the errors given for their texts.
+type fakeOutboundClient struct {
+	telegram_bot.Client
+	clock    *fakeClock
+	mutex    sync.Mutex
+	sent     []telegram.SendResponse
+	sentAt   []time.Duration
+	failures map[string][]error
+}
+
+func (f *fakeOutboundClient) SendMessage(_ context.Context, sendMessage *telegram.SendResponse) (*telegram.Message, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.sent = append(f.sent, *sendMessage)
+	f.sentAt = append(f.sentAt, f.clock.Now().Sub(f.clock.startedAt))
+	if failures := f.failures[sendMessage.Text]; len(failures) > 0 {
+		f.failures[sendMessage.Text] = failures[1:]
+		return nil, failures[0]
+	}
+	return &telegram.Message{}, nil
+}
+
+func (f *fakeOutboundClient) fail(text string, err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.failures[text] = append(f.failures[text], err)
+}
+
+func (f *fakeOutboundClient) count() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.sent)
+}
+
+// history lists texts sent to the chat with the time since the start, e.g. [a@0s b@1s].
+func (f *fakeOutboundClient) history(chatID int64) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	history := make([]string, 0, len(f.sent))
+	for idx, sendMessage := range f.sent {
+		if sendMessage.ChatID == chatID {
+			history = append(history, fmt.Sprintf("%s@%s", sendMessage.Text, f.sentAt[idx]))
+		}
+	}
+	return fmt.Sprint(history)
+}
+
+func (f *fakeOutboundClient) waitSent(t *testing.T, count int) {
+	t.Helper()
+	eventually(t, func() bool {
+		return f.count() >= count
+	}, fmt.Sprintf("%d messages aren't sent", count))
+}
+
+// fakeBlockedProfiles records chats of profiles marked blocked.
+type fakeBlockedProfiles struct {
+	repository.ProfileRepository
+	mutex          sync.Mutex
+	blockedChatIDs []int64
+}
+
+func (f *fakeBlockedProfiles) MarkBotBlockedByChatID(_ context.Context, chatID int64) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.blockedChatIDs = append(f.blockedChatIDs, chatID)
+	return nil
+}
+
+func (f *fakeBlockedProfiles) blocked() []int64 {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]int64{}, f.blockedChatIDs...)
+}
+
+func eventually(t *testing.T, condition func() bool, message string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal(message)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
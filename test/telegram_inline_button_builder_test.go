@@ -0,0 +1,91 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"go-ton-pass-telegram-bot/internal/manager"
+	"go-ton-pass-telegram-bot/internal/model/app"
+	"testing"
+)
+
+// fakeCallbackDataStore uses command names as tokens and keeps saved payloads in memory.
+type fakeCallbackDataStore struct {
+	callbackData map[string]app.TelegramCallbackData
+	saves        int
+}
+
+func (f *fakeCallbackDataStore) CallbackDataToken(callbackData app.TelegramCallbackData) (string, error) {
+	return callbackData.Name, nil
+}
+
+func (f *fakeCallbackDataStore) SaveCallbackData(_ context.Context, callbackData []app.TelegramCallbackData) error {
+	f.saves++
+	for _, data := range callbackData {
+		f.callbackData[data.Name] = data
+	}
+	return nil
+}
+
+func TestTelegramInlineButtonBuilder(t *testing.T) {
+	t.Run("payload is kept by the store", func(t *testing.T) {
+		store := fakeCallbackDataStore{callbackData: make(map[string]app.TelegramCallbackData)}
+		batch := manager.NewCallbackDataBatch(&store)
+		button, err := manager.NewTelegramInlineButtonBuilder(batch).
+			SetText("Pay").
+			SetCommandName(app.ConfirmationPayServiceQueryCmdText).
+			SetParameters([]any{"tg", int64(6), 12.34567, 15.98765, "any operator with a long name"}).
+			Build()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if button.Data == nil || *button.Data != app.ConfirmationPayServiceQueryCmdText {
+			t.Fatalf("unexpected callback data: %v", button.Data)
+		}
+		if len(store.callbackData) != 0 {
+			t.Fatalf("payload is saved before the keyboard is built")
+		}
+		if _, err := batch.Markup(context.Background(), nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		callbackData := store.callbackData[app.ConfirmationPayServiceQueryCmdText]
+		if callbackData.Name != app.ConfirmationPayServiceQueryCmdText || len(*callbackData.Parameters) != 5 {
+			t.Errorf("unexpected stored callback data: %+v", callbackData)
+		}
+	})
+	t.Run("payloads of a keyboard are saved at once", func(t *testing.T) {
+		store := fakeCallbackDataStore{callbackData: make(map[string]app.TelegramCallbackData)}
+		batch := manager.NewCallbackDataBatch(&store)
+		for _, commandName := range []string{app.MainMenuCallbackQueryCmdText, app.BackQueryCmdText} {
+			if _, err := manager.NewTelegramInlineButtonBuilder(batch).SetText("Button").SetCommandName(commandName).Build(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if _, err := batch.Markup(context.Background(), nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if store.saves != 1 || len(store.callbackData) != 2 {
+			t.Errorf("unexpected saves: %d of %d payloads", store.saves, len(store.callbackData))
+		}
+	})
+	t.Run("link without store", func(t *testing.T) {
+		button, err := manager.NewTelegramInlineButtonBuilder(nil).
+			SetText("News").
+			SetLink("https://t.me/news").
+			Build()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if button.Data != nil || button.URL == nil {
+			t.Errorf("unexpected button: %+v", button)
+		}
+	})
+	t.Run("command without store", func(t *testing.T) {
+		_, err := manager.NewTelegramInlineButtonBuilder(nil).
+			SetText("Menu").
+			SetCommandName(app.MainMenuCallbackQueryCmdText).
+			Build()
+		if !errors.Is(err, app.RequiredFieldError) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package test
+
+import (
+	"encoding/json"
+	"go-ton-pass-telegram-bot/internal/model/telegram"
+	"testing"
+)
+
+func TestDecodeMyChatMemberUpdate(t *testing.T) {
+	payload := `{
+		"update_id": 10,
+		"my_chat_member": {
+			"chat": {"id": 42, "type": "private"},
+			"from": {"id": 42, "is_bot": false, "first_name": "Ann"},
+			"date": 1700000000,
+			"old_chat_member": {"status": "member", "user": {"id": 7, "is_bot": true}},
+			"new_chat_member": {"status": "kicked", "user": {"id": 7, "is_bot": true}}
+		}
+	}`
+	var update telegram.Update
+	if err := json.Unmarshal([]byte(payload), &update); err != nil {
+		t.Fatalf("fail to decode update: %v", err)
+	}
+	if update.MyChatMember == nil {
+		t.Fatal("my_chat_member is missed")
+	}
+	if update.GetTelegramID() != 42 || update.GetChatID() != 42 {
+		t.Errorf("unexpected ids: %v %v", update.GetTelegramID(), update.GetChatID())
+	}
+	if update.MyChatMember.Chat.Type != telegram.PrivateChatType {
+		t.Errorf("unexpected chat type: %v", update.MyChatMember.Chat.Type)
+	}
+	if update.MyChatMember.NewChatMember.Status != telegram.BannedMemberStatus {
+		t.Errorf("unexpected status: %v", update.MyChatMember.NewChatMember.Status)
+	}
+	var chatMember telegram.ChatMember
+	if err := json.Unmarshal([]byte(`{"status": "restricted", "user": {"id": 7, "is_bot": true}}`), &chatMember); err != nil {
+		t.Errorf("fail to decode restricted member: %v", err)
+	}
+}
@@ -28,3 +28,67 @@ func TestCurrencyTextFormatted(t *testing.T) {
 		}
 	})
 }
+
+func TestISOCodeFromFlagEmoji(t *testing.T) {
+	t.Run("flag of a country", func(t *testing.T) {
+		isoCode := utils.ISOCodeFromFlagEmoji("🇺🇦")
+		if isoCode == nil || *isoCode != "UA" {
+			t.Errorf("unexpected iso code: %v", isoCode)
+		}
+	})
+	t.Run("not a flag of a country", func(t *testing.T) {
+		for _, flag := range []string{"🏴‍☠️", "UA", "🇺", ""} {
+			if isoCode := utils.ISOCodeFromFlagEmoji(flag); isoCode != nil {
+				t.Errorf("unexpected iso code of %q: %v", flag, *isoCode)
+			}
+		}
+	})
+}
+
+func TestParsePhoneNumber(t *testing.T) {
+	dialingCodes := []string{"1", "7", "354", "380", "44"}
+	t.Run("short national number", func(t *testing.T) {
+		phoneNumber, err := utils.ParsePhoneNumber("3546111234", "354", dialingCodes)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if phoneNumber.CountryCode != "354" || phoneNumber.ShortPhoneNumber != "6111234" {
+			t.Errorf("unexpected phone number: %+v", phoneNumber)
+		}
+	})
+	t.Run("dialing code of another country", func(t *testing.T) {
+		phoneNumber, err := utils.ParsePhoneNumber("+380501234567", "7", dialingCodes)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if phoneNumber.CountryCode != "380" || phoneNumber.ShortPhoneNumber != "501234567" {
+			t.Errorf("unexpected phone number: %+v", phoneNumber)
+		}
+	})
+	t.Run("unknown format", func(t *testing.T) {
+		for _, number := range []string{"", "+", "99912345", "7", "79a1234567"} {
+			if _, err := utils.ParsePhoneNumber(number, "7", dialingCodes); err != app.UnknownPhoneNumberFormatError {
+				t.Errorf("unexpected error of %q: %v", number, err)
+			}
+		}
+	})
+}
+
+func TestPhoneNumberGrouping(t *testing.T) {
+	phoneNumber := app.PhoneNumber{
+		CountryCode:      "7",
+		ShortPhoneNumber: "9123456789",
+		Grouping:         []int{3, 3, 2, 2},
+	}
+	if phoneNumber.InternationalNumber() != "+7 912 345 67 89" {
+		t.Errorf("unexpected international number: %v", phoneNumber.InternationalNumber())
+	}
+	phoneNumber.ShortPhoneNumber = "91234567890"
+	if phoneNumber.NationalNumber() != "912 345 67 89 0" {
+		t.Errorf("unexpected national number: %v", phoneNumber.NationalNumber())
+	}
+	phoneNumber.Grouping = nil
+	if phoneNumber.NationalNumber() != "91234567890" {
+		t.Errorf("unexpected national number: %v", phoneNumber.NationalNumber())
+	}
+}